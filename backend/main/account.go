@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+
+	verifyEmailTTL   = 48 * time.Hour
+	resetPasswordTTL = time.Hour
+)
+
+const defaultAppURL = "http://localhost:5173"
+
+// validEmail пропускает только голый адрес: без имени и угловых скобок,
+// чтобы его можно было безопасно подставить в заголовок письма
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+// issueUserToken создаёт одноразовый токен и возвращает ссылку на страницу фронтенда с ним
+func (s *Server) issueUserToken(ctx context.Context, userID int64, purpose, path string, ttl time.Duration) (string, error) {
+	token, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	if err := s.userTokens.Create(ctx, userID, purpose, hashToken(token), time.Now().Add(ttl)); err != nil {
+		return "", err
+	}
+	return s.appURL + path + "?token=" + url.QueryEscape(token), nil
+}
+
+func (s *Server) sendVerificationEmail(ctx context.Context, user User) error {
+	link, err := s.issueUserToken(ctx, user.ID, TokenPurposeVerifyEmail, "/verify-email", verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, Mail{
+		To:      user.Email,
+		Subject: "Подтверждение email в СтройСторе",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы подтвердить адрес, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %d часов. Если вы не регистрировались, просто проигнорируйте письмо.\n",
+			user.Username, link, int(verifyEmailTTL.Hours())),
+	})
+}
+
+func (s *Server) sendPasswordResetEmail(ctx context.Context, user User) error {
+	link, err := s.issueUserToken(ctx, user.ID, TokenPurposeResetPassword, "/reset-password", resetPasswordTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, Mail{
+		To:      user.Email,
+		Subject: "Восстановление пароля в СтройСторе",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы задать новый пароль, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %d минут и сработает один раз. Если вы не запрашивали сброс, просто проигнорируйте письмо.\n",
+			user.Username, link, int(resetPasswordTTL.Minutes())),
+	})
+}
+
+func (s *Server) verifyEmailHandler(c *gin.Context) {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID, err := s.userTokens.Consume(ctx, TokenPurposeVerifyEmail, hashToken(req.Token))
+	if errors.Is(err, ErrInvalidToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Ссылка недействительна или устарела"})
+		return
+	} else if err != nil {
+		log.Println("Verify email error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	if err := s.users.MarkEmailVerified(ctx, userID); err != nil {
+		log.Println("Verify email error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email подтверждён"})
+}
+
+func (s *Server) resendVerificationHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := s.users.GetByID(ctx, claims.ID)
+	if err != nil {
+		log.Println("Resend verification error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if user.EmailVerified {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Email уже подтверждён"})
+		return
+	}
+
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		log.Println("Resend verification error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Не удалось отправить письмо"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Письмо отправлено на " + user.Email})
+}
+
+// forgotPasswordHandler всегда отвечает одинаково, чтобы по ответу нельзя было узнать,
+// зарегистрирован ли адрес
+func (s *Server) forgotPasswordHandler(c *gin.Context) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Email) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+
+	// письмо уходит в фоне: время ответа не должно выдавать, зарегистрирован ли адрес
+	email := strings.TrimSpace(req.Email)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+		defer cancel()
+		user, err := s.users.GetByEmail(ctx, email)
+		if err == nil {
+			err = s.sendPasswordResetEmail(ctx, user)
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			log.Println("Forgot password error:", err)
+		}
+	}()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Если адрес зарегистрирован, на него отправлена ссылка для сброса пароля"})
+}
+
+func (s *Server) resetPasswordHandler(c *gin.Context) {
+	var req struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+	if req.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Пароль обязателен"})
+		return
+	}
+
+	// токен гасим только после проверки пароля, чтобы слабый пароль не сжигал ссылку
+	ctx := c.Request.Context()
+	tokenHash := hashToken(req.Token)
+	userID, err := s.userTokens.Lookup(ctx, TokenPurposeResetPassword, tokenHash)
+	var user User
+	if err == nil {
+		user, err = s.users.GetByID(ctx, userID)
+	}
+	if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Ссылка недействительна или устарела"})
+		return
+	} else if err != nil {
+		log.Println("Reset password error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	if err := s.policy.Validate(req.Password, user.Username); err != nil {
+		msg, _ := passwordErrorMessage(err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": msg})
+		return
+	}
+
+	hashed, err := s.passwords.Hash(req.Password)
+	if err != nil {
+		log.Println("Reset password error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	if _, err := s.userTokens.Consume(ctx, TokenPurposeResetPassword, tokenHash); errors.Is(err, ErrInvalidToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Ссылка недействительна или устарела"})
+		return
+	} else if err != nil {
+		log.Println("Reset password error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	if err := s.users.SetPassword(ctx, userID, hashed); err != nil {
+		log.Println("Reset password error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	// письмо дошло до владельца адреса — значит, адрес подтверждён
+	if err := s.users.MarkEmailVerified(ctx, userID); err != nil {
+		log.Println("Reset password error:", err)
+	}
+	// старый пароль мог быть известен злоумышленнику: завершаем все его сессии
+	if _, err := s.sessions.RevokeAllForUser(ctx, userID); err != nil {
+		log.Println("Reset password error:", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Пароль изменён, войдите с новым паролем"})
+}
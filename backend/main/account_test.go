@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// waitMail ждёт, пока фоновая отправка доведёт число писем до n
+func (ts *testServer) waitMail(t *testing.T, n int) Mail {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for ts.mail.count() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("sent %d mails, want %d", ts.mail.count(), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	ts.mail.mu.Lock()
+	defer ts.mail.mu.Unlock()
+	return ts.mail.sent[n-1]
+}
+
+func TestForgotPassword(t *testing.T) {
+	ts := newTestServer(t)
+	ts.register(t, "prorab")
+	before := ts.mail.count()
+
+	// на неизвестный адрес ответ тот же, но письма нет
+	w := ts.do(t, http.MethodPost, "/api/password/forgot", "", gin.H{"email": "nobody@example.com"})
+	expect(t, w, http.StatusOK, nil)
+
+	w = ts.do(t, http.MethodPost, "/api/password/forgot", "", gin.H{"email": "prorab@example.com"})
+	expect(t, w, http.StatusOK, nil)
+	mail := ts.waitMail(t, before+1)
+	if mail.To != "prorab@example.com" || !strings.Contains(mail.Body, "/reset-password") {
+		t.Fatalf("unexpected mail: %+v", mail)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if got := ts.mail.count(); got != before+1 {
+		t.Fatalf("sent %d mails, want %d", got, before+1)
+	}
+}
+
+// у аккаунта без пароля вход по паролю не проходит, даже паролем фиктивного хеша
+func TestLoginWithoutPassword(t *testing.T) {
+	ts := newTestServer(t)
+	if _, err := ts.users.Create(context.Background(), "oauth-prorab", "oauth@example.com", "", "user"); err != nil {
+		t.Fatal(err)
+	}
+	for _, password := range []string{"", "dummy password", "Kirpich-2024-stroy"} {
+		w := ts.do(t, http.MethodPost, "/api/login", "", gin.H{"username": "oauth-prorab", "password": password})
+		expect(t, w, http.StatusBadRequest, nil)
+	}
+
+	ts.register(t, "prorab")
+	w := ts.do(t, http.MethodPost, "/api/login", "", gin.H{"username": "prorab", "password": "Kirpich-2024-stroy"})
+	expect(t, w, http.StatusOK, nil)
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ApplicationStatusNew      = "new"
+	ApplicationStatusViewed   = "viewed"
+	ApplicationStatusInvited  = "invited"
+	ApplicationStatusRejected = "rejected"
+)
+
+// applicationTransitions — какие статусы работодатель может выставить отклику.
+// Приглашённому ещё можно отказать, отказ окончательный.
+var applicationTransitions = map[string][]string{
+	ApplicationStatusNew:      {ApplicationStatusViewed, ApplicationStatusInvited, ApplicationStatusRejected},
+	ApplicationStatusViewed:   {ApplicationStatusInvited, ApplicationStatusRejected},
+	ApplicationStatusInvited:  {ApplicationStatusRejected},
+	ApplicationStatusRejected: {},
+}
+
+const maxCoverLetter = 5000
+
+// JobApplication — отклик соискателя на вакансию. Контакты видят только соискатель
+// и автор вакансии.
+type JobApplication struct {
+	ID           int64     `json:"id"`
+	JobID        int64     `json:"job_id"`
+	JobTitle     string    `json:"job_title"`
+	Company      string    `json:"company"`
+	UserID       int64     `json:"user_id"`
+	Username     string    `json:"username"`
+	CoverLetter  string    `json:"cover_letter"`
+	ContactName  string    `json:"contact_name"`
+	ContactPhone string    `json:"contact_phone"`
+	ContactEmail string    `json:"contact_email"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func canTransitionApplication(from, to string) bool {
+	for _, next := range applicationTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) applyToJobHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	var req struct {
+		CoverLetter  string `json:"cover_letter"`
+		ContactName  string `json:"contact_name"`
+		ContactPhone string `json:"contact_phone"`
+		ContactEmail string `json:"contact_email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+
+	in := ApplicationInput{
+		JobID:        jobID,
+		UserID:       claims.ID,
+		CoverLetter:  strings.TrimSpace(req.CoverLetter),
+		ContactName:  strings.TrimSpace(req.ContactName),
+		ContactPhone: strings.TrimSpace(req.ContactPhone),
+		ContactEmail: strings.TrimSpace(req.ContactEmail),
+	}
+	if in.ContactName == "" || utf8.RuneCountInString(in.ContactName) > 150 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Укажите имя"})
+		return
+	}
+	if in.ContactPhone == "" && in.ContactEmail == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Укажите телефон или email для связи"})
+		return
+	}
+	if in.ContactPhone != "" && !phonePattern.MatchString(in.ContactPhone) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат телефона"})
+		return
+	}
+	if in.ContactEmail != "" && (!validEmail(in.ContactEmail) || len(in.ContactEmail) > 100) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат email"})
+		return
+	}
+	if utf8.RuneCountInString(in.CoverLetter) > maxCoverLetter {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Сопроводительное письмо не должно быть длиннее 5000 символов"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	job, err := s.jobs.Get(ctx, jobID)
+	if errors.Is(err, ErrNotFound) || (err == nil && job.Status != JobStatusApproved) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Вакансия не найдена"})
+		return
+	} else if err != nil {
+		log.Println("Apply to job error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if ownsJob(job, claims.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Нельзя откликнуться на свою вакансию"})
+		return
+	}
+
+	application, err := s.applications.Create(ctx, in)
+	if errors.Is(err, ErrAlreadyApplied) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Вы уже откликнулись на эту вакансию"})
+		return
+	} else if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Вакансия не найдена"})
+		return
+	} else if err != nil {
+		log.Println("Apply to job error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	log.Printf("Отклик %d: %s на вакансию %d", application.ID, claims.Username, jobID)
+	c.JSON(http.StatusCreated, application)
+}
+
+// getJobApplicationsHandler — отклики на вакансию видит только её автор
+func (s *Server) getJobApplicationsHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	job, err := s.jobs.Get(ctx, jobID)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Вакансия не найдена"})
+		return
+	} else if err != nil {
+		log.Println("Get job applications error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if !ownsJob(job, claims.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Недостаточно прав"})
+		return
+	}
+
+	applications, err := s.applications.ListForJob(ctx, jobID)
+	if err != nil {
+		log.Println("Get job applications error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, applications)
+}
+
+// getMyApplicationsHandler — история откликов соискателя со статусами
+func (s *Server) getMyApplicationsHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	applications, err := s.applications.ListForUser(c.Request.Context(), claims.ID)
+	if err != nil {
+		log.Println("Get my applications error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, applications)
+}
+
+// updateApplicationStatusHandler — работодатель отмечает отклик просмотренным,
+// приглашает соискателя или отказывает
+func (s *Server) updateApplicationStatusHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	var req struct {
+		Status string `json:"status"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+	if _, ok := applicationTransitions[req.Status]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неизвестный статус"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	application, err := s.applications.Get(ctx, id)
+	var job Job
+	if err == nil {
+		job, err = s.jobs.Get(ctx, application.JobID)
+	}
+	// чужие отклики для работодателя «не существуют», как чужие заказы
+	if errors.Is(err, ErrNotFound) || (err == nil && !ownsJob(job, claims.ID)) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Отклик не найден"})
+		return
+	} else if err != nil {
+		log.Println("Update application status error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	if application.Status == req.Status {
+		c.JSON(http.StatusOK, application)
+		return
+	}
+	if !canTransitionApplication(application.Status, req.Status) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Недопустимая смена статуса отклика"})
+		return
+	}
+
+	application, err = s.applications.SetStatus(ctx, id, application.Status, req.Status)
+	if errors.Is(err, ErrStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Статус отклика уже изменился, обновите страницу"})
+		return
+	} else if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Отклик не найден"})
+		return
+	} else if err != nil {
+		log.Println("Update application status error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, application)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BasketItem struct {
+	ProductID int64   `json:"product_id"`
+	Name      string  `json:"name"`
+	Price     float64 `json:"price"`
+	Image     string  `json:"image"`
+	Quantity  int     `json:"quantity"`
+	Subtotal  float64 `json:"subtotal"`
+}
+
+type Basket struct {
+	Items []BasketItem `json:"items"`
+	Count int          `json:"count"`
+	Total float64      `json:"total"`
+}
+
+const maxBasketItemQuantity = 999
+
+// add кладёт товар в корзину и пересчитывает итоги
+func (b *Basket) add(item BasketItem) {
+	item.Subtotal = item.Price * float64(item.Quantity)
+	b.Items = append(b.Items, item)
+	b.Count += item.Quantity
+	b.Total += item.Subtotal
+}
+
+func (s *Server) respondBasket(c *gin.Context, userID int64, status int) {
+	basket, err := s.baskets.Get(c.Request.Context(), userID)
+	if err != nil {
+		log.Println("Load basket error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	c.JSON(status, basket)
+}
+
+func (s *Server) getBasketHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	s.respondBasket(c, claims.ID, http.StatusOK)
+}
+
+func (s *Server) addBasketItemHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	var req struct {
+		ProductID int64 `json:"product_id"`
+		Quantity  int   `json:"quantity"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+	if req.ProductID <= 0 || req.Quantity < 0 || req.Quantity > maxBasketItemQuantity {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный товар или количество"})
+		return
+	}
+
+	exists, err := s.products.Exists(c.Request.Context(), req.ProductID)
+	if err != nil {
+		log.Println("Check product error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Продукт не найден"})
+		return
+	}
+
+	if err := s.baskets.AddItem(c.Request.Context(), claims.ID, req.ProductID, req.Quantity); err != nil {
+		log.Println("Add basket item error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	s.respondBasket(c, claims.ID, http.StatusOK)
+}
+
+func (s *Server) updateBasketItemHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	var req struct {
+		Quantity int `json:"quantity"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+
+	if req.Quantity < 0 || req.Quantity > maxBasketItemQuantity {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверное количество"})
+		return
+	}
+
+	err = s.baskets.SetQuantity(c.Request.Context(), claims.ID, productID, req.Quantity)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Товар не найден в корзине"})
+		return
+	} else if err != nil {
+		log.Println("Update basket item error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	s.respondBasket(c, claims.ID, http.StatusOK)
+}
+
+func (s *Server) removeBasketItemHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	err = s.baskets.RemoveItem(c.Request.Context(), claims.ID, productID)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Товар не найден в корзине"})
+		return
+	} else if err != nil {
+		log.Println("Remove basket item error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	s.respondBasket(c, claims.ID, http.StatusOK)
+}
+
+func (s *Server) clearBasketHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	if err := s.baskets.Clear(c.Request.Context(), claims.ID); err != nil {
+		log.Println("Clear basket error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	s.respondBasket(c, claims.ID, http.StatusOK)
+}
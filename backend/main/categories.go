@@ -0,0 +1,393 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxCategoryNameLength = 50
+
+var errCategoryNotFound = errors.New("category not found")
+
+type Category struct {
+	ID        int64       `json:"id"`
+	ParentID  *int64      `json:"parent_id"`
+	Name      string      `json:"name"`
+	Slug      string      `json:"slug"`
+	SortOrder int         `json:"sort_order"`
+	Children  []*Category `json:"children,omitempty"`
+}
+
+// slugify строит адрес категории из названия: нижний регистр, слова через дефис.
+// Кириллица сохраняется — так же слаги заполнила миграция 0002.
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if b.Len() > 0 && !dash {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// buildCategoryTree раскладывает плоский список по родителям, сохраняя порядок List
+func buildCategoryTree(flat []Category) []*Category {
+	nodes := make(map[int64]*Category, len(flat))
+	for i := range flat {
+		c := flat[i]
+		c.Children = nil
+		nodes[c.ID] = &c
+	}
+
+	roots := []*Category{}
+	for _, c := range flat {
+		node := nodes[c.ID]
+		if c.ParentID != nil {
+			if parent, ok := nodes[*c.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
+
+// categoryDescendants возвращает id категории и всех её подкатегорий
+func categoryDescendants(flat []Category, id int64) []int64 {
+	ids := []int64{id}
+	for i := 0; i < len(ids); i++ {
+		for _, c := range flat {
+			if c.ParentID != nil && *c.ParentID == ids[i] {
+				ids = append(ids, c.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// findCategory ищет категорию по id, слагу или названию без учёта регистра
+func findCategory(flat []Category, ref string) (Category, bool) {
+	ref = strings.TrimSpace(ref)
+	id, idErr := strconv.ParseInt(ref, 10, 64)
+	for _, c := range flat {
+		if (idErr == nil && c.ID == id) || c.Slug == ref || strings.EqualFold(c.Name, ref) {
+			return c, true
+		}
+	}
+	return Category{}, false
+}
+
+// categoryRefs собирает значения параметра category (повторяющегося или через запятую).
+// «Все» означает отсутствие фильтра.
+func categoryRefs(c *gin.Context) []string {
+	var refs []string
+	for _, value := range c.QueryArray("category") {
+		for _, ref := range strings.Split(value, ",") {
+			ref = strings.TrimSpace(ref)
+			if ref == "" || ref == "Все" {
+				continue
+			}
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// categoryFilterIDs превращает ссылки на категории в id вместе с подкатегориями.
+// Неизвестные категории ничего не добавляют, поэтому фильтр по ним даёт пустой результат.
+func (s *Server) categoryFilterIDs(ctx context.Context, refs []string) ([]int64, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	flat, err := s.categories.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []int64{}
+	for _, ref := range refs {
+		if c, ok := findCategory(flat, ref); ok {
+			ids = append(ids, categoryDescendants(flat, c.ID)...)
+		}
+	}
+	return ids, nil
+}
+
+// resolveCategory проверяет категорию из запроса на создание или изменение товара/вакансии.
+// Можно передать category_id или category (название либо слаг).
+func (s *Server) resolveCategory(ctx context.Context, id *int64, ref string) (*int64, error) {
+	if id != nil {
+		if _, err := s.categories.Get(ctx, *id); errors.Is(err, ErrNotFound) {
+			return nil, errCategoryNotFound
+		} else if err != nil {
+			return nil, err
+		}
+		return id, nil
+	}
+	if strings.TrimSpace(ref) == "" {
+		return nil, nil
+	}
+
+	flat, err := s.categories.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := findCategory(flat, ref)
+	if !ok {
+		return nil, errCategoryNotFound
+	}
+	return &c.ID, nil
+}
+
+func (s *Server) getCategoriesHandler(c *gin.Context) {
+	flat, err := s.categories.List(c.Request.Context())
+	if err != nil {
+		log.Println("Get categories error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, buildCategoryTree(flat))
+}
+
+type categoryRequest struct {
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	ParentID  *int64 `json:"parent_id"`
+	SortOrder int    `json:"sort_order"`
+}
+
+// validateCategory проверяет запрос и возвращает сообщение об ошибке для клиента.
+// id — изменяемая категория (0 при создании): её нельзя сделать потомком самой себя.
+func validateCategory(flat []Category, id int64, req *categoryRequest) string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || utf8.RuneCountInString(req.Name) > maxCategoryNameLength {
+		return "Неверное название категории"
+	}
+
+	if req.Slug != "" && slugify(req.Slug) != req.Slug {
+		return "Адрес может содержать только строчные буквы, цифры и дефисы"
+	}
+
+	if req.ParentID != nil {
+		parentFound := false
+		for _, c := range flat {
+			if c.ID == *req.ParentID {
+				parentFound = true
+				break
+			}
+		}
+		if !parentFound {
+			return "Родительская категория не найдена"
+		}
+		if id != 0 {
+			for _, d := range categoryDescendants(flat, id) {
+				if d == *req.ParentID {
+					return "Категорию нельзя вложить в саму себя"
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+func (s *Server) createCategoryHandler(c *gin.Context) {
+	var req categoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	flat, err := s.categories.List(ctx)
+	if err != nil {
+		log.Println("Create category error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if msg := validateCategory(flat, 0, &req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": msg})
+		return
+	}
+	if req.Slug == "" {
+		req.Slug = slugify(req.Name)
+	}
+
+	category, err := s.categories.Create(ctx, CategoryInput{
+		ParentID:  req.ParentID,
+		Name:      req.Name,
+		Slug:      req.Slug,
+		SortOrder: req.SortOrder,
+	})
+	if errors.Is(err, ErrDuplicateSlug) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Категория с таким адресом уже существует"})
+		return
+	} else if err != nil {
+		log.Println("Create category error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// updateCategoryHandler переименовывает и перемещает категорию.
+// Если slug не передан, прежний адрес сохраняется, чтобы не ломать ссылки.
+func (s *Server) updateCategoryHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	var req categoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	current, err := s.categories.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Категория не найдена"})
+		return
+	} else if err != nil {
+		log.Println("Update category error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	flat, err := s.categories.List(ctx)
+	if err != nil {
+		log.Println("Update category error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if msg := validateCategory(flat, id, &req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": msg})
+		return
+	}
+	if req.Slug == "" {
+		req.Slug = current.Slug
+	}
+
+	category, err := s.categories.Update(ctx, id, CategoryInput{
+		ParentID:  req.ParentID,
+		Name:      req.Name,
+		Slug:      req.Slug,
+		SortOrder: req.SortOrder,
+	})
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Категория не найдена"})
+		return
+	} else if errors.Is(err, ErrDuplicateSlug) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Категория с таким адресом уже существует"})
+		return
+	} else if err != nil {
+		log.Println("Update category error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	// название категории входит в поисковые документы
+	if category.Name != current.Name {
+		if err := s.rebuildSearchIndex(ctx); err != nil {
+			log.Println("Rebuild search index error:", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+func (s *Server) mergeCategoryHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	var req struct {
+		TargetID int64 `json:"target_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.TargetID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	flat, err := s.categories.List(ctx)
+	if err != nil {
+		log.Println("Merge category error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	// подкатегории переезжают в target, поэтому target не может быть среди них
+	for _, d := range categoryDescendants(flat, id) {
+		if d == req.TargetID {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Нельзя объединить категорию с её подкатегорией"})
+			return
+		}
+	}
+
+	err = s.categories.Merge(ctx, id, req.TargetID)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Категория не найдена"})
+		return
+	} else if err != nil {
+		log.Println("Merge category error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	if err := s.rebuildSearchIndex(ctx); err != nil {
+		log.Println("Rebuild search index error:", err)
+	}
+
+	target, err := s.categories.Get(ctx, req.TargetID)
+	if err != nil {
+		log.Println("Merge category error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	c.JSON(http.StatusOK, target)
+}
+
+func (s *Server) deleteCategoryHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	err = s.categories.Delete(c.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Категория не найдена"})
+		return
+	} else if errors.Is(err, ErrCategoryInUse) {
+		c.JSON(http.StatusConflict, gin.H{"message": "В категории есть товары, вакансии или подкатегории — объедините её с другой"})
+		return
+	} else if err != nil {
+		log.Println("Delete category error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Категория удалена"})
+}
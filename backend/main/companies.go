@@ -0,0 +1,817 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxCompanyNameLength        = 100
+	maxCompanyDescriptionLength = 5000
+	companyLogoSide             = 256
+)
+
+// Роли участника компании: владельцы приглашают и убирают коллег, остальные участники
+// размещают вакансии и правят профиль
+const (
+	CompanyRoleOwner  = "owner"
+	CompanyRoleMember = "member"
+)
+
+var (
+	errCompanyNotFound  = errors.New("company not found")
+	errCompanyNotMember = errors.New("not a company member")
+)
+
+// Company — профиль работодателя. Управляют компанией её участники: создатель
+// и те, кто принял приглашение владельца. Verified выставляет администратор после проверки.
+type Company struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	INN         string     `json:"inn"`
+	Description string     `json:"description"`
+	Logo        string     `json:"logo"` // URL логотипа, пустая строка — логотипа нет
+	LogoKey     string     `json:"-"`
+	Website     string     `json:"website"`
+	Phone       string     `json:"phone"`
+	Email       string     `json:"email"`
+	Verified    bool       `json:"verified"`
+	VerifiedAt  *time.Time `json:"verified_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type CompanyMember struct {
+	UserID    int64     `json:"user_id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"` // CompanyRoleOwner или CompanyRoleMember
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CompanyInvitation — приглашение пользователя в участники компании
+type CompanyInvitation struct {
+	CompanyID int64     `json:"company_id"`
+	Company   string    `json:"company"`
+	InvitedBy string    `json:"invited_by"` // пустая строка — пригласивший удалил аккаунт
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CompanyProfile — публичная страница компании с её открытыми вакансиями
+type CompanyProfile struct {
+	Company
+	Jobs []Job `json:"jobs"`
+}
+
+// validINN проверяет контрольные цифры ИНН организации (10 цифр) или ИП (12 цифр)
+func validINN(inn string) bool {
+	if len(inn) != 10 && len(inn) != 12 {
+		return false
+	}
+	digits := make([]int, len(inn))
+	for i, r := range inn {
+		if r < '0' || r > '9' {
+			return false
+		}
+		digits[i] = int(r - '0')
+	}
+
+	check := func(weights []int) int {
+		sum := 0
+		for i, w := range weights {
+			sum += w * digits[i]
+		}
+		return sum % 11 % 10
+	}
+	if len(inn) == 10 {
+		return check([]int{2, 4, 10, 3, 5, 9, 4, 6, 8}) == digits[9]
+	}
+	return check([]int{7, 2, 4, 10, 3, 5, 9, 4, 6, 8}) == digits[10] &&
+		check([]int{3, 7, 2, 4, 10, 3, 5, 9, 4, 6, 8}) == digits[11]
+}
+
+// normalizeWebsite дописывает схему к адресу вида «example.ru»; "" — адрес не подходит
+func normalizeWebsite(site string) string {
+	if !strings.Contains(site, "://") {
+		site = "https://" + site
+	}
+	u, err := url.Parse(site)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !strings.Contains(u.Host, ".") || len(site) > 255 {
+		return ""
+	}
+	return site
+}
+
+// bindCompanyInput читает и проверяет профиль компании. false — клиенту уже ответили ошибкой.
+func bindCompanyInput(c *gin.Context) (CompanyInput, bool) {
+	var req struct {
+		Name        string `json:"name"`
+		INN         string `json:"inn"`
+		Description string `json:"description"`
+		Website     string `json:"website"`
+		Phone       string `json:"phone"`
+		Email       string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return CompanyInput{}, false
+	}
+
+	in := CompanyInput{
+		Name:        strings.Join(strings.Fields(req.Name), " "),
+		INN:         strings.TrimSpace(req.INN),
+		Description: strings.TrimSpace(req.Description),
+		Website:     strings.TrimSpace(req.Website),
+		Phone:       strings.TrimSpace(req.Phone),
+		Email:       strings.TrimSpace(req.Email),
+	}
+
+	var msg string
+	switch {
+	case utf8.RuneCountInString(in.Name) < 2 || utf8.RuneCountInString(in.Name) > maxCompanyNameLength:
+		msg = "Название компании должно быть от 2 до 100 символов"
+	case in.INN != "" && !validINN(in.INN):
+		msg = "Неверный ИНН"
+	case utf8.RuneCountInString(in.Description) > maxCompanyDescriptionLength:
+		msg = "Описание не должно быть длиннее 5000 символов"
+	case in.Phone != "" && !phonePattern.MatchString(in.Phone):
+		msg = "Неверный формат телефона"
+	case in.Email != "" && (!validEmail(in.Email) || len(in.Email) > 100):
+		msg = "Неверный формат email"
+	case in.Website != "":
+		if in.Website = normalizeWebsite(in.Website); in.Website == "" {
+			msg = "Неверный адрес сайта"
+		}
+	}
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": msg})
+		return CompanyInput{}, false
+	}
+	return in, true
+}
+
+// memberCompany находит компанию из :id и проверяет, что пользователь в ней состоит.
+// false — клиенту уже ответили ошибкой.
+func (s *Server) memberCompany(c *gin.Context, userID int64) (Company, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return Company{}, false
+	}
+
+	ctx := c.Request.Context()
+	company, err := s.companies.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Компания не найдена"})
+		return company, false
+	} else if err != nil {
+		log.Println("Get company error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return company, false
+	}
+
+	member, err := s.companies.IsMember(ctx, id, userID)
+	if err != nil {
+		log.Println("Get company error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return company, false
+	}
+	if !member {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Недостаточно прав"})
+		return company, false
+	}
+	return company, true
+}
+
+// ownerCompany — как memberCompany, но пускает только владельцев компании
+func (s *Server) ownerCompany(c *gin.Context, userID int64) (Company, bool) {
+	company, ok := s.memberCompany(c, userID)
+	if !ok {
+		return company, false
+	}
+	role, err := s.companies.MemberRole(c.Request.Context(), company.ID, userID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		log.Println("Get company error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return company, false
+	}
+	if role != CompanyRoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Это может только владелец компании"})
+		return company, false
+	}
+	return company, true
+}
+
+// soleOwnedCompany ищет компанию, где пользователь единственный владелец и есть другие
+// участники: удалив аккаунт, он оставил бы их без владельца
+func (s *Server) soleOwnedCompany(ctx context.Context, userID int64) (Company, bool, error) {
+	companies, err := s.companies.ListForUser(ctx, userID)
+	if err != nil {
+		return Company{}, false, err
+	}
+	for _, company := range companies {
+		members, err := s.companies.Members(ctx, company.ID)
+		if err != nil {
+			return Company{}, false, err
+		}
+		owned, otherOwners := false, false
+		for _, m := range members {
+			if m.Role == CompanyRoleOwner {
+				if m.UserID == userID {
+					owned = true
+				} else {
+					otherOwners = true
+				}
+			}
+		}
+		if owned && !otherOwners && len(members) > 1 {
+			return company, true, nil
+		}
+	}
+	return Company{}, false, nil
+}
+
+// syncEmployerRole выдаёт роль работодателя обычному пользователю, состоящему в проверенной
+// компании, и снимает её, когда таких компаний не осталось. Непроверенная компания роли не даёт:
+// зарегистрировать её может любой, а роль открывает поиск резюме с контактами соискателей.
+// Роли, назначенные администратором, не трогаются. Ошибка только пишется в лог:
+// участие в компании важнее роли.
+func (s *Server) syncEmployerRole(ctx context.Context, userID int64) {
+	companies, err := s.companies.ListForUser(ctx, userID)
+	if err == nil {
+		if slices.ContainsFunc(companies, func(c Company) bool { return c.Verified }) {
+			_, err = s.users.SwitchRole(ctx, userID, RoleUser, RoleEmployer)
+		} else {
+			_, err = s.users.SwitchRole(ctx, userID, RoleEmployer, RoleUser)
+		}
+	}
+	if err != nil {
+		log.Println("Sync employer role error:", err)
+	}
+}
+
+// syncCompanyEmployers пересчитывает роли участников, когда у компании появилась или снялась проверка
+func (s *Server) syncCompanyEmployers(ctx context.Context, companyID int64) {
+	members, err := s.companies.Members(ctx, companyID)
+	if err != nil {
+		log.Println("Sync employer role error:", err)
+		return
+	}
+	for _, m := range members {
+		s.syncEmployerRole(ctx, m.UserID)
+	}
+}
+
+// resolveJobCompany находит компанию для вакансии. По company_id подходит только компания,
+// в которой состоит автор. Старые клиенты присылают лишь название — тогда берётся компания
+// автора с таким названием, а если её нет, она создаётся.
+func (s *Server) resolveJobCompany(ctx context.Context, userID int64, id *int64, name string) (Company, error) {
+	if id != nil {
+		company, err := s.companies.Get(ctx, *id)
+		if errors.Is(err, ErrNotFound) {
+			return company, errCompanyNotFound
+		} else if err != nil {
+			return company, err
+		}
+		member, err := s.companies.IsMember(ctx, company.ID, userID)
+		if err != nil {
+			return company, err
+		}
+		if !member {
+			return company, errCompanyNotMember
+		}
+		return company, nil
+	}
+
+	own, err := s.companies.ListForUser(ctx, userID)
+	if err != nil {
+		return Company{}, err
+	}
+	for _, company := range own {
+		if strings.EqualFold(company.Name, name) {
+			return company, nil
+		}
+	}
+	return s.companies.Create(ctx, CompanyInput{Name: name}, userID)
+}
+
+// getCompaniesHandler — справочник компаний; verified=true|false отбирает
+// проверенные или ожидающие проверки
+func (s *Server) getCompaniesHandler(c *gin.Context) {
+	filter := CompanyFilter{Search: strings.TrimSpace(c.Query("search"))}
+	if v := c.Query("verified"); v != "" {
+		verified, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+			return
+		}
+		filter.Verified = &verified
+	}
+
+	companies, err := s.companies.List(c.Request.Context(), filter)
+	if err != nil {
+		log.Println("Get companies error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, companies)
+}
+
+// getCompanyHandler — страница компании с опубликованными и не истёкшими вакансиями
+func (s *Server) getCompanyHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	company, err := s.companies.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Компания не найдена"})
+		return
+	} else if err != nil {
+		log.Println("Get company error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	now := time.Now()
+	jobs, err := s.jobs.List(ctx, JobFilter{CompanyID: &id, Status: JobStatusApproved, ActiveAt: &now}, PageRequest{})
+	if err != nil {
+		log.Println("Get company error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, CompanyProfile{Company: company, Jobs: jobs.Items})
+}
+
+// getMyCompaniesHandler — компании пользователя, от которых он может размещать вакансии
+func (s *Server) getMyCompaniesHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	companies, err := s.companies.ListForUser(c.Request.Context(), claims.ID)
+	if err != nil {
+		log.Println("Get my companies error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, companies)
+}
+
+func (s *Server) createCompanyHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	// как и вакансии, компании регистрируют только владельцы подтверждённых адресов
+	ctx := c.Request.Context()
+	user, err := s.users.GetByID(ctx, claims.ID)
+	if err != nil {
+		log.Println("Create company error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if !user.EmailVerified {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Подтвердите email, чтобы регистрировать компании"})
+		return
+	}
+
+	in, ok := bindCompanyInput(c)
+	if !ok {
+		return
+	}
+
+	company, err := s.companies.Create(ctx, in, claims.ID)
+	if errors.Is(err, ErrCompanyExists) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Компания с таким ИНН уже зарегистрирована, попросите её участников добавить вас"})
+		return
+	} else if err != nil {
+		log.Println("Create company error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, company)
+}
+
+// updateCompanyHandler — участник правит профиль. После смены названия или ИНН
+// компанию нужно проверить заново.
+func (s *Server) updateCompanyHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	company, ok := s.memberCompany(c, claims.ID)
+	if !ok {
+		return
+	}
+	in, ok := bindCompanyInput(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	updated, err := s.companies.Update(ctx, company.ID, in)
+	if errors.Is(err, ErrCompanyExists) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Компания с таким ИНН уже зарегистрирована"})
+		return
+	} else if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Компания не найдена"})
+		return
+	} else if err != nil {
+		log.Println("Update company error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if updated.Name != company.Name {
+		s.reindexCompanyJobs(ctx, company.ID)
+	}
+	if company.Verified && !updated.Verified {
+		s.syncCompanyEmployers(ctx, company.ID)
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// reindexCompanyJobs обновляет название компании в поисковом индексе вакансий
+func (s *Server) reindexCompanyJobs(ctx context.Context, companyID int64) {
+	jobs, err := s.jobs.List(ctx, JobFilter{CompanyID: &companyID, Status: JobStatusApproved}, PageRequest{})
+	if err != nil {
+		log.Println("Reindex company jobs error:", err)
+		return
+	}
+	for _, j := range jobs.Items {
+		s.search.Index(jobSearchDoc(j))
+	}
+}
+
+// uploadCompanyLogoHandler заменяет логотип: картинка уменьшается до companyLogoSide
+// и хранится в JPEG, прежний файл удаляется
+func (s *Server) uploadCompanyLogoHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	company, ok := s.memberCompany(c, claims.ID)
+	if !ok {
+		return
+	}
+	data, ok := readImageUpload(c, "logo")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	key, err := s.storeCompanyLogo(ctx, company.ID, data)
+	if !imageStoreOK(c, err, "Upload company logo error:") {
+		return
+	}
+
+	updated, err := s.companies.SetLogo(ctx, company.ID, key, s.storage.URL(key))
+	if err != nil {
+		s.deleteStoredFiles(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Компания не найдена"})
+			return
+		}
+		log.Println("Upload company logo error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if company.LogoKey != "" {
+		s.deleteStoredFiles(ctx, company.LogoKey)
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func (s *Server) storeCompanyLogo(ctx context.Context, companyID int64, data []byte) (string, error) {
+	img, _, err := decodeUpload(data)
+	if err != nil {
+		return "", err
+	}
+	logo, err := encodeJPEG(resizeToFit(flattenImage(img), companyLogoSide))
+	if err != nil {
+		return "", err
+	}
+
+	name, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("companies/%d/logo_%s.jpg", companyID, name)
+	return key, s.storage.Save(ctx, key, bytes.NewReader(logo), "image/jpeg")
+}
+
+func (s *Server) getCompanyMembersHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	company, ok := s.memberCompany(c, claims.ID)
+	if !ok {
+		return
+	}
+
+	members, err := s.companies.Members(c.Request.Context(), company.ID)
+	if err != nil {
+		log.Println("Get company members error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// inviteCompanyMemberHandler — владелец приглашает коллегу по логину. Участником, а с ним
+// и работодателем проверенной компании, коллега становится, только приняв приглашение.
+func (s *Server) inviteCompanyMemberHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	company, ok := s.ownerCompany(c, claims.ID)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Username string `json:"username"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Username) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, _, err := s.users.GetByUsername(ctx, strings.TrimSpace(req.Username))
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Пользователь не найден"})
+		return
+	} else if err != nil {
+		log.Println("Invite company member error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	err = s.companies.Invite(ctx, company.ID, user.ID, claims.ID)
+	if errors.Is(err, ErrAlreadyMember) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Пользователь уже участник компании"})
+		return
+	} else if err != nil {
+		log.Println("Invite company member error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	log.Printf("Компания %d: %s пригласил участника %s", company.ID, claims.Username, user.Username)
+
+	if err := s.mailUser(ctx, user.ID, "Приглашение в компанию",
+		"%s приглашает вас в участники компании «%s»: участники размещают вакансии от её имени и правят её профиль.\n"+
+			"Принять или отклонить приглашение можно в профиле:\n%s/profile\n",
+		claims.Username, company.Name, s.appURL,
+	); err != nil {
+		log.Println("Send company invitation email error:", err)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Приглашение отправлено"})
+}
+
+// getMyCompanyInvitationsHandler — приглашения в компании, ждущие ответа пользователя
+func (s *Server) getMyCompanyInvitationsHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	invitations, err := s.companies.Invitations(c.Request.Context(), claims.ID)
+	if err != nil {
+		log.Println("Get company invitations error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// acceptCompanyInvitationHandler делает пользователя участником компании, в которую его пригласили
+func (s *Server) acceptCompanyInvitationHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+	companyID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	err = s.companies.AcceptInvitation(ctx, companyID, claims.ID)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Приглашение не найдено"})
+		return
+	} else if err != nil {
+		log.Println("Accept company invitation error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	s.syncEmployerRole(ctx, claims.ID)
+	log.Printf("Компания %d: %s принял приглашение", companyID, claims.Username)
+
+	company, err := s.companies.Get(ctx, companyID)
+	if err != nil {
+		log.Println("Accept company invitation error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, company)
+}
+
+func (s *Server) declineCompanyInvitationHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+	companyID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	err = s.companies.DeclineInvitation(c.Request.Context(), companyID, claims.ID)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Приглашение не найдено"})
+		return
+	} else if err != nil {
+		log.Println("Decline company invitation error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Приглашение отклонено"})
+}
+
+// setCompanyMemberRoleHandler — владелец назначает участника владельцем или снимает с него
+// эту роль, в том числе с себя; последнего владельца снять нельзя
+func (s *Server) setCompanyMemberRoleHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	company, ok := s.ownerCompany(c, claims.ID)
+	if !ok {
+		return
+	}
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Role != CompanyRoleOwner && req.Role != CompanyRoleMember) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неизвестная роль"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	err = s.companies.SetMemberRole(ctx, company.ID, userID, req.Role)
+	if errors.Is(err, ErrLastOwner) {
+		c.JSON(http.StatusConflict, gin.H{"message": "У компании должен остаться владелец"})
+		return
+	} else if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Участник не найден"})
+		return
+	} else if err != nil {
+		log.Println("Set company member role error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	log.Printf("Компания %d: %s назначил участнику %d роль %s", company.ID, claims.Username, userID, req.Role)
+
+	members, err := s.companies.Members(ctx, company.ID)
+	if err != nil {
+		log.Println("Set company member role error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// removeCompanyMemberHandler: участник может уйти из компании сам, убрать другого — только владелец.
+// Последнего участника и последнего владельца убрать нельзя, иначе компанией некому будет управлять.
+func (s *Server) removeCompanyMemberHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+	var company Company
+	var ok bool
+	if userID == claims.ID {
+		company, ok = s.memberCompany(c, claims.ID)
+	} else {
+		company, ok = s.ownerCompany(c, claims.ID)
+	}
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	err = s.companies.RemoveMember(ctx, company.ID, userID)
+	if errors.Is(err, ErrLastMember) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Нельзя убрать последнего участника компании"})
+		return
+	} else if errors.Is(err, ErrLastOwner) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Сначала назначьте владельцем другого участника"})
+		return
+	} else if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Участник не найден"})
+		return
+	} else if err != nil {
+		log.Println("Remove company member error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	s.syncEmployerRole(ctx, userID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Участник удалён"})
+}
+
+// verifyCompanyHandler — администратор подтверждает компанию после проверки
+// документов или снимает отметку
+func (s *Server) verifyCompanyHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	var req struct {
+		Verified *bool `json:"verified"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Verified == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	company, err := s.companies.SetVerified(ctx, id, *req.Verified)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Компания не найдена"})
+		return
+	} else if err != nil {
+		log.Println("Verify company error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	s.syncCompanyEmployers(ctx, company.ID)
+
+	if claims := getUserClaims(c); claims != nil {
+		log.Printf("Компания %d: проверка %t, администратор %s", id, company.Verified, claims.Username)
+	}
+	c.JSON(http.StatusOK, company)
+}
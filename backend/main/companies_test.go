@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (ts *testServer) role(t *testing.T, userID int64) string {
+	t.Helper()
+	user, err := ts.users.GetByID(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user.Role
+}
+
+// роль работодателя даёт только проверенная компания
+func TestEmployerRoleRequiresVerifiedCompany(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	ownerID, owner := ts.register(t, "prorab")
+	if err := ts.users.MarkEmailVerified(ctx, ownerID); err != nil {
+		t.Fatal(err)
+	}
+	colleagueID, colleague := ts.register(t, "master")
+	adminID, admin := ts.register(t, "admin")
+	ts.grantRole(t, adminID, "admin")
+
+	var company Company
+	expect(t, ts.do(t, http.MethodPost, "/api/companies", owner, gin.H{"name": "СтройГрупп"}), http.StatusCreated, &company)
+	// компания, заведённая по названию из вакансии, тоже не даёт роли
+	if _, err := ts.resolveJobCompany(ctx, colleagueID, nil, "РемонтПро"); err != nil {
+		t.Fatal(err)
+	}
+	if ts.role(t, ownerID) != RoleUser || ts.role(t, colleagueID) != RoleUser {
+		t.Fatal("unverified company granted the employer role")
+	}
+	expect(t, ts.do(t, http.MethodGet, "/api/resumes", owner, nil), http.StatusForbidden, nil)
+
+	verify := func(verified bool) {
+		t.Helper()
+		w := ts.do(t, http.MethodPut, "/api/admin/companies/"+itoa(company.ID)+"/verify", admin, gin.H{"verified": verified})
+		expect(t, w, http.StatusOK, nil)
+	}
+	verify(true)
+	if got := ts.role(t, ownerID); got != RoleEmployer {
+		t.Fatalf("role after verification = %s, want %s", got, RoleEmployer)
+	}
+	expect(t, ts.do(t, http.MethodGet, "/api/resumes", owner, nil), http.StatusOK, nil)
+
+	// коллега, принявший приглашение в проверенную компанию, становится работодателем, а ушедший — перестаёт
+	w := ts.do(t, http.MethodPost, "/api/companies/"+itoa(company.ID)+"/invitations", owner, gin.H{"username": "master"})
+	expect(t, w, http.StatusCreated, nil)
+	w = ts.do(t, http.MethodPost, "/api/my/company-invitations/"+itoa(company.ID)+"/accept", colleague, nil)
+	expect(t, w, http.StatusOK, nil)
+	if got := ts.role(t, colleagueID); got != RoleEmployer {
+		t.Fatalf("colleague role = %s, want %s", got, RoleEmployer)
+	}
+	w = ts.do(t, http.MethodDelete, "/api/companies/"+itoa(company.ID)+"/members/"+itoa(colleagueID), colleague, nil)
+	expect(t, w, http.StatusOK, nil)
+	if got := ts.role(t, colleagueID); got != RoleUser {
+		t.Fatalf("colleague role after leaving = %s, want %s", got, RoleUser)
+	}
+
+	// смена названия снимает проверку, а с ней и роль
+	w = ts.do(t, http.MethodPut, "/api/companies/"+itoa(company.ID), owner, gin.H{"name": "СтройГрупп Плюс"})
+	expect(t, w, http.StatusOK, nil)
+	if got := ts.role(t, ownerID); got != RoleUser {
+		t.Fatalf("role after rename = %s, want %s", got, RoleUser)
+	}
+
+	verify(true)
+	verify(false)
+	if got := ts.role(t, ownerID); got != RoleUser {
+		t.Fatalf("role after unverification = %s, want %s", got, RoleUser)
+	}
+}
+
+// роль, назначенную администратором, участие в компаниях не меняет
+func TestEmployerRoleAssignedByAdmin(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	userID, token := ts.register(t, "prorab")
+	if err := ts.users.MarkEmailVerified(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+	adminID, admin := ts.register(t, "admin")
+	ts.grantRole(t, adminID, "admin")
+
+	w := ts.do(t, http.MethodPut, "/api/admin/users/"+itoa(userID)+"/role", admin, gin.H{"role": RoleEmployer})
+	expect(t, w, http.StatusOK, nil)
+	expect(t, ts.do(t, http.MethodGet, "/api/resumes", token, nil), http.StatusOK, nil)
+
+	var company Company
+	expect(t, ts.do(t, http.MethodPost, "/api/companies", token, gin.H{"name": "СтройГрупп"}), http.StatusCreated, &company)
+	w = ts.do(t, http.MethodPut, "/api/admin/companies/"+itoa(company.ID)+"/verify", admin, gin.H{"verified": false})
+	expect(t, w, http.StatusOK, nil)
+	if got := ts.role(t, userID); got != RoleEmployer {
+		t.Fatalf("role = %s, want %s", got, RoleEmployer)
+	}
+
+	// право поиска резюме не делает роль административной: вход без второго фактора
+	var resp struct {
+		Token string `json:"token"`
+	}
+	w = ts.do(t, http.MethodPost, "/api/login", "", gin.H{"username": "prorab", "password": "Kirpich-2024-stroy"})
+	expect(t, w, http.StatusOK, &resp)
+	if resp.Token == "" {
+		t.Fatalf("employer login asked for a second factor: %s", w.Body.String())
+	}
+}
+
+// пригласить в компанию можно любого, но участником он становится, только приняв приглашение
+func TestCompanyInvitation(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	ownerID, owner := ts.register(t, "prorab")
+	if err := ts.users.MarkEmailVerified(ctx, ownerID); err != nil {
+		t.Fatal(err)
+	}
+	guestID, guest := ts.register(t, "master")
+	_, stranger := ts.register(t, "chuzhoy")
+
+	var company Company
+	expect(t, ts.do(t, http.MethodPost, "/api/companies", owner, gin.H{"name": "СтройГрупп"}), http.StatusCreated, &company)
+	if _, err := ts.companies.SetVerified(ctx, company.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	invitations := "/api/companies/" + itoa(company.ID) + "/invitations"
+
+	// чужая компания приглашать не может
+	expect(t, ts.do(t, http.MethodPost, invitations, stranger, gin.H{"username": "master"}), http.StatusForbidden, nil)
+	expect(t, ts.do(t, http.MethodPost, invitations, owner, gin.H{"username": "nobody"}), http.StatusNotFound, nil)
+	expect(t, ts.do(t, http.MethodPost, invitations, owner, gin.H{"username": "prorab"}), http.StatusConflict, nil)
+
+	before := ts.mail.count()
+	expect(t, ts.do(t, http.MethodPost, invitations, owner, gin.H{"username": "master"}), http.StatusCreated, nil)
+	if mail := ts.waitMail(t, before+1); mail.To != "master@example.com" || !strings.Contains(mail.Body, "СтройГрупп") {
+		t.Fatalf("invitation mail = %+v", mail)
+	}
+
+	// до ответа приглашённый не участник и не работодатель
+	if member, _ := ts.companies.IsMember(ctx, company.ID, guestID); member {
+		t.Fatal("invited user became a member before accepting")
+	}
+	if got := ts.role(t, guestID); got != RoleUser {
+		t.Fatalf("invited user role = %s, want %s", got, RoleUser)
+	}
+	expect(t, ts.do(t, http.MethodGet, "/api/companies/"+itoa(company.ID)+"/members", guest, nil), http.StatusForbidden, nil)
+
+	var pending []CompanyInvitation
+	expect(t, ts.do(t, http.MethodGet, "/api/my/company-invitations", guest, nil), http.StatusOK, &pending)
+	if len(pending) != 1 || pending[0].CompanyID != company.ID || pending[0].InvitedBy != "prorab" {
+		t.Fatalf("invitations = %+v", pending)
+	}
+
+	// чужое приглашение принять нельзя
+	accept := "/api/my/company-invitations/" + itoa(company.ID) + "/accept"
+	expect(t, ts.do(t, http.MethodPost, accept, stranger, nil), http.StatusNotFound, nil)
+
+	// отклонённое приглашение больше не действует
+	expect(t, ts.do(t, http.MethodDelete, "/api/my/company-invitations/"+itoa(company.ID), guest, nil), http.StatusOK, nil)
+	expect(t, ts.do(t, http.MethodPost, accept, guest, nil), http.StatusNotFound, nil)
+
+	expect(t, ts.do(t, http.MethodPost, invitations, owner, gin.H{"username": "master"}), http.StatusCreated, nil)
+	expect(t, ts.do(t, http.MethodPost, accept, guest, nil), http.StatusOK, nil)
+	if got := ts.role(t, guestID); got != RoleEmployer {
+		t.Fatalf("role after accepting = %s, want %s", got, RoleEmployer)
+	}
+	expect(t, ts.do(t, http.MethodGet, "/api/my/company-invitations", guest, nil), http.StatusOK, &pending)
+	if len(pending) != 0 {
+		t.Fatalf("accepted invitation still pending: %+v", pending)
+	}
+}
+
+// приглашать и убирать коллег может только владелец, участник — лишь уйти сам
+func TestCompanyOwnerRole(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	ownerID, owner := ts.register(t, "prorab")
+	if err := ts.users.MarkEmailVerified(ctx, ownerID); err != nil {
+		t.Fatal(err)
+	}
+	var company Company
+	expect(t, ts.do(t, http.MethodPost, "/api/companies", owner, gin.H{"name": "СтройГрупп"}), http.StatusCreated, &company)
+	base := "/api/companies/" + itoa(company.ID)
+
+	join := func(username string) (int64, string) {
+		t.Helper()
+		id, token := ts.register(t, username)
+		expect(t, ts.do(t, http.MethodPost, base+"/invitations", owner, gin.H{"username": username}), http.StatusCreated, nil)
+		expect(t, ts.do(t, http.MethodPost, "/api/my/company-invitations/"+itoa(company.ID)+"/accept", token, nil), http.StatusOK, nil)
+		return id, token
+	}
+	masterID, master := join("master")
+	helperID, helper := join("pomoshnik")
+
+	var members []CompanyMember
+	expect(t, ts.do(t, http.MethodGet, base+"/members", master, nil), http.StatusOK, &members)
+	if len(members) != 3 || members[0].UserID != ownerID || members[0].Role != CompanyRoleOwner || members[1].Role != CompanyRoleMember {
+		t.Fatalf("members = %+v", members)
+	}
+
+	// обычный участник не убирает ни владельца, ни коллег и не приглашает
+	expect(t, ts.do(t, http.MethodDelete, base+"/members/"+itoa(ownerID), master, nil), http.StatusForbidden, nil)
+	expect(t, ts.do(t, http.MethodDelete, base+"/members/"+itoa(helperID), master, nil), http.StatusForbidden, nil)
+	expect(t, ts.do(t, http.MethodPut, base+"/members/"+itoa(masterID), master, gin.H{"role": CompanyRoleOwner}), http.StatusForbidden, nil)
+	ts.register(t, "novichok")
+	expect(t, ts.do(t, http.MethodPost, base+"/invitations", master, gin.H{"username": "novichok"}), http.StatusForbidden, nil)
+	if member, _ := ts.companies.IsMember(ctx, company.ID, ownerID); !member {
+		t.Fatal("member removed the owner")
+	}
+
+	// но может уйти сам
+	expect(t, ts.do(t, http.MethodDelete, base+"/members/"+itoa(masterID), master, nil), http.StatusOK, nil)
+
+	// единственный владелец не уходит и не удаляет аккаунт, пока не назначит преемника
+	expect(t, ts.do(t, http.MethodDelete, base+"/members/"+itoa(ownerID), owner, nil), http.StatusConflict, nil)
+	w := ts.do(t, http.MethodDelete, "/api/me", owner, gin.H{"password": "Kirpich-2024-stroy"})
+	expect(t, w, http.StatusConflict, nil)
+
+	expect(t, ts.do(t, http.MethodPut, base+"/members/"+itoa(helperID), owner, gin.H{"role": CompanyRoleOwner}), http.StatusOK, &members)
+	if len(members) != 2 || members[1].UserID != helperID || members[1].Role != CompanyRoleOwner {
+		t.Fatalf("members after promotion = %+v", members)
+	}
+	expect(t, ts.do(t, http.MethodDelete, base+"/members/"+itoa(ownerID), helper, nil), http.StatusOK, nil)
+	expect(t, ts.do(t, http.MethodPut, base+"/members/"+itoa(helperID), helper, gin.H{"role": CompanyRoleMember}), http.StatusConflict, nil)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// jobLifetime — срок публикации вакансии после одобрения или продления
+	jobLifetime = 30 * 24 * time.Hour
+	// jobExpiryWarning — за сколько до окончания срока автору приходит письмо
+	jobExpiryWarning = 3 * 24 * time.Hour
+)
+
+// jobExpiredReason записывается в журнал модерации при снятии вакансии по сроку
+const jobExpiredReason = "Истёк срок публикации"
+
+// startJobExpiryWorker периодически предупреждает авторов о скором окончании срока
+// и убирает в архив истёкшие вакансии. Первый проход — сразу при запуске, чтобы
+// после перезапуска истёкшие вакансии не ждали целый интервал.
+func (s *Server) startJobExpiryWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.expireJobs(context.Background(), time.Now())
+			<-ticker.C
+		}
+	}()
+}
+
+// expireJobs — один проход фоновой задачи. Ошибки писем только логируются:
+// вакансия всё равно уходит в архив, а предупреждение повторится на следующем проходе.
+func (s *Server) expireJobs(ctx context.Context, now time.Time) {
+	expiring, err := s.jobs.ExpiringBefore(ctx, now.Add(jobExpiryWarning))
+	if err != nil {
+		log.Println("Job expiry warning error:", err)
+	}
+	for _, job := range expiring {
+		if !job.ExpiresAt.After(now) {
+			continue // уже истекла, предупреждать поздно
+		}
+		if err := s.sendJobExpiryEmail(ctx, job); err != nil {
+			log.Println("Send job expiry email error:", err)
+			continue
+		}
+		if err := s.jobs.MarkExpiryWarned(ctx, job.ID); err != nil {
+			log.Println("Job expiry warning error:", err)
+		}
+	}
+
+	archived, err := s.jobs.ArchiveExpired(ctx, now)
+	if err != nil {
+		log.Println("Job archive error:", err)
+		return
+	}
+	for _, job := range archived {
+		s.search.Remove(SearchKindJob, job.ID)
+		if err := s.sendJobArchivedEmail(ctx, job); err != nil {
+			log.Println("Send job archived email error:", err)
+		}
+	}
+	if len(archived) > 0 {
+		log.Printf("Снято с публикации по сроку вакансий: %d", len(archived))
+	}
+}
+
+// renewJobHandler — автор продлевает публикацию ещё на jobLifetime. Снятая по сроку вакансия
+// возвращается на доску без повторной модерации: её содержимое уже проверено.
+func (s *Server) renewJobHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	job, ok := s.ownJob(c, claims.ID)
+	if !ok {
+		return
+	}
+	// у снятых модератором вакансий срока нет, их возвращает только модератор
+	renewable := job.Status == JobStatusApproved || (job.Status == JobStatusArchived && job.ExpiresAt != nil)
+	if !renewable {
+		c.JSON(http.StatusConflict, gin.H{"message": "Продлить можно только опубликованную или снятую по сроку вакансию"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	job, err := s.jobs.Renew(ctx, job.ID, job.Status, time.Now().Add(jobLifetime), claims.ID)
+	if errors.Is(err, ErrStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Статус вакансии уже изменился, обновите страницу"})
+		return
+	} else if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Вакансия не найдена"})
+		return
+	} else if err != nil {
+		log.Println("Renew job error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	s.reindexJob(ctx, job.ID)
+
+	c.JSON(http.StatusOK, job)
+}
+
+func (s *Server) sendJobExpiryEmail(ctx context.Context, job Job) error {
+	return s.mailJobAuthor(ctx, job, "Срок публикации вакансии заканчивается",
+		"Вакансия «%s» будет снята с публикации %s.\n\n"+
+			"Если она ещё актуальна, продлите её в разделе «Мои вакансии»:\n%s/profile\n",
+		job.Title, job.ExpiresAt.Format("02.01.2006 15:04"), s.appURL)
+}
+
+func (s *Server) sendJobArchivedEmail(ctx context.Context, job Job) error {
+	return s.mailJobAuthor(ctx, job, "Вакансия снята с публикации",
+		"Срок публикации вакансии «%s» истёк, она перенесена в архив.\n\n"+
+			"Вернуть её на доску можно в разделе «Мои вакансии»:\n%s/profile\n",
+		job.Title, s.appURL)
+}
+
+// mailJobAuthor пишет автору вакансии; у удалённых авторов письма нет
+func (s *Server) mailJobAuthor(ctx context.Context, job Job, subject, format string, args ...interface{}) error {
+	if job.UserID == nil {
+		return nil
+	}
+	return s.mailUser(ctx, *job.UserID, subject, format, args...)
+}
+
+// mailUser пишет пользователю; удалённым пользователям и пользователям без email письма нет
+func (s *Server) mailUser(ctx context.Context, userID int64, subject, format string, args ...interface{}) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return nil
+	}
+	return s.mailer.Send(ctx, Mail{
+		To:      user.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf("Здравствуйте, %s!\n\n", user.Username) + fmt.Sprintf(format, args...),
+	})
+}
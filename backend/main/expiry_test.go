@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// первый проход фоновой задачи идёт сразу при запуске, а не через интервал
+func TestJobExpiryWorkerRunsOnStart(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+	job := ts.job(t, "Прораб", SalaryRange{Currency: "RUB", Period: SalaryPeriodMonth})
+	if _, err := ts.jobs.Renew(ctx, job.ID, JobStatusApproved, time.Now().Add(-time.Minute), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	ts.startJobExpiryWorker(time.Hour)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		job, err := ts.jobs.Get(ctx, job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status == JobStatusArchived {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("status = %s, want %s", job.Status, JobStatusArchived)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
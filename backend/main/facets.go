@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPriceBuckets = 5
+	maxPriceBuckets     = 20
+)
+
+var errInvalidPrice = errors.New("invalid price")
+
+// parseProductFilter разбирает фильтры каталога, общие для списка товаров и фасетов.
+// category можно передать несколько раз или через запятую (см. categoryRefs).
+func (s *Server) parseProductFilter(c *gin.Context) (ProductFilter, error) {
+	filter := ProductFilter{
+		Search:  c.Query("search"),
+		InStock: c.Query("in_stock") == "true",
+	}
+
+	var err error
+	if filter.MinPrice, err = parsePriceParam(c.Query("min_price")); err != nil {
+		return filter, err
+	}
+	if filter.MaxPrice, err = parsePriceParam(c.Query("max_price")); err != nil {
+		return filter, err
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return filter, errInvalidPrice
+	}
+
+	if filter.CategoryIDs, err = s.categoryFilterIDs(c.Request.Context(), categoryRefs(c)); err != nil {
+		return filter, err
+	}
+
+	// поиск идёт по индексу (название, описание, категория), а не LIKE по названию
+	if filter.Search != "" {
+		filter.IDs = s.searchIDs(filter.Search, SearchKindProduct)
+		filter.Search = ""
+	}
+
+	return filter, nil
+}
+
+func parsePriceParam(v string) (*float64, error) {
+	if v == "" {
+		return nil, nil
+	}
+	price, err := strconv.ParseFloat(v, 64)
+	if err != nil || price < 0 || math.IsInf(price, 0) || math.IsNaN(price) {
+		return nil, errInvalidPrice
+	}
+	return &price, nil
+}
+
+// priceHistogram делит диапазон цен на не более чем n интервалов «круглой» ширины (1, 2, 5 × 10^k)
+func priceHistogram(lo, hi float64, n int) []PriceBucket {
+	span := hi - lo
+	if span <= 0 {
+		return []PriceBucket{{From: lo, To: hi}}
+	}
+
+	raw := span / float64(n)
+	mag := math.Pow(10, math.Floor(math.Log10(raw)))
+	width := 10 * mag
+	for _, m := range []float64{1, 2, 5} {
+		if m*mag >= raw {
+			width = m * mag
+			break
+		}
+	}
+
+	start := math.Floor(lo/width) * width
+	count := max(int(math.Ceil((hi-start)/width)), 1)
+
+	buckets := make([]PriceBucket, count)
+	for i := range buckets {
+		buckets[i].From = roundPrice(start + float64(i)*width)
+		buckets[i].To = roundPrice(start + float64(i+1)*width)
+	}
+	return buckets
+}
+
+// bucketIndex возвращает интервал для цены; верхняя граница последнего интервала включается в него
+func bucketIndex(buckets []PriceBucket, price float64) int {
+	width := buckets[0].To - buckets[0].From
+	if width <= 0 {
+		return 0
+	}
+	i := int(math.Floor((price - buckets[0].From) / width))
+	return min(max(i, 0), len(buckets)-1)
+}
+
+func roundPrice(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func (s *Server) getProductFacetsHandler(c *gin.Context) {
+	filter, err := s.parseProductFilter(c)
+	if errors.Is(err, errInvalidPrice) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный диапазон цен"})
+		return
+	} else if err != nil {
+		log.Println("Get product facets error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	buckets := defaultPriceBuckets
+	if v := c.Query("buckets"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPriceBuckets {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Неверное количество интервалов"})
+			return
+		}
+		buckets = n
+	}
+
+	facets, err := s.products.Facets(c.Request.Context(), filter, buckets)
+	if err != nil {
+		log.Println("Get product facets error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, facets)
+}
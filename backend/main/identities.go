@@ -0,0 +1,409 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	TokenPurposeOAuthLogin = "oauth_login"
+
+	// за это время фронтенд должен обменять код из редиректа на токены
+	oauthLoginTTL = 5 * time.Minute
+)
+
+// Коды ошибок в редиректе на фронтенд (?oauth_error=...); тексты показывает фронтенд
+const (
+	oauthErrDenied     = "denied"
+	oauthErrExpired    = "expired"
+	oauthErrProvider   = "provider"
+	oauthErrNoEmail    = "no_email"
+	oauthErrEmailTaken = "email_taken"
+	oauthErrLinked     = "linked"
+	oauthErrServer     = "server"
+)
+
+var usernameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+func (s *Server) oauthProvider(name string) *OAuthProvider {
+	for _, p := range s.oauthProviders {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Server) getOAuthProvidersHandler(c *gin.Context) {
+	providers := []gin.H{}
+	for _, p := range s.oauthProviders {
+		providers = append(providers, gin.H{"name": p.Name, "title": p.Title})
+	}
+	c.JSON(http.StatusOK, providers)
+}
+
+// startOAuth сохраняет state с PKCE-верификатором и отдаёт адрес страницы провайдера.
+// userID != nil — внешний аккаунт привязывается к уже вошедшему пользователю.
+func (s *Server) startOAuth(c *gin.Context, p *OAuthProvider, userID *int64) {
+	state, err := randomHex(32)
+	var verifier, nonce string
+	if err == nil {
+		verifier, err = randomHex(32)
+	}
+	if err == nil {
+		nonce, err = randomHex(16)
+	}
+	if err == nil {
+		err = s.identities.SaveState(c.Request.Context(), hashToken(state), OAuthState{
+			Provider:     p.Name,
+			CodeVerifier: verifier,
+			Nonce:        nonce,
+			UserID:       userID,
+			ExpiresAt:    time.Now().Add(oauthStateTTL),
+		})
+	}
+	if err != nil {
+		log.Println("OAuth start error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"url": p.authCodeURL(state, verifier, nonce)})
+}
+
+func (s *Server) startOAuthLoginHandler(c *gin.Context) {
+	p := s.oauthProvider(c.Param("provider"))
+	if p == nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Провайдер не найден"})
+		return
+	}
+	s.startOAuth(c, p, nil)
+}
+
+func (s *Server) startOAuthLinkHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+	p := s.oauthProvider(c.Param("provider"))
+	if p == nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Провайдер не найден"})
+		return
+	}
+
+	identities, err := s.identities.ListForUser(c.Request.Context(), claims.ID)
+	if err != nil {
+		log.Println("OAuth link error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	for _, i := range identities {
+		if i.Provider == p.Name {
+			c.JSON(http.StatusConflict, gin.H{"message": "Аккаунт этого провайдера уже привязан"})
+			return
+		}
+	}
+
+	userID := claims.ID
+	s.startOAuth(c, p, &userID)
+}
+
+// oauthRedirect возвращает браузер на фронтенд
+func (s *Server) oauthRedirect(c *gin.Context, path string, params url.Values) {
+	c.Redirect(http.StatusFound, s.appURL+path+"?"+params.Encode())
+}
+
+// oauthCallbackHandler — сюда провайдер возвращает браузер с кодом. Ответ всегда редирект
+// на фронтенд: при входе с одноразовым кодом для /api/oauth/exchange, при привязке — в профиль.
+func (s *Server) oauthCallbackHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	query := c.Request.URL.Query()
+
+	p := s.oauthProvider(c.Param("provider"))
+	st, err := s.identities.TakeState(ctx, hashToken(query.Get("state")))
+	if p == nil || errors.Is(err, ErrInvalidToken) || (err == nil && st.Provider != p.Name) {
+		s.oauthRedirect(c, "/login", url.Values{"oauth_error": {oauthErrExpired}})
+		return
+	} else if err != nil {
+		log.Println("OAuth callback error:", err)
+		s.oauthRedirect(c, "/login", url.Values{"oauth_error": {oauthErrServer}})
+		return
+	}
+
+	back := "/login"
+	if st.UserID != nil {
+		back = "/profile"
+	}
+	fail := func(code string) {
+		s.oauthRedirect(c, back, url.Values{"oauth_error": {code}})
+	}
+
+	if query.Get("error") != "" || query.Get("code") == "" {
+		fail(oauthErrDenied)
+		return
+	}
+
+	token, err := p.exchange(ctx, query.Get("code"), st.CodeVerifier, query)
+	var ident ExternalIdentity
+	if err == nil {
+		ident, err = p.identity(ctx, p, token, st.Nonce)
+	}
+	if err != nil {
+		log.Println("OAuth callback error:", err)
+		fail(oauthErrProvider)
+		return
+	}
+
+	if st.UserID != nil {
+		err := s.identities.Link(ctx, *st.UserID, Identity{Provider: p.Name, Subject: ident.Subject, Email: ident.Email})
+		if errors.Is(err, ErrIdentityLinked) {
+			fail(oauthErrLinked)
+			return
+		} else if err != nil {
+			log.Println("OAuth link error:", err)
+			fail(oauthErrServer)
+			return
+		}
+		log.Printf("Пользователю id %d привязан аккаунт %s", *st.UserID, p.Name)
+		s.oauthRedirect(c, "/profile", url.Values{"linked": {p.Name}})
+		return
+	}
+
+	user, code, err := s.oauthUser(ctx, p, ident)
+	if err != nil {
+		log.Println("OAuth login error:", err)
+		fail(oauthErrServer)
+		return
+	}
+	if code != "" {
+		fail(code)
+		return
+	}
+
+	link, err := s.issueUserToken(ctx, user.ID, TokenPurposeOAuthLogin, "/oauth/callback", oauthLoginTTL)
+	if err != nil {
+		log.Println("OAuth login error:", err)
+		fail(oauthErrServer)
+		return
+	}
+	c.Redirect(http.StatusFound, link)
+}
+
+// oauthUser находит пользователя по внешнему аккаунту или заводит нового.
+// Существующий аккаунт с тем же email привязывается, только если адрес подтвердили
+// и провайдер, и мы: иначе чужой аккаунт можно было бы захватить, указав его почту.
+// Непустой code — ошибка для пользователя.
+func (s *Server) oauthUser(ctx context.Context, p *OAuthProvider, ident ExternalIdentity) (user User, code string, err error) {
+	userID, err := s.identities.FindUser(ctx, p.Name, ident.Subject)
+	if err == nil {
+		user, err = s.users.GetByID(ctx, userID)
+		return user, "", err
+	} else if !errors.Is(err, ErrNotFound) {
+		return user, "", err
+	}
+
+	if ident.Email == "" || !validEmail(ident.Email) {
+		return user, oauthErrNoEmail, nil
+	}
+	identity := Identity{Provider: p.Name, Subject: ident.Subject, Email: ident.Email}
+
+	user, err = s.users.GetByEmail(ctx, ident.Email)
+	if err == nil {
+		if !ident.EmailVerified || !user.EmailVerified {
+			return user, oauthErrEmailTaken, nil
+		}
+		if err := s.identities.Link(ctx, user.ID, identity); errors.Is(err, ErrIdentityLinked) {
+			return user, oauthErrLinked, nil
+		} else if err != nil {
+			return user, "", err
+		}
+		log.Printf("Аккаунт %s привязан к %s по подтверждённому email", p.Name, user.Username)
+		return user, "", nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return user, "", err
+	}
+
+	username, err := s.freeUsername(ctx, ident)
+	if err != nil {
+		return user, "", err
+	}
+	// пароля нет: войти можно через провайдера, задать пароль — через восстановление
+	user, err = s.users.Create(ctx, username, ident.Email, "", RoleUser)
+	if err != nil {
+		return user, "", err
+	}
+	if ident.EmailVerified {
+		if err := s.users.MarkEmailVerified(ctx, user.ID); err != nil {
+			return user, "", err
+		}
+		user.EmailVerified = true
+	} else if err := s.sendVerificationEmail(ctx, user); err != nil {
+		log.Println("OAuth login error:", err)
+	}
+	if err := s.identities.Link(ctx, user.ID, identity); err != nil {
+		return user, "", err
+	}
+
+	log.Printf("Новый пользователь создан через %s: %s", p.Name, user.Username)
+	return user, "", nil
+}
+
+// freeUsername подбирает свободный логин из логина у провайдера или начала email
+func (s *Server) freeUsername(ctx context.Context, ident ExternalIdentity) (string, error) {
+	base := ident.Login
+	if base == "" {
+		base, _, _ = strings.Cut(ident.Email, "@")
+	}
+	base = usernameUnsafe.ReplaceAllString(base, "")
+	if len(base) < 3 {
+		base = "user" + base
+	}
+	if len(base) > 40 {
+		base = base[:40]
+	}
+
+	candidate := base
+	for i := 2; i <= 20; i++ {
+		_, _, err := s.users.GetByUsername(ctx, candidate)
+		if errors.Is(err, ErrNotFound) {
+			return candidate, nil
+		} else if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s%d", base, i)
+	}
+
+	suffix, err := randomHex(4)
+	if err != nil {
+		return "", err
+	}
+	return base + "_" + suffix, nil
+}
+
+// oauthExchangeHandler меняет одноразовый код из редиректа на токены. Для ролей
+// с обязательной 2FA вход продолжается через /api/login/2fa, как после пароля.
+func (s *Server) oauthExchangeHandler(c *gin.Context) {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID, err := s.userTokens.Consume(ctx, TokenPurposeOAuthLogin, hashToken(req.Token))
+	var user User
+	if err == nil {
+		user, err = s.users.GetByID(ctx, userID)
+	}
+	if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Время на вход истекло, войдите заново"})
+		return
+	} else if err != nil {
+		log.Println("OAuth exchange error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	challenge, err := s.loginChallenge(ctx, user)
+	if err != nil {
+		log.Println("OAuth exchange error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if challenge != nil {
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	tokens, err := s.startSession(c, user)
+	if err != nil {
+		log.Println("OAuth exchange error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	resp, err := s.authResponse(ctx, tokens, user)
+	if err != nil {
+		log.Println("OAuth exchange error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	log.Printf("Успешный логин: %s (внешний провайдер)", user.Username)
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) getIdentitiesHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	identities, err := s.identities.ListForUser(ctx, claims.ID)
+	var hashed string
+	if err == nil {
+		hashed, err = s.users.PasswordHash(ctx, claims.ID)
+	}
+	if err != nil {
+		log.Println("Get identities error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"identities":   identities,
+		"has_password": hashed != "",
+	})
+}
+
+// unlinkIdentityHandler отвязывает внешний аккаунт. Последний способ входа
+// у пользователя без пароля не отвязываем.
+func (s *Server) unlinkIdentityHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	provider := c.Param("provider")
+	identities, err := s.identities.ListForUser(ctx, claims.ID)
+	var hashed string
+	if err == nil {
+		hashed, err = s.users.PasswordHash(ctx, claims.ID)
+	}
+	if err != nil {
+		log.Println("Unlink identity error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if hashed == "" && len(identities) == 1 && identities[0].Provider == provider {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Сначала задайте пароль, иначе вы не сможете войти"})
+		return
+	}
+
+	if err := s.identities.Unlink(ctx, claims.ID, provider); errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Аккаунт не привязан"})
+		return
+	} else if err != nil {
+		log.Println("Unlink identity error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	log.Printf("Пользователь %s отвязал аккаунт %s", claims.Username, provider)
+	c.JSON(http.StatusOK, gin.H{"message": "Аккаунт отвязан"})
+}
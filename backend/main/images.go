@@ -0,0 +1,410 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // декодеры регистрируются для image.Decode
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxImageUploadSize = 5 << 20 // 5 МБ
+	maxImagePixels     = 25_000_000
+	maxProductImages   = 10
+	mediumImageSide    = 800
+	thumbImageSide     = 300
+	thumbJPEGQuality   = 85
+)
+
+// принимаемые форматы: тип определяется по содержимому файла, а не по заголовку клиента
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+var (
+	errImageType       = errors.New("unsupported image type")
+	errImageDimensions = errors.New("image dimensions too large")
+)
+
+type ProductImage struct {
+	ID          int64     `json:"id"`
+	ProductID   int64     `json:"product_id"`
+	URL         string    `json:"url"`
+	MediumURL   string    `json:"medium_url"`
+	ThumbURL    string    `json:"thumb_url"`
+	ContentType string    `json:"content_type"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	IsPrimary   bool      `json:"is_primary"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// ключи в FileStorage, нужны для удаления файлов
+	OriginalKey string `json:"-"`
+	MediumKey   string `json:"-"`
+	ThumbKey    string `json:"-"`
+}
+
+func (img ProductImage) storageKeys() []string {
+	return []string{img.OriginalKey, img.MediumKey, img.ThumbKey}
+}
+
+// decodeUpload проверяет тип и размеры изображения до полного декодирования,
+// чтобы маленький файл не развернулся в гигабайты пикселей
+func decodeUpload(data []byte) (image.Image, string, error) {
+	contentType := http.DetectContentType(data)
+	if _, ok := imageExtensions[contentType]; !ok {
+		return nil, "", errImageType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", errImageType
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, "", errImageDimensions
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", errImageType
+	}
+	return img, contentType, nil
+}
+
+// flattenImage переводит изображение в RGBA на белом фоне — миниатюры сохраняются в JPEG без прозрачности
+func flattenImage(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Over)
+	return dst
+}
+
+// resizeToFit уменьшает изображение так, чтобы большая сторона не превышала maxSide.
+// Каждый пиксель результата — среднее по соответствующему прямоугольнику исходника (box filter).
+func resizeToFit(src *image.RGBA, maxSide int) *image.RGBA {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	if w <= maxSide && h <= maxSide {
+		return src
+	}
+
+	scale := float64(maxSide) / float64(max(w, h))
+	dw := max(int(math.Round(float64(w)*scale)), 1)
+	dh := max(int(math.Round(float64(h)*scale)), 1)
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		sy0 := y * h / dh
+		sy1 := max((y+1)*h/dh, sy0+1)
+		for x := 0; x < dw; x++ {
+			sx0 := x * w / dw
+			sx1 := max((x+1)*w/dw, sx0+1)
+
+			var r, g, b, n uint32
+			for sy := sy0; sy < sy1; sy++ {
+				off := src.PixOffset(sx0, sy)
+				for sx := sx0; sx < sx1; sx++ {
+					r += uint32(src.Pix[off])
+					g += uint32(src.Pix[off+1])
+					b += uint32(src.Pix[off+2])
+					off += 4
+					n++
+				}
+			}
+
+			off := dst.PixOffset(x, y)
+			dst.Pix[off] = uint8(r / n)
+			dst.Pix[off+1] = uint8(g / n)
+			dst.Pix[off+2] = uint8(b / n)
+			dst.Pix[off+3] = 0xff
+		}
+	}
+	return dst
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: thumbJPEGQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readImageUpload читает файл из поля field multipart-формы с ограничением maxImageUploadSize.
+// false — клиенту уже ответили ошибкой.
+func readImageUpload(c *gin.Context, field string) ([]byte, bool) {
+	// запас в 64 КБ на служебные части multipart
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImageUploadSize+64<<10)
+	file, header, err := c.Request.FormFile(field)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "Файл слишком большой (максимум 5 МБ)"})
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Файл не передан"})
+		return nil, false
+	}
+	defer file.Close()
+
+	if header.Size > maxImageUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "Файл слишком большой (максимум 5 МБ)"})
+		return nil, false
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxImageUploadSize+1))
+	if err != nil {
+		log.Println("Read image upload error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return nil, false
+	}
+	if len(data) > maxImageUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "Файл слишком большой (максимум 5 МБ)"})
+		return nil, false
+	}
+	return data, true
+}
+
+// imageStoreOK отвечает клиенту на ошибку разбора или сохранения изображения;
+// false — ответ уже отправлен
+func imageStoreOK(c *gin.Context, err error, logPrefix string) bool {
+	switch {
+	case errors.Is(err, errImageType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"message": "Поддерживаются только JPEG, PNG и GIF"})
+		return false
+	case errors.Is(err, errImageDimensions):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Слишком большое разрешение изображения"})
+		return false
+	case err != nil:
+		log.Println(logPrefix, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return false
+	}
+	return true
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// storeProductImage сохраняет оригинал и две уменьшенные копии.
+// При ошибке уже записанные файлы удаляются.
+func (s *Server) storeProductImage(ctx context.Context, productID int64, data []byte) (ProductImageInput, error) {
+	img, contentType, err := decodeUpload(data)
+	if err != nil {
+		return ProductImageInput{}, err
+	}
+
+	flat := flattenImage(img)
+	medium, err := encodeJPEG(resizeToFit(flat, mediumImageSide))
+	if err != nil {
+		return ProductImageInput{}, err
+	}
+	thumb, err := encodeJPEG(resizeToFit(flat, thumbImageSide))
+	if err != nil {
+		return ProductImageInput{}, err
+	}
+
+	name, err := randomHex(16)
+	if err != nil {
+		return ProductImageInput{}, err
+	}
+	base := fmt.Sprintf("products/%d/%s", productID, name)
+
+	in := ProductImageInput{
+		OriginalKey: base + imageExtensions[contentType],
+		MediumKey:   base + "_medium.jpg",
+		ThumbKey:    base + "_thumb.jpg",
+		ContentType: contentType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+	}
+
+	files := []struct {
+		key         string
+		data        []byte
+		contentType string
+	}{
+		{in.OriginalKey, data, contentType},
+		{in.MediumKey, medium, "image/jpeg"},
+		{in.ThumbKey, thumb, "image/jpeg"},
+	}
+	for i, f := range files {
+		if err := s.storage.Save(ctx, f.key, bytes.NewReader(f.data), f.contentType); err != nil {
+			for _, saved := range files[:i] {
+				s.deleteStoredFiles(ctx, saved.key)
+			}
+			return ProductImageInput{}, err
+		}
+	}
+
+	in.URL = s.storage.URL(in.OriginalKey)
+	in.MediumURL = s.storage.URL(in.MediumKey)
+	in.ThumbURL = s.storage.URL(in.ThumbKey)
+	return in, nil
+}
+
+// deleteStoredFiles удаляет файлы из хранилища; ошибки только логируются,
+// так как запись в БД к этому моменту уже изменена
+func (s *Server) deleteStoredFiles(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.Println("Delete stored file error:", err)
+		}
+	}
+}
+
+func parseImageParams(c *gin.Context) (productID, imageID int64, err error) {
+	if productID, err = strconv.ParseInt(c.Param("id"), 10, 64); err != nil {
+		return 0, 0, err
+	}
+	if v := c.Param("image_id"); v != "" {
+		if imageID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return 0, 0, err
+		}
+	}
+	return productID, imageID, nil
+}
+
+func (s *Server) getProductImagesHandler(c *gin.Context) {
+	productID, _, err := parseImageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if exists, err := s.products.Exists(ctx, productID); err != nil {
+		log.Println("Get product images error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	} else if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Продукт не найден"})
+		return
+	}
+
+	images, err := s.products.ListImages(ctx, productID)
+	if err != nil {
+		log.Println("Get product images error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, images)
+}
+
+// uploadProductImageHandler принимает multipart-поле image; primary=true делает изображение основным
+func (s *Server) uploadProductImageHandler(c *gin.Context) {
+	productID, _, err := parseImageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if exists, err := s.products.Exists(ctx, productID); err != nil {
+		log.Println("Upload product image error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	} else if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Продукт не найден"})
+		return
+	}
+	images, err := s.products.ListImages(ctx, productID)
+	if err != nil {
+		log.Println("Upload product image error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if len(images) >= maxProductImages {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("У товара может быть не больше %d изображений", maxProductImages)})
+		return
+	}
+
+	data, ok := readImageUpload(c, "image")
+	if !ok {
+		return
+	}
+
+	in, err := s.storeProductImage(ctx, productID, data)
+	if !imageStoreOK(c, err, "Upload product image error:") {
+		return
+	}
+	in.Primary = c.PostForm("primary") == "true"
+
+	img, err := s.products.AddImage(ctx, productID, in)
+	if err != nil {
+		s.deleteStoredFiles(ctx, in.OriginalKey, in.MediumKey, in.ThumbKey)
+		if errors.Is(err, ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Продукт не найден"})
+			return
+		}
+		log.Println("Upload product image error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, img)
+}
+
+func (s *Server) setPrimaryProductImageHandler(c *gin.Context) {
+	productID, imageID, err := parseImageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	img, err := s.products.SetPrimaryImage(c.Request.Context(), productID, imageID)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Изображение не найдено"})
+		return
+	} else if err != nil {
+		log.Println("Set primary image error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, img)
+}
+
+func (s *Server) deleteProductImageHandler(c *gin.Context) {
+	productID, imageID, err := parseImageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	img, err := s.products.DeleteImage(ctx, productID, imageID)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Изображение не найдено"})
+		return
+	} else if err != nil {
+		log.Println("Delete image error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	s.deleteStoredFiles(ctx, img.storageKeys()...)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Изображение удалено"})
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ReservationActive   = "active"
+	ReservationReleased = "released"
+	ReservationConsumed = "consumed"
+)
+
+// reservationTTL — сколько держится резерв под неподтверждённый заказ
+var reservationTTL = 30 * time.Minute
+
+// OutOfStockError сообщает, каких товаров не хватает для оформления заказа
+type OutOfStockError struct {
+	ProductID int64
+	Name      string
+	Requested int
+	Available int
+}
+
+func (e *OutOfStockError) Error() string {
+	return fmt.Sprintf("product %d: requested %d, available %d", e.ProductID, e.Requested, e.Available)
+}
+
+// releaseExpiredReservations отменяет неподтверждённые заказы с истёкшим резервом
+func (s *Server) releaseExpiredReservations(ctx context.Context) (int, error) {
+	ids, err := s.orders.ExpiredReservations(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, id := range ids {
+		_, err := s.orders.Transition(ctx, id, 0, OrderStatusCancelled)
+		if errors.Is(err, errInvalidTransition) || errors.Is(err, ErrNotFound) {
+			// заказ успели подтвердить или отменить параллельно
+			continue
+		} else if err != nil {
+			return released, err
+		}
+		released++
+	}
+
+	return released, nil
+}
+
+// startReservationReaper периодически освобождает просроченные резервы
+func (s *Server) startReservationReaper(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			n, err := s.releaseExpiredReservations(context.Background())
+			if err != nil {
+				log.Println("Release expired reservations error:", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("Отменено заказов с истёкшим резервом: %d", n)
+			}
+		}
+	}()
+}
+
+func outOfStockMessage(err *OutOfStockError) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Недостаточно товара «%s» на складе", err.Name)
+	if err.Available > 0 {
+		fmt.Fprintf(&b, " (доступно: %d)", err.Available)
+	}
+	return b.String()
+}
+
+func (s *Server) updateProductStockHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	var req struct {
+		Stock *int `json:"stock"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || req.Stock == nil || *req.Stock < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверное количество"})
+		return
+	}
+
+	product, err := s.products.SetStock(c.Request.Context(), id, *req.Stock)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Продукт не найден"})
+		return
+	} else if err != nil {
+		log.Println("Update stock error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
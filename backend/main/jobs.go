@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindJobInput читает и проверяет вакансию автора userID из запроса на создание или изменение.
+// Зарплату можно передать числами (salary_min, salary_max и т. д.) — тогда строка для показа
+// собирается из них, — или, как раньше, строкой salary, которая разбирается parseSalary.
+// Компания — company_id или, как раньше, название company (см. resolveJobCompany).
+// false — клиенту уже ответили ошибкой.
+func (s *Server) bindJobInput(c *gin.Context, userID int64) (JobInput, bool) {
+	var req struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Salary      string `json:"salary"`
+		CategoryID  *int64 `json:"category_id"`
+		Category    string `json:"category"`
+		CompanyID   *int64 `json:"company_id"`
+		Company     string `json:"company"`
+		SalaryRange
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return JobInput{}, false
+	}
+
+	structured := req.Min != nil || req.Max != nil
+	req.Company = strings.Join(strings.Fields(req.Company), " ")
+	if req.Title == "" || req.Description == "" || (req.Salary == "" && !structured) || (req.Category == "" && req.CategoryID == nil) || (req.Company == "" && req.CompanyID == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Все поля обязательны"})
+		return JobInput{}, false
+	}
+	if req.CompanyID == nil && utf8.RuneCountInString(req.Company) > maxCompanyNameLength {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Название компании не должно быть длиннее 100 символов"})
+		return JobInput{}, false
+	}
+
+	salary := parseSalary(req.Salary)
+	if structured {
+		salary = req.SalaryRange
+		if err := salary.normalize(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Неверно указана зарплата"})
+			return JobInput{}, false
+		}
+		req.Salary = salary.String()
+	}
+
+	categoryID, err := s.resolveCategory(c.Request.Context(), req.CategoryID, req.Category)
+	if errors.Is(err, errCategoryNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Категория не найдена"})
+		return JobInput{}, false
+	} else if err != nil {
+		log.Println("Resolve job category error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return JobInput{}, false
+	}
+
+	company, err := s.resolveJobCompany(c.Request.Context(), userID, req.CompanyID, req.Company)
+	if errors.Is(err, errCompanyNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Компания не найдена"})
+		return JobInput{}, false
+	} else if errors.Is(err, errCompanyNotMember) {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Вы не состоите в этой компании"})
+		return JobInput{}, false
+	} else if err != nil {
+		log.Println("Resolve job company error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return JobInput{}, false
+	}
+
+	return JobInput{
+		Title:       req.Title,
+		Description: req.Description,
+		Salary:      req.Salary,
+		CategoryID:  categoryID,
+		CompanyID:   &company.ID,
+		Company:     company.Name,
+		UserID:      userID,
+		SalaryRange: salary,
+	}, true
+}
+
+// ownsJob — вакансия принадлежит пользователю. У вакансий удалённых авторов владельца нет.
+func ownsJob(job Job, userID int64) bool {
+	return job.UserID != nil && *job.UserID == userID
+}
+
+// ownJob находит вакансию из :id и проверяет, что она принадлежит пользователю.
+// false — клиенту уже ответили ошибкой.
+func (s *Server) ownJob(c *gin.Context, userID int64) (Job, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return Job{}, false
+	}
+
+	job, err := s.jobs.Get(c.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Вакансия не найдена"})
+		return job, false
+	} else if err != nil {
+		log.Println("Get job error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return job, false
+	}
+	if !ownsJob(job, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Недостаточно прав"})
+		return job, false
+	}
+	return job, true
+}
+
+// getMyJobsHandler — вакансии автора, в том числе ожидающие модерации
+func (s *Server) getMyJobsHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	jobs, err := s.jobs.ListForUser(c.Request.Context(), claims.ID)
+	if err != nil {
+		log.Println("Get my jobs error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// updateJobHandler — автор правит вакансию; изменённая вакансия заново проходит модерацию
+// и до одобрения пропадает с доски. Отклонённую вакансию так исправляют после отказа.
+func (s *Server) updateJobHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	job, ok := s.ownJob(c, claims.ID)
+	if !ok {
+		return
+	}
+	if job.Status == JobStatusArchived {
+		c.JSON(http.StatusConflict, gin.H{"message": "Вакансия в архиве, её нельзя изменить"})
+		return
+	}
+	in, ok := s.bindJobInput(c, claims.ID)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	job, err := s.jobs.Update(ctx, job.ID, in)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Вакансия не найдена"})
+		return
+	} else if err != nil {
+		log.Println("Update job error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	s.reindexJob(ctx, job.ID)
+
+	c.JSON(http.StatusOK, job)
+}
+
+// withdrawJobHandler — автор снимает свою вакансию; отклики на неё удаляются вместе с ней
+func (s *Server) withdrawJobHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	job, ok := s.ownJob(c, claims.ID)
+	if !ok {
+		return
+	}
+
+	err := s.jobs.Delete(c.Request.Context(), job.ID)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Вакансия не найдена"})
+		return
+	} else if err != nil {
+		log.Println("Withdraw job error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	s.search.Remove(SearchKindJob, job.ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Вакансия удалена"})
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// mailSendTimeout ограничивает отправку писем, которые уходят в фоне после ответа клиенту
+const mailSendTimeout = 30 * time.Second
+
+type Mail struct {
+	To      string
+	Subject string
+	Body    string // обычный текст
+}
+
+// Mailer — подключаемая отправка писем. В продакшене SMTP, при локальной разработке письма
+// пишутся в лог или в каталог, чтобы ссылки из них можно было открыть без почтового сервера.
+type Mailer interface {
+	Send(ctx context.Context, m Mail) error
+}
+
+// buildMessage собирает письмо в формате RFC 5322 с темой в кодировке UTF-8
+func buildMessage(from string, m Mail) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	return b.Bytes()
+}
+
+// SMTPMailer отправляет письма через SMTP-сервер; STARTTLS включается, если сервер его поддерживает
+type SMTPMailer struct {
+	addr     string
+	from     string
+	username string
+	password string
+}
+
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		addr:     net.JoinHostPort(host, port),
+		from:     from,
+		username: username,
+		password: password,
+	}
+}
+
+// Send повторяет smtp.SendMail, но соединение открывается и закрывается с учётом ctx:
+// зависший SMTP-сервер не держит вызывающего дольше дедлайна
+func (m *SMTPMailer) Send(ctx context.Context, mail Mail) (err error) {
+	host, _, _ := net.SplitHostPort(m.addr)
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// отмена ctx обрывает соединение; наружу отдаём причину отмены, а не ошибку сокета
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer func() {
+		if !stop() && err != nil {
+			err = ctx.Err()
+		}
+	}()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if m.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.username, m.password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(m.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(mail.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buildMessage(m.from, mail)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// LogMailer — заглушка для разработки: пишет письма в лог, а если задан dir — ещё и в файлы .eml
+type LogMailer struct {
+	dir string
+}
+
+func NewLogMailer(dir string) *LogMailer {
+	return &LogMailer{dir: dir}
+}
+
+func (m *LogMailer) Send(ctx context.Context, mail Mail) error {
+	log.Printf("Письмо для %s: %s\n%s", mail.To, mail.Subject, mail.Body)
+	if m.dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(mail.To))
+	return os.WriteFile(filepath.Join(m.dir, name), buildMessage("noreply@localhost", mail), 0o644)
+}
+
+// newMailer выбирает SMTP, если задан SMTP_HOST; иначе письма пишутся в лог и в MAIL_DIR
+func newMailer() Mailer {
+	host := getEnv("SMTP_HOST", "")
+	if host == "" {
+		log.Println("SMTP_HOST не задан: письма пишутся в лог")
+		return NewLogMailer(getEnv("MAIL_DIR", ""))
+	}
+	return NewSMTPMailer(
+		host,
+		getEnv("SMTP_PORT", "587"),
+		getEnv("SMTP_USER", ""),
+		getEnv("SMTP_PASSWORD", ""),
+		getEnv("MAIL_FROM", "noreply@stroystore.ru"),
+	)
+}
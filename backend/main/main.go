@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -18,17 +20,13 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-var (
-	db        *sql.DB
-	jwtSecret []byte
-)
-
 type User struct {
-	ID        int64     `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Role      string    `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            int64     `json:"id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	Role          string    `json:"role"`
+	EmailVerified bool      `json:"email_verified"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 type Product struct {
@@ -36,28 +34,41 @@ type Product struct {
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
 	Price       float64   `json:"price"`
+	CategoryID  *int64    `json:"category_id"`
 	Category    string    `json:"category"`
 	Image       string    `json:"image"`
+	Stock       int       `json:"stock"`
+	Available   int       `json:"available"`
+	InStock     bool      `json:"in_stock"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
 type Job struct {
-	ID          int64     `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Salary      string    `json:"salary"`
-	Category    string    `json:"category"`
-	Company     string    `json:"company"`
-	UserID      int64     `json:"user_id"`
-	Approved    bool      `json:"approved"`
-	CreatedAt   time.Time `json:"created_at"`
-	Username    string    `json:"username"`
+	ID              int64  `json:"id"`
+	Title           string `json:"title"`
+	Description     string `json:"description"`
+	Salary          string `json:"salary"` // строка для показа, собирается из SalaryRange
+	CategoryID      *int64 `json:"category_id"`
+	Category        string `json:"category"`
+	CompanyID       *int64 `json:"company_id"`
+	Company         string `json:"company"` // название компании CompanyID
+	CompanyVerified bool   `json:"company_verified"`
+	UserID          *int64 `json:"user_id"` // nil, если автор удалил аккаунт
+	Status          string `json:"status"`
+	// RejectionReason заполнен только у отклонённых вакансий
+	RejectionReason string     `json:"rejection_reason,omitempty"`
+	ModeratedAt     *time.Time `json:"moderated_at"`
+	ExpiresAt       *time.Time `json:"expires_at"` // после этого момента вакансия уходит в архив
+	CreatedAt       time.Time  `json:"created_at"`
+	Username        string     `json:"username"`
+	SalaryRange
 }
 
 type Claims struct {
-	ID       int64  `json:"id"`
-	Username string `json:"username"`
-	Role     string `json:"role"`
+	ID        int64  `json:"id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
@@ -78,21 +89,60 @@ func main() {
 		log.Println(".env не найден")
 	}
 
-	jwtSecret = []byte(getEnv("JWT_SECRET", "your-secret-key"))
+	jwtSecret := []byte(getEnv("JWT_SECRET", "your-secret-key"))
 
-	if err := initializeDatabase(); err != nil {
+	db, err := initializeDatabase()
+	if err != nil {
 		log.Fatalf("Ошибка инициализации базы данных: %v", err)
 	}
 	defer db.Close()
 
-	router := setupRouter()
+	// go run . migrate up|down [N]|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(db, os.Args[2:]); err != nil {
+			log.Fatalf("Ошибка миграции: %v", err)
+		}
+		return
+	}
+
+	if err := checkSchemaUpToDate(db); err != nil {
+		log.Fatalf("Схема базы данных не актуальна: %v (выполните `go run . migrate up`)", err)
+	}
+
+	if err := insertTestData(db); err != nil {
+		log.Fatalf("Ошибка добавления тестовых данных: %v", err)
+	}
+
+	storage := NewLocalStorage(getEnv("UPLOAD_DIR", "uploads"), "/uploads")
+	server := NewServer(db, storage, newMailer(), jwtSecret)
+	server.appURL = strings.TrimSuffix(getEnv("APP_URL", defaultAppURL), "/")
+
+	bcryptCost, err := strconv.Atoi(getEnv("PASSWORD_BCRYPT_COST", "12"))
+	if err != nil {
+		log.Fatalf("Неверный PASSWORD_BCRYPT_COST: %v", err)
+	}
+	if server.passwords, err = NewPasswordHasher(getEnv("PASSWORD_HASH", HashArgon2id), bcryptCost); err != nil {
+		log.Fatalf("Неверные настройки хеширования паролей: %v", err)
+	}
+	if server.policy.MinLength, err = strconv.Atoi(getEnv("PASSWORD_MIN_LENGTH", "8")); err != nil {
+		log.Fatalf("Неверный PASSWORD_MIN_LENGTH: %v", err)
+	}
+	server.oauthProviders = oauthProvidersFromEnv(context.Background(), strings.TrimSuffix(getEnv("API_URL", "http://localhost:3001"), "/"))
+	if err := server.rebuildSearchIndex(context.Background()); err != nil {
+		log.Fatalf("Ошибка построения поискового индекса: %v", err)
+	}
+	server.startReservationReaper(time.Minute)
+	server.startSessionJanitor(time.Hour)
+	server.startJobExpiryWorker(time.Hour)
+
+	router := server.setupRouter()
 	port := getEnv("PORT", "3001")
 
 	log.Printf("Сервер запущен на порту %s", port)
 	log.Printf("API: http://localhost:%s", port)
 	log.Println("Frontend: http://localhost:5173")
 	log.Println("Используется MySQL база данных")
-	log.Println("База данных и таблицы созданы автоматически")
+	log.Println("Схема базы данных актуальна")
 	log.Println("Тестовые аккаунты:")
 	log.Println(" Админ: admin / password")
 	log.Println(" Пользователь: user1 / password")
@@ -102,47 +152,157 @@ func main() {
 	}
 }
 
-func setupRouter() *gin.Engine {
+func (s *Server) setupRouter() *gin.Engine {
 	r := gin.Default()
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "Retry-After"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
+	// загруженные файлы отдаёт сам сервер, пока они лежат на локальном диске
+	if local, ok := s.storage.(*LocalStorage); ok {
+		r.Static(local.urlPrefix, local.dir)
+	}
 
-	r.GET("/", rootHandler)
-	r.GET("/api/health", healthHandler)
-
-	// Аутентификация
-	r.POST("/api/register", registerHandler)
-	r.POST("/api/login", loginHandler)
 
+	r.GET("/", s.rootHandler)
+	r.GET("/api/health", s.healthHandler)
 
-	r.GET("/api/products", getProductsHandler)
-	r.GET("/api/jobs", getJobsHandler)
-	r.GET("/api/shop/location", shopLocationHandler)
-	r.GET("/api/shop/map-links", shopMapLinksHandler)
+	// Аутентификация
+	r.POST("/api/register", s.registerHandler)
+	r.POST("/api/login", s.loginHandler)
+	r.POST("/api/login/2fa", s.loginTwoFactorHandler)
+	r.POST("/api/login/2fa/setup", s.loginSetupTwoFactorHandler)
+	r.POST("/api/token/refresh", s.refreshTokenHandler)
+	r.POST("/api/email/verify", s.verifyEmailHandler)
+	r.POST("/api/password/forgot", s.forgotPasswordHandler)
+	r.POST("/api/password/reset", s.resetPasswordHandler)
+	r.GET("/api/oauth/providers", s.getOAuthProvidersHandler)
+	r.POST("/api/oauth/:provider/start", s.startOAuthLoginHandler)
+	r.GET("/api/oauth/:provider/callback", s.oauthCallbackHandler)
+	r.POST("/api/oauth/exchange", s.oauthExchangeHandler)
+
+
+	r.GET("/api/products", s.getProductsHandler)
+	r.GET("/api/products/facets", s.getProductFacetsHandler)
+	r.GET("/api/products/:id/images", s.getProductImagesHandler)
+	r.GET("/api/jobs", s.getJobsHandler)
+	r.GET("/api/companies", s.getCompaniesHandler)
+	r.GET("/api/companies/:id", s.getCompanyHandler)
+	r.GET("/api/search", s.searchHandler)
+	r.GET("/api/categories", s.getCategoriesHandler)
+	r.GET("/api/shop/location", s.shopLocationHandler)
+	r.GET("/api/shop/map-links", s.shopMapLinksHandler)
 
 
 	protected := r.Group("/api")
-	protected.Use(authMiddleware())
+	protected.Use(s.authMiddleware())
 	{
-		protected.POST("/products", createProductHandler)
-		protected.PUT("/products/:id", updateProductHandler)
-		protected.DELETE("/products/:id", deleteProductHandler)
+		protected.POST("/logout", s.logoutHandler)
+		protected.POST("/email/verify/resend", s.resendVerificationHandler)
+
+		// Профиль
+		protected.GET("/me", s.getMeHandler)
+		protected.PUT("/me", s.updateMeHandler)
+		protected.POST("/me/password", s.changePasswordHandler)
+		protected.DELETE("/me", s.deleteMeHandler)
+		protected.GET("/me/2fa", s.getTwoFactorHandler)
+		protected.POST("/me/2fa/setup", s.setupTwoFactorHandler)
+		protected.POST("/me/2fa/enable", s.enableTwoFactorHandler)
+		protected.POST("/me/2fa/recovery-codes", s.regenerateRecoveryCodesHandler)
+		protected.DELETE("/me/2fa", s.disableTwoFactorHandler)
+		protected.GET("/me/identities", s.getIdentitiesHandler)
+		protected.DELETE("/me/identities/:provider", s.unlinkIdentityHandler)
+		protected.POST("/oauth/:provider/link", s.startOAuthLinkHandler)
+
+		protected.POST("/products", s.requirePermission(PermProductsWrite), s.createProductHandler)
+		protected.PUT("/products/:id", s.requirePermission(PermProductsWrite), s.updateProductHandler)
+		protected.DELETE("/products/:id", s.requirePermission(PermProductsWrite), s.deleteProductHandler)
+		protected.PUT("/products/:id/stock", s.requirePermission(PermProductsWrite), s.updateProductStockHandler)
+		protected.POST("/products/:id/images", s.requirePermission(PermProductsWrite), s.uploadProductImageHandler)
+		protected.PUT("/products/:id/images/:image_id/primary", s.requirePermission(PermProductsWrite), s.setPrimaryProductImageHandler)
+		protected.DELETE("/products/:id/images/:image_id", s.requirePermission(PermProductsWrite), s.deleteProductImageHandler)
+
+		// Корзина
+		protected.GET("/basket", s.getBasketHandler)
+		protected.POST("/basket/items", s.addBasketItemHandler)
+		protected.PUT("/basket/items/:product_id", s.updateBasketItemHandler)
+		protected.DELETE("/basket/items/:product_id", s.removeBasketItemHandler)
+		protected.DELETE("/basket", s.clearBasketHandler)
+
+		// Заказы
+		protected.POST("/orders", s.createOrderHandler)
+		protected.GET("/orders", s.getOrdersHandler)
+		protected.GET("/orders/:id", s.getOrderHandler)
+		protected.POST("/orders/:id/cancel", s.cancelOrderHandler)
 
 		
-		protected.POST("/jobs", createJobHandler)
+		protected.POST("/jobs", s.createJobHandler)
+		protected.PUT("/jobs/:id", s.updateJobHandler)
+		protected.PUT("/jobs/:id/renew", s.renewJobHandler)
+		protected.DELETE("/jobs/:id", s.withdrawJobHandler)
+		protected.GET("/my/jobs", s.getMyJobsHandler)
+		protected.POST("/jobs/:id/applications", s.applyToJobHandler)
+		protected.GET("/jobs/:id/applications", s.getJobApplicationsHandler)
+		protected.PUT("/applications/:id/status", s.updateApplicationStatusHandler)
+		protected.GET("/my/applications", s.getMyApplicationsHandler)
+
+		// Компании работодателей
+		protected.POST("/companies", s.createCompanyHandler)
+		protected.PUT("/companies/:id", s.updateCompanyHandler)
+		protected.POST("/companies/:id/logo", s.uploadCompanyLogoHandler)
+		protected.GET("/companies/:id/members", s.getCompanyMembersHandler)
+		protected.POST("/companies/:id/invitations", s.inviteCompanyMemberHandler)
+		protected.PUT("/companies/:id/members/:user_id", s.setCompanyMemberRoleHandler)
+		protected.DELETE("/companies/:id/members/:user_id", s.removeCompanyMemberHandler)
+		protected.GET("/my/companies", s.getMyCompaniesHandler)
+		protected.GET("/my/company-invitations", s.getMyCompanyInvitationsHandler)
+		protected.POST("/my/company-invitations/:id/accept", s.acceptCompanyInvitationHandler)
+		protected.DELETE("/my/company-invitations/:id", s.declineCompanyInvitationHandler)
+		protected.PUT("/admin/companies/:id/verify", s.requirePermission(PermCompaniesVerify), s.verifyCompanyHandler)
+
+		// Резюме: соискатель ведёт свои, работодатели ищут и приглашают
+		protected.POST("/resumes", s.createResumeHandler)
+		protected.PUT("/resumes/:id", s.updateResumeHandler)
+		protected.DELETE("/resumes/:id", s.deleteResumeHandler)
+		protected.GET("/my/resumes", s.getMyResumesHandler)
+		protected.GET("/my/invitations", s.getMyInvitationsHandler)
+		protected.GET("/resumes", s.requirePermission(PermResumesSearch), s.getResumesHandler)
+		protected.GET("/resumes/:id", s.getResumeHandler)
+		protected.POST("/resumes/:id/invitations", s.requirePermission(PermResumesSearch), s.inviteResumeHandler)
+		protected.GET("/admin/resumes", s.requirePermission(PermResumesModerate), s.getResumeModerationQueueHandler)
+		protected.PUT("/admin/resumes/:id/status", s.requirePermission(PermResumesModerate), s.setResumeStatusHandler)
+		protected.GET("/admin/resumes/:id/history", s.requirePermission(PermResumesModerate), s.getResumeModerationHistoryHandler)
 
 		
-		protected.GET("/admin/jobs", getPendingJobsHandler)
-		protected.PUT("/admin/jobs/:id/approve", approveJobHandler)
-		protected.DELETE("/admin/jobs/:id", deleteJobHandler)
+		protected.GET("/admin/jobs", s.requirePermission(PermJobsModerate), s.getModerationQueueHandler)
+		protected.PUT("/admin/jobs/:id/approve", s.requirePermission(PermJobsModerate), s.approveJobHandler)
+		protected.PUT("/admin/jobs/:id/status", s.requirePermission(PermJobsModerate), s.setJobStatusHandler)
+		protected.GET("/admin/jobs/:id/history", s.requirePermission(PermJobsModerate), s.getJobModerationHistoryHandler)
+		protected.GET("/admin/moderation/history", s.requirePermission(PermJobsModerate), s.getModerationHistoryHandler)
+		protected.DELETE("/admin/jobs/:id", s.requirePermission(PermJobsModerate), s.deleteJobHandler)
+
+		protected.GET("/admin/orders", s.requirePermission(PermOrdersManage), s.getAdminOrdersHandler)
+		protected.PUT("/admin/orders/:id/status", s.requirePermission(PermOrdersManage), s.updateOrderStatusHandler)
+
+		// Категории
+		protected.POST("/admin/categories", s.requirePermission(PermCategoriesWrite), s.createCategoryHandler)
+		protected.PUT("/admin/categories/:id", s.requirePermission(PermCategoriesWrite), s.updateCategoryHandler)
+		protected.POST("/admin/categories/:id/merge", s.requirePermission(PermCategoriesWrite), s.mergeCategoryHandler)
+		protected.DELETE("/admin/categories/:id", s.requirePermission(PermCategoriesWrite), s.deleteCategoryHandler)
+
+		// Пользователи и роли
+		protected.GET("/admin/roles", s.requirePermission(PermUsersManage), s.getRolesHandler)
+		protected.PUT("/admin/users/:id/role", s.requirePermission(PermUsersManage), s.setUserRoleHandler)
+		protected.DELETE("/admin/users/:id/sessions", s.requirePermission(PermUsersManage), s.revokeUserSessionsHandler)
+		protected.DELETE("/admin/users/:id/2fa", s.requirePermission(PermUsersManage), s.resetUserTwoFactorHandler)
+		protected.GET("/admin/security/lockouts", s.requirePermission(PermUsersManage), s.getLoginLockoutsHandler)
+		protected.POST("/admin/security/unlock", s.requirePermission(PermUsersManage), s.unlockLoginHandler)
 	}
 
 	
@@ -159,7 +319,7 @@ func setupRouter() *gin.Engine {
 
 
 
-func initializeDatabase() error {
+func initializeDatabase() (*sql.DB, error) {
 	host := getEnv("DB_HOST", "localhost")
 	user := getEnv("DB_USER", "root")
 	pass := getEnv("DB_PASSWORD", "")
@@ -170,17 +330,17 @@ func initializeDatabase() error {
 
 	tempDB, err := sql.Open("mysql", dsnNoDB)
 	if err != nil {
-		return fmt.Errorf("open temp db: %w", err)
+		return nil, fmt.Errorf("open temp db: %w", err)
 	}
 	defer tempDB.Close()
 
 	if err := tempDB.Ping(); err != nil {
-		return fmt.Errorf("ping temp db: %w", err)
+		return nil, fmt.Errorf("ping temp db: %w", err)
 	}
 
 	_, err = tempDB.Exec("CREATE DATABASE IF NOT EXISTS " + name + " CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci")
 	if err != nil {
-		return fmt.Errorf("create database: %w", err)
+		return nil, fmt.Errorf("create database: %w", err)
 	}
 	log.Println("База данных stroy_store создана/проверена")
 
@@ -188,70 +348,20 @@ func initializeDatabase() error {
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:3306)/%s?parseTime=true&charset=utf8mb4&loc=Local",
 		user, pass, host, name)
 
-	db, err = sql.Open("mysql", dsn)
+	db, err := sql.Open("mysql", dsn)
 	if err != nil {
-		return fmt.Errorf("open db: %w", err)
+		return nil, fmt.Errorf("open db: %w", err)
 	}
 
 	if err := db.Ping(); err != nil {
-		return fmt.Errorf("ping db: %w", err)
-	}
-
-	if err := createTables(); err != nil {
-		return fmt.Errorf("create tables: %w", err)
-	}
-
-	if err := insertTestData(); err != nil {
-		return fmt.Errorf("insert test data: %w", err)
-	}
-
-	return nil
-}
-
-func createTables() error {
-	stmts := []string{
-		`CREATE TABLE IF NOT EXISTS users (
-			id INT AUTO_INCREMENT PRIMARY KEY,
-			username VARCHAR(50) UNIQUE NOT NULL,
-			email VARCHAR(100) UNIQUE NOT NULL,
-			password VARCHAR(255) NOT NULL,
-			role VARCHAR(20) DEFAULT 'user',
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-		`CREATE TABLE IF NOT EXISTS products (
-			id INT AUTO_INCREMENT PRIMARY KEY,
-			name VARCHAR(100) NOT NULL,
-			description TEXT,
-			price DECIMAL(10,2) NOT NULL,
-			category VARCHAR(50),
-			image VARCHAR(255),
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-		`CREATE TABLE IF NOT EXISTS jobs (
-			id INT AUTO_INCREMENT PRIMARY KEY,
-			title VARCHAR(100) NOT NULL,
-			description TEXT,
-			salary VARCHAR(50),
-			category VARCHAR(50),
-			company VARCHAR(100),
-			user_id INT,
-			approved BOOLEAN DEFAULT false,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			FOREIGN KEY (user_id) REFERENCES users(id)
-		)`,
-	}
-
-	for _, stmt := range stmts {
-		if _, err := db.Exec(stmt); err != nil {
-			return err
-		}
+		db.Close()
+		return nil, fmt.Errorf("ping db: %w", err)
 	}
 
-	log.Println("✅ Таблицы созданы/проверены")
-	return nil
+	return db, nil
 }
 
-func insertTestData() error {
+func insertTestData(db *sql.DB) error {
 	
 	var userCount int
 	if err := db.QueryRow("SELECT COUNT(*) FROM users").Scan(&userCount); err != nil {
@@ -264,14 +374,14 @@ func insertTestData() error {
 		}
 
 		if _, err := db.Exec(
-			"INSERT IGNORE INTO users (username, email, password, role) VALUES (?, ?, ?, ?)",
+			"INSERT IGNORE INTO users (username, email, password, role, email_verified_at) VALUES (?, ?, ?, ?, NOW())",
 			"admin", "admin@stroystore.ru", string(hashed), "admin",
 		); err != nil {
 			return err
 		}
 
 		if _, err := db.Exec(
-			"INSERT IGNORE INTO users (username, email, password, role) VALUES (?, ?, ?, ?)",
+			"INSERT IGNORE INTO users (username, email, password, role, email_verified_at) VALUES (?, ?, ?, ?, NOW())",
 			"user1", "user1@example.ru", string(hashed), "user",
 		); err != nil {
 			return err
@@ -281,25 +391,44 @@ func insertTestData() error {
 	}
 
 	
+	// категории каталога и вакансий; INSERT IGNORE пропускает уже существующие слаги
+	if _, err := db.Exec(`
+		INSERT IGNORE INTO categories (name, slug, sort_order) VALUES
+		('Электроинструменты', 'электроинструменты', 1),
+		('Строительное оборудование', 'строительное-оборудование', 2),
+		('СИЗ', 'сиз', 3),
+		('Ручные инструменты', 'ручные-инструменты', 4),
+		('Строительство', 'строительство', 10),
+		('Отделка', 'отделка', 11),
+		('Электрика', 'электрика', 12),
+		('Сантехника', 'сантехника', 13),
+		('Проектирование', 'проектирование', 14)
+	`); err != nil {
+		return err
+	}
+
 	var productCount int
 	if err := db.QueryRow("SELECT COUNT(*) FROM products").Scan(&productCount); err != nil {
 		return err
 	}
 	if productCount == 0 {
 		_, err := db.Exec(`
-			INSERT IGNORE INTO products (name, description, price, category, image) VALUES 
-			('Перфоратор', 'Мощный перфоратор для строительных работ', 15000.00, 'Электроинструменты', '/placeholder-product.jpg'),
-			('Шуруповерт', 'Аккумуляторный шуруповерт', 8000.00, 'Электроинструменты', '/placeholder-product.jpg'),
-			('Бетономешалка', 'Бетономешалка на 150 литров', 25000.00, 'Строительное оборудование', '/placeholder-product.jpg'),
-			('Строительные перчатки', 'Защитные перчатки', 500.00, 'СИЗ', '/placeholder-product.jpg'),
-			('Защитные очки', 'Строительные защитные очки', 300.00, 'СИЗ', '/placeholder-product.jpg'),
-			('Молоток', 'Профессиональный строительный молоток', 1500.00, 'Ручные инструменты', '/placeholder-product.jpg'),
-			('Дрель', 'Беспроводная дрель', 12000.00, 'Электроинструменты', '/placeholder-product.jpg'),
-			('Строительная каска', 'Защитная каска', 800.00, 'СИЗ', '/placeholder-product.jpg')
+			INSERT IGNORE INTO products (name, description, price, category_id, image) VALUES 
+			('Перфоратор', 'Мощный перфоратор для строительных работ', 15000.00, (SELECT id FROM categories WHERE slug = 'электроинструменты'), '/placeholder-product.jpg'),
+			('Шуруповерт', 'Аккумуляторный шуруповерт', 8000.00, (SELECT id FROM categories WHERE slug = 'электроинструменты'), '/placeholder-product.jpg'),
+			('Бетономешалка', 'Бетономешалка на 150 литров', 25000.00, (SELECT id FROM categories WHERE slug = 'строительное-оборудование'), '/placeholder-product.jpg'),
+			('Строительные перчатки', 'Защитные перчатки', 500.00, (SELECT id FROM categories WHERE slug = 'сиз'), '/placeholder-product.jpg'),
+			('Защитные очки', 'Строительные защитные очки', 300.00, (SELECT id FROM categories WHERE slug = 'сиз'), '/placeholder-product.jpg'),
+			('Молоток', 'Профессиональный строительный молоток', 1500.00, (SELECT id FROM categories WHERE slug = 'ручные-инструменты'), '/placeholder-product.jpg'),
+			('Дрель', 'Беспроводная дрель', 12000.00, (SELECT id FROM categories WHERE slug = 'электроинструменты'), '/placeholder-product.jpg'),
+			('Строительная каска', 'Защитная каска', 800.00, (SELECT id FROM categories WHERE slug = 'сиз'), '/placeholder-product.jpg')
 		`)
 		if err != nil {
 			return err
 		}
+		if _, err := db.Exec("INSERT IGNORE INTO product_stock (product_id, quantity) SELECT id, 10 FROM products"); err != nil {
+			return err
+		}
 		log.Println("Тестовые продукты добавлены")
 	}
 
@@ -319,17 +448,32 @@ func insertTestData() error {
 		}
 
 		_, err = db.Exec(`
-			INSERT IGNORE INTO jobs (title, description, salary, category, company, user_id, approved) VALUES 
-			('Строитель', 'Работа на строительном объекте', '80000 ₽', 'Строительство', 'СтройГрупп', ?, true),
-			('Отделочник', 'Отделочные работы', '75000 ₽', 'Отделка', 'РемонтПро', ?, true),
-			('Электрик', 'Электромонтажные работы', '90000 ₽', 'Электрика', 'ЭлектроСервис', ?, true),
-			('Сантехник', 'Монтаж сантехнического оборудования', '85000 ₽', 'Сантехника', 'АкваПроф', ?, true),
-			('Маляр', 'Покрасочные работы', '70000 ₽', 'Отделка', 'ИнтерьерСтрой', ?, true)
+			INSERT IGNORE INTO jobs (title, description, salary, salary_min, salary_max, category_id, company, user_id, status) VALUES 
+			('Строитель', 'Работа на строительном объекте', '80 000 ₽', 80000, 80000, (SELECT id FROM categories WHERE slug = 'строительство'), 'СтройГрупп', ?, 'approved'),
+			('Отделочник', 'Отделочные работы', '75 000 ₽', 75000, 75000, (SELECT id FROM categories WHERE slug = 'отделка'), 'РемонтПро', ?, 'approved'),
+			('Электрик', 'Электромонтажные работы', '90 000 ₽', 90000, 90000, (SELECT id FROM categories WHERE slug = 'электрика'), 'ЭлектроСервис', ?, 'approved'),
+			('Сантехник', 'Монтаж сантехнического оборудования', '85 000 ₽', 85000, 85000, (SELECT id FROM categories WHERE slug = 'сантехника'), 'АкваПроф', ?, 'approved'),
+			('Маляр', 'Покрасочные работы', '70 000 ₽', 70000, 70000, (SELECT id FROM categories WHERE slug = 'отделка'), 'ИнтерьерСтрой', ?, 'approved')
 		`, userID, userID, userID, userID, userID)
 		if err != nil {
 			return err
 		}
 
+		// компании тестовых вакансий заводятся так же, как в миграции 0015_companies
+		for _, query := range []string{
+			"INSERT INTO companies (name, description) SELECT DISTINCT company, '' FROM jobs WHERE company_id IS NULL",
+			"UPDATE jobs j JOIN companies c ON c.name = j.company SET j.company_id = c.id WHERE j.company_id IS NULL",
+			"INSERT IGNORE INTO company_members (company_id, user_id) SELECT DISTINCT company_id, user_id FROM jobs WHERE company_id IS NOT NULL AND user_id IS NOT NULL",
+			// тестовые компании сразу проверены, чтобы их участники могли искать резюме
+			"UPDATE companies SET verified = true, verified_at = NOW() WHERE id IN (SELECT company_id FROM jobs)",
+			// участники проверенных компаний — работодатели, как в миграции 0016_resumes
+			"UPDATE users SET role = 'employer' WHERE role = 'user' AND id IN (SELECT m.user_id FROM company_members m JOIN companies c ON c.id = m.company_id WHERE c.verified)",
+		} {
+			if _, err := db.Exec(query); err != nil {
+				return err
+			}
+		}
+
 		log.Println("Тестовые вакансии добавлены")
 	}
 
@@ -345,20 +489,6 @@ func getEnv(key, def string) string {
 	return def
 }
 
-func createToken(id int64, username, role string) (string, error) {
-	expires := time.Now().Add(24 * time.Hour)
-	claims := &Claims{
-		ID:       id,
-		Username: username,
-		Role:     role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expires),
-		},
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
-}
-
 func getUserClaims(c *gin.Context) *Claims {
 	val, exists := c.Get("user")
 	if !exists {
@@ -373,7 +503,7 @@ func getUserClaims(c *gin.Context) *Claims {
 
 
 
-func authMiddleware() gin.HandlerFunc {
+func (s *Server) authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
@@ -388,9 +518,15 @@ func authMiddleware() gin.HandlerFunc {
 			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 			}
-			return jwtSecret, nil
+			return s.jwtSecret, nil
 		})
 
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			// 401, а не 403: клиент должен обновить токен через /api/token/refresh
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Токен истёк"})
+			c.Abort()
+			return
+		}
 		if err != nil || !token.Valid {
 			c.JSON(http.StatusForbidden, gin.H{"message": "Неверный токен"})
 			c.Abort()
@@ -404,6 +540,23 @@ func authMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// токены без сессии выпущены до её введения, токены отозванной сессии — после выхода
+		active := false
+		if claims.SessionID != "" {
+			active, err = s.sessions.IsActive(c.Request.Context(), claims.SessionID)
+			if err != nil {
+				log.Println("Session check error:", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+				c.Abort()
+				return
+			}
+		}
+		if !active {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Сессия завершена"})
+			c.Abort()
+			return
+		}
+
 		c.Set("user", claims)
 		c.Next()
 	}
@@ -411,7 +564,7 @@ func authMiddleware() gin.HandlerFunc {
 
 
 
-func rootHandler(c *gin.Context) {
+func (s *Server) rootHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "StroyStore API Server (MySQL, Go)",
 		"status":  "Running",
@@ -419,9 +572,18 @@ func rootHandler(c *gin.Context) {
 	})
 }
 
-func healthHandler(c *gin.Context) {
+func (s *Server) healthHandler(c *gin.Context) {
+	if s.db == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":    "OK",
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+			"database":  "In-memory",
+		})
+		return
+	}
+
 	var one int
-	if err := db.QueryRow("SELECT 1").Scan(&one); err != nil {
+	if err := s.db.QueryRowContext(c.Request.Context(), "SELECT 1").Scan(&one); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "Error",
 			"message": "Database connection failed",
@@ -439,7 +601,7 @@ func healthHandler(c *gin.Context) {
 
 
 
-func registerHandler(c *gin.Context) {
+func (s *Server) registerHandler(c *gin.Context) {
 	var req struct {
 		Username string `json:"username"`
 		Password string `json:"password"`
@@ -458,76 +620,70 @@ func registerHandler(c *gin.Context) {
 		return
 	}
 
-	var count int
-	if err := db.QueryRow(
-		"SELECT COUNT(*) FROM users WHERE username = ? OR email = ?",
-		req.Username, req.Email,
-	).Scan(&count); err != nil {
-		log.Println("Ошибка проверки существования пользователя:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера при регистрации"})
+	if !validEmail(req.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат email"})
 		return
 	}
 
-	if count > 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Пользователь уже существует"})
+	if err := s.policy.Validate(req.Password, req.Username); err != nil {
+		msg, _ := passwordErrorMessage(err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": msg})
 		return
 	}
 
-	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	ctx := c.Request.Context()
+
+	exists, err := s.users.ExistsByUsernameOrEmail(ctx, req.Username, req.Email)
 	if err != nil {
-		log.Println("Ошибка хеширования пароля:", err)
+		log.Println("Ошибка проверки существования пользователя:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера при регистрации"})
 		return
 	}
 
-	res, err := db.Exec(
-		"INSERT INTO users (username, password, email, role) VALUES (?, ?, ?, ?)",
-		req.Username, string(hashed), req.Email, "user",
-	)
+	if exists {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Пользователь уже существует"})
+		return
+	}
+
+	hashed, err := s.passwords.Hash(req.Password)
 	if err != nil {
-	log.Println("Ошибка вставки пользователя:", err)
+		log.Println("Ошибка хеширования пароля:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера при регистрации"})
 		return
 	}
 
-	id, err := res.LastInsertId()
+	user, err := s.users.Create(ctx, req.Username, req.Email, hashed, RoleUser)
 	if err != nil {
-		log.Println("Ошибка получения ID пользователя:", err)
+		log.Println("Ошибка вставки пользователя:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера при регистрации"})
 		return
 	}
 
-	var user User
-	if err := db.QueryRow(
-		"SELECT id, username, email, role, created_at FROM users WHERE id = ?",
-		id,
-	).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.CreatedAt); err != nil {
-		log.Println("Ошибка чтения пользователя:", err)
+	tokens, err := s.startSession(c, user)
+	if err != nil {
+		log.Println("Ошибка создания токена:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера при регистрации"})
 		return
 	}
 
-	token, err := createToken(user.ID, user.Username, user.Role)
+	resp, err := s.authResponse(ctx, tokens, user)
 	if err != nil {
-		log.Println("Ошибка создания токена:", err)
+		log.Println("Ошибка чтения прав пользователя:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера при регистрации"})
 		return
 	}
 
 	log.Printf("Новый пользователь создан: %s", user.Username)
 
-	c.JSON(http.StatusCreated, gin.H{
-		"token": token,
-		"user": gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-			"role":     user.Role,
-		},
-	})
+	// регистрация не должна срываться из-за почты: письмо можно запросить повторно из профиля
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		log.Println("Ошибка отправки письма с подтверждением:", err)
+	}
+
+	c.JSON(http.StatusCreated, resp)
 }
 
-func loginHandler(c *gin.Context) {
+func (s *Server) loginHandler(c *gin.Context) {
 	var req struct {
 		Username string `json:"username"`
 		Password string `json:"password"`
@@ -545,112 +701,145 @@ func loginHandler(c *gin.Context) {
 
 	log.Printf("Логин: %s", req.Username)
 
-	var user User
-	var hashed string
-
-	err := db.QueryRow(
-		"SELECT id, username, email, role, password, created_at FROM users WHERE username = ?",
-		req.Username,
-	).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &hashed, &user.CreatedAt)
+	ctx := c.Request.Context()
+	ip := c.ClientIP()
 
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверные учетные данные"})
+	wait, err := s.loginRetryAfter(ctx, req.Username, ip)
+	if err != nil {
+		log.Println("Ошибка проверки блокировки входа:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера при входе"})
 		return
+	}
+	if wait > 0 {
+		tooManyLoginAttempts(c, wait)
+		return
+	}
+
+	user, hashed, err := s.users.GetByUsername(ctx, req.Username)
+	found := err == nil
+	hasPassword := found && hashed != ""
+	if errors.Is(err, ErrNotFound) || (found && !hasPassword) {
+		// сравниваем с фиктивным хешем, чтобы по времени ответа нельзя было понять, есть ли такой логин
+		// и входит ли аккаунт только через внешнего провайдера
+		hashed = s.passwords.DummyHash()
 	} else if err != nil {
 		log.Println("Ошибка чтения пользователя при логине:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера при входе"})
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte(req.Password)); err != nil {
+	ok, rehash, err := s.passwords.Verify(hashed, req.Password)
+	if err != nil {
+		log.Println("Ошибка проверки пароля:", err)
+	}
+	if !ok || !hasPassword {
+		var userID *int64
+		if found {
+			userID = &user.ID
+		}
+		wait, err := s.recordLoginFailure(ctx, req.Username, ip, userID)
+		if err != nil {
+			log.Println("Ошибка учёта неудачного входа:", err)
+		}
+		if wait > 0 {
+			tooManyLoginAttempts(c, wait)
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверные учетные данные"})
 		return
 	}
 
-	token, err := createToken(user.ID, user.Username, user.Role)
+	// хеш старым алгоритмом или с устаревшими параметрами заменяем, пока пароль известен
+	if rehash {
+		if newHash, err := s.passwords.Hash(req.Password); err != nil {
+			log.Println("Ошибка перехеширования пароля:", err)
+		} else if err := s.users.SetPassword(ctx, user.ID, newHash); err != nil {
+			log.Println("Ошибка перехеширования пароля:", err)
+		}
+	}
+
+	// после пароля может понадобиться код из приложения; токены выдаст loginTwoFactorHandler
+	challenge, err := s.loginChallenge(ctx, user)
 	if err != nil {
-		log.Println("Ошибка создания токена при логине:", err)
+		log.Println("Ошибка создания запроса второго фактора:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера при входе"})
 		return
 	}
+	if challenge != nil {
+		log.Printf("Пароль верный, ожидается второй фактор: %s", user.Username)
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
 
-	log.Printf("Успешный логин: %s", user.Username)
-
-	c.JSON(http.StatusOK, gin.H{
-		"token": token,
-		"user": gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-			"role":     user.Role,
-		},
-	})
-}
-
-
-func getProductsHandler(c *gin.Context) {
-	search := c.Query("search")
-	category := c.Query("category")
-
-	query := "SELECT id, name, description, price, category, image, created_at FROM products WHERE 1=1"
-	var args []interface{}
+	// счётчик по IP не сбрасываем: иначе перебор можно было бы перемежать входом в свой аккаунт
+	if err := s.logins.Reset(ctx, ThrottleScopeAccount, loginSubjects(req.Username, ip)[ThrottleScopeAccount]); err != nil {
+		log.Println("Ошибка сброса счётчика входа:", err)
+	}
 
-	if search != "" {
-		query += " AND name LIKE ?"
-		args = append(args, "%"+search+"%")
+	tokens, err := s.startSession(c, user)
+	if err != nil {
+		log.Println("Ошибка создания токена при логине:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера при входе"})
+		return
 	}
 
-	if category != "" && category != "Все" {
-		query += " AND category = ?"
-		args = append(args, category)
+	resp, err := s.authResponse(ctx, tokens, user)
+	if err != nil {
+		log.Println("Ошибка чтения прав пользователя:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера при входе"})
+		return
 	}
 
-	query += " ORDER BY created_at DESC"
+	log.Printf("Успешный логин: %s", user.Username)
 
-	rows, err := db.Query(query, args...)
-	if err != nil {
+	c.JSON(http.StatusOK, resp)
+}
+
+
+func (s *Server) getProductsHandler(c *gin.Context) {
+	filter, err := s.parseProductFilter(c)
+	if errors.Is(err, errInvalidPrice) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный диапазон цен"})
+		return
+	} else if err != nil {
 		log.Println("Get products error:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
 		return
 	}
-	defer rows.Close()
 
-	var products []Product
-	for rows.Next() {
-		var p Product
-		if err := rows.Scan(
-			&p.ID, &p.Name, &p.Description, &p.Price,
-			&p.Category, &p.Image, &p.CreatedAt,
-		); err != nil {
-			log.Println("Scan product error:", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
-			return
-		}
-		products = append(products, p)
+	page, paginated, err := parsePageRequest(c, productSorts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": pageErrorMessage(err)})
+		return
 	}
 
-	if err := rows.Err(); err != nil {
-		log.Println("Rows error:", err)
+	result, err := s.products.List(c.Request.Context(), filter, page)
+	if errors.Is(err, errInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": pageErrorMessage(err)})
+		return
+	} else if err != nil {
+		log.Println("Get products error:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
 		return
 	}
 
-	c.JSON(http.StatusOK, products)
-}
-
-func createProductHandler(c *gin.Context) {
-	user := getUserClaims(c)
-	if user == nil || user.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Недостаточно прав"})
+	// без limit/cursor/page отдаём простой массив, как раньше
+	if !paginated {
+		c.JSON(http.StatusOK, result.Items)
 		return
 	}
+	c.JSON(http.StatusOK, result)
+}
 
+func (s *Server) createProductHandler(c *gin.Context) {
 	var req struct {
 		Name        string  `json:"name"`
 		Description string  `json:"description"`
 		Price       float64 `json:"price"`
+		CategoryID  *int64  `json:"category_id"`
 		Category    string  `json:"category"`
 		Image       string  `json:"image"`
+		Stock       int     `json:"stock"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -658,54 +847,50 @@ func createProductHandler(c *gin.Context) {
 		return
 	}
 
-	if req.Name == "" || req.Description == "" || req.Price == 0 || req.Category == "" {
+	if req.Name == "" || req.Description == "" || req.Price == 0 || (req.Category == "" && req.CategoryID == nil) {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Все поля обязательны"})
 		return
 	}
 
-	image := req.Image
-	if image == "" {
-		image = "/placeholder-product.jpg"
+	if req.Stock < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверное количество"})
+		return
 	}
 
-	res, err := db.Exec(
-		"INSERT INTO products (name, description, price, category, image) VALUES (?, ?, ?, ?, ?)",
-		req.Name, req.Description, req.Price, req.Category, image,
-	)
-	if err != nil {
+	categoryID, err := s.resolveCategory(c.Request.Context(), req.CategoryID, req.Category)
+	if errors.Is(err, errCategoryNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Категория не найдена"})
+		return
+	} else if err != nil {
 		log.Println("Create product error:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
 		return
 	}
 
-	id, err := res.LastInsertId()
-	if err != nil {
-		log.Println("Get product id error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
-		return
+	image := req.Image
+	if image == "" {
+		image = "/placeholder-product.jpg"
 	}
 
-	var product Product
-	if err := db.QueryRow(
-		"SELECT id, name, description, price, category, image, created_at FROM products WHERE id = ?",
-		id,
-	).Scan(&product.ID, &product.Name, &product.Description, &product.Price,
-		&product.Category, &product.Image, &product.CreatedAt); err != nil {
-		log.Println("Read product error:", err)
+	product, err := s.products.Create(c.Request.Context(), ProductInput{
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       req.Price,
+		CategoryID:  categoryID,
+		Image:       image,
+		Stock:       req.Stock,
+	})
+	if err != nil {
+		log.Println("Create product error:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
 		return
 	}
+	s.search.Index(productSearchDoc(product))
 
 	c.JSON(http.StatusCreated, product)
 }
 
-func updateProductHandler(c *gin.Context) {
-	user := getUserClaims(c)
-	if user == nil || user.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Недостаточно прав"})
-		return
-	}
-
+func (s *Server) updateProductHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -717,6 +902,7 @@ func updateProductHandler(c *gin.Context) {
 		Name        string  `json:"name"`
 		Description string  `json:"description"`
 		Price       float64 `json:"price"`
+		CategoryID  *int64  `json:"category_id"`
 		Category    string  `json:"category"`
 		Image       string  `json:"image"`
 	}
@@ -726,48 +912,37 @@ func updateProductHandler(c *gin.Context) {
 		return
 	}
 
-	res, err := db.Exec(
-		"UPDATE products SET name = ?, description = ?, price = ?, category = ?, image = ? WHERE id = ?",
-		req.Name, req.Description, req.Price, req.Category, req.Image, id,
-	)
-	if err != nil {
+	categoryID, err := s.resolveCategory(c.Request.Context(), req.CategoryID, req.Category)
+	if errors.Is(err, errCategoryNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Категория не найдена"})
+		return
+	} else if err != nil {
 		log.Println("Update product error:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
 		return
 	}
 
-	aff, err := res.RowsAffected()
-	if err != nil {
-		log.Println("RowsAffected error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
-		return
-	}
-	if aff == 0 {
+	product, err := s.products.Update(c.Request.Context(), id, ProductInput{
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       req.Price,
+		CategoryID:  categoryID,
+		Image:       req.Image,
+	})
+	if errors.Is(err, ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Продукт не найден"})
 		return
-	}
-
-	var product Product
-	if err := db.QueryRow(
-		"SELECT id, name, description, price, category, image, created_at FROM products WHERE id = ?",
-		id,
-	).Scan(&product.ID, &product.Name, &product.Description, &product.Price,
-		&product.Category, &product.Image, &product.CreatedAt); err != nil {
-		log.Println("Read updated product error:", err)
+	} else if err != nil {
+		log.Println("Update product error:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
 		return
 	}
+	s.search.Index(productSearchDoc(product))
 
 	c.JSON(http.StatusOK, product)
 }
 
-func deleteProductHandler(c *gin.Context) {
-	user := getUserClaims(c)
-	if user == nil || user.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Недостаточно прав"})
-		return
-	}
-
+func (s *Server) deleteProductHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -775,25 +950,26 @@ func deleteProductHandler(c *gin.Context) {
 		return
 	}
 
-	res, err := db.Exec(
-		"DELETE FROM products WHERE id = ?",
-		id,
-	)
+	// записи изображений удалятся каскадно, а файлы нужно убрать из хранилища самим
+	images, err := s.products.ListImages(c.Request.Context(), id)
 	if err != nil {
 		log.Println("Delete product error:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
 		return
 	}
 
-	aff, err := res.RowsAffected()
-	if err != nil {
-		log.Println("RowsAffected error:", err)
+	err = s.products.Delete(c.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Продукт не найден"})
+		return
+	} else if err != nil {
+		log.Println("Delete product error:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
 		return
 	}
-	if aff == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Продукт не найден"})
-		return
+	s.search.Remove(SearchKindProduct, id)
+	for _, img := range images {
+		s.deleteStoredFiles(c.Request.Context(), img.storageKeys()...)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Продукт удален"})
@@ -801,225 +977,116 @@ func deleteProductHandler(c *gin.Context) {
 
 
 
-func getJobsHandler(c *gin.Context) {
-	search := c.Query("search")
-	category := c.Query("category")
-
-	query := `
-		SELECT j.id, j.title, j.description, j.salary, j.category, j.company,
-		       j.user_id, j.approved, j.created_at, u.username
-		FROM jobs j
-		JOIN users u ON j.user_id = u.id
-		WHERE j.approved = true
-	`
-	var args []interface{}
-
-	if search != "" {
-		query += " AND j.title LIKE ?"
-		args = append(args, "%"+search+"%")
+func (s *Server) getJobsHandler(c *gin.Context) {
+	filter := JobFilter{
+		Search: c.Query("search"),
+		Status: JobStatusApproved,
 	}
-
-	if category != "" && category != "Все" {
-		query += " AND j.category = ?"
-		args = append(args, category)
+	// истёкшие вакансии скрыты, даже если фоновая задача ещё не убрала их в архив
+	if c.Query("include_expired") != "true" {
+		now := time.Now()
+		filter.ActiveAt = &now
 	}
 
-	query += " ORDER BY j.created_at DESC"
-
-	rows, err := db.Query(query, args...)
-	if err != nil {
-		log.Println("Get jobs error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+	var err error
+	if filter.SalaryFrom, err = parseSalaryParam(c.Query("salary_from")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный диапазон зарплаты"})
 		return
 	}
-	defer rows.Close()
-
-	var jobs []Job
-	for rows.Next() {
-		var j Job
-		if err := rows.Scan(
-			&j.ID, &j.Title, &j.Description, &j.Salary,
-			&j.Category, &j.Company, &j.UserID, &j.Approved,
-			&j.CreatedAt, &j.Username,
-		); err != nil {
-			log.Println("Scan job error:", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+	if filter.SalaryTo, err = parseSalaryParam(c.Query("salary_to")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный диапазон зарплаты"})
+		return
+	}
+	if filter.SalaryFrom != nil && filter.SalaryTo != nil && *filter.SalaryFrom > *filter.SalaryTo {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный диапазон зарплаты"})
+		return
+	}
+	if v := c.Query("company_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
 			return
 		}
-		jobs = append(jobs, j)
+		filter.CompanyID = &id
 	}
+	filter.SalaryCurrency = strings.ToUpper(c.Query("salary_currency"))
+	filter.SalaryPeriod = c.Query("salary_period")
 
-	if err := rows.Err(); err != nil {
-		log.Println("Rows error:", err)
+	if filter.CategoryIDs, err = s.categoryFilterIDs(c.Request.Context(), categoryRefs(c)); err != nil {
+		log.Println("Get jobs error:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
 		return
 	}
 
-	c.JSON(http.StatusOK, jobs)
-}
-
-func createJobHandler(c *gin.Context) {
-	claims := getUserClaims(c)
-	if claims == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
-		return
-	}
-
-	var req struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		Salary      string `json:"salary"`
-		Category    string `json:"category"`
-		Company     string `json:"company"`
+	if filter.Search != "" {
+		filter.IDs = s.searchIDs(filter.Search, SearchKindJob)
+		filter.Search = ""
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+	page, paginated, err := parsePageRequest(c, jobSorts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": pageErrorMessage(err)})
 		return
 	}
-
-	if req.Title == "" || req.Description == "" || req.Salary == "" || req.Category == "" || req.Company == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Все поля обязательны"})
+	if salaryUnitsMissing(filter.SalaryCurrency, filter.SalaryPeriod, filter.SalaryFrom, filter.SalaryTo, page.Sort) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": salaryUnitsMessage})
 		return
 	}
 
-	res, err := db.Exec(
-		"INSERT INTO jobs (title, description, salary, category, company, user_id, approved) VALUES (?, ?, ?, ?, ?, ?, ?)",
-		req.Title, req.Description, req.Salary, req.Category, req.Company, claims.ID, false,
-	)
-	if err != nil {
-		log.Println("Create job error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+	result, err := s.jobs.List(c.Request.Context(), filter, page)
+	if errors.Is(err, errInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": pageErrorMessage(err)})
 		return
-	}
-
-	id, err := res.LastInsertId()
-	if err != nil {
-		log.Println("Get job id error:", err)
+	} else if err != nil {
+		log.Println("Get jobs error:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
 		return
 	}
 
-	var job Job
-	err = db.QueryRow(`
-		SELECT j.id, j.title, j.description, j.salary, j.category, j.company,
-		       j.user_id, j.approved, j.created_at, u.username
-		FROM jobs j
-		JOIN users u ON j.user_id = u.id
-		WHERE j.id = ?
-	`, id).Scan(
-		&job.ID, &job.Title, &job.Description, &job.Salary,
-		&job.Category, &job.Company, &job.UserID, &job.Approved,
-		&job.CreatedAt, &job.Username,
-	)
-	if err != nil {
-		log.Println("Read job error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+	if !paginated {
+		c.JSON(http.StatusOK, result.Items)
 		return
 	}
-
-	c.JSON(http.StatusCreated, job)
+	c.JSON(http.StatusOK, result)
 }
 
-
-
-func getPendingJobsHandler(c *gin.Context) {
-	user := getUserClaims(c)
-	if user == nil || user.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Недостаточно прав"})
+func (s *Server) createJobHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
 		return
 	}
 
-	rows, err := db.Query(`
-		SELECT j.id, j.title, j.description, j.salary, j.category, j.company,
-		       j.user_id, j.approved, j.created_at, u.username
-		FROM jobs j
-		JOIN users u ON j.user_id = u.id
-		WHERE j.approved = false
-		ORDER BY j.created_at DESC
-	`)
+	// вакансии размещают только владельцы подтверждённых адресов — так меньше спама
+	user, err := s.users.GetByID(c.Request.Context(), claims.ID)
 	if err != nil {
-		log.Println("Get pending jobs error:", err)
+		log.Println("Create job error:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
 		return
 	}
-	defer rows.Close()
-
-	var jobs []Job
-	for rows.Next() {
-		var j Job
-		if err := rows.Scan(
-			&j.ID, &j.Title, &j.Description, &j.Salary,
-			&j.Category, &j.Company, &j.UserID, &j.Approved,
-			&j.CreatedAt, &j.Username,
-		); err != nil {
-			log.Println("Scan pending job error:", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
-			return
-		}
-		jobs = append(jobs, j)
-	}
-
-	if err := rows.Err(); err != nil {
-		log.Println("Rows error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+	if !user.EmailVerified {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Подтвердите email, чтобы размещать вакансии"})
 		return
 	}
 
-	c.JSON(http.StatusOK, jobs)
-}
-
-func approveJobHandler(c *gin.Context) {
-	user := getUserClaims(c)
-	if user == nil || user.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Недостаточно прав"})
+	in, ok := s.bindJobInput(c, claims.ID)
+	if !ok {
 		return
 	}
 
-	idStr := c.Param("id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	job, err := s.jobs.Create(c.Request.Context(), in)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
-		return
-	}
-
-	if _, err := db.Exec("UPDATE jobs SET approved = true WHERE id = ?", id); err != nil {
-		log.Println("Approve job error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
-		return
-	}
-
-	var job Job
-	err = db.QueryRow(`
-		SELECT j.id, j.title, j.description, j.salary, j.category, j.company,
-		       j.user_id, j.approved, j.created_at, u.username
-		FROM jobs j
-		JOIN users u ON j.user_id = u.id
-		WHERE j.id = ?
-	`, id).Scan(
-		&job.ID, &job.Title, &job.Description, &job.Salary,
-		&job.Category, &job.Company, &job.UserID, &job.Approved,
-		&job.CreatedAt, &job.Username,
-	)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Вакансия не найдена"})
-		return
-	} else if err != nil {
-		log.Println("Read approved job error:", err)
+		log.Println("Create job error:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
 		return
 	}
 
-	c.JSON(http.StatusOK, job)
+	c.JSON(http.StatusCreated, job)
 }
 
-func deleteJobHandler(c *gin.Context) {
-	user := getUserClaims(c)
-	if user == nil || user.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Недостаточно прав"})
-		return
-	}
 
+
+func (s *Server) deleteJobHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -1027,30 +1094,23 @@ func deleteJobHandler(c *gin.Context) {
 		return
 	}
 
-	res, err := db.Exec("DELETE FROM jobs WHERE id = ?", id)
-	if err != nil {
-		log.Println("Delete job error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+	err = s.jobs.Delete(c.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Вакансия не найдена"})
 		return
-	}
-
-	aff, err := res.RowsAffected()
-	if err != nil {
-		log.Println("RowsAffected error:", err)
+	} else if err != nil {
+		log.Println("Delete job error:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
 		return
 	}
-	if aff == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Вакансия не найдена"})
-		return
-	}
+	s.search.Remove(SearchKindJob, id)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Вакансия удалена"})
 }
 
 
 
-func shopLocationHandler(c *gin.Context) {
+func (s *Server) shopLocationHandler(c *gin.Context) {
 	shopLocation := ShopLocation{
 		Lat:          55.614831077219144,
 		Lon:          37.48326799993517,
@@ -1066,7 +1126,7 @@ func shopLocationHandler(c *gin.Context) {
 	})
 }
 
-func shopMapLinksHandler(c *gin.Context) {
+func (s *Server) shopMapLinksHandler(c *gin.Context) {
 	lat := 55.614831077219144
 	lon := 37.48326799993517
 
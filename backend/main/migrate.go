@@ -0,0 +1,390 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockName — имя advisory-блокировки MySQL, чтобы два процесса
+// не применяли миграции одновременно
+const migrationLockName = "stroystore_schema_migrations"
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+var errSchemaBehind = errors.New("database schema is behind")
+
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type appliedMigration struct {
+	Version   int
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// loadMigrations читает встроенные в бинарник файлы NNNN_name.up.sql / NNNN_name.down.sql
+func loadMigrations() ([]Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		m := migrationFileRe.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("unexpected migration file name %q", e.Name())
+		}
+
+		version, _ := strconv.Atoi(m[1])
+		body, err := migrationFiles.ReadFile(path.Join("migrations", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, mig.Name, m[2])
+		}
+
+		if m[3] == "up" {
+			mig.Up = string(body)
+			sum := sha256.Sum256(body)
+			mig.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// splitSQLStatements делит файл миграции на отдельные запросы по «;»,
+// пропуская комментарии и точки с запятой внутри строковых литералов
+func splitSQLStatements(script string) []string {
+	var stmts []string
+	var cur strings.Builder
+	var quote rune
+
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if quote != 0 {
+			cur.WriteRune(r)
+			if r == '\\' && i+1 < len(runes) {
+				i++
+				cur.WriteRune(runes[i])
+			} else if r == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch {
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+			cur.WriteRune(r)
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			cur.WriteRune('\n')
+		case r == ';':
+			if stmt := strings.TrimSpace(cur.String()); stmt != "" {
+				stmts = append(stmts, stmt)
+			}
+			cur.Reset()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+
+	if stmt := strings.TrimSpace(cur.String()); stmt != "" {
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
+
+func ensureMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	return err
+}
+
+func loadAppliedMigrations(ctx context.Context, q interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}) (map[int]appliedMigration, error) {
+	rows, err := q.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]appliedMigration{}
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[a.Version] = a
+	}
+	return applied, rows.Err()
+}
+
+// verifyChecksums проверяет, что уже применённые миграции не редактировались
+func verifyChecksums(migrations []Migration, applied map[int]appliedMigration) error {
+	known := map[int]bool{}
+	for _, m := range migrations {
+		known[m.Version] = true
+		if a, ok := applied[m.Version]; ok && a.Checksum != m.Checksum {
+			return fmt.Errorf("migration %04d_%s was modified after it was applied (checksum mismatch)", m.Version, m.Name)
+		}
+	}
+	for v, a := range applied {
+		if !known[v] {
+			return fmt.Errorf("migration %04d_%s is applied but missing from the binary", v, a.Name)
+		}
+	}
+	return nil
+}
+
+// withMigrationLock выполняет fn на отдельном соединении под блокировкой GET_LOCK
+func withMigrationLock(db *sql.DB, fn func(ctx context.Context, conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var got sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 30)", migrationLockName).Scan(&got); err != nil {
+		return err
+	}
+	if !got.Valid || got.Int64 != 1 {
+		return errors.New("could not acquire migration lock")
+	}
+	defer conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", migrationLockName)
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(ctx, conn)
+}
+
+// runMigration выполняет запросы по одному. DDL в MySQL фиксируется неявно,
+// поэтому при ошибке миграция может остаться применённой частично.
+func runMigration(ctx context.Context, conn *sql.Conn, m Migration, script string) error {
+	for _, stmt := range splitSQLStatements(script) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// migrateUp применяет все ещё не применённые миграции по порядку
+func migrateUp(db *sql.DB, out io.Writer) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	return withMigrationLock(db, func(ctx context.Context, conn *sql.Conn) error {
+		applied, err := loadAppliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := verifyChecksums(migrations, applied); err != nil {
+			return err
+		}
+
+		count := 0
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			if err := runMigration(ctx, conn, m, m.Up); err != nil {
+				return err
+			}
+			if _, err := conn.ExecContext(ctx,
+				"INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?)",
+				m.Version, m.Name, m.Checksum,
+			); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "applied  %04d_%s\n", m.Version, m.Name)
+			count++
+		}
+
+		if count == 0 {
+			fmt.Fprintln(out, "Схема базы данных актуальна")
+		}
+		return nil
+	})
+}
+
+// migrateDown откатывает steps последних применённых миграций
+func migrateDown(db *sql.DB, out io.Writer, steps int) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	return withMigrationLock(db, func(ctx context.Context, conn *sql.Conn) error {
+		applied, err := loadAppliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := verifyChecksums(migrations, applied); err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+			m := migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			if m.Down == "" {
+				return fmt.Errorf("migration %04d_%s has no down file", m.Version, m.Name)
+			}
+			if err := runMigration(ctx, conn, m, m.Down); err != nil {
+				return err
+			}
+			if _, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", m.Version); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "reverted %04d_%s\n", m.Version, m.Name)
+			steps--
+		}
+		return nil
+	})
+}
+
+func migrateStatus(db *sql.DB, out io.Writer) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	return withMigrationLock(db, func(ctx context.Context, conn *sql.Conn) error {
+		applied, err := loadAppliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, m := range migrations {
+			a, ok := applied[m.Version]
+			switch {
+			case !ok:
+				fmt.Fprintf(w, "%04d\t%s\tpending\t\n", m.Version, m.Name)
+			case a.Checksum != m.Checksum:
+				fmt.Fprintf(w, "%04d\t%s\tmodified\t%s\n", m.Version, m.Name, a.AppliedAt.Format(time.DateTime))
+			default:
+				fmt.Fprintf(w, "%04d\t%s\tapplied\t%s\n", m.Version, m.Name, a.AppliedAt.Format(time.DateTime))
+			}
+			delete(applied, m.Version)
+		}
+		for _, a := range applied {
+			fmt.Fprintf(w, "%04d\t%s\tmissing\t%s\n", a.Version, a.Name, a.AppliedAt.Format(time.DateTime))
+		}
+		return w.Flush()
+	})
+}
+
+// checkSchemaUpToDate вызывается при старте сервера: работать со схемой,
+// которая отстаёт от кода или расходится с ним, нельзя
+func checkSchemaUpToDate(db *sql.DB) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	applied, err := loadAppliedMigrations(ctx, db)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errSchemaBehind, err)
+	}
+	if err := verifyChecksums(migrations, applied); err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, fmt.Sprintf("%04d_%s", m.Version, m.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: pending migrations %s", errSchemaBehind, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// runMigrateCommand обрабатывает подкоманду `migrate up|down [N]|status`
+func runMigrateCommand(db *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down [N]|status")
+	}
+
+	switch args[0] {
+	case "up":
+		return migrateUp(db, os.Stdout)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+		return migrateDown(db, os.Stdout, steps)
+	case "status":
+		return migrateStatus(db, os.Stdout)
+	default:
+		return fmt.Errorf("unknown migrate command %q (expected up, down or status)", args[0])
+	}
+}
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS stock_reservations;
+DROP TABLE IF EXISTS product_stock;
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS basket_items;
+DROP TABLE IF EXISTS baskets;
+DROP TABLE IF EXISTS jobs;
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,10 @@
+ALTER TABLE products ADD COLUMN category VARCHAR(50) AFTER category_id;
+ALTER TABLE jobs ADD COLUMN category VARCHAR(50) AFTER category_id;
+
+UPDATE products p JOIN categories c ON c.id = p.category_id SET p.category = c.name;
+UPDATE jobs j JOIN categories c ON c.id = j.category_id SET j.category = c.name;
+
+ALTER TABLE products DROP FOREIGN KEY fk_products_category, DROP COLUMN category_id;
+ALTER TABLE jobs DROP FOREIGN KEY fk_jobs_category, DROP COLUMN category_id;
+
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,36 @@
+-- Справочник категорий вместо свободного текста в products.category и jobs.category.
+-- Варианты с разным регистром ("Электроинструменты" / "электроинструменты")
+-- сливаются в одну категорию благодаря регистронезависимой collation.
+
+CREATE TABLE categories (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    parent_id INT NULL,
+    name VARCHAR(50) NOT NULL,
+    slug VARCHAR(60) NOT NULL UNIQUE,
+    sort_order INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_categories_parent FOREIGN KEY (parent_id) REFERENCES categories(id)
+);
+
+INSERT INTO categories (name, slug)
+SELECT MIN(name), LOWER(REPLACE(MIN(name), ' ', '-'))
+FROM (
+    SELECT TRIM(category) AS name FROM products WHERE TRIM(COALESCE(category, '')) <> ''
+    UNION ALL
+    SELECT TRIM(category) AS name FROM jobs WHERE TRIM(COALESCE(category, '')) <> ''
+) names
+GROUP BY name;
+
+ALTER TABLE products
+    ADD COLUMN category_id INT NULL AFTER category,
+    ADD CONSTRAINT fk_products_category FOREIGN KEY (category_id) REFERENCES categories(id);
+
+ALTER TABLE jobs
+    ADD COLUMN category_id INT NULL AFTER category,
+    ADD CONSTRAINT fk_jobs_category FOREIGN KEY (category_id) REFERENCES categories(id);
+
+UPDATE products p JOIN categories c ON c.name = TRIM(p.category) SET p.category_id = c.id;
+UPDATE jobs j JOIN categories c ON c.name = TRIM(j.category) SET j.category_id = c.id;
+
+ALTER TABLE products DROP COLUMN category;
+ALTER TABLE jobs DROP COLUMN category;
@@ -0,0 +1,20 @@
+-- Несколько изображений у товара. Основное (is_primary) подменяет products.image в выдаче;
+-- products.image остаётся запасным значением для внешних ссылок и заглушки.
+
+CREATE TABLE product_images (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    product_id INT NOT NULL,
+    original_key VARCHAR(255) NOT NULL,
+    medium_key VARCHAR(255) NOT NULL,
+    thumb_key VARCHAR(255) NOT NULL,
+    url VARCHAR(255) NOT NULL,
+    medium_url VARCHAR(255) NOT NULL,
+    thumb_url VARCHAR(255) NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    is_primary BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_product_images_product (product_id, is_primary),
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
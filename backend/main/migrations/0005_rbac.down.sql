@@ -0,0 +1,10 @@
+ALTER TABLE users
+    DROP FOREIGN KEY fk_users_role,
+    MODIFY role VARCHAR(20) DEFAULT 'user';
+
+-- роли без прав администратора до появления RBAC были обычными пользователями
+UPDATE users SET role = 'user' WHERE role <> 'admin';
+
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
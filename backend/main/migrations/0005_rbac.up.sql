@@ -0,0 +1,49 @@
+-- Роли и права вместо проверок role = 'admin' в обработчиках.
+-- users.role остаётся строкой, но теперь ссылается на справочник ролей.
+
+CREATE TABLE roles (
+    name VARCHAR(20) PRIMARY KEY,
+    title VARCHAR(100) NOT NULL
+);
+
+CREATE TABLE permissions (
+    name VARCHAR(50) PRIMARY KEY,
+    description VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE role_permissions (
+    role VARCHAR(20) NOT NULL,
+    permission VARCHAR(50) NOT NULL,
+    PRIMARY KEY (role, permission),
+    FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (permission) REFERENCES permissions(name) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+INSERT INTO roles (name, title) VALUES
+    ('admin', 'Администратор'),
+    ('catalog_manager', 'Менеджер каталога'),
+    ('moderator', 'Модератор вакансий'),
+    ('order_operator', 'Оператор заказов'),
+    ('user', 'Пользователь');
+
+INSERT INTO permissions (name, description) VALUES
+    ('products.write', 'Создание и изменение товаров, остатков и изображений'),
+    ('categories.write', 'Управление справочником категорий'),
+    ('jobs.moderate', 'Модерация и удаление вакансий'),
+    ('orders.manage', 'Просмотр всех заказов и смена их статуса'),
+    ('users.manage', 'Назначение ролей и завершение сессий пользователей');
+
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', name FROM permissions;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('catalog_manager', 'products.write'),
+    ('catalog_manager', 'categories.write'),
+    ('moderator', 'jobs.moderate'),
+    ('order_operator', 'orders.manage');
+
+UPDATE users SET role = 'user' WHERE role IS NULL OR role NOT IN (SELECT name FROM roles);
+
+ALTER TABLE users
+    MODIFY role VARCHAR(20) NOT NULL DEFAULT 'user',
+    ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE;
@@ -0,0 +1,18 @@
+-- Подтверждение email и сброс пароля. Как и для refresh-токенов, в БД хранится только SHA-256 токена.
+
+ALTER TABLE users ADD COLUMN email_verified_at DATETIME NULL AFTER role;
+
+-- аккаунты, созданные до появления подтверждения, считаем подтверждёнными
+UPDATE users SET email_verified_at = created_at;
+
+CREATE TABLE user_tokens (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    purpose VARCHAR(20) NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_user_tokens_user (user_id, purpose),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
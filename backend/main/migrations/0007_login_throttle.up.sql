@@ -0,0 +1,24 @@
+-- Защита входа от перебора паролей: счётчики неудачных попыток по логину и по IP
+-- и журнал блокировок для администратора.
+
+CREATE TABLE login_throttle (
+    scope VARCHAR(10) NOT NULL,
+    subject VARCHAR(100) NOT NULL,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at DATETIME NOT NULL,
+    locked_until DATETIME NULL,
+    PRIMARY KEY (scope, subject)
+);
+
+CREATE TABLE login_lockouts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    scope VARCHAR(10) NOT NULL,
+    subject VARCHAR(100) NOT NULL,
+    user_id INT NULL,
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    failures INT NOT NULL,
+    locked_until DATETIME NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_login_lockouts_created (created_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
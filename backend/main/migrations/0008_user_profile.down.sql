@@ -0,0 +1,16 @@
+-- заказы и вакансии удалённых пользователей вернуть к автору нельзя
+DELETE FROM jobs WHERE user_id IS NULL;
+DELETE FROM orders WHERE user_id IS NULL;
+
+ALTER TABLE orders DROP FOREIGN KEY fk_orders_user;
+ALTER TABLE orders
+    MODIFY user_id INT NOT NULL,
+    ADD CONSTRAINT orders_ibfk_1 FOREIGN KEY (user_id) REFERENCES users(id);
+
+ALTER TABLE jobs
+    DROP FOREIGN KEY fk_jobs_user,
+    ADD CONSTRAINT jobs_ibfk_1 FOREIGN KEY (user_id) REFERENCES users(id);
+
+DROP TABLE IF EXISTS user_addresses;
+
+ALTER TABLE users DROP COLUMN phone, DROP COLUMN full_name;
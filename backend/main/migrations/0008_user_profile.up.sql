@@ -0,0 +1,28 @@
+-- Профиль пользователя и удаление аккаунта.
+-- После удаления аккаунта его вакансии и заказы остаются, но теряют связь с автором.
+
+ALTER TABLE users
+    ADD COLUMN full_name VARCHAR(150) NULL AFTER email,
+    ADD COLUMN phone VARCHAR(20) NULL AFTER full_name;
+
+CREATE TABLE user_addresses (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    label VARCHAR(50) NOT NULL DEFAULT '',
+    address VARCHAR(255) NOT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    sort_order INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_user_addresses_user (user_id, sort_order),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- ключи из 0001 созданы без имени, MySQL назвал их <таблица>_ibfk_1
+ALTER TABLE jobs
+    DROP FOREIGN KEY jobs_ibfk_1,
+    ADD CONSTRAINT fk_jobs_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE orders DROP FOREIGN KEY orders_ibfk_1;
+ALTER TABLE orders
+    MODIFY user_id INT NULL,
+    ADD CONSTRAINT fk_orders_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,28 @@
+-- Двухфакторная аутентификация (TOTP) и одноразовые коды восстановления.
+
+CREATE TABLE user_totp (
+    user_id INT PRIMARY KEY,
+    secret VARCHAR(64) NOT NULL,
+    -- NULL, пока пользователь не подтвердил настройку первым кодом
+    enabled_at DATETIME NULL,
+    -- последний принятый шаг TOTP: один код нельзя использовать дважды
+    last_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE user_recovery_codes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at DATETIME NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_recovery_code (user_id, code_hash),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- у пользователей с административными правами 2FA теперь обязательна:
+-- их текущие сессии завершаем, чтобы следующий вход прошёл через настройку
+UPDATE auth_sessions SET revoked_at = NOW()
+WHERE revoked_at IS NULL
+  AND user_id IN (SELECT u.id FROM users u JOIN role_permissions rp ON rp.role = u.role);
@@ -0,0 +1,28 @@
+-- Вход через внешних провайдеров (Яндекс ID, VK ID, OpenID Connect).
+
+CREATE TABLE user_identities (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    provider VARCHAR(20) NOT NULL,
+    -- постоянный идентификатор пользователя у провайдера (sub в OIDC)
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(100) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_identity (provider, subject),
+    UNIQUE KEY uq_identity_user (user_id, provider),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Незавершённые авторизации: state из ссылки на провайдера и code_verifier для PKCE.
+-- user_id заполнен, если пользователь привязывает провайдера к уже открытому аккаунту.
+CREATE TABLE oauth_states (
+    state_hash CHAR(64) PRIMARY KEY,
+    provider VARCHAR(20) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    user_id INT NULL,
+    expires_at DATETIME NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
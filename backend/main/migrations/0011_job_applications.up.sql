@@ -0,0 +1,18 @@
+-- Отклики соискателей на вакансии. Один пользователь откликается на вакансию один раз.
+
+CREATE TABLE job_applications (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    job_id INT NOT NULL,
+    user_id INT NOT NULL,
+    cover_letter TEXT NOT NULL,
+    contact_name VARCHAR(150) NOT NULL,
+    contact_phone VARCHAR(30) NOT NULL DEFAULT '',
+    contact_email VARCHAR(100) NOT NULL DEFAULT '',
+    status ENUM('new', 'viewed', 'invited', 'rejected') NOT NULL DEFAULT 'new',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_job_application (job_id, user_id),
+    INDEX idx_job_applications_user (user_id, created_at),
+    FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS job_moderation_log;
+
+ALTER TABLE jobs ADD COLUMN approved BOOLEAN DEFAULT false AFTER user_id;
+
+UPDATE jobs SET approved = (status = 'approved');
+
+DROP INDEX idx_jobs_status ON jobs;
+
+ALTER TABLE jobs
+    DROP COLUMN moderated_at,
+    DROP COLUMN rejection_reason,
+    DROP COLUMN status;
@@ -0,0 +1,27 @@
+-- Модерация вакансий: статус вместо флага approved, причина отказа и журнал решений.
+
+ALTER TABLE jobs
+    ADD COLUMN status ENUM('pending', 'approved', 'rejected', 'archived') NOT NULL DEFAULT 'pending' AFTER user_id,
+    ADD COLUMN rejection_reason VARCHAR(500) NULL AFTER status,
+    ADD COLUMN moderated_at DATETIME NULL AFTER rejection_reason;
+
+UPDATE jobs SET status = IF(approved, 'approved', 'pending');
+
+ALTER TABLE jobs DROP COLUMN approved;
+
+CREATE INDEX idx_jobs_status ON jobs (status, created_at);
+
+-- actor_id — модератор или автор, отправивший изменённую вакансию на повторную проверку
+CREATE TABLE job_moderation_log (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    job_id INT NOT NULL,
+    actor_id INT NULL,
+    from_status ENUM('pending', 'approved', 'rejected', 'archived') NOT NULL,
+    to_status ENUM('pending', 'approved', 'rejected', 'archived') NOT NULL,
+    reason VARCHAR(500) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_job_moderation_job (job_id, created_at),
+    INDEX idx_job_moderation_actor (actor_id, created_at),
+    FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL
+);
@@ -0,0 +1,8 @@
+DROP INDEX idx_jobs_salary ON jobs;
+
+ALTER TABLE jobs
+    DROP COLUMN salary_gross,
+    DROP COLUMN salary_period,
+    DROP COLUMN salary_currency,
+    DROP COLUMN salary_max,
+    DROP COLUMN salary_min;
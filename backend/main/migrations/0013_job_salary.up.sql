@@ -0,0 +1,56 @@
+-- Зарплата вакансии в числах. Строка salary остаётся для показа, суммы разбираются из неё
+-- по тем же правилам, что в parseSalary (salary.go).
+
+ALTER TABLE jobs
+    ADD COLUMN salary_min INT UNSIGNED NULL AFTER salary,
+    ADD COLUMN salary_max INT UNSIGNED NULL AFTER salary_min,
+    ADD COLUMN salary_currency CHAR(3) NOT NULL DEFAULT 'RUB' AFTER salary_max,
+    ADD COLUMN salary_period ENUM('month', 'shift', 'hour') NOT NULL DEFAULT 'month' AFTER salary_currency,
+    ADD COLUMN salary_gross BOOLEAN NOT NULL DEFAULT false AFTER salary_period,
+    ADD COLUMN salary_parsed VARCHAR(255) NULL,
+    ADD COLUMN salary_lo DOUBLE NULL,
+    ADD COLUMN salary_hi DOUBLE NULL;
+
+-- пробелы между разрядами («80 000») мешают выделить число целиком
+UPDATE jobs SET salary_parsed = LOWER(REGEXP_REPLACE(salary, '([0-9])[[:space:]]+(?=[0-9])', '$1'));
+
+-- два числа — вилка, одно после «до» — только верхняя граница, после «от» — только нижняя
+UPDATE jobs SET
+    salary_lo = CASE
+        WHEN REGEXP_SUBSTR(salary_parsed, '[0-9]+', 1, 1) IS NULL THEN NULL
+        WHEN REGEXP_SUBSTR(salary_parsed, '[0-9]+', 1, 2) IS NOT NULL THEN
+            LEAST(REGEXP_SUBSTR(salary_parsed, '[0-9]+', 1, 1) + 0, REGEXP_SUBSTR(salary_parsed, '[0-9]+', 1, 2) + 0)
+        WHEN salary_parsed REGEXP 'до[[:space:]]*[0-9]' AND NOT salary_parsed REGEXP 'от[[:space:]]*[0-9]' THEN NULL
+        ELSE REGEXP_SUBSTR(salary_parsed, '[0-9]+', 1, 1) + 0
+    END * IF(salary_parsed LIKE '%тыс%', 1000, 1),
+    salary_hi = CASE
+        WHEN REGEXP_SUBSTR(salary_parsed, '[0-9]+', 1, 1) IS NULL THEN NULL
+        WHEN REGEXP_SUBSTR(salary_parsed, '[0-9]+', 1, 2) IS NOT NULL THEN
+            GREATEST(REGEXP_SUBSTR(salary_parsed, '[0-9]+', 1, 1) + 0, REGEXP_SUBSTR(salary_parsed, '[0-9]+', 1, 2) + 0)
+        WHEN salary_parsed REGEXP 'до[[:space:]]*[0-9]' AND NOT salary_parsed REGEXP 'от[[:space:]]*[0-9]' THEN
+            REGEXP_SUBSTR(salary_parsed, '[0-9]+', 1, 1) + 0
+        WHEN salary_parsed REGEXP 'от[[:space:]]*[0-9]' THEN NULL
+        ELSE REGEXP_SUBSTR(salary_parsed, '[0-9]+', 1, 1) + 0
+    END * IF(salary_parsed LIKE '%тыс%', 1000, 1),
+    salary_currency = CASE
+        WHEN salary_parsed LIKE '%$%' OR salary_parsed LIKE '%usd%' OR salary_parsed LIKE '%долл%' THEN 'USD'
+        WHEN salary_parsed LIKE '%€%' OR salary_parsed LIKE '%eur%' OR salary_parsed LIKE '%евро%' THEN 'EUR'
+        ELSE 'RUB'
+    END,
+    salary_period = CASE
+        WHEN salary_parsed LIKE '%смен%' THEN 'shift'
+        WHEN salary_parsed REGEXP 'час|/[[:space:]]*ч' THEN 'hour'
+        ELSE 'month'
+    END,
+    salary_gross = (salary_parsed LIKE '%до вычета%' OR salary_parsed LIKE '%gross%' OR salary_parsed LIKE '%брутто%');
+
+-- суммы больше 100 млн — опечатки, такие строки остаются «по договорённости»
+UPDATE jobs SET salary_min = salary_lo, salary_max = salary_hi
+WHERE COALESCE(salary_lo, 0) <= 100000000 AND COALESCE(salary_hi, 0) <= 100000000;
+
+ALTER TABLE jobs
+    DROP COLUMN salary_parsed,
+    DROP COLUMN salary_lo,
+    DROP COLUMN salary_hi;
+
+CREATE INDEX idx_jobs_salary ON jobs (salary_max, salary_min);
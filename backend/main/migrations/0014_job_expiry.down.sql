@@ -0,0 +1,5 @@
+DROP INDEX idx_jobs_expires ON jobs;
+
+ALTER TABLE jobs
+    DROP COLUMN expiry_warned_at,
+    DROP COLUMN expires_at;
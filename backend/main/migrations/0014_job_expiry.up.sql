@@ -0,0 +1,11 @@
+-- Срок публикации вакансии. expires_at задаётся при одобрении и продлении,
+-- у снятых модератором и ожидающих проверки вакансий он пуст.
+
+ALTER TABLE jobs
+    ADD COLUMN expires_at DATETIME NULL AFTER moderated_at,
+    ADD COLUMN expiry_warned_at DATETIME NULL AFTER expires_at;
+
+-- уже опубликованным вакансиям даём полный срок с момента миграции
+UPDATE jobs SET expires_at = DATE_ADD(NOW(), INTERVAL 30 DAY) WHERE status = 'approved';
+
+CREATE INDEX idx_jobs_expires ON jobs (status, expires_at);
@@ -0,0 +1,9 @@
+DELETE FROM permissions WHERE name = 'companies.verify';
+
+ALTER TABLE jobs
+    DROP FOREIGN KEY fk_jobs_company,
+    DROP COLUMN company_id;
+
+DROP TABLE IF EXISTS company_invitations;
+DROP TABLE IF EXISTS company_members;
+DROP TABLE IF EXISTS companies;
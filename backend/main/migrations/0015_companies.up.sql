@@ -0,0 +1,78 @@
+-- Компании работодателей. Вакансия ссылается на компанию, а jobs.company остаётся
+-- копией её названия для старых клиентов и откликов.
+
+CREATE TABLE companies (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    inn VARCHAR(12) NULL,
+    description TEXT NOT NULL,
+    logo_key VARCHAR(255) NULL,
+    logo_url VARCHAR(500) NULL,
+    website VARCHAR(255) NOT NULL DEFAULT '',
+    phone VARCHAR(30) NOT NULL DEFAULT '',
+    email VARCHAR(100) NOT NULL DEFAULT '',
+    verified BOOLEAN NOT NULL DEFAULT false,
+    verified_at DATETIME NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_companies_inn (inn),
+    INDEX idx_companies_name (name)
+);
+
+-- пользователи, которые управляют компанией и размещают от неё вакансии;
+-- приглашают и убирают коллег только владельцы
+CREATE TABLE company_members (
+    company_id INT NOT NULL,
+    user_id INT NOT NULL,
+    role ENUM('owner', 'member') NOT NULL DEFAULT 'member',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (company_id, user_id),
+    INDEX idx_company_members_user (user_id),
+    FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- участником пользователь становится, только приняв приглашение
+CREATE TABLE company_invitations (
+    company_id INT NOT NULL,
+    user_id INT NOT NULL,
+    invited_by INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (company_id, user_id),
+    INDEX idx_company_invitations_user (user_id),
+    FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+ALTER TABLE jobs
+    ADD COLUMN company_id INT NULL AFTER company,
+    ADD CONSTRAINT fk_jobs_company FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE SET NULL;
+
+-- по компании на каждое написание из старых вакансий: collation без учёта регистра
+-- склеивает «СтройМир» и «строймир», лишние пробелы обрезаются
+INSERT INTO companies (name, description)
+SELECT MIN(TRIM(company)), '' FROM jobs
+WHERE company IS NOT NULL AND TRIM(company) <> ''
+GROUP BY TRIM(company);
+
+UPDATE jobs j JOIN companies c ON c.name = TRIM(j.company) SET j.company_id = c.id, j.company = c.name;
+
+-- управлять компанией могут авторы её вакансий
+INSERT IGNORE INTO company_members (company_id, user_id)
+SELECT DISTINCT company_id, user_id FROM jobs WHERE company_id IS NOT NULL AND user_id IS NOT NULL;
+
+-- владелец — автор первой вакансии компании
+UPDATE company_members m
+JOIN (
+    SELECT company_id, MIN(id) AS job_id FROM jobs
+    WHERE company_id IS NOT NULL AND user_id IS NOT NULL
+    GROUP BY company_id
+) f ON f.company_id = m.company_id
+JOIN jobs j ON j.id = f.job_id AND j.user_id = m.user_id
+SET m.role = 'owner';
+
+INSERT INTO permissions (name, description) VALUES
+    ('companies.verify', 'Проверка компаний работодателей');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'companies.verify');
@@ -0,0 +1,11 @@
+UPDATE users SET role = 'user' WHERE role = 'employer';
+ALTER TABLE users DROP COLUMN role_assigned;
+
+DELETE FROM permissions WHERE name IN ('resumes.search', 'resumes.moderate');
+DELETE FROM roles WHERE name = 'employer';
+UPDATE roles SET title = 'Модератор вакансий' WHERE name = 'moderator';
+
+DROP TABLE IF EXISTS resume_invitations;
+DROP TABLE IF EXISTS resume_moderation_log;
+DROP TABLE IF EXISTS resume_skills;
+DROP TABLE IF EXISTS resumes;
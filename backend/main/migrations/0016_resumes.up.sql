@@ -0,0 +1,91 @@
+-- Резюме соискателей: работодатели ищут мастеров так же, как соискатели — вакансии.
+-- Модерация устроена как у вакансий (0012_job_moderation).
+
+CREATE TABLE resumes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    status ENUM('pending', 'approved', 'rejected', 'archived') NOT NULL DEFAULT 'pending',
+    rejection_reason VARCHAR(500) NULL,
+    moderated_at DATETIME NULL,
+    title VARCHAR(100) NOT NULL,
+    experience_years TINYINT UNSIGNED NOT NULL DEFAULT 0,
+    experience TEXT NOT NULL,
+    region VARCHAR(100) NOT NULL,
+    availability ENUM('immediately', 'two_weeks', 'month') NOT NULL DEFAULT 'immediately',
+    salary_min INT UNSIGNED NULL,
+    salary_max INT UNSIGNED NULL,
+    salary_currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    salary_period ENUM('month', 'shift', 'hour') NOT NULL DEFAULT 'month',
+    salary_gross BOOLEAN NOT NULL DEFAULT false,
+    contact_name VARCHAR(150) NOT NULL,
+    contact_phone VARCHAR(30) NOT NULL DEFAULT '',
+    contact_email VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_resumes_status (status, created_at),
+    INDEX idx_resumes_user (user_id, created_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- навыки отдельной таблицей, чтобы искать мастеров по точному навыку
+CREATE TABLE resume_skills (
+    resume_id INT NOT NULL,
+    position TINYINT UNSIGNED NOT NULL,
+    skill VARCHAR(50) NOT NULL,
+    PRIMARY KEY (resume_id, position),
+    INDEX idx_resume_skills_skill (skill),
+    FOREIGN KEY (resume_id) REFERENCES resumes(id) ON DELETE CASCADE
+);
+
+CREATE TABLE resume_moderation_log (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    resume_id INT NOT NULL,
+    actor_id INT NULL,
+    from_status ENUM('pending', 'approved', 'rejected', 'archived') NOT NULL,
+    to_status ENUM('pending', 'approved', 'rejected', 'archived') NOT NULL,
+    reason VARCHAR(500) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_resume_moderation_resume (resume_id, created_at),
+    INDEX idx_resume_moderation_actor (actor_id, created_at),
+    FOREIGN KEY (resume_id) REFERENCES resumes(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- приглашение работодателя на его вакансию открывает ему контакты из резюме
+CREATE TABLE resume_invitations (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    resume_id INT NOT NULL,
+    job_id INT NOT NULL,
+    employer_id INT NOT NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_resume_invitation (resume_id, job_id),
+    INDEX idx_resume_invitations_employer (employer_id, resume_id),
+    FOREIGN KEY (resume_id) REFERENCES resumes(id) ON DELETE CASCADE,
+    FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE,
+    FOREIGN KEY (employer_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO roles (name, title) VALUES
+    ('employer', 'Работодатель');
+
+UPDATE roles SET title = 'Модератор вакансий и резюме' WHERE name = 'moderator';
+
+INSERT INTO permissions (name, description) VALUES
+    ('resumes.search', 'Поиск резюме соискателей'),
+    ('resumes.moderate', 'Модерация резюме');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'resumes.search'),
+    ('admin', 'resumes.moderate'),
+    ('moderator', 'resumes.moderate'),
+    ('employer', 'resumes.search');
+
+-- роль, назначенную администратором, автоматические правила (роль работодателя) не меняют
+ALTER TABLE users ADD COLUMN role_assigned BOOLEAN NOT NULL DEFAULT false AFTER role;
+
+-- работодатели — участники проверенных компаний; роль выдаётся и снимается вместе с проверкой и участием
+UPDATE users SET role = 'employer'
+WHERE role = 'user' AND id IN (
+    SELECT m.user_id FROM company_members m JOIN companies c ON c.id = m.company_id WHERE c.verified
+);
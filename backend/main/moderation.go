@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	JobStatusPending  = "pending"
+	JobStatusApproved = "approved"
+	JobStatusRejected = "rejected"
+	JobStatusArchived = "archived"
+)
+
+// jobModerationTransitions — решения модератора. В pending вакансия возвращается
+// только после правки автором (см. JobRepository.Update).
+var jobModerationTransitions = map[string][]string{
+	JobStatusPending:  {JobStatusApproved, JobStatusRejected, JobStatusArchived},
+	JobStatusApproved: {JobStatusRejected, JobStatusArchived},
+	JobStatusRejected: {JobStatusApproved, JobStatusArchived},
+	JobStatusArchived: {JobStatusApproved},
+}
+
+const (
+	minRejectionReason = 3
+	maxRejectionReason = 500
+)
+
+// ModerationEntry — запись журнала модерации: кто, когда и почему сменил статус вакансии.
+// ActorID пуст, если аккаунт модератора удалён.
+type ModerationEntry struct {
+	ID         int64     `json:"id"`
+	JobID      int64     `json:"job_id"`
+	JobTitle   string    `json:"job_title"`
+	ActorID    *int64    `json:"actor_id"`
+	ActorName  string    `json:"actor_name"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func canTransitionJob(from, to string) bool {
+	for _, next := range jobModerationTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// getModerationQueueHandler — очередь модерации. По умолчанию ожидающие проверки,
+// старые первыми; status=all — вакансии в любом статусе.
+func (s *Server) getModerationQueueHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	filter := JobFilter{
+		Search:         strings.TrimSpace(c.Query("search")),
+		Status:         c.DefaultQuery("status", JobStatusPending),
+		SalaryCurrency: strings.ToUpper(c.Query("salary_currency")),
+		SalaryPeriod:   c.Query("salary_period"),
+	}
+	if filter.Status == "all" {
+		filter.Status = ""
+	} else if _, ok := jobModerationTransitions[filter.Status]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неизвестный статус"})
+		return
+	}
+
+	if author := strings.TrimSpace(c.Query("author")); author != "" {
+		user, _, err := s.users.GetByUsername(ctx, author)
+		if errors.Is(err, ErrNotFound) {
+			c.JSON(http.StatusOK, []Job{})
+			return
+		} else if err != nil {
+			log.Println("Get moderation queue error:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+			return
+		}
+		filter.AuthorID = &user.ID
+	}
+
+	var err error
+	if filter.CategoryIDs, err = s.categoryFilterIDs(ctx, categoryRefs(c)); err != nil {
+		log.Println("Get moderation queue error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	page, paginated, err := parsePageRequest(c, jobSorts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": pageErrorMessage(err)})
+		return
+	}
+	if page.Sort == "" {
+		page.Sort = "oldest"
+	}
+	if salaryUnitsMissing(filter.SalaryCurrency, filter.SalaryPeriod, nil, nil, page.Sort) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": salaryUnitsMessage})
+		return
+	}
+
+	result, err := s.jobs.List(ctx, filter, page)
+	if errors.Is(err, errInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": pageErrorMessage(err)})
+		return
+	} else if err != nil {
+		log.Println("Get moderation queue error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	if !paginated {
+		c.JSON(http.StatusOK, result.Items)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// moderateJob переводит вакансию из :id в статус to, обновляет поисковый индекс
+// и при отказе пишет автору. false — клиенту уже ответили ошибкой.
+func (s *Server) moderateJob(c *gin.Context, to, reason string) (Job, bool) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return Job{}, false
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return Job{}, false
+	}
+
+	ctx := c.Request.Context()
+	job, err := s.jobs.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Вакансия не найдена"})
+		return job, false
+	} else if err != nil {
+		log.Println("Moderate job error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return job, false
+	}
+
+	if job.Status == to {
+		return job, true
+	}
+	if !canTransitionJob(job.Status, to) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Недопустимая смена статуса вакансии"})
+		return job, false
+	}
+
+	from := job.Status
+	job, err = s.jobs.SetStatus(ctx, id, from, to, reason, claims.ID)
+	if errors.Is(err, ErrStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Статус вакансии уже изменился, обновите страницу"})
+		return job, false
+	} else if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Вакансия не найдена"})
+		return job, false
+	} else if err != nil {
+		log.Println("Moderate job error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return job, false
+	}
+	s.reindexJob(ctx, id)
+
+	log.Printf("Модерация вакансии %d: %s → %s, модератор %s", id, from, to, claims.Username)
+	if to == JobStatusRejected {
+		if err := s.sendJobRejectedEmail(ctx, job); err != nil {
+			log.Println("Send job rejected email error:", err)
+		}
+	}
+	return job, true
+}
+
+// setJobStatusHandler — решение модератора; отказ без причины не принимается,
+// причину видит автор вакансии
+func (s *Server) setJobStatusHandler(c *gin.Context) {
+	var req struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+	if _, ok := jobModerationTransitions[req.Status]; !ok || req.Status == JobStatusPending {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неизвестный статус"})
+		return
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if req.Status == JobStatusRejected {
+		if n := utf8.RuneCountInString(reason); n < minRejectionReason || n > maxRejectionReason {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Укажите причину отказа (от 3 до 500 символов)"})
+			return
+		}
+	}
+
+	job, ok := s.moderateJob(c, req.Status, reason)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// approveJobHandler — короткий путь для одобрения, оставлен для совместимости
+func (s *Server) approveJobHandler(c *gin.Context) {
+	job, ok := s.moderateJob(c, JobStatusApproved, "")
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+func (s *Server) sendJobRejectedEmail(ctx context.Context, job Job) error {
+	return s.mailJobAuthor(ctx, job, "Вакансия не прошла модерацию",
+		"Вакансия «%s» отклонена модератором.\nПричина: %s\n\n"+
+			"Исправьте вакансию в разделе «Мои вакансии», и она снова уйдёт на проверку:\n%s/profile\n",
+		job.Title, job.RejectionReason, s.appURL)
+}
+
+func (s *Server) getJobModerationHistoryHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := s.jobs.Get(ctx, id); errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Вакансия не найдена"})
+		return
+	} else if err != nil {
+		log.Println("Get job moderation history error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	entries, err := s.jobs.History(ctx, ModerationFilter{JobID: &id})
+	if err != nil {
+		log.Println("Get job moderation history error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// getModerationHistoryHandler — последние решения всех модераторов или одного (moderator_id)
+func (s *Server) getModerationHistoryHandler(c *gin.Context) {
+	filter := ModerationFilter{Limit: defaultPageLimit}
+	if v := c.Query("moderator_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+			return
+		}
+		filter.ActorID = &id
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": pageErrorMessage(errInvalidPage)})
+			return
+		}
+		filter.Limit = min(n, maxPageLimit)
+	}
+
+	entries, err := s.jobs.History(c.Request.Context(), filter)
+	if err != nil {
+		log.Println("Get moderation history error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
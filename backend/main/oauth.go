@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Вход через внешних провайдеров: authorization code + PKCE (RFC 7636).
+// Провайдер подтверждает личность, дальше пользователь получает обычные токены СтройСтора.
+
+const (
+	ProviderYandex = "yandex"
+	ProviderVK     = "vk"
+	ProviderOIDC   = "oidc"
+
+	// за это время нужно вернуться от провайдера
+	oauthStateTTL = 10 * time.Minute
+)
+
+var oauthHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// ExternalIdentity — пользователь глазами провайдера
+type ExternalIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Login         string // подсказка для логина нового аккаунта
+	Name          string
+}
+
+type oauthToken struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type OAuthProvider struct {
+	Name         string
+	Title        string // подпись кнопки на странице входа
+	ClientID     string
+	ClientSecret string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	RedirectURL  string
+	Scopes       []string
+	Issuer       string // только OIDC: ожидаемый iss в id_token
+
+	// callbackParams — параметры колбэка, которые нужно передать в token endpoint
+	callbackParams []string
+	identity       func(ctx context.Context, p *OAuthProvider, token oauthToken, nonce string) (ExternalIdentity, error)
+}
+
+// pkceChallenge — code_challenge для метода S256
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (p *OAuthProvider) authCodeURL(state, verifier, nonce string) string {
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.ClientID)
+	q.Set("redirect_uri", p.RedirectURL)
+	q.Set("state", state)
+	q.Set("code_challenge", pkceChallenge(verifier))
+	q.Set("code_challenge_method", "S256")
+	if len(p.Scopes) > 0 {
+		q.Set("scope", strings.Join(p.Scopes, " "))
+	}
+	if p.Name == ProviderOIDC {
+		q.Set("nonce", nonce)
+	}
+
+	sep := "?"
+	if strings.Contains(p.AuthURL, "?") {
+		sep = "&"
+	}
+	return p.AuthURL + sep + q.Encode()
+}
+
+// exchange меняет код из колбэка на токены
+func (p *OAuthProvider) exchange(ctx context.Context, code, verifier string, callback url.Values) (oauthToken, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.RedirectURL)
+	form.Set("client_id", p.ClientID)
+	form.Set("code_verifier", verifier)
+	if p.ClientSecret != "" {
+		form.Set("client_secret", p.ClientSecret)
+	}
+	for _, name := range p.callbackParams {
+		form.Set(name, callback.Get(name))
+	}
+
+	var token oauthToken
+	err := oauthPostForm(ctx, p.TokenURL, form, &token)
+	if token.Error != "" {
+		return token, fmt.Errorf("%s token: %s %s", p.Name, token.Error, token.ErrorDescription)
+	}
+	if err != nil {
+		return token, err
+	}
+	if token.AccessToken == "" && token.IDToken == "" {
+		return token, fmt.Errorf("%s token: empty response", p.Name)
+	}
+	return token, nil
+}
+
+func oauthPostForm(ctx context.Context, endpoint string, form url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return oauthDo(req, out)
+}
+
+func oauthGetJSON(ctx context.Context, endpoint, authorization string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return oauthDo(req, out)
+}
+
+// oauthDo декодирует JSON и при ошибочном статусе: провайдеры кладут в тело описание ошибки
+func oauthDo(req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := oauthHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	decodeErr := json.Unmarshal(body, out)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: status %d", req.URL.Host, resp.StatusCode)
+	}
+	return decodeErr
+}
+
+// ---------- Яндекс ID ----------
+
+func newYandexProvider(clientID, clientSecret string) *OAuthProvider {
+	return &OAuthProvider{
+		Name:         ProviderYandex,
+		Title:        "Яндекс ID",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		AuthURL:      "https://oauth.yandex.ru/authorize",
+		TokenURL:     "https://oauth.yandex.ru/token",
+		UserInfoURL:  "https://login.yandex.ru/info?format=json",
+		Scopes:       []string{"login:info", "login:email"},
+		identity:     yandexIdentity,
+	}
+}
+
+func yandexIdentity(ctx context.Context, p *OAuthProvider, token oauthToken, nonce string) (ExternalIdentity, error) {
+	var info struct {
+		ID           string `json:"id"`
+		Login        string `json:"login"`
+		DefaultEmail string `json:"default_email"`
+		RealName     string `json:"real_name"`
+	}
+	if err := oauthGetJSON(ctx, p.UserInfoURL, "OAuth "+token.AccessToken, &info); err != nil {
+		return ExternalIdentity{}, err
+	}
+	if info.ID == "" {
+		return ExternalIdentity{}, errors.New("yandex: empty user id")
+	}
+	// default_email — адрес Яндекс ID, который Яндекс уже подтвердил
+	return ExternalIdentity{
+		Subject:       info.ID,
+		Email:         info.DefaultEmail,
+		EmailVerified: info.DefaultEmail != "",
+		Login:         info.Login,
+		Name:          info.RealName,
+	}, nil
+}
+
+// ---------- VK ID ----------
+
+func newVKProvider(clientID, clientSecret string) *OAuthProvider {
+	return &OAuthProvider{
+		Name:         ProviderVK,
+		Title:        "VK ID",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		AuthURL:      "https://id.vk.com/authorize",
+		TokenURL:     "https://id.vk.com/oauth2/auth",
+		UserInfoURL:  "https://id.vk.com/oauth2/user_info",
+		Scopes:       []string{"email"},
+		// VK ID требует device_id и state из колбэка при обмене кода
+		callbackParams: []string{"device_id", "state"},
+		identity:       vkIdentity,
+	}
+}
+
+func vkIdentity(ctx context.Context, p *OAuthProvider, token oauthToken, nonce string) (ExternalIdentity, error) {
+	var info struct {
+		User struct {
+			UserID    string `json:"user_id"`
+			FirstName string `json:"first_name"`
+			LastName  string `json:"last_name"`
+			Email     string `json:"email"`
+		} `json:"user"`
+	}
+	form := url.Values{"client_id": {p.ClientID}, "access_token": {token.AccessToken}}
+	if err := oauthPostForm(ctx, p.UserInfoURL, form, &info); err != nil {
+		return ExternalIdentity{}, err
+	}
+	if info.User.UserID == "" {
+		return ExternalIdentity{}, errors.New("vk: empty user id")
+	}
+	// VK не сообщает, подтверждён ли адрес, поэтому по нему аккаунты не связываем
+	return ExternalIdentity{
+		Subject: info.User.UserID,
+		Email:   info.User.Email,
+		Name:    strings.TrimSpace(info.User.FirstName + " " + info.User.LastName),
+	}, nil
+}
+
+// ---------- OpenID Connect ----------
+
+// discoverOIDC читает настройки провайдера из issuer/.well-known/openid-configuration
+func discoverOIDC(ctx context.Context, issuer, clientID, clientSecret string) (*OAuthProvider, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	var doc struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	}
+	if err := oauthGetJSON(ctx, issuer+"/.well-known/openid-configuration", "", &doc); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("oidc: discovery issuer %q does not match %q", doc.Issuer, issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" {
+		return nil, errors.New("oidc: discovery document has no endpoints")
+	}
+
+	return &OAuthProvider{
+		Name:         ProviderOIDC,
+		Title:        "OpenID Connect",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		AuthURL:      doc.AuthorizationEndpoint,
+		TokenURL:     doc.TokenEndpoint,
+		UserInfoURL:  doc.UserinfoEndpoint,
+		Scopes:       []string{"openid", "email", "profile"},
+		Issuer:       doc.Issuer,
+		identity:     oidcIdentity,
+	}, nil
+}
+
+type oidcClaims struct {
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	EmailVerified     any    `json:"email_verified"` // некоторые провайдеры отдают строку "true"
+	PreferredUsername string `json:"preferred_username"`
+	Name              string `json:"name"`
+	jwt.RegisteredClaims
+}
+
+func (c oidcClaims) emailVerified() bool {
+	switch v := c.EmailVerified.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// oidcIdentity читает id_token. Подпись не проверяем: токен получен напрямую от token endpoint
+// по TLS (OpenID Connect Core, 3.1.3.7), но iss, aud, срок и nonce сверяем.
+func oidcIdentity(ctx context.Context, p *OAuthProvider, token oauthToken, nonce string) (ExternalIdentity, error) {
+	if token.IDToken == "" {
+		return ExternalIdentity{}, errors.New("oidc: no id_token in response")
+	}
+	var claims oidcClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(token.IDToken, &claims); err != nil {
+		return ExternalIdentity{}, err
+	}
+
+	if strings.TrimSuffix(claims.Issuer, "/") != strings.TrimSuffix(p.Issuer, "/") {
+		return ExternalIdentity{}, fmt.Errorf("oidc: unexpected issuer %q", claims.Issuer)
+	}
+	if !slices.Contains(claims.Audience, p.ClientID) {
+		return ExternalIdentity{}, errors.New("oidc: id_token is issued for another client")
+	}
+	if claims.ExpiresAt == nil || claims.ExpiresAt.Before(time.Now()) {
+		return ExternalIdentity{}, errors.New("oidc: id_token expired")
+	}
+	if claims.Nonce != nonce {
+		return ExternalIdentity{}, errors.New("oidc: nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return ExternalIdentity{}, errors.New("oidc: empty sub")
+	}
+
+	// в id_token может не быть email — тогда спрашиваем userinfo
+	if claims.Email == "" && p.UserInfoURL != "" && token.AccessToken != "" {
+		var info oidcClaims
+		if err := oauthGetJSON(ctx, p.UserInfoURL, "Bearer "+token.AccessToken, &info); err != nil {
+			return ExternalIdentity{}, err
+		}
+		if info.Subject == claims.Subject {
+			claims.Email, claims.EmailVerified = info.Email, info.EmailVerified
+			if claims.PreferredUsername == "" {
+				claims.PreferredUsername = info.PreferredUsername
+			}
+			if claims.Name == "" {
+				claims.Name = info.Name
+			}
+		}
+	}
+
+	return ExternalIdentity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.Email != "" && claims.emailVerified(),
+		Login:         claims.PreferredUsername,
+		Name:          claims.Name,
+	}, nil
+}
+
+// oauthProvidersFromEnv собирает провайдеров, для которых заданы client id.
+// Колбэки приходят на API: {apiURL}/api/oauth/{provider}/callback.
+func oauthProvidersFromEnv(ctx context.Context, apiURL string) []*OAuthProvider {
+	var providers []*OAuthProvider
+
+	if id := getEnv("YANDEX_CLIENT_ID", ""); id != "" {
+		providers = append(providers, newYandexProvider(id, getEnv("YANDEX_CLIENT_SECRET", "")))
+	}
+	if id := getEnv("VK_CLIENT_ID", ""); id != "" {
+		providers = append(providers, newVKProvider(id, getEnv("VK_CLIENT_SECRET", "")))
+	}
+	if issuer := getEnv("OIDC_ISSUER", ""); issuer != "" {
+		p, err := discoverOIDC(ctx, issuer, getEnv("OIDC_CLIENT_ID", ""), getEnv("OIDC_CLIENT_SECRET", ""))
+		if err != nil {
+			// недоступный провайдер не должен мешать запуску магазина
+			log.Println("OIDC discovery error:", err)
+		} else {
+			p.Title = getEnv("OIDC_TITLE", p.Title)
+			if scopes := getEnv("OIDC_SCOPES", ""); scopes != "" {
+				p.Scopes = strings.Fields(scopes)
+			}
+			providers = append(providers, p)
+		}
+	}
+
+	for _, p := range providers {
+		p.RedirectURL = apiURL + "/api/oauth/" + p.Name + "/callback"
+	}
+	return providers
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	fakeOIDCClientID     = "stroystore"
+	fakeOIDCClientSecret = "client-secret"
+	fakeOIDCRedirectURL  = "http://api.test/api/oauth/oidc/callback"
+)
+
+// fakeOIDCUser — кого провайдер впустит при следующей авторизации
+type fakeOIDCUser struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Login         string
+}
+
+type fakeOIDCCode struct {
+	challenge   string
+	nonce       string
+	redirectURI string
+	user        fakeOIDCUser
+}
+
+// fakeOIDC — провайдер OpenID Connect на httptest: discovery, authorize с PKCE и token endpoint
+type fakeOIDC struct {
+	*httptest.Server
+	mu    sync.Mutex
+	codes map[string]fakeOIDCCode
+	user  fakeOIDCUser
+	nonce string // если задан, подменяет nonce в id_token
+}
+
+func newFakeOIDC(t *testing.T) *fakeOIDC {
+	t.Helper()
+	f := &fakeOIDC{codes: map[string]fakeOIDCCode{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", f.discovery)
+	mux.HandleFunc("/authorize", f.authorize)
+	mux.HandleFunc("/token", f.token)
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeOIDC) setUser(user fakeOIDCUser) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.user = user
+}
+
+func (f *fakeOIDC) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 f.URL,
+		"authorization_endpoint": f.URL + "/authorize",
+		"token_endpoint":         f.URL + "/token",
+	})
+}
+
+func (f *fakeOIDC) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("response_type") != "code" || q.Get("client_id") != fakeOIDCClientID ||
+		q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" || q.Get("nonce") == "" {
+		http.Error(w, "bad authorization request", http.StatusBadRequest)
+		return
+	}
+	code, err := randomHex(16)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	f.mu.Lock()
+	f.codes[code] = fakeOIDCCode{
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		redirectURI: q.Get("redirect_uri"),
+		user:        f.user,
+	}
+	f.mu.Unlock()
+
+	back := url.Values{"code": {code}, "state": {q.Get("state")}}
+	http.Redirect(w, r, q.Get("redirect_uri")+"?"+back.Encode(), http.StatusFound)
+}
+
+func (f *fakeOIDC) token(w http.ResponseWriter, r *http.Request) {
+	fail := func(code string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": code})
+	}
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		fail("invalid_request")
+		return
+	}
+	if r.PostForm.Get("client_id") != fakeOIDCClientID || r.PostForm.Get("client_secret") != fakeOIDCClientSecret {
+		fail("invalid_client")
+		return
+	}
+
+	// код одноразовый, даже если обмен не удался
+	f.mu.Lock()
+	code, ok := f.codes[r.PostForm.Get("code")]
+	delete(f.codes, r.PostForm.Get("code"))
+	nonce := f.nonce
+	f.mu.Unlock()
+	if !ok || code.redirectURI != r.PostForm.Get("redirect_uri") ||
+		pkceChallenge(r.PostForm.Get("code_verifier")) != code.challenge {
+		fail("invalid_grant")
+		return
+	}
+	if nonce == "" {
+		nonce = code.nonce
+	}
+
+	idToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, oidcClaims{
+		Nonce:             nonce,
+		Email:             code.user.Email,
+		EmailVerified:     code.user.EmailVerified,
+		PreferredUsername: code.user.Login,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    f.URL,
+			Subject:   code.user.Subject,
+			Audience:  jwt.ClaimStrings{fakeOIDCClientID},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}).SignedString([]byte(fakeOIDCClientSecret))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"access_token": "access-" + code.user.Subject, "id_token": idToken})
+}
+
+// useOIDC подключает к серверу фейкового провайдера через discovery, как при запуске
+func (ts *testServer) useOIDC(t *testing.T, f *fakeOIDC) {
+	t.Helper()
+	p, err := discoverOIDC(context.Background(), f.URL, fakeOIDCClientID, fakeOIDCClientSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.RedirectURL = fakeOIDCRedirectURL
+	ts.oauthProviders = []*OAuthProvider{p}
+}
+
+// oauthStart начинает вход (token пустой) или привязку и возвращает адрес страницы провайдера
+func (ts *testServer) oauthStart(t *testing.T, token string) string {
+	t.Helper()
+	path := "/api/oauth/oidc/start"
+	if token != "" {
+		path = "/api/oauth/oidc/link"
+	}
+	var resp struct {
+		URL string `json:"url"`
+	}
+	expect(t, ts.do(t, http.MethodPost, path, token, nil), http.StatusOK, &resp)
+	return resp.URL
+}
+
+// authorizeURL проходит страницу провайдера и возвращает параметры редиректа на колбэк
+func (f *fakeOIDC) authorizeURL(t *testing.T, authURL string) url.Values {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize status = %d", resp.StatusCode)
+	}
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.Scheme+"://"+loc.Host+loc.Path != fakeOIDCRedirectURL {
+		t.Fatalf("redirected to %s", loc)
+	}
+	return loc.Query()
+}
+
+// callback отдаёт серверу редирект провайдера и возвращает, куда сервер отправил браузер
+func (ts *testServer) callback(t *testing.T, params url.Values) *url.URL {
+	t.Helper()
+	w := ts.do(t, http.MethodGet, "/api/oauth/oidc/callback?"+params.Encode(), "", nil)
+	expect(t, w, http.StatusFound, nil)
+	loc, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+// oauthFlow проходит весь круг: старт, провайдер, колбэк
+func (ts *testServer) oauthFlow(t *testing.T, f *fakeOIDC, token string) *url.URL {
+	t.Helper()
+	return ts.callback(t, f.authorizeURL(t, ts.oauthStart(t, token)))
+}
+
+// oauthExchange меняет одноразовый код из редиректа на токены и возвращает id пользователя
+func (ts *testServer) oauthExchange(t *testing.T, loc *url.URL) int64 {
+	t.Helper()
+	if loc.Path != "/oauth/callback" {
+		t.Fatalf("redirected to %s, want /oauth/callback", loc)
+	}
+	var resp struct {
+		User struct {
+			ID int64 `json:"id"`
+		} `json:"user"`
+	}
+	w := ts.do(t, http.MethodPost, "/api/oauth/exchange", "", gin.H{"token": loc.Query().Get("token")})
+	expect(t, w, http.StatusOK, &resp)
+	return resp.User.ID
+}
+
+func expectOAuthError(t *testing.T, loc *url.URL, path, code string) {
+	t.Helper()
+	if loc.Path != path || loc.Query().Get("oauth_error") != code {
+		t.Fatalf("redirected to %s, want %s?oauth_error=%s", loc, path, code)
+	}
+}
+
+func TestOAuthLoginCreatesUser(t *testing.T) {
+	ts := newTestServer(t)
+	f := newFakeOIDC(t)
+	ts.useOIDC(t, f)
+	f.setUser(fakeOIDCUser{Subject: "sub-1", Email: "master@example.com", EmailVerified: true, Login: "master"})
+
+	id := ts.oauthExchange(t, ts.oauthFlow(t, f, ""))
+	user, err := ts.users.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "master" || user.Email != "master@example.com" || !user.EmailVerified {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+
+	// повторный вход находит того же пользователя по sub, а одноразовый код второй раз не работает
+	loc := ts.oauthFlow(t, f, "")
+	if again := ts.oauthExchange(t, loc); again != id {
+		t.Fatalf("second login as user %d, want %d", again, id)
+	}
+	w := ts.do(t, http.MethodPost, "/api/oauth/exchange", "", gin.H{"token": loc.Query().Get("token")})
+	expect(t, w, http.StatusBadRequest, nil)
+}
+
+func TestOAuthPKCE(t *testing.T) {
+	ts := newTestServer(t)
+	f := newFakeOIDC(t)
+	ts.useOIDC(t, f)
+	f.setUser(fakeOIDCUser{Subject: "sub-1", Email: "master@example.com", EmailVerified: true})
+
+	// перехваченный код, предъявленный с чужим state, не обменять: у того state другой верификатор
+	stolen := f.authorizeURL(t, ts.oauthStart(t, ""))
+	own := f.authorizeURL(t, ts.oauthStart(t, ""))
+	stolen.Set("state", own.Get("state"))
+	expectOAuthError(t, ts.callback(t, stolen), "/login", oauthErrProvider)
+}
+
+func TestOAuthState(t *testing.T) {
+	ts := newTestServer(t)
+	f := newFakeOIDC(t)
+	ts.useOIDC(t, f)
+	f.setUser(fakeOIDCUser{Subject: "sub-1", Email: "master@example.com", EmailVerified: true})
+
+	params := f.authorizeURL(t, ts.oauthStart(t, ""))
+	forged := url.Values{"code": {params.Get("code")}, "state": {"forged"}}
+	expectOAuthError(t, ts.callback(t, forged), "/login", oauthErrExpired)
+
+	ts.oauthExchange(t, ts.callback(t, params))
+	// state одноразовый
+	expectOAuthError(t, ts.callback(t, params), "/login", oauthErrExpired)
+
+	denied := f.authorizeURL(t, ts.oauthStart(t, ""))
+	denied.Del("code")
+	denied.Set("error", "access_denied")
+	expectOAuthError(t, ts.callback(t, denied), "/login", oauthErrDenied)
+}
+
+func TestOAuthNonce(t *testing.T) {
+	ts := newTestServer(t)
+	f := newFakeOIDC(t)
+	ts.useOIDC(t, f)
+	f.setUser(fakeOIDCUser{Subject: "sub-1", Email: "master@example.com", EmailVerified: true})
+	f.nonce = "replayed"
+
+	expectOAuthError(t, ts.oauthFlow(t, f, ""), "/login", oauthErrProvider)
+}
+
+func TestOAuthLinksByVerifiedEmail(t *testing.T) {
+	ts := newTestServer(t)
+	f := newFakeOIDC(t)
+	ts.useOIDC(t, f)
+	ctx := context.Background()
+
+	id, _ := ts.register(t, "prorab")
+	// пока наш адрес не подтверждён, чужой провайдер не может войти в аккаунт по email
+	f.setUser(fakeOIDCUser{Subject: "sub-1", Email: "prorab@example.com", EmailVerified: true})
+	expectOAuthError(t, ts.oauthFlow(t, f, ""), "/login", oauthErrEmailTaken)
+
+	if err := ts.users.MarkEmailVerified(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	// и наоборот: адрес не подтверждён у провайдера
+	f.setUser(fakeOIDCUser{Subject: "sub-1", Email: "prorab@example.com"})
+	expectOAuthError(t, ts.oauthFlow(t, f, ""), "/login", oauthErrEmailTaken)
+
+	f.setUser(fakeOIDCUser{Subject: "sub-1", Email: "prorab@example.com", EmailVerified: true})
+	if got := ts.oauthExchange(t, ts.oauthFlow(t, f, "")); got != id {
+		t.Fatalf("logged in as user %d, want %d", got, id)
+	}
+	identities, err := ts.identities.ListForUser(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(identities) != 1 || identities[0].Subject != "sub-1" {
+		t.Fatalf("identities = %+v", identities)
+	}
+
+	// другой аккаунт того же провайдера с тем же адресом не подменяет привязанный
+	f.setUser(fakeOIDCUser{Subject: "sub-2", Email: "prorab@example.com", EmailVerified: true})
+	expectOAuthError(t, ts.oauthFlow(t, f, ""), "/login", oauthErrLinked)
+}
+
+func TestOAuthLinkToAccount(t *testing.T) {
+	ts := newTestServer(t)
+	f := newFakeOIDC(t)
+	ts.useOIDC(t, f)
+
+	id, token := ts.register(t, "prorab")
+	_, otherToken := ts.register(t, "master")
+	f.setUser(fakeOIDCUser{Subject: "sub-1", Email: "other@example.com"})
+
+	loc := ts.oauthFlow(t, f, token)
+	if loc.Path != "/profile" || loc.Query().Get("linked") != ProviderOIDC {
+		t.Fatalf("redirected to %s", loc)
+	}
+	// вход через провайдера теперь ведёт в привязанный аккаунт, хотя email другой
+	if got := ts.oauthExchange(t, ts.oauthFlow(t, f, "")); got != id {
+		t.Fatalf("logged in as user %d, want %d", got, id)
+	}
+
+	// уже привязанный внешний аккаунт к другому пользователю не привязать
+	expectOAuthError(t, ts.oauthFlow(t, f, otherToken), "/profile", oauthErrLinked)
+	// и второй раз тот же провайдер не привязать
+	expect(t, ts.do(t, http.MethodPost, "/api/oauth/oidc/link", token, nil), http.StatusConflict, nil)
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	OrderStatusNew       = "new"
+	OrderStatusConfirmed = "confirmed"
+	OrderStatusAssembled = "assembled"
+	OrderStatusShipped   = "shipped"
+	OrderStatusDelivered = "delivered"
+	OrderStatusCancelled = "cancelled"
+)
+
+// orderTransitions описывает допустимые переходы между статусами заказа
+var orderTransitions = map[string][]string{
+	OrderStatusNew:       {OrderStatusConfirmed, OrderStatusCancelled},
+	OrderStatusConfirmed: {OrderStatusAssembled, OrderStatusCancelled},
+	OrderStatusAssembled: {OrderStatusShipped, OrderStatusCancelled},
+	OrderStatusShipped:   {OrderStatusDelivered},
+	OrderStatusDelivered: {},
+	OrderStatusCancelled: {},
+}
+
+var (
+	errBasketEmpty       = errors.New("basket is empty")
+	errInvalidTransition = errors.New("invalid order status transition")
+)
+
+type OrderItem struct {
+	ID          int64   `json:"id"`
+	ProductID   *int64  `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Price       float64 `json:"price"`
+	Quantity    int     `json:"quantity"`
+	Subtotal    float64 `json:"subtotal"`
+}
+
+type Order struct {
+	ID        int64       `json:"id"`
+	UserID    *int64      `json:"user_id"` // nil, если покупатель удалил аккаунт
+	Status    string      `json:"status"`
+	Total     float64     `json:"total"`
+	Comment   string      `json:"comment"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+	Items     []OrderItem `json:"items"`
+}
+
+func canTransitionOrder(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) createOrderHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	var req struct {
+		Comment string `json:"comment"`
+	}
+
+	// тело запроса необязательно
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+			return
+		}
+	}
+
+	order, err := s.orders.Place(c.Request.Context(), claims.ID, req.Comment)
+	var stockErr *OutOfStockError
+	if errors.Is(err, errBasketEmpty) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Корзина пуста"})
+		return
+	} else if errors.As(err, &stockErr) {
+		c.JSON(http.StatusConflict, gin.H{
+			"message":    outOfStockMessage(stockErr),
+			"product_id": stockErr.ProductID,
+			"available":  stockErr.Available,
+		})
+		return
+	} else if err != nil {
+		log.Println("Create order error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	log.Printf("Новый заказ #%d от пользователя %s", order.ID, claims.Username)
+
+	c.JSON(http.StatusCreated, order)
+}
+
+func (s *Server) getOrdersHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	orders, err := s.orders.List(c.Request.Context(), OrderFilter{UserID: &claims.ID})
+	if err != nil {
+		log.Println("Get orders error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+func (s *Server) getOrderHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	order, err := s.orders.Get(c.Request.Context(), id)
+	if err == nil && (order.UserID == nil || *order.UserID != claims.ID) {
+		// чужой заказ видят только операторы; остальным он «не существует»
+		var allowed bool
+		allowed, err = s.hasPermission(c.Request.Context(), claims, PermOrdersManage)
+		if err == nil && !allowed {
+			err = ErrNotFound
+		}
+	}
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Заказ не найден"})
+		return
+	} else if err != nil {
+		log.Println("Get order error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func (s *Server) cancelOrderHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	s.respondOrderTransition(c, id, claims.ID, OrderStatusCancelled)
+}
+
+func (s *Server) getAdminOrdersHandler(c *gin.Context) {
+	filter := OrderFilter{Status: c.Query("status")}
+	if _, ok := orderTransitions[filter.Status]; filter.Status != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неизвестный статус"})
+		return
+	}
+
+	orders, err := s.orders.List(c.Request.Context(), filter)
+	if err != nil {
+		log.Println("Get admin orders error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+func (s *Server) updateOrderStatusHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	var req struct {
+		Status string `json:"status"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+
+	if _, ok := orderTransitions[req.Status]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неизвестный статус"})
+		return
+	}
+
+	s.respondOrderTransition(c, id, 0, req.Status)
+}
+
+func (s *Server) respondOrderTransition(c *gin.Context, orderID, userID int64, to string) {
+	order, err := s.orders.Transition(c.Request.Context(), orderID, userID, to)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Заказ не найден"})
+		return
+	} else if errors.Is(err, errInvalidTransition) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Недопустимая смена статуса заказа"})
+		return
+	} else if err != nil {
+		log.Println("Update order status error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+	// maxPageOffset ограничивает глубину постраничной выдачи без курсора:
+	// дальше листать курсором, а огромный page= не переполнит OFFSET
+	maxPageOffset = 10000
+)
+
+var (
+	errInvalidCursor = errors.New("invalid cursor")
+	errInvalidSort   = errors.New("invalid sort")
+	errInvalidPage   = errors.New("invalid page parameters")
+)
+
+// PageRequest описывает запрошенную страницу. Limit == 0 означает «все записи»
+// (старый формат ответа без обёртки).
+type PageRequest struct {
+	Sort   string
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+// Page — обёртка ответа для постраничной выдачи
+type Page[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"next_cursor"`
+	Total      int     `json:"total"`
+}
+
+type sortValueKind int
+
+const (
+	sortNumber sortValueKind = iota
+	sortString
+	sortTime
+)
+
+// sortSpec — один вариант сортировки: колонка, направление и тип значения
+// для курсора. При равных значениях порядок добивается по id в ту же сторону.
+type sortSpec struct {
+	Column string
+	Desc   bool
+	Kind   sortValueKind
+}
+
+type pageCursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v"`
+	ID    int64           `json:"id"`
+}
+
+// sortKey — значение сортируемого поля вместе с id записи
+type sortKey struct {
+	Value interface{}
+	ID    int64
+}
+
+func encodeCursor(sortName string, key sortKey) (string, error) {
+	value := key.Value
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC().Format(time.RFC3339Nano)
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(pageCursor{Sort: sortName, Value: raw, ID: key.ID})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(s, sortName string, spec sortSpec) (sortKey, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return sortKey{}, errInvalidCursor
+	}
+
+	var cur pageCursor
+	if err := json.Unmarshal(data, &cur); err != nil || cur.Sort != sortName {
+		return sortKey{}, errInvalidCursor
+	}
+
+	key := sortKey{ID: cur.ID}
+	switch spec.Kind {
+	case sortNumber:
+		var v float64
+		if err := json.Unmarshal(cur.Value, &v); err != nil {
+			return sortKey{}, errInvalidCursor
+		}
+		key.Value = v
+	case sortString:
+		var v string
+		if err := json.Unmarshal(cur.Value, &v); err != nil {
+			return sortKey{}, errInvalidCursor
+		}
+		key.Value = v
+	case sortTime:
+		var v string
+		if err := json.Unmarshal(cur.Value, &v); err != nil {
+			return sortKey{}, errInvalidCursor
+		}
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return sortKey{}, errInvalidCursor
+		}
+		key.Value = t.Local()
+	}
+	return key, nil
+}
+
+// orderBySQL строит ORDER BY для сортировки с добивкой по id
+func orderBySQL(spec sortSpec, idColumn string) string {
+	dir := "ASC"
+	if spec.Desc {
+		dir = "DESC"
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, %s %s", spec.Column, dir, idColumn, dir)
+}
+
+// keysetSQL строит условие «строго после курсора» для keyset-пагинации
+func keysetSQL(spec sortSpec, idColumn string, key sortKey) (string, []interface{}) {
+	op := ">"
+	if spec.Desc {
+		op = "<"
+	}
+	clause := fmt.Sprintf(" AND (%[1]s %[2]s ? OR (%[1]s = ? AND %[3]s %[2]s ?))", spec.Column, op, idColumn)
+	return clause, []interface{}{key.Value, key.Value, key.ID}
+}
+
+// resolvePage проверяет сортировку и курсор; возвращает спецификацию сортировки
+// и ключ курсора (nil, если курсора нет)
+func resolvePage(page PageRequest, sorts map[string]sortSpec, defaultSort string) (string, sortSpec, *sortKey, error) {
+	name := page.Sort
+	if name == "" {
+		name = defaultSort
+	}
+	spec, ok := sorts[name]
+	if !ok {
+		return "", sortSpec{}, nil, errInvalidSort
+	}
+	if page.Cursor == "" {
+		return name, spec, nil, nil
+	}
+	key, err := decodeCursor(page.Cursor, name, spec)
+	if err != nil {
+		return "", sortSpec{}, nil, err
+	}
+	return name, spec, &key, nil
+}
+
+// nextCursorFor возвращает курсор на последний элемент, если после него есть ещё записи
+func nextCursorFor[T any](items []T, hasMore bool, sortName string, keyOf func(T) sortKey) (*string, error) {
+	if !hasMore || len(items) == 0 {
+		return nil, nil
+	}
+	cur, err := encodeCursor(sortName, keyOf(items[len(items)-1]))
+	if err != nil {
+		return nil, err
+	}
+	return &cur, nil
+}
+
+func compareSortValues(a, b interface{}) int {
+	switch av := a.(type) {
+	case float64:
+		bv := b.(float64)
+		switch {
+		case av < bv:
+			return -1
+		case av > bv:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(strings.ToLower(av), strings.ToLower(b.(string)))
+	case time.Time:
+		return av.Compare(b.(time.Time))
+	}
+	return 0
+}
+
+func compareSortKeys(a, b sortKey, desc bool) int {
+	cmp := compareSortValues(a.Value, b.Value)
+	if cmp == 0 {
+		switch {
+		case a.ID < b.ID:
+			cmp = -1
+		case a.ID > b.ID:
+			cmp = 1
+		}
+	}
+	if desc {
+		cmp = -cmp
+	}
+	return cmp
+}
+
+// paginateSlice — in-memory аналог ORDER BY + keyset/OFFSET + LIMIT
+func paginateSlice[T any](items []T, page PageRequest, sorts map[string]sortSpec, defaultSort string, keyFor func(T, string) sortKey) (Page[T], error) {
+	name, spec, after, err := resolvePage(page, sorts, defaultSort)
+	if err != nil {
+		return Page[T]{}, err
+	}
+
+	keyOf := func(item T) sortKey { return keyFor(item, name) }
+	sort.SliceStable(items, func(i, j int) bool {
+		return compareSortKeys(keyOf(items[i]), keyOf(items[j]), spec.Desc) < 0
+	})
+
+	result := Page[T]{Items: []T{}, Total: len(items)}
+
+	if after != nil {
+		i := 0
+		for i < len(items) && compareSortKeys(keyOf(items[i]), *after, spec.Desc) <= 0 {
+			i++
+		}
+		items = items[i:]
+	} else if page.Offset > 0 {
+		items = items[min(page.Offset, len(items)):]
+	}
+
+	hasMore := false
+	if page.Limit > 0 && len(items) > page.Limit {
+		items = items[:page.Limit]
+		hasMore = true
+	}
+	result.Items = append(result.Items, items...)
+
+	result.NextCursor, err = nextCursorFor(result.Items, hasMore, name, keyOf)
+	return result, err
+}
+
+// parsePageRequest читает limit, cursor, page и sort из запроса. paginated == false,
+// если клиент не просил постраничную выдачу — тогда ответ остаётся простым массивом.
+func parsePageRequest(c *gin.Context, sorts map[string]sortSpec) (page PageRequest, paginated bool, err error) {
+	page.Sort = c.Query("sort")
+	if page.Sort != "" {
+		if _, ok := sorts[page.Sort]; !ok {
+			return page, false, errInvalidSort
+		}
+	}
+
+	limitStr, hasLimit := c.GetQuery("limit")
+	pageStr, hasPage := c.GetQuery("page")
+	page.Cursor = c.Query("cursor")
+
+	paginated = hasLimit || hasPage || page.Cursor != ""
+	if !paginated {
+		return page, false, nil
+	}
+
+	if hasPage && page.Cursor != "" {
+		return page, true, errInvalidPage
+	}
+
+	page.Limit = defaultPageLimit
+	if hasLimit {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n < 1 {
+			return page, true, errInvalidPage
+		}
+		page.Limit = min(n, maxPageLimit)
+	}
+
+	if hasPage {
+		n, err := strconv.Atoi(pageStr)
+		if err != nil || n < 1 || n-1 > maxPageOffset/page.Limit {
+			return page, true, errInvalidPage
+		}
+		page.Offset = (n - 1) * page.Limit
+	}
+
+	return page, true, nil
+}
+
+func pageErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, errInvalidSort):
+		return "Неизвестная сортировка"
+	case errors.Is(err, errInvalidCursor):
+		return "Неверный курсор"
+	default:
+		return "Неверные параметры страницы"
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestProductPages(t *testing.T) {
+	ts := newTestServer(t)
+	for i := 0; i < 5; i++ {
+		ts.product(t, "Товар "+strconv.Itoa(i), float64(100+i), 1)
+	}
+
+	var page Page[Product]
+	expect(t, ts.do(t, http.MethodGet, "/api/products?limit=2&page=3&sort=price_asc", "", nil), http.StatusOK, &page)
+	if len(page.Items) != 1 || page.Items[0].Price != 104 {
+		t.Errorf("page 3 = %+v", page.Items)
+	}
+}
+
+func TestPageOutOfRange(t *testing.T) {
+	ts := newTestServer(t)
+
+	for _, query := range []string{
+		"page=0",
+		"limit=0",
+		"page=1&cursor=abc",
+		"limit=100&page=102",
+		// (n-1)*limit переполняет int и давал отрицательный OFFSET
+		"limit=100&page=" + strconv.Itoa(1<<62),
+		"page=99999999999999999999",
+	} {
+		expect(t, ts.do(t, http.MethodGet, "/api/products?"+query, "", nil), http.StatusBadRequest, nil)
+	}
+	expect(t, ts.do(t, http.MethodGet, "/api/products?limit=100&page=101", "", nil), http.StatusOK, nil)
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+var commonPasswords = sync.OnceValue(func() map[string]bool {
+	set := map[string]bool{}
+	scanner := bufio.NewScanner(strings.NewReader(commonPasswordsFile))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			set[strings.ToLower(line)] = true
+		}
+	}
+	return set
+})
+
+// PasswordPolicy — требования к новому паролю при регистрации, сбросе и смене
+type PasswordPolicy struct {
+	MinLength   int
+	CheckCommon bool
+}
+
+func defaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{MinLength: 8, CheckCommon: true}
+}
+
+// passwordMaxLength ограничивает стоимость хеширования; у bcrypt к тому же учитываются только первые 72 байта
+const passwordMaxLength = 72
+
+// weakPasswordError несёт сообщение для пользователя
+type weakPasswordError struct {
+	message string
+}
+
+func (e weakPasswordError) Error() string { return e.message }
+
+func (p PasswordPolicy) Validate(password, username string) error {
+	if n := utf8.RuneCountInString(password); n < p.MinLength {
+		return weakPasswordError{fmt.Sprintf("Пароль должен содержать не менее %d символов", p.MinLength)}
+	}
+	if len(password) > passwordMaxLength {
+		return weakPasswordError{fmt.Sprintf("Пароль должен быть не длиннее %d байт", passwordMaxLength)}
+	}
+	if strings.EqualFold(password, username) {
+		return weakPasswordError{"Пароль не должен совпадать с логином"}
+	}
+	if p.CheckCommon && commonPasswords()[strings.ToLower(password)] {
+		return weakPasswordError{"Этот пароль слишком распространён, придумайте другой"}
+	}
+	return nil
+}
+
+// passwordErrorMessage возвращает текст для ответа 400, если err — нарушение политики
+func passwordErrorMessage(err error) (string, bool) {
+	var weak weakPasswordError
+	if errors.As(err, &weak) {
+		return weak.message, true
+	}
+	return "", false
+}
+
+const (
+	HashArgon2id = "argon2id"
+	HashBcrypt   = "bcrypt"
+)
+
+// Argon2Params — параметры argon2id; по умолчанию минимальный набор, рекомендованный OWASP
+type Argon2Params struct {
+	Memory  uint32 // КиБ
+	Time    uint32
+	Threads uint8
+	KeyLen  uint32
+	SaltLen uint32
+}
+
+var defaultArgon2Params = Argon2Params{Memory: 19 * 1024, Time: 2, Threads: 1, KeyLen: 32, SaltLen: 16}
+
+// PasswordHasher хеширует пароли выбранным алгоритмом и проверяет хеши любого
+// поддерживаемого алгоритма. Хеши со старым алгоритмом или параметрами
+// помечаются для перехеширования при следующем входе.
+type PasswordHasher struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2     Argon2Params
+
+	dummyOnce sync.Once
+	dummy     string
+}
+
+func defaultPasswordHasher() *PasswordHasher {
+	return &PasswordHasher{Algorithm: HashArgon2id, BcryptCost: 12, Argon2: defaultArgon2Params}
+}
+
+func NewPasswordHasher(algorithm string, bcryptCost int) (*PasswordHasher, error) {
+	if algorithm != HashArgon2id && algorithm != HashBcrypt {
+		return nil, fmt.Errorf("unknown password hash algorithm %q", algorithm)
+	}
+	if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost %d out of range", bcryptCost)
+	}
+	return &PasswordHasher{Algorithm: algorithm, BcryptCost: bcryptCost, Argon2: defaultArgon2Params}, nil
+}
+
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	if h.Algorithm == HashBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+		return string(hash), err
+	}
+
+	p := h.Argon2
+	salt := make([]byte, p.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify сообщает, подходит ли пароль, и нужно ли перехешировать его текущими настройками
+func (h *PasswordHasher) Verify(hash, password string) (ok, rehash bool, err error) {
+	// у аккаунтов, созданных через внешнего провайдера, пароля нет
+	if hash == "" {
+		return false, false, nil
+	}
+	if strings.HasPrefix(hash, "$argon2id$") {
+		p, salt, key, err := parseArgon2Hash(hash)
+		if err != nil {
+			return false, false, err
+		}
+		got := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(got, key) != 1 {
+			return false, false, nil
+		}
+		p.SaltLen = uint32(len(salt))
+		return true, h.Algorithm != HashArgon2id || p != h.Argon2, nil
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, false, nil
+	} else if err != nil {
+		return false, false, err
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false, false, err
+	}
+	return true, h.Algorithm != HashBcrypt || cost != h.BcryptCost, nil
+}
+
+// DummyHash — хеш текущими настройками для сравнения, когда пользователя нет:
+// так ответ для несуществующего логина занимает столько же времени
+func (h *PasswordHasher) DummyHash() string {
+	h.dummyOnce.Do(func() {
+		hash, err := h.Hash("dummy password")
+		if err != nil {
+			panic(err)
+		}
+		h.dummy = hash
+	})
+	return h.dummy
+}
+
+// parseArgon2Hash разбирает строку вида $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+func parseArgon2Hash(hash string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	version, err := strconv.Atoi(strings.TrimPrefix(parts[2], "v="))
+	if err != nil || version != argon2.Version {
+		return p, nil, nil, errors.New("unsupported argon2 version")
+	}
+
+	var threads uint32
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &threads); err != nil {
+		return p, nil, nil, errors.New("invalid argon2id parameters")
+	}
+	p.Threads = uint8(threads)
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, err
+	}
+	p.KeyLen = uint32(len(key))
+	return p, salt, key, nil
+}
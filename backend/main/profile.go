@@ -0,0 +1,335 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Address struct {
+	ID        int64  `json:"id"`
+	Label     string `json:"label"` // «Дом», «Объект на Лесной» и т.п.
+	Address   string `json:"address"`
+	IsDefault bool   `json:"is_default"`
+}
+
+// Profile — пользователь вместе с контактами и адресами доставки; отдаётся только владельцу
+type Profile struct {
+	User
+	FullName  string    `json:"full_name"`
+	Phone     string    `json:"phone"`
+	Addresses []Address `json:"addresses"`
+}
+
+const maxAddresses = 10
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9 ()-]{5,20}$`)
+
+type profileRequest struct {
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	FullName  string `json:"full_name"`
+	Phone     string `json:"phone"`
+	Addresses []struct {
+		Label     string `json:"label"`
+		Address   string `json:"address"`
+		IsDefault bool   `json:"is_default"`
+	} `json:"addresses"`
+}
+
+// input проверяет запрос и возвращает текст ошибки для ответа 400
+func (req profileRequest) input() (ProfileInput, string) {
+	in := ProfileInput{
+		Username: strings.TrimSpace(req.Username),
+		Email:    strings.TrimSpace(req.Email),
+		FullName: strings.TrimSpace(req.FullName),
+		Phone:    strings.TrimSpace(req.Phone),
+	}
+
+	if n := utf8.RuneCountInString(in.Username); n < 3 || n > 50 {
+		return in, "Логин должен содержать от 3 до 50 символов"
+	}
+	if !validEmail(in.Email) || len(in.Email) > 100 {
+		return in, "Неверный формат email"
+	}
+	if utf8.RuneCountInString(in.FullName) > 150 {
+		return in, "Слишком длинное имя"
+	}
+	if in.Phone != "" && !phonePattern.MatchString(in.Phone) {
+		return in, "Неверный формат телефона"
+	}
+
+	if len(req.Addresses) > maxAddresses {
+		return in, "Можно сохранить не больше 10 адресов"
+	}
+	hasDefault := false
+	for _, a := range req.Addresses {
+		addr := AddressInput{Label: strings.TrimSpace(a.Label), Address: strings.TrimSpace(a.Address)}
+		if addr.Address == "" || utf8.RuneCountInString(addr.Address) > 255 || utf8.RuneCountInString(addr.Label) > 50 {
+			return in, "Неверный адрес доставки"
+		}
+		// основным может быть только один адрес
+		addr.IsDefault = a.IsDefault && !hasDefault
+		hasDefault = hasDefault || addr.IsDefault
+		in.Addresses = append(in.Addresses, addr)
+	}
+	if !hasDefault && len(in.Addresses) > 0 {
+		in.Addresses[0].IsDefault = true
+	}
+	return in, ""
+}
+
+func (s *Server) getMeHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	profile, err := s.users.GetProfile(c.Request.Context(), claims.ID)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Пользователь не найден"})
+		return
+	} else if err != nil {
+		log.Println("Get profile error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// updateMeHandler заменяет профиль целиком. Новый email нужно подтвердить заново,
+// новый логин попадёт в access-токен при следующем обновлении.
+func (s *Server) updateMeHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	var req profileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+	in, msg := req.input()
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": msg})
+		return
+	}
+
+	ctx := c.Request.Context()
+	profile, err := s.users.UpdateProfile(ctx, claims.ID, in)
+	if errors.Is(err, ErrUserExists) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Логин или email уже заняты"})
+		return
+	} else if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Пользователь не найден"})
+		return
+	} else if err != nil {
+		log.Println("Update profile error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	if !profile.EmailVerified {
+		if err := s.sendVerificationEmail(ctx, profile.User); err != nil {
+			log.Println("Update profile error:", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// checkCurrentPassword проверяет пароль перед сменой пароля или удалением аккаунта и сам
+// отвечает клиенту при неудаче. Ошибки считаются вместе с неудачными входами, чтобы
+// по украденному access-токену нельзя было подобрать пароль.
+func (s *Server) checkCurrentPassword(c *gin.Context, user User, password string) bool {
+	ctx := c.Request.Context()
+	ip := c.ClientIP()
+
+	if s.loginLocked(c, user.Username) {
+		return false
+	}
+
+	hashed, err := s.users.PasswordHash(ctx, user.ID)
+	if err != nil {
+		log.Println("Check password error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return false
+	}
+	if hashed == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Пароль не задан: задайте его через восстановление пароля"})
+		return false
+	}
+
+	ok, _, err := s.passwords.Verify(hashed, password)
+	if err != nil {
+		log.Println("Check password error:", err)
+	}
+	if !ok {
+		wait, err := s.recordLoginFailure(ctx, user.Username, ip, &user.ID)
+		if err != nil {
+			log.Println("Check password error:", err)
+		}
+		if wait > 0 {
+			tooManyLoginAttempts(c, wait)
+			return false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный текущий пароль"})
+		return false
+	}
+
+	if err := s.logins.Reset(ctx, ThrottleScopeAccount, loginSubjects(user.Username, ip)[ThrottleScopeAccount]); err != nil {
+		log.Println("Check password error:", err)
+	}
+	return true
+}
+
+// changePasswordHandler меняет пароль и завершает все сессии, кроме новой, выданной в ответе
+func (s *Server) changePasswordHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	var req struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.CurrentPassword == "" || req.NewPassword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := s.users.GetByID(ctx, claims.ID)
+	if err != nil {
+		log.Println("Change password error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	if !s.checkCurrentPassword(c, user, req.CurrentPassword) {
+		return
+	}
+	if err := s.policy.Validate(req.NewPassword, user.Username); err != nil {
+		msg, _ := passwordErrorMessage(err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": msg})
+		return
+	}
+
+	hashed, err := s.passwords.Hash(req.NewPassword)
+	if err != nil {
+		log.Println("Change password error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if err := s.users.SetPassword(ctx, user.ID, hashed); err != nil {
+		log.Println("Change password error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if _, err := s.sessions.RevokeAllForUser(ctx, user.ID); err != nil {
+		log.Println("Change password error:", err)
+	}
+
+	tokens, err := s.startSession(c, user)
+	if err != nil {
+		log.Println("Change password error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	resp, err := s.authResponse(ctx, tokens, user)
+	if err != nil {
+		log.Println("Change password error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	log.Printf("Пользователь %s сменил пароль", user.Username)
+	c.JSON(http.StatusOK, resp)
+}
+
+// deleteMeHandler удаляет аккаунт после подтверждения паролем. Вакансии и заказы
+// остаются (заказы нужны для учёта), но больше не связаны с пользователем.
+func (s *Server) deleteMeHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Введите пароль для подтверждения"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := s.users.GetByID(ctx, claims.ID)
+	if err != nil {
+		log.Println("Delete account error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	// последний, кто управляет пользователями, не может уйти: назначать роли станет некому
+	perms, err := s.roles.UserPermissions(ctx, user.ID)
+	if err != nil {
+		log.Println("Delete account error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if slices.Contains(perms, PermUsersManage) {
+		managers, err := s.roles.CountUsersWithPermission(ctx, PermUsersManage)
+		if err != nil {
+			log.Println("Delete account error:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+			return
+		}
+		if managers <= 1 {
+			c.JSON(http.StatusConflict, gin.H{"message": "Вы последний администратор: сначала назначьте другого"})
+			return
+		}
+	}
+
+	company, owned, err := s.soleOwnedCompany(ctx, user.ID)
+	if err != nil {
+		log.Println("Delete account error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if owned {
+		c.JSON(http.StatusConflict, gin.H{"message": fmt.Sprintf("Сначала назначьте владельцем другого участника компании «%s»", company.Name)})
+		return
+	}
+
+	if !s.checkCurrentPassword(c, user, req.Password) {
+		return
+	}
+
+	if _, err := s.sessions.RevokeAllForUser(ctx, user.ID); err != nil {
+		log.Println("Delete account error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if err := s.users.Delete(ctx, user.ID); err != nil {
+		log.Println("Delete account error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	log.Printf("Пользователь %s (id %d) удалил аккаунт", user.Username, user.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Аккаунт удалён"})
+}
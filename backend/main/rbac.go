@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Права, которые проверяют обработчики. Набор прав каждой роли хранится в role_permissions.
+const (
+	PermProductsWrite   = "products.write"
+	PermCategoriesWrite = "categories.write"
+	PermJobsModerate    = "jobs.moderate"
+	PermOrdersManage    = "orders.manage"
+	PermUsersManage     = "users.manage"
+	PermCompaniesVerify = "companies.verify"
+	PermResumesModerate = "resumes.moderate"
+	PermResumesSearch   = "resumes.search"
+)
+
+// Роли, которые сервер назначает сам: новым пользователям и участникам проверенных компаний (см. syncEmployerRole)
+const (
+	RoleUser     = "user"
+	RoleEmployer = "employer"
+)
+
+type Role struct {
+	Name        string   `json:"name"`
+	Title       string   `json:"title"`
+	Permissions []string `json:"permissions"`
+}
+
+// hasPermission проверяет права по роли пользователя в БД, а не по роли из токена:
+// смена или отзыв роли действует сразу, не дожидаясь выпуска нового токена.
+// Административные права без включённой 2FA не действуют (см. checkPermission).
+func (s *Server) hasPermission(ctx context.Context, claims *Claims, perm string) (bool, error) {
+	ok, _, err := s.checkPermission(ctx, claims.ID, perm)
+	return ok, err
+}
+
+// checkPermission — проверка права с причиной отказа. needTwoFactor — право у роли есть,
+// но оно административное, а 2FA у пользователя не включена: роль выдали уже после входа,
+// и токен, полученный без второго фактора, административных прав не даёт.
+func (s *Server) checkPermission(ctx context.Context, userID int64, perm string) (ok, needTwoFactor bool, err error) {
+	perms, err := s.roles.UserPermissions(ctx, userID)
+	if err != nil || !slices.Contains(perms, perm) {
+		return false, false, err
+	}
+	if slices.Contains(nonAdminPermissions, perm) {
+		return true, false, nil
+	}
+	enabled, err := s.twoFactorEnabled(ctx, userID)
+	if err != nil {
+		return false, false, err
+	}
+	return enabled, !enabled, nil
+}
+
+// requirePermission пропускает запрос дальше, только если у пользователя есть право perm.
+// Ставится после authMiddleware.
+func (s *Server) requirePermission(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := getUserClaims(c)
+		if claims == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+			c.Abort()
+			return
+		}
+
+		ok, needTwoFactor, err := s.checkPermission(c.Request.Context(), claims.ID, perm)
+		if err != nil {
+			log.Println("Permission check error:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+			c.Abort()
+			return
+		}
+		if needTwoFactor {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Нужна двухфакторная аутентификация, войдите заново"})
+			c.Abort()
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusForbidden, gin.H{"message": "Недостаточно прав"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func (s *Server) getRolesHandler(c *gin.Context) {
+	roles, err := s.roles.List(c.Request.Context())
+	if err != nil {
+		log.Println("Get roles error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+func (s *Server) setUserRoleHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Role == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+
+	// иначе последний администратор может случайно лишить себя доступа
+	if id == claims.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Нельзя изменить собственную роль"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := s.roles.Get(ctx, req.Role); errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неизвестная роль"})
+		return
+	} else if err != nil {
+		log.Println("Set user role error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	user, err := s.users.SetRole(ctx, id, req.Role)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Пользователь не найден"})
+		return
+	} else if err != nil {
+		log.Println("Set user role error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	// с административной ролью без 2FA прежние сессии завершаются: войти заново
+	// можно только со вторым фактором
+	required, err := s.twoFactorRequired(ctx, user.ID)
+	enabled := false
+	if err == nil && required {
+		enabled, err = s.twoFactorEnabled(ctx, user.ID)
+	}
+	if err == nil && required && !enabled {
+		_, err = s.sessions.RevokeAllForUser(ctx, user.ID)
+	}
+	if err != nil {
+		log.Println("Set user role error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	log.Printf("%s назначил пользователю %s роль %s", claims.Username, user.Username, user.Role)
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// права берутся из роли: без токена — 401, без права — 403, с правом — доступ
+func TestRequirePermission(t *testing.T) {
+	ts := newTestServer(t)
+	_, user := ts.register(t, "prorab")
+	operatorID, operator := ts.register(t, "operator")
+	ts.grantRole(t, operatorID, "order_operator")
+	managerID, manager := ts.register(t, "katalog")
+	ts.grantRole(t, managerID, "catalog_manager")
+
+	expect(t, ts.do(t, http.MethodGet, "/api/admin/orders", "", nil), http.StatusUnauthorized, nil)
+	expect(t, ts.do(t, http.MethodGet, "/api/admin/orders", user, nil), http.StatusForbidden, nil)
+	expect(t, ts.do(t, http.MethodGet, "/api/admin/orders", operator, nil), http.StatusOK, nil)
+	expect(t, ts.do(t, http.MethodGet, "/api/admin/orders", manager, nil), http.StatusForbidden, nil)
+
+	expect(t, ts.do(t, http.MethodPost, "/api/admin/categories", operator, gin.H{"name": "Сухие смеси"}), http.StatusForbidden, nil)
+	expect(t, ts.do(t, http.MethodPost, "/api/admin/categories", manager, gin.H{"name": "Сухие смеси"}), http.StatusCreated, nil)
+	product := gin.H{"name": "Цемент М500", "description": "Мешок 50 кг", "price": 450, "category": "Сухие смеси", "stock": 10}
+	expect(t, ts.do(t, http.MethodPost, "/api/products", operator, product), http.StatusForbidden, nil)
+	expect(t, ts.do(t, http.MethodPost, "/api/products", manager, product), http.StatusCreated, nil)
+
+	// роли назначает только users.manage
+	w := ts.do(t, http.MethodPut, "/api/admin/users/"+itoa(operatorID)+"/role", manager, gin.H{"role": "admin"})
+	expect(t, w, http.StatusForbidden, nil)
+	if got := ts.role(t, operatorID); got != "order_operator" {
+		t.Fatalf("role = %s, want order_operator", got)
+	}
+}
+
+// роль с административными правами не действует по токену, полученному без второго фактора
+func TestPromotionRequiresTwoFactor(t *testing.T) {
+	ts := newTestServer(t)
+	adminID, admin := ts.register(t, "admin")
+	ts.grantRole(t, adminID, "admin")
+	userID, user := ts.register(t, "prorab")
+
+	expect(t, ts.do(t, http.MethodGet, "/api/admin/jobs", user, nil), http.StatusForbidden, nil)
+
+	// назначение через администратора завершает прежние сессии
+	w := ts.do(t, http.MethodPut, "/api/admin/users/"+itoa(userID)+"/role", admin, gin.H{"role": "moderator"})
+	expect(t, w, http.StatusOK, nil)
+	expect(t, ts.do(t, http.MethodGet, "/api/admin/jobs", user, nil), http.StatusUnauthorized, nil)
+	expect(t, ts.do(t, http.MethodGet, "/api/me", user, nil), http.StatusUnauthorized, nil)
+
+	// роль, сменившаяся в обход обработчика, тоже не открывает админку без 2FA
+	otherID, other := ts.register(t, "master")
+	if _, err := ts.users.SetRole(context.Background(), otherID, "moderator"); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, ts.do(t, http.MethodGet, "/api/admin/jobs", other, nil), http.StatusUnauthorized, nil)
+	expect(t, ts.do(t, http.MethodGet, "/api/me", other, nil), http.StatusOK, nil)
+}
+
+// роль работодателя не административная: сессии остаются, поиск резюме работает сразу
+func TestEmployerPromotionKeepsSessions(t *testing.T) {
+	ts := newTestServer(t)
+	adminID, admin := ts.register(t, "admin")
+	ts.grantRole(t, adminID, "admin")
+	userID, user := ts.register(t, "prorab")
+
+	w := ts.do(t, http.MethodPut, "/api/admin/users/"+itoa(userID)+"/role", admin, gin.H{"role": RoleEmployer})
+	expect(t, w, http.StatusOK, nil)
+	expect(t, ts.do(t, http.MethodGet, "/api/resumes", user, nil), http.StatusOK, nil)
+}
+
+// последний пользователь с правом users.manage не может удалить аккаунт
+func TestLastAdminCannotDeleteAccount(t *testing.T) {
+	ts := newTestServer(t)
+	password := gin.H{"password": "Kirpich-2024-stroy"}
+
+	firstID, first := ts.register(t, "glavny")
+	ts.grantRole(t, firstID, "admin")
+	expect(t, ts.do(t, http.MethodDelete, "/api/me", first, password), http.StatusConflict, nil)
+
+	secondID, second := ts.register(t, "zamestitel")
+	ts.grantRole(t, secondID, "admin")
+	expect(t, ts.do(t, http.MethodDelete, "/api/me", first, password), http.StatusOK, nil)
+	expect(t, ts.do(t, http.MethodDelete, "/api/me", second, password), http.StatusConflict, nil)
+
+	_, user := ts.register(t, "prorab")
+	expect(t, ts.do(t, http.MethodDelete, "/api/me", user, password), http.StatusOK, nil)
+}
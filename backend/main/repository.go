@@ -0,0 +1,574 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound возвращается репозиториями, когда запись не найдена
+var ErrNotFound = errors.New("not found")
+
+var (
+	ErrDuplicateSlug = errors.New("duplicate slug")
+	// ErrCategoryInUse — у категории есть товары, вакансии или подкатегории
+	ErrCategoryInUse = errors.New("category in use")
+
+	// ErrInvalidRefreshToken — токен неизвестен, истёк или его сессия завершена
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused — предъявлен уже использованный токен; сессия отзывается целиком
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+
+	// ErrInvalidToken — одноразовый токен из письма неизвестен, истёк или уже использован
+	ErrInvalidToken = errors.New("invalid token")
+
+	// ErrUserExists — логин или email уже заняты другим пользователем
+	ErrUserExists = errors.New("user exists")
+
+	// ErrIdentityLinked — внешний аккаунт привязан к другому пользователю
+	// или у пользователя уже есть аккаунт этого провайдера
+	ErrIdentityLinked = errors.New("identity already linked")
+
+	// ErrAlreadyApplied — пользователь уже откликался на эту вакансию
+	ErrAlreadyApplied = errors.New("already applied")
+	// ErrStatusChanged — статус успели изменить параллельно
+	ErrStatusChanged = errors.New("status changed")
+
+	// ErrCompanyExists — компания с таким ИНН уже зарегистрирована
+	ErrCompanyExists = errors.New("company exists")
+	// ErrLastMember — у компании должен остаться хотя бы один участник
+	ErrLastMember = errors.New("last company member")
+	// ErrLastOwner — у компании должен остаться хотя бы один владелец
+	ErrLastOwner = errors.New("last company owner")
+	// ErrAlreadyMember — приглашённый пользователь уже состоит в компании
+	ErrAlreadyMember = errors.New("already a company member")
+
+	// ErrAlreadyInvited — работодатель уже приглашал соискателя на эту вакансию
+	ErrAlreadyInvited = errors.New("already invited")
+)
+
+type ProductFilter struct {
+	Search      string
+	CategoryIDs []int64 // любая из перечисленных; nil — без ограничения
+	MinPrice    *float64
+	MaxPrice    *float64
+	InStock     bool
+	// IDs ограничивает выборку найденными в поисковом индексе товарами.
+	// nil — без ограничения, пустой срез — ничего не найдено.
+	IDs []int64
+}
+
+var productSorts = map[string]sortSpec{
+	"newest":     {Column: "p.created_at", Desc: true, Kind: sortTime},
+	"price_asc":  {Column: "p.price", Kind: sortNumber},
+	"price_desc": {Column: "p.price", Desc: true, Kind: sortNumber},
+	"name":       {Column: "p.name", Kind: sortString},
+}
+
+func productSortKey(p Product, sort string) sortKey {
+	switch sort {
+	case "price_asc", "price_desc":
+		return sortKey{Value: p.Price, ID: p.ID}
+	case "name":
+		return sortKey{Value: p.Name, ID: p.ID}
+	default:
+		return sortKey{Value: p.CreatedAt, ID: p.ID}
+	}
+}
+
+type CategoryCount struct {
+	CategoryID int64  `json:"category_id"`
+	Category   string `json:"category"`
+	Slug       string `json:"slug"`
+	Count      int    `json:"count"`
+}
+
+type PriceBucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int     `json:"count"`
+}
+
+type ProductFacets struct {
+	Categories []CategoryCount `json:"categories"`
+	MinPrice   float64         `json:"min_price"`
+	MaxPrice   float64         `json:"max_price"`
+	Price      []PriceBucket   `json:"price"`
+}
+
+type ProductInput struct {
+	Name        string
+	Description string
+	Price       float64
+	CategoryID  *int64
+	Image       string
+	Stock       int
+}
+
+// ProductImageInput — сохранённые в FileStorage файлы нового изображения
+type ProductImageInput struct {
+	OriginalKey string
+	MediumKey   string
+	ThumbKey    string
+	URL         string
+	MediumURL   string
+	ThumbURL    string
+	ContentType string
+	Width       int
+	Height      int
+	Primary     bool
+}
+
+type ProductRepository interface {
+	List(ctx context.Context, filter ProductFilter, page PageRequest) (Page[Product], error)
+	Get(ctx context.Context, id int64) (Product, error)
+	Exists(ctx context.Context, id int64) (bool, error)
+	Create(ctx context.Context, in ProductInput) (Product, error)
+	// Update не меняет остаток — для этого есть SetStock
+	Update(ctx context.Context, id int64, in ProductInput) (Product, error)
+	Delete(ctx context.Context, id int64) error
+	SetStock(ctx context.Context, id int64, stock int) (Product, error)
+	// Facets считает количество товаров по категориям и ценовым интервалам.
+	// Счётчик категорий не учитывает фильтр по категориям, гистограмма — фильтр по цене,
+	// чтобы было видно, сколько товаров добавит выбор соседнего значения.
+	Facets(ctx context.Context, filter ProductFilter, buckets int) (ProductFacets, error)
+
+	// Изображения. Основное изображение подставляется в Product.Image;
+	// первое загруженное становится основным автоматически.
+	ListImages(ctx context.Context, productID int64) ([]ProductImage, error)
+	AddImage(ctx context.Context, productID int64, in ProductImageInput) (ProductImage, error)
+	SetPrimaryImage(ctx context.Context, productID, imageID int64) (ProductImage, error)
+	// DeleteImage возвращает удалённую запись, чтобы вызывающий удалил файлы;
+	// если удалено основное изображение, основным становится самое раннее из оставшихся
+	DeleteImage(ctx context.Context, productID, imageID int64) (ProductImage, error)
+}
+
+// BasketRepository — корзины пользователей. Названия и цены берутся из каталога
+// при каждом чтении, товары, удалённые из каталога, из корзины пропадают.
+type BasketRepository interface {
+	Get(ctx context.Context, userID int64) (Basket, error)
+	// AddItem прибавляет quantity к уже лежащему в корзине, но не больше maxBasketItemQuantity
+	AddItem(ctx context.Context, userID, productID int64, quantity int) error
+	// SetQuantity меняет количество товара, 0 убирает товар из корзины.
+	// ErrNotFound — товара нет в корзине
+	SetQuantity(ctx context.Context, userID, productID int64, quantity int) error
+	// RemoveItem — ErrNotFound, если товара нет в корзине
+	RemoveItem(ctx context.Context, userID, productID int64) error
+	Clear(ctx context.Context, userID int64) error
+}
+
+type OrderFilter struct {
+	UserID *int64
+	Status string // пустая строка — любой статус
+}
+
+// OrderRepository — заказы и резервы товара под них
+type OrderRepository interface {
+	// Place переносит корзину пользователя в новый заказ и резервирует товар.
+	// errBasketEmpty — корзина пуста, *OutOfStockError — товара не хватает
+	Place(ctx context.Context, userID int64, comment string) (Order, error)
+	Get(ctx context.Context, id int64) (Order, error)
+	// List — новые заказы первыми
+	List(ctx context.Context, filter OrderFilter) ([]Order, error)
+	// Transition меняет статус заказа и синхронизирует с ним резервы.
+	// userID > 0 — покупатель: чужие заказы для него не существуют (ErrNotFound),
+	// отменить можно только ещё не подтверждённый заказ (errInvalidTransition).
+	Transition(ctx context.Context, id, userID int64, to string) (Order, error)
+	// ExpiredReservations — id неподтверждённых заказов, резерв которых истёк к now
+	ExpiredReservations(ctx context.Context, now time.Time) ([]int64, error)
+}
+
+type JobFilter struct {
+	Search      string
+	CategoryIDs []int64
+	Status      string // пустая строка — любой статус
+	AuthorID    *int64
+	CompanyID   *int64
+	// ActiveAt скрывает вакансии, срок публикации которых истёк к этому моменту
+	ActiveAt *time.Time
+	// SalaryFrom — вилка достигает суммы, SalaryTo — начинается не выше неё.
+	// Вакансии без суммы под эти фильтры не попадают. Суммы задаются вместе
+	// с SalaryCurrency и SalaryPeriod (см. salaryUnitsMissing).
+	SalaryFrom     *int64
+	SalaryTo       *int64
+	SalaryCurrency string
+	SalaryPeriod   string
+	IDs            []int64 // как в ProductFilter
+}
+
+var jobSorts = map[string]sortSpec{
+	"newest": {Column: "j.created_at", Desc: true, Kind: sortTime},
+	"oldest": {Column: "j.created_at", Kind: sortTime},
+	"name":   {Column: "j.title", Kind: sortString},
+	// по убыванию сравнивается верхняя граница вилки, по возрастанию — нижняя (см. salaryValue);
+	// обработчики разрешают эти сортировки только вместе с фильтром по валюте и периоду
+	"salary_asc":  {Column: "COALESCE(j.salary_min, j.salary_max, 0)", Kind: sortNumber},
+	"salary_desc": {Column: "COALESCE(j.salary_max, j.salary_min, 0)", Desc: true, Kind: sortNumber},
+}
+
+func jobSortKey(j Job, sort string) sortKey {
+	switch sort {
+	case "name":
+		return sortKey{Value: j.Title, ID: j.ID}
+	case "salary_asc", "salary_desc":
+		return sortKey{Value: salaryValue(j.SalaryRange, sort == "salary_desc"), ID: j.ID}
+	}
+	return sortKey{Value: j.CreatedAt, ID: j.ID}
+}
+
+type JobInput struct {
+	Title       string
+	Description string
+	Salary      string
+	CategoryID  *int64
+	CompanyID   *int64
+	Company     string // название компании CompanyID
+	UserID      int64
+	SalaryRange
+}
+
+type JobRepository interface {
+	List(ctx context.Context, filter JobFilter, page PageRequest) (Page[Job], error)
+	Get(ctx context.Context, id int64) (Job, error)
+	Create(ctx context.Context, in JobInput) (Job, error)
+	// ListForUser — все вакансии автора, включая не прошедшие модерацию, новые первыми
+	ListForUser(ctx context.Context, userID int64) ([]Job, error)
+	// Update меняет вакансию и снова отправляет её на модерацию; автор не меняется,
+	// in.UserID записывается в журнал как инициатор смены статуса
+	Update(ctx context.Context, id int64, in JobInput) (Job, error)
+	// SetStatus меняет статус, только если он всё ещё равен from (иначе ErrStatusChanged),
+	// и записывает решение в журнал модерации. reason сохраняется только при отказе.
+	SetStatus(ctx context.Context, id int64, from, to, reason string, actorID int64) (Job, error)
+	// History — журнал модерации, новые записи первыми
+	History(ctx context.Context, filter ModerationFilter) ([]ModerationEntry, error)
+	// Renew продлевает публикацию до expiresAt, если статус всё ещё from (иначе ErrStatusChanged).
+	// Снятая по сроку вакансия возвращается на доску, снятую модератором продлить нельзя.
+	Renew(ctx context.Context, id int64, from string, expiresAt time.Time, actorID int64) (Job, error)
+	// ExpiringBefore — опубликованные вакансии, срок которых истекает до t,
+	// если автора о них ещё не предупреждали
+	ExpiringBefore(ctx context.Context, t time.Time) ([]Job, error)
+	MarkExpiryWarned(ctx context.Context, id int64) error
+	// ArchiveExpired убирает в архив вакансии, срок которых истёк к now, и возвращает их
+	ArchiveExpired(ctx context.Context, now time.Time) ([]Job, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+type ModerationFilter struct {
+	JobID   *int64
+	ActorID *int64
+	Limit   int
+}
+
+type ApplicationInput struct {
+	JobID        int64
+	UserID       int64
+	CoverLetter  string
+	ContactName  string
+	ContactPhone string
+	ContactEmail string
+}
+
+type ApplicationRepository interface {
+	Create(ctx context.Context, in ApplicationInput) (JobApplication, error)
+	Get(ctx context.Context, id int64) (JobApplication, error)
+	// ListForJob — отклики на вакансию, новые первыми
+	ListForJob(ctx context.Context, jobID int64) ([]JobApplication, error)
+	// ListForUser — история откликов соискателя
+	ListForUser(ctx context.Context, userID int64) ([]JobApplication, error)
+	// SetStatus меняет статус, только если он всё ещё равен from; иначе ErrStatusChanged
+	SetStatus(ctx context.Context, id int64, from, to string) (JobApplication, error)
+}
+
+type CompanyFilter struct {
+	Search   string
+	Verified *bool
+}
+
+type CompanyInput struct {
+	Name        string
+	INN         string // пустая строка — ИНН не указан
+	Description string
+	Website     string
+	Phone       string
+	Email       string
+}
+
+type CompanyRepository interface {
+	// List возвращает компании по названию
+	List(ctx context.Context, filter CompanyFilter) ([]Company, error)
+	Get(ctx context.Context, id int64) (Company, error)
+	// ListForUser — компании, в которых состоит пользователь
+	ListForUser(ctx context.Context, userID int64) ([]Company, error)
+	// Create регистрирует компанию, ownerID становится её первым участником и владельцем.
+	// Занятый ИНН — ErrCompanyExists.
+	Create(ctx context.Context, in CompanyInput, ownerID int64) (Company, error)
+	// Update меняет данные компании и её название в вакансиях. Смена названия
+	// или ИНН снимает отметку о проверке.
+	Update(ctx context.Context, id int64, in CompanyInput) (Company, error)
+	SetLogo(ctx context.Context, id int64, key, url string) (Company, error)
+	SetVerified(ctx context.Context, id int64, verified bool) (Company, error)
+	IsMember(ctx context.Context, companyID, userID int64) (bool, error)
+	// MemberRole — роль участника в компании; не участник — ErrNotFound
+	MemberRole(ctx context.Context, companyID, userID int64) (string, error)
+	// SetMemberRole меняет роль участника: ErrNotFound, если его нет, ErrLastOwner — если
+	// снимается последний владелец
+	SetMemberRole(ctx context.Context, companyID, userID int64, role string) error
+	// Members — участники в порядке добавления
+	Members(ctx context.Context, companyID int64) ([]CompanyMember, error)
+	// Invite приглашает пользователя в участники; повторное приглашение обновляет прежнее,
+	// участнику — ErrAlreadyMember
+	Invite(ctx context.Context, companyID, userID, invitedBy int64) error
+	// Invitations — приглашения пользователя, новые первыми
+	Invitations(ctx context.Context, userID int64) ([]CompanyInvitation, error)
+	// AcceptInvitation делает приглашённого участником; нет приглашения — ErrNotFound
+	AcceptInvitation(ctx context.Context, companyID, userID int64) error
+	// DeclineInvitation удаляет приглашение; нет приглашения — ErrNotFound
+	DeclineInvitation(ctx context.Context, companyID, userID int64) error
+	// RemoveMember убирает участника: ErrNotFound, если его нет, ErrLastMember — если он последний,
+	// ErrLastOwner — если он последний владелец
+	RemoveMember(ctx context.Context, companyID, userID int64) error
+}
+
+type ResumeFilter struct {
+	Search         string // по желаемой должности и навыкам
+	Skill          string // навык целиком, без учёта регистра
+	Region         string
+	Availability   string
+	ExperienceFrom *int
+	Status         string // пустая строка — любой статус
+	AuthorID       *int64
+	// SalaryTo — соискатель согласен на сумму не больше указанной;
+	// резюме без суммы под фильтр не попадают. Как у JobFilter, сумма задаётся
+	// вместе с SalaryCurrency и SalaryPeriod.
+	SalaryTo       *int64
+	SalaryCurrency string
+	SalaryPeriod   string
+}
+
+var resumeSorts = map[string]sortSpec{
+	"newest":     {Column: "r.created_at", Desc: true, Kind: sortTime},
+	"oldest":     {Column: "r.created_at", Kind: sortTime},
+	"experience": {Column: "r.experience_years", Desc: true, Kind: sortNumber},
+	"salary_asc": {Column: "COALESCE(r.salary_min, r.salary_max, 0)", Kind: sortNumber},
+}
+
+func resumeSortKey(r Resume, sort string) sortKey {
+	switch sort {
+	case "experience":
+		return sortKey{Value: float64(r.ExperienceYears), ID: r.ID}
+	case "salary_asc":
+		return sortKey{Value: salaryValue(r.SalaryRange, false), ID: r.ID}
+	}
+	return sortKey{Value: r.CreatedAt, ID: r.ID}
+}
+
+type ResumeInput struct {
+	UserID          int64
+	Title           string
+	Skills          []string
+	ExperienceYears int
+	Experience      string
+	Region          string
+	Availability    string
+	ContactName     string
+	ContactPhone    string
+	ContactEmail    string
+	SalaryRange
+}
+
+type ResumeRepository interface {
+	List(ctx context.Context, filter ResumeFilter, page PageRequest) (Page[Resume], error)
+	Get(ctx context.Context, id int64) (Resume, error)
+	Create(ctx context.Context, in ResumeInput) (Resume, error)
+	// ListForUser — все резюме соискателя, новые первыми
+	ListForUser(ctx context.Context, userID int64) ([]Resume, error)
+	// Update меняет резюме и снова отправляет его на модерацию, как JobRepository.Update
+	Update(ctx context.Context, id int64, in ResumeInput) (Resume, error)
+	// SetStatus — как JobRepository.SetStatus
+	SetStatus(ctx context.Context, id int64, from, to, reason string, actorID int64) (Resume, error)
+	// History — журнал модерации резюме, новые записи первыми
+	History(ctx context.Context, resumeID int64) ([]ResumeModerationEntry, error)
+	Delete(ctx context.Context, id int64) error
+
+	// Invite сохраняет приглашение работодателя; повторное на ту же вакансию — ErrAlreadyInvited
+	Invite(ctx context.Context, in InvitationInput) (ResumeInvitation, error)
+	// Invitations — приглашения на резюме соискателя, новые первыми
+	Invitations(ctx context.Context, userID int64) ([]ResumeInvitation, error)
+	// ContactsVisible — работодатель приглашал соискателя по этому резюме
+	// или соискатель откликался на вакансию работодателя
+	ContactsVisible(ctx context.Context, resumeID, employerID int64) (bool, error)
+}
+
+type InvitationInput struct {
+	ResumeID   int64
+	JobID      int64
+	EmployerID int64
+	Message    string
+}
+
+type CategoryInput struct {
+	ParentID  *int64
+	Name      string
+	Slug      string
+	SortOrder int
+}
+
+type CategoryRepository interface {
+	// List возвращает все категории плоским списком в порядке sort_order, name
+	List(ctx context.Context) ([]Category, error)
+	Get(ctx context.Context, id int64) (Category, error)
+	Create(ctx context.Context, in CategoryInput) (Category, error)
+	Update(ctx context.Context, id int64, in CategoryInput) (Category, error)
+	// Merge переносит товары, вакансии и подкатегории из from в to и удаляет from
+	Merge(ctx context.Context, from, to int64) error
+	// Delete удаляет только пустую категорию, иначе ErrCategoryInUse
+	Delete(ctx context.Context, id int64) error
+}
+
+type AddressInput struct {
+	Label     string
+	Address   string
+	IsDefault bool
+}
+
+type ProfileInput struct {
+	Username  string
+	Email     string
+	FullName  string
+	Phone     string
+	Addresses []AddressInput // заменяют прежний список целиком
+}
+
+type UserRepository interface {
+	Create(ctx context.Context, username, email, passwordHash, role string) (User, error)
+	GetByID(ctx context.Context, id int64) (User, error)
+	// GetByUsername возвращает пользователя вместе с хешем пароля
+	GetByUsername(ctx context.Context, username string) (User, string, error)
+	ExistsByUsernameOrEmail(ctx context.Context, username, email string) (bool, error)
+	GetByEmail(ctx context.Context, email string) (User, error)
+	// SetRole назначает роль от имени администратора: после этого SwitchRole её не меняет
+	SetRole(ctx context.Context, id int64, role string) (User, error)
+	// SwitchRole меняет роль from на to, если у пользователя всё ещё роль from
+	// и её не назначал администратор; false — роль не изменилась
+	SwitchRole(ctx context.Context, id int64, from, to string) (bool, error)
+	SetPassword(ctx context.Context, id int64, passwordHash string) error
+	MarkEmailVerified(ctx context.Context, id int64) error
+	PasswordHash(ctx context.Context, id int64) (string, error)
+
+	GetProfile(ctx context.Context, id int64) (Profile, error)
+	// UpdateProfile возвращает ErrUserExists, если логин или email заняты;
+	// при смене email подтверждение сбрасывается
+	UpdateProfile(ctx context.Context, id int64, in ProfileInput) (Profile, error)
+	// Delete удаляет пользователя; его вакансии и заказы остаются без автора
+	Delete(ctx context.Context, id int64) error
+}
+
+// UserTokenRepository хранит одноразовые токены из писем: подтверждение email и сброс пароля
+type UserTokenRepository interface {
+	// Create выдаёт новый токен и гасит прежние неиспользованные токены того же назначения
+	Create(ctx context.Context, userID int64, purpose, tokenHash string, expiresAt time.Time) error
+	// Lookup возвращает id владельца действующего токена, не погашая его
+	Lookup(ctx context.Context, purpose, tokenHash string) (int64, error)
+	// Consume помечает токен использованным и возвращает id его владельца
+	Consume(ctx context.Context, purpose, tokenHash string) (int64, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+// LoginThrottleRepository хранит счётчики неудачных входов и журнал блокировок
+type LoginThrottleRepository interface {
+	// Get возвращает пустой счётчик, если неудач не было
+	Get(ctx context.Context, scope, subject string) (LoginThrottle, error)
+	// RecordFailure атомарно увеличивает счётчик и назначает блокировку по policy
+	RecordFailure(ctx context.Context, scope, subject string, policy LoginPolicy, now time.Time) (LoginThrottle, error)
+	Reset(ctx context.Context, scope, subject string) error
+	AddLockout(ctx context.Context, lockout LoginLockout) error
+	// ListLockouts возвращает последние блокировки, новые первыми
+	ListLockouts(ctx context.Context, limit int) ([]LoginLockout, error)
+	// DeleteStale удаляет счётчики без неудач после before и без действующей блокировки
+	DeleteStale(ctx context.Context, before, now time.Time) (int, error)
+}
+
+type TOTPState struct {
+	Secret   string
+	Enabled  bool
+	LastStep int64
+}
+
+// TwoFactorRepository хранит TOTP-секреты и хеши кодов восстановления
+type TwoFactorRepository interface {
+	// Get возвращает ErrNotFound, если пользователь не начинал настройку
+	Get(ctx context.Context, userID int64) (TOTPState, error)
+	// SetPending сохраняет новый секрет, который ещё нужно подтвердить кодом
+	SetPending(ctx context.Context, userID int64, secret string) error
+	// Enable включает 2FA после первого верного кода и выдаёт коды восстановления
+	Enable(ctx context.Context, userID int64, step int64, codeHashes []string) error
+	// UseStep запоминает принятый шаг; false — код этого или более позднего шага уже принят
+	UseStep(ctx context.Context, userID int64, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error
+	// UseRecoveryCode гасит код; false — такого неиспользованного кода нет
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error)
+	RecoveryCodesLeft(ctx context.Context, userID int64) (int, error)
+	Disable(ctx context.Context, userID int64) error
+}
+
+type Identity struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"-"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OAuthState — незавершённая авторизация у провайдера
+type OAuthState struct {
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+	UserID       *int64 // привязка к уже открытому аккаунту
+	ExpiresAt    time.Time
+}
+
+// IdentityRepository хранит привязки внешних аккаунтов и state незавершённых авторизаций
+type IdentityRepository interface {
+	// FindUser возвращает id пользователя, к которому привязан внешний аккаунт
+	FindUser(ctx context.Context, provider, subject string) (int64, error)
+	Link(ctx context.Context, userID int64, in Identity) error
+	ListForUser(ctx context.Context, userID int64) ([]Identity, error)
+	Unlink(ctx context.Context, userID int64, provider string) error
+
+	SaveState(ctx context.Context, stateHash string, st OAuthState) error
+	// TakeState возвращает и удаляет state; ErrInvalidToken, если он неизвестен или истёк
+	TakeState(ctx context.Context, stateHash string) (OAuthState, error)
+	DeleteExpiredStates(ctx context.Context, now time.Time) (int, error)
+}
+
+type RoleRepository interface {
+	List(ctx context.Context) ([]Role, error)
+	Get(ctx context.Context, name string) (Role, error)
+	// UserPermissions возвращает права текущей роли пользователя; для неизвестного пользователя — пустой список
+	UserPermissions(ctx context.Context, userID int64) ([]string, error)
+	// CountUsersWithPermission — сколько пользователей получают право через свою роль
+	CountUsersWithPermission(ctx context.Context, perm string) (int, error)
+}
+
+type SessionInput struct {
+	ID        string
+	UserID    int64
+	TokenHash string // хеш первого refresh-токена
+	ExpiresAt time.Time
+	UserAgent string
+	IP        string
+}
+
+type SessionRepository interface {
+	Create(ctx context.Context, in SessionInput) error
+	// Rotate помечает токен oldHash использованным и выдаёт вместо него newHash, продлевая сессию.
+	// При ErrRefreshTokenReused возвращается отозванная сессия.
+	Rotate(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (Session, error)
+	// IsActive — сессия существует, не истекла и не отозвана
+	IsActive(ctx context.Context, sessionID string) (bool, error)
+	// Revoke завершает сессию; повторный вызов и неизвестный id не считаются ошибкой
+	Revoke(ctx context.Context, sessionID string) error
+	RevokeAllForUser(ctx context.Context, userID int64) (int, error)
+	// DeleteExpired удаляет истёкшие сессии и токены, а также давно отозванные сессии
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
@@ -0,0 +1,2778 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// In-memory реализации репозиториев для тестов и запуска без MySQL.
+// Поведение повторяет MySQL-версии, включая порядок выдачи и фильтры.
+
+// ---------- Products ----------
+
+type memoryProduct struct {
+	Product
+	reserved int
+	images   []ProductImage
+}
+
+type MemoryProductRepository struct {
+	mu          sync.RWMutex
+	nextID      int64
+	nextImageID int64
+	products    map[int64]*memoryProduct
+	categories  *MemoryCategoryRepository
+}
+
+// NewMemoryProductRepository берёт названия категорий из categories, как JOIN в MySQL-версии
+func NewMemoryProductRepository(categories *MemoryCategoryRepository) *MemoryProductRepository {
+	return &MemoryProductRepository{nextID: 1, nextImageID: 1, products: map[int64]*memoryProduct{}, categories: categories}
+}
+
+func (r *MemoryProductRepository) view(p *memoryProduct) Product {
+	out := p.Product
+	out.Category = r.categories.name(out.CategoryID)
+	for _, img := range p.images {
+		if img.IsPrimary {
+			out.Image = img.URL
+		}
+	}
+	out.Available = max(out.Stock-p.reserved, 0)
+	out.InStock = out.Available > 0
+	return out
+}
+
+func (r *MemoryProductRepository) List(ctx context.Context, filter ProductFilter, page PageRequest) (Page[Product], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var products []Product
+	for _, p := range r.products {
+		if view := r.view(p); productMatches(view, filter) {
+			products = append(products, view)
+		}
+	}
+
+	return paginateSlice(products, page, productSorts, "newest", productSortKey)
+}
+
+func productMatches(p Product, filter ProductFilter) bool {
+	switch {
+	case filter.Search != "" && !containsFold(p.Name, filter.Search):
+		return false
+	case filter.CategoryIDs != nil && (p.CategoryID == nil || !slices.Contains(filter.CategoryIDs, *p.CategoryID)):
+		return false
+	case filter.MinPrice != nil && p.Price < *filter.MinPrice:
+		return false
+	case filter.MaxPrice != nil && p.Price > *filter.MaxPrice:
+		return false
+	case filter.InStock && !p.InStock:
+		return false
+	case filter.IDs != nil && !slices.Contains(filter.IDs, p.ID):
+		return false
+	}
+	return true
+}
+
+func (r *MemoryProductRepository) Get(ctx context.Context, id int64) (Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.products[id]
+	if !ok {
+		return Product{}, ErrNotFound
+	}
+	return r.view(p), nil
+}
+
+func (r *MemoryProductRepository) Exists(ctx context.Context, id int64) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.products[id]
+	return ok, nil
+}
+
+func (r *MemoryProductRepository) Create(ctx context.Context, in ProductInput) (Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := &memoryProduct{Product: Product{
+		ID:          r.nextID,
+		Name:        in.Name,
+		Description: in.Description,
+		Price:       in.Price,
+		CategoryID:  in.CategoryID,
+		Image:       in.Image,
+		Stock:       in.Stock,
+		CreatedAt:   time.Now(),
+	}}
+	r.products[p.ID] = p
+	r.nextID++
+	return r.view(p), nil
+}
+
+func (r *MemoryProductRepository) Update(ctx context.Context, id int64, in ProductInput) (Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.products[id]
+	if !ok {
+		return Product{}, ErrNotFound
+	}
+	p.Name = in.Name
+	p.Description = in.Description
+	p.Price = in.Price
+	p.CategoryID = in.CategoryID
+	p.Image = in.Image
+	return r.view(p), nil
+}
+
+func (r *MemoryProductRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.products[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.products, id)
+	return nil
+}
+
+func (r *MemoryProductRepository) SetStock(ctx context.Context, id int64, stock int) (Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.products[id]
+	if !ok {
+		return Product{}, ErrNotFound
+	}
+	p.Stock = stock
+	return r.view(p), nil
+}
+
+func (r *MemoryProductRepository) ListImages(ctx context.Context, productID int64) ([]ProductImage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	images := []ProductImage{}
+	if p, ok := r.products[productID]; ok {
+		images = append(images, p.images...)
+	}
+	// как ORDER BY is_primary DESC, created_at, id; images хранятся в порядке загрузки
+	slices.SortStableFunc(images, func(a, b ProductImage) int {
+		switch {
+		case a.IsPrimary == b.IsPrimary:
+			return 0
+		case a.IsPrimary:
+			return -1
+		default:
+			return 1
+		}
+	})
+	return images, nil
+}
+
+func (r *MemoryProductRepository) AddImage(ctx context.Context, productID int64, in ProductImageInput) (ProductImage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.products[productID]
+	if !ok {
+		return ProductImage{}, ErrNotFound
+	}
+
+	primary := in.Primary || !slices.ContainsFunc(p.images, func(img ProductImage) bool { return img.IsPrimary })
+	if primary {
+		for i := range p.images {
+			p.images[i].IsPrimary = false
+		}
+	}
+
+	img := ProductImage{
+		ID:          r.nextImageID,
+		ProductID:   productID,
+		URL:         in.URL,
+		MediumURL:   in.MediumURL,
+		ThumbURL:    in.ThumbURL,
+		ContentType: in.ContentType,
+		Width:       in.Width,
+		Height:      in.Height,
+		IsPrimary:   primary,
+		CreatedAt:   time.Now(),
+		OriginalKey: in.OriginalKey,
+		MediumKey:   in.MediumKey,
+		ThumbKey:    in.ThumbKey,
+	}
+	p.images = append(p.images, img)
+	r.nextImageID++
+	return img, nil
+}
+
+func (r *MemoryProductRepository) SetPrimaryImage(ctx context.Context, productID, imageID int64) (ProductImage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.products[productID]
+	if !ok {
+		return ProductImage{}, ErrNotFound
+	}
+	i := slices.IndexFunc(p.images, func(img ProductImage) bool { return img.ID == imageID })
+	if i < 0 {
+		return ProductImage{}, ErrNotFound
+	}
+
+	for j := range p.images {
+		p.images[j].IsPrimary = j == i
+	}
+	return p.images[i], nil
+}
+
+func (r *MemoryProductRepository) DeleteImage(ctx context.Context, productID, imageID int64) (ProductImage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.products[productID]
+	if !ok {
+		return ProductImage{}, ErrNotFound
+	}
+	i := slices.IndexFunc(p.images, func(img ProductImage) bool { return img.ID == imageID })
+	if i < 0 {
+		return ProductImage{}, ErrNotFound
+	}
+
+	img := p.images[i]
+	p.images = slices.Delete(p.images, i, i+1)
+	if img.IsPrimary && len(p.images) > 0 {
+		p.images[0].IsPrimary = true
+	}
+	return img, nil
+}
+
+// moveCategory — часть MemoryCategoryRepository.Merge
+func (r *MemoryProductRepository) moveCategory(from, to int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range r.products {
+		if p.CategoryID != nil && *p.CategoryID == from {
+			p.CategoryID = &to
+		}
+	}
+}
+
+func (r *MemoryProductRepository) usesCategory(id int64) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, p := range r.products {
+		if p.CategoryID != nil && *p.CategoryID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryProductRepository) Facets(ctx context.Context, filter ProductFilter, buckets int) (ProductFacets, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	facets := ProductFacets{Categories: []CategoryCount{}, Price: []PriceBucket{}}
+
+	byCategory := filter
+	byCategory.CategoryIDs = nil
+	byPrice := filter
+	byPrice.MinPrice, byPrice.MaxPrice = nil, nil
+
+	counts := map[int64]int{}
+	var prices []float64
+	for _, p := range r.products {
+		view := r.view(p)
+		if view.CategoryID != nil && productMatches(view, byCategory) {
+			counts[*view.CategoryID]++
+		}
+		if productMatches(view, byPrice) {
+			prices = append(prices, view.Price)
+		}
+	}
+
+	categories, _ := r.categories.List(ctx)
+	for _, c := range categories {
+		if count := counts[c.ID]; count > 0 {
+			facets.Categories = append(facets.Categories, CategoryCount{CategoryID: c.ID, Category: c.Name, Slug: c.Slug, Count: count})
+		}
+	}
+
+	if len(prices) == 0 {
+		return facets, nil
+	}
+	facets.MinPrice, facets.MaxPrice = slices.Min(prices), slices.Max(prices)
+	facets.Price = priceHistogram(facets.MinPrice, facets.MaxPrice, buckets)
+	for _, price := range prices {
+		facets.Price[bucketIndex(facets.Price, price)].Count++
+	}
+	return facets, nil
+}
+
+// ---------- Baskets ----------
+
+type memoryBasketItem struct {
+	productID int64
+	quantity  int
+}
+
+type MemoryBasketRepository struct {
+	mu       sync.Mutex
+	baskets  map[int64][]memoryBasketItem // по пользователю, в порядке добавления
+	products *MemoryProductRepository
+}
+
+// NewMemoryBasketRepository берёт названия и цены из products, как JOIN в MySQL-версии
+func NewMemoryBasketRepository(products *MemoryProductRepository) *MemoryBasketRepository {
+	return &MemoryBasketRepository{baskets: map[int64][]memoryBasketItem{}, products: products}
+}
+
+func (r *MemoryBasketRepository) Get(ctx context.Context, userID int64) (Basket, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.products.mu.RLock()
+	defer r.products.mu.RUnlock()
+
+	basket := Basket{Items: []BasketItem{}}
+	for _, item := range r.baskets[userID] {
+		p, ok := r.products.products[item.productID]
+		if !ok {
+			continue
+		}
+		view := r.products.view(p)
+		basket.add(BasketItem{ProductID: view.ID, Name: view.Name, Price: view.Price, Image: view.Image, Quantity: item.quantity})
+	}
+	return basket, nil
+}
+
+func (r *MemoryBasketRepository) AddItem(ctx context.Context, userID, productID int64, quantity int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	items := r.baskets[userID]
+	if i := slices.IndexFunc(items, func(it memoryBasketItem) bool { return it.productID == productID }); i >= 0 {
+		items[i].quantity = min(items[i].quantity+quantity, maxBasketItemQuantity)
+		return nil
+	}
+	r.baskets[userID] = append(items, memoryBasketItem{productID: productID, quantity: quantity})
+	return nil
+}
+
+func (r *MemoryBasketRepository) SetQuantity(ctx context.Context, userID, productID int64, quantity int) error {
+	if quantity == 0 {
+		return r.RemoveItem(ctx, userID, productID)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	items := r.baskets[userID]
+	i := slices.IndexFunc(items, func(it memoryBasketItem) bool { return it.productID == productID })
+	if i < 0 {
+		return ErrNotFound
+	}
+	items[i].quantity = quantity
+	return nil
+}
+
+func (r *MemoryBasketRepository) RemoveItem(ctx context.Context, userID, productID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	items := r.baskets[userID]
+	i := slices.IndexFunc(items, func(it memoryBasketItem) bool { return it.productID == productID })
+	if i < 0 {
+		return ErrNotFound
+	}
+	r.baskets[userID] = slices.Delete(items, i, i+1)
+	return nil
+}
+
+func (r *MemoryBasketRepository) Clear(ctx context.Context, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.baskets, userID)
+	return nil
+}
+
+// ---------- Orders ----------
+
+type memoryReservation struct {
+	orderID   int64
+	productID int64
+	quantity  int
+	status    string
+	expiresAt *time.Time
+}
+
+type MemoryOrderRepository struct {
+	mu           sync.Mutex
+	nextID       int64
+	nextItemID   int64
+	orders       map[int64]*Order
+	reservations []*memoryReservation
+	baskets      *MemoryBasketRepository
+	products     *MemoryProductRepository
+}
+
+// NewMemoryOrderRepository оформляет заказы из корзин baskets и резервирует остатки товаров products
+func NewMemoryOrderRepository(baskets *MemoryBasketRepository, products *MemoryProductRepository) *MemoryOrderRepository {
+	return &MemoryOrderRepository{nextID: 1, nextItemID: 1, orders: map[int64]*Order{}, baskets: baskets, products: products}
+}
+
+// view копирует заказ вместе с позициями, чтобы вызывающий не менял хранилище
+func (r *MemoryOrderRepository) view(o *Order) Order {
+	out := *o
+	out.Items = slices.Clone(o.Items)
+	return out
+}
+
+// Place блокирует заказы, корзины и товары в одном порядке, как транзакция MySQL-версии
+func (r *MemoryOrderRepository) Place(ctx context.Context, userID int64, comment string) (Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.baskets.mu.Lock()
+	defer r.baskets.mu.Unlock()
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	var items []OrderItem
+	var total float64
+	for _, item := range r.baskets.baskets[userID] {
+		p, ok := r.products.products[item.productID]
+		if !ok {
+			continue
+		}
+		productID := p.ID
+		items = append(items, OrderItem{ProductID: &productID, ProductName: p.Name, Price: p.Price, Quantity: item.quantity})
+		total += p.Price * float64(item.quantity)
+	}
+	if len(items) == 0 {
+		return Order{}, errBasketEmpty
+	}
+
+	// остатки проверяются в порядке id товаров, как блокировки в reserveStock
+	sorted := slices.Clone(items)
+	slices.SortFunc(sorted, func(a, b OrderItem) int { return int(*a.ProductID - *b.ProductID) })
+	for _, item := range sorted {
+		p := r.products.products[*item.ProductID]
+		if available := p.Stock - p.reserved; available < item.Quantity {
+			return Order{}, &OutOfStockError{
+				ProductID: p.ID,
+				Name:      item.ProductName,
+				Requested: item.Quantity,
+				Available: max(available, 0),
+			}
+		}
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(reservationTTL)
+	order := &Order{
+		ID:        r.nextID,
+		UserID:    &userID,
+		Status:    OrderStatusNew,
+		Total:     total,
+		Comment:   comment,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.nextID++
+	for _, item := range items {
+		item.ID = r.nextItemID
+		r.nextItemID++
+		item.Subtotal = item.Price * float64(item.Quantity)
+		order.Items = append(order.Items, item)
+
+		r.products.products[*item.ProductID].reserved += item.Quantity
+		r.reservations = append(r.reservations, &memoryReservation{
+			orderID:   order.ID,
+			productID: *item.ProductID,
+			quantity:  item.Quantity,
+			status:    ReservationActive,
+			expiresAt: &expiresAt,
+		})
+	}
+	r.orders[order.ID] = order
+	delete(r.baskets.baskets, userID)
+
+	return r.view(order), nil
+}
+
+func (r *MemoryOrderRepository) Get(ctx context.Context, id int64) (Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	o, ok := r.orders[id]
+	if !ok {
+		return Order{}, ErrNotFound
+	}
+	return r.view(o), nil
+}
+
+func (r *MemoryOrderRepository) List(ctx context.Context, filter OrderFilter) ([]Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	orders := []Order{}
+	for _, o := range r.orders {
+		if filter.UserID != nil && (o.UserID == nil || *o.UserID != *filter.UserID) {
+			continue
+		}
+		if filter.Status != "" && o.Status != filter.Status {
+			continue
+		}
+		orders = append(orders, r.view(o))
+	}
+	// как ORDER BY created_at DESC, id DESC: id растут вместе с created_at
+	slices.SortFunc(orders, func(a, b Order) int { return int(b.ID - a.ID) })
+	return orders, nil
+}
+
+func (r *MemoryOrderRepository) Transition(ctx context.Context, id, userID int64, to string) (Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	o, ok := r.orders[id]
+	if !ok || (userID > 0 && (o.UserID == nil || *o.UserID != userID)) {
+		return Order{}, ErrNotFound
+	}
+	if !canTransitionOrder(o.Status, to) || (userID > 0 && o.Status != OrderStatusNew) {
+		return Order{}, errInvalidTransition
+	}
+
+	o.Status = to
+	o.UpdatedAt = time.Now()
+	r.applyStockTransition(id, to)
+	return r.view(o), nil
+}
+
+// applyStockTransition — как applyOrderStockTransition в MySQL-версии
+func (r *MemoryOrderRepository) applyStockTransition(orderID int64, to string) {
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	for _, res := range r.reservations {
+		if res.orderID != orderID || res.status != ReservationActive {
+			continue
+		}
+		p := r.products.products[res.productID]
+		switch to {
+		case OrderStatusConfirmed:
+			// подтверждённый заказ больше не истекает
+			res.expiresAt = nil
+		case OrderStatusShipped:
+			res.status = ReservationConsumed
+			if p != nil {
+				p.reserved = max(p.reserved-res.quantity, 0)
+				p.Stock = max(p.Stock-res.quantity, 0)
+			}
+		case OrderStatusCancelled:
+			res.status = ReservationReleased
+			if p != nil {
+				p.reserved = max(p.reserved-res.quantity, 0)
+			}
+		}
+	}
+}
+
+func (r *MemoryOrderRepository) ExpiredReservations(ctx context.Context, now time.Time) ([]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []int64
+	for _, res := range r.reservations {
+		if res.status != ReservationActive || res.expiresAt == nil || !res.expiresAt.Before(now) {
+			continue
+		}
+		if o := r.orders[res.orderID]; o.Status == OrderStatusNew && !slices.Contains(ids, o.ID) {
+			ids = append(ids, o.ID)
+		}
+	}
+	return ids, nil
+}
+
+// ---------- Jobs ----------
+
+type MemoryJobRepository struct {
+	mu         sync.RWMutex
+	nextID     int64
+	jobs       map[int64]Job
+	log        []ModerationEntry
+	warned     map[int64]bool // авторы предупреждены об истечении срока
+	users      *MemoryUserRepository
+	categories *MemoryCategoryRepository
+	companies  *MemoryCompanyRepository
+}
+
+// NewMemoryJobRepository берёт имена авторов из users, названия категорий из categories
+// и компании из companies, как JOIN в MySQL-версии
+func NewMemoryJobRepository(users *MemoryUserRepository, categories *MemoryCategoryRepository, companies *MemoryCompanyRepository) *MemoryJobRepository {
+	return &MemoryJobRepository{
+		nextID:     1,
+		jobs:       map[int64]Job{},
+		warned:     map[int64]bool{},
+		users:      users,
+		categories: categories,
+		companies:  companies,
+	}
+}
+
+// withUsername подставляет автора; вакансия удалённого пользователя остаётся без автора,
+// как после ON DELETE SET NULL в MySQL
+func (r *MemoryJobRepository) withUsername(j Job) Job {
+	j.Username = ""
+	if j.UserID != nil {
+		if u, err := r.users.GetByID(context.Background(), *j.UserID); err == nil {
+			j.Username = u.Username
+		} else {
+			j.UserID = nil
+		}
+	}
+	j.Category = r.categories.name(j.CategoryID)
+	j.CompanyVerified = false
+	if company, ok := r.companies.ref(j.CompanyID); ok {
+		j.Company, j.CompanyVerified = company.Name, company.Verified
+	}
+	return j
+}
+
+func (r *MemoryJobRepository) List(ctx context.Context, filter JobFilter, page PageRequest) (Page[Job], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var jobs []Job
+	for _, j := range r.jobs {
+		if filter.Status != "" && j.Status != filter.Status {
+			continue
+		}
+		if filter.AuthorID != nil && (j.UserID == nil || *j.UserID != *filter.AuthorID) {
+			continue
+		}
+		if filter.CompanyID != nil && (j.CompanyID == nil || *j.CompanyID != *filter.CompanyID) {
+			continue
+		}
+		if filter.ActiveAt != nil && j.ExpiresAt != nil && !j.ExpiresAt.After(*filter.ActiveAt) {
+			continue
+		}
+		if filter.Search != "" && !containsFold(j.Title, filter.Search) {
+			continue
+		}
+		if !salaryMatches(j.SalaryRange, filter) {
+			continue
+		}
+		if filter.CategoryIDs != nil && (j.CategoryID == nil || !slices.Contains(filter.CategoryIDs, *j.CategoryID)) {
+			continue
+		}
+		if filter.IDs != nil && !slices.Contains(filter.IDs, j.ID) {
+			continue
+		}
+		jobs = append(jobs, r.withUsername(j))
+	}
+
+	return paginateSlice(jobs, page, jobSorts, "newest", jobSortKey)
+}
+
+// salaryMatches — in-memory аналог фильтров по зарплате из jobWhere
+func salaryMatches(r SalaryRange, filter JobFilter) bool {
+	if filter.SalaryCurrency != "" && r.Currency != filter.SalaryCurrency {
+		return false
+	}
+	if filter.SalaryPeriod != "" && r.Period != filter.SalaryPeriod {
+		return false
+	}
+	if filter.SalaryFrom == nil && filter.SalaryTo == nil {
+		return true
+	}
+	if r.Min == nil && r.Max == nil {
+		return false
+	}
+	lo, hi := r.Min, r.Max
+	if lo == nil {
+		lo = hi
+	}
+	if hi == nil {
+		hi = lo
+	}
+	return (filter.SalaryFrom == nil || *hi >= *filter.SalaryFrom) &&
+		(filter.SalaryTo == nil || *lo <= *filter.SalaryTo)
+}
+
+func (r *MemoryJobRepository) Get(ctx context.Context, id int64) (Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	j, ok := r.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return r.withUsername(j), nil
+}
+
+func (r *MemoryJobRepository) Create(ctx context.Context, in JobInput) (Job, error) {
+	r.mu.Lock()
+	j := Job{
+		ID:          r.nextID,
+		Title:       in.Title,
+		Description: in.Description,
+		Salary:      in.Salary,
+		CategoryID:  in.CategoryID,
+		CompanyID:   in.CompanyID,
+		Company:     in.Company,
+		UserID:      &in.UserID,
+		Status:      JobStatusPending,
+		CreatedAt:   time.Now(),
+		SalaryRange: in.SalaryRange,
+	}
+	r.jobs[j.ID] = j
+	r.nextID++
+	r.mu.Unlock()
+
+	return r.Get(ctx, j.ID)
+}
+
+func (r *MemoryJobRepository) ListForUser(ctx context.Context, userID int64) ([]Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	jobs := []Job{}
+	for _, j := range r.jobs {
+		if j.UserID != nil && *j.UserID == userID {
+			jobs = append(jobs, r.withUsername(j))
+		}
+	}
+	slices.SortFunc(jobs, func(a, b Job) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return int(b.ID - a.ID)
+	})
+	return jobs, nil
+}
+
+func (r *MemoryJobRepository) Update(ctx context.Context, id int64, in JobInput) (Job, error) {
+	r.mu.Lock()
+	j, ok := r.jobs[id]
+	if ok {
+		if j.Status != JobStatusPending {
+			r.addLog(id, &in.UserID, j.Status, JobStatusPending, "")
+		}
+		j.Title = in.Title
+		j.Description = in.Description
+		j.Salary = in.Salary
+		j.SalaryRange = in.SalaryRange
+		j.CategoryID = in.CategoryID
+		j.CompanyID = in.CompanyID
+		j.Company = in.Company
+		j.Status = JobStatusPending
+		j.RejectionReason = ""
+		j.ExpiresAt = nil
+		delete(r.warned, id)
+		r.jobs[id] = j
+	}
+	r.mu.Unlock()
+
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *MemoryJobRepository) SetStatus(ctx context.Context, id int64, from, to, reason string, actorID int64) (Job, error) {
+	r.mu.Lock()
+	j, ok := r.jobs[id]
+	if !ok {
+		r.mu.Unlock()
+		return Job{}, ErrNotFound
+	}
+	if j.Status != from {
+		r.mu.Unlock()
+		return Job{}, ErrStatusChanged
+	}
+
+	if to != JobStatusRejected {
+		reason = ""
+	}
+	now := time.Now()
+	j.Status = to
+	j.RejectionReason = reason
+	j.ModeratedAt = &now
+	j.ExpiresAt = nil
+	delete(r.warned, id)
+	if to == JobStatusApproved {
+		expiresAt := now.Add(jobLifetime)
+		j.ExpiresAt = &expiresAt
+	}
+	r.jobs[id] = j
+	r.addLog(id, &actorID, from, to, reason)
+	r.mu.Unlock()
+
+	return r.Get(ctx, id)
+}
+
+// addLog вызывается под r.mu
+func (r *MemoryJobRepository) addLog(jobID int64, actorID *int64, from, to, reason string) {
+	r.log = append(r.log, ModerationEntry{
+		ID:         int64(len(r.log) + 1),
+		JobID:      jobID,
+		ActorID:    actorID,
+		FromStatus: from,
+		ToStatus:   to,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	})
+}
+
+func (r *MemoryJobRepository) Renew(ctx context.Context, id int64, from string, expiresAt time.Time, actorID int64) (Job, error) {
+	r.mu.Lock()
+	j, ok := r.jobs[id]
+	if !ok {
+		r.mu.Unlock()
+		return Job{}, ErrNotFound
+	}
+	if j.Status != from || j.ExpiresAt == nil {
+		r.mu.Unlock()
+		return Job{}, ErrStatusChanged
+	}
+
+	if from != JobStatusApproved {
+		r.addLog(id, &actorID, from, JobStatusApproved, "")
+	}
+	j.Status = JobStatusApproved
+	j.ExpiresAt = &expiresAt
+	delete(r.warned, id)
+	r.jobs[id] = j
+	r.mu.Unlock()
+
+	return r.Get(ctx, id)
+}
+
+func (r *MemoryJobRepository) ExpiringBefore(ctx context.Context, t time.Time) ([]Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	jobs := []Job{}
+	for _, j := range r.jobs {
+		if j.Status == JobStatusApproved && j.ExpiresAt != nil && !j.ExpiresAt.After(t) && !r.warned[j.ID] {
+			jobs = append(jobs, r.withUsername(j))
+		}
+	}
+	slices.SortFunc(jobs, func(a, b Job) int { return a.ExpiresAt.Compare(*b.ExpiresAt) })
+	return jobs, nil
+}
+
+func (r *MemoryJobRepository) MarkExpiryWarned(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.jobs[id]; ok {
+		r.warned[id] = true
+	}
+	return nil
+}
+
+func (r *MemoryJobRepository) ArchiveExpired(ctx context.Context, now time.Time) ([]Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	jobs := []Job{}
+	for id, j := range r.jobs {
+		if j.Status != JobStatusApproved || j.ExpiresAt == nil || j.ExpiresAt.After(now) {
+			continue
+		}
+		j.Status = JobStatusArchived
+		r.jobs[id] = j
+		r.addLog(id, nil, JobStatusApproved, JobStatusArchived, jobExpiredReason)
+		jobs = append(jobs, r.withUsername(j))
+	}
+	return jobs, nil
+}
+
+func (r *MemoryJobRepository) History(ctx context.Context, filter ModerationFilter) ([]ModerationEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := []ModerationEntry{}
+	for i := len(r.log) - 1; i >= 0; i-- {
+		e := r.log[i]
+		j, ok := r.jobs[e.JobID]
+		if !ok {
+			// записи удалённых вакансий уходят вместе с ними, как ON DELETE CASCADE
+			continue
+		}
+		if filter.JobID != nil && e.JobID != *filter.JobID {
+			continue
+		}
+		if filter.ActorID != nil && (e.ActorID == nil || *e.ActorID != *filter.ActorID) {
+			continue
+		}
+
+		e.JobTitle = j.Title
+		if e.ActorID != nil {
+			if u, err := r.users.GetByID(ctx, *e.ActorID); err == nil {
+				e.ActorName = u.Username
+			} else {
+				e.ActorID = nil
+			}
+		}
+		entries = append(entries, e)
+		if filter.Limit > 0 && len(entries) == filter.Limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+func (r *MemoryJobRepository) moveCategory(from, to int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, j := range r.jobs {
+		if j.CategoryID != nil && *j.CategoryID == from {
+			j.CategoryID = &to
+			r.jobs[id] = j
+		}
+	}
+}
+
+func (r *MemoryJobRepository) usesCategory(id int64) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, j := range r.jobs {
+		if j.CategoryID != nil && *j.CategoryID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryJobRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.jobs[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.jobs, id)
+	delete(r.warned, id)
+	return nil
+}
+
+// ---------- Job applications ----------
+
+type MemoryApplicationRepository struct {
+	mu           sync.Mutex
+	nextID       int64
+	applications map[int64]JobApplication
+	jobs         *MemoryJobRepository
+	users        *MemoryUserRepository
+}
+
+// NewMemoryApplicationRepository берёт вакансии из jobs и логины из users; отклики
+// на удалённые вакансии и от удалённых пользователей пропадают, как при ON DELETE CASCADE
+func NewMemoryApplicationRepository(jobs *MemoryJobRepository, users *MemoryUserRepository) *MemoryApplicationRepository {
+	return &MemoryApplicationRepository{nextID: 1, applications: map[int64]JobApplication{}, jobs: jobs, users: users}
+}
+
+// fill подставляет данные вакансии и соискателя; false — одной из записей уже нет
+func (r *MemoryApplicationRepository) fill(a JobApplication) (JobApplication, bool) {
+	job, err := r.jobs.Get(context.Background(), a.JobID)
+	if err != nil {
+		return a, false
+	}
+	user, err := r.users.GetByID(context.Background(), a.UserID)
+	if err != nil {
+		return a, false
+	}
+	a.JobTitle, a.Company, a.Username = job.Title, job.Company, user.Username
+	return a, true
+}
+
+func (r *MemoryApplicationRepository) Create(ctx context.Context, in ApplicationInput) (JobApplication, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, a := range r.applications {
+		if _, ok := r.fill(a); ok && a.JobID == in.JobID && a.UserID == in.UserID {
+			return JobApplication{}, ErrAlreadyApplied
+		}
+	}
+
+	now := time.Now()
+	a := JobApplication{
+		ID:           r.nextID,
+		JobID:        in.JobID,
+		UserID:       in.UserID,
+		CoverLetter:  in.CoverLetter,
+		ContactName:  in.ContactName,
+		ContactPhone: in.ContactPhone,
+		ContactEmail: in.ContactEmail,
+		Status:       ApplicationStatusNew,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	a, ok := r.fill(a)
+	if !ok {
+		return JobApplication{}, ErrNotFound
+	}
+	r.applications[a.ID] = a
+	r.nextID++
+	return a, nil
+}
+
+func (r *MemoryApplicationRepository) Get(ctx context.Context, id int64) (JobApplication, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a, ok := r.applications[id]
+	if ok {
+		a, ok = r.fill(a)
+	}
+	if !ok {
+		return JobApplication{}, ErrNotFound
+	}
+	return a, nil
+}
+
+func (r *MemoryApplicationRepository) list(match func(JobApplication) bool) []JobApplication {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	applications := []JobApplication{}
+	for _, a := range r.applications {
+		if a, ok := r.fill(a); ok && match(a) {
+			applications = append(applications, a)
+		}
+	}
+	slices.SortFunc(applications, func(a, b JobApplication) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return int(b.ID - a.ID)
+	})
+	return applications
+}
+
+func (r *MemoryApplicationRepository) ListForJob(ctx context.Context, jobID int64) ([]JobApplication, error) {
+	return r.list(func(a JobApplication) bool { return a.JobID == jobID }), nil
+}
+
+func (r *MemoryApplicationRepository) ListForUser(ctx context.Context, userID int64) ([]JobApplication, error) {
+	return r.list(func(a JobApplication) bool { return a.UserID == userID }), nil
+}
+
+func (r *MemoryApplicationRepository) SetStatus(ctx context.Context, id int64, from, to string) (JobApplication, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a, ok := r.applications[id]
+	if ok {
+		_, ok = r.fill(a)
+	}
+	if !ok {
+		return JobApplication{}, ErrNotFound
+	}
+	if a.Status != from {
+		return JobApplication{}, ErrStatusChanged
+	}
+	a.Status = to
+	a.UpdatedAt = time.Now()
+	r.applications[id] = a
+	a, _ = r.fill(a)
+	return a, nil
+}
+
+// ---------- Companies ----------
+
+type memoryCompanyMember struct {
+	userID    int64
+	role      string
+	createdAt time.Time
+}
+
+type memoryCompanyInvitation struct {
+	companyID int64
+	userID    int64
+	invitedBy int64
+	createdAt time.Time
+}
+
+type MemoryCompanyRepository struct {
+	mu          sync.RWMutex
+	nextID      int64
+	companies   map[int64]Company
+	members     map[int64][]memoryCompanyMember
+	invitations []memoryCompanyInvitation
+	users       *MemoryUserRepository
+}
+
+// NewMemoryCompanyRepository берёт логины участников из users; участники,
+// удалившие аккаунт, пропадают, как при ON DELETE CASCADE
+func NewMemoryCompanyRepository(users *MemoryUserRepository) *MemoryCompanyRepository {
+	return &MemoryCompanyRepository{
+		nextID:    1,
+		companies: map[int64]Company{},
+		members:   map[int64][]memoryCompanyMember{},
+		users:     users,
+	}
+}
+
+// ref — название и отметка о проверке для вакансий, как LEFT JOIN в MySQL-версии
+func (r *MemoryCompanyRepository) ref(id *int64) (Company, bool) {
+	if id == nil {
+		return Company{}, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.companies[*id]
+	return c, ok
+}
+
+// activeMembers — участники, чьи аккаунты ещё существуют
+func (r *MemoryCompanyRepository) activeMembers(companyID int64) []memoryCompanyMember {
+	var members []memoryCompanyMember
+	for _, m := range r.members[companyID] {
+		if _, err := r.users.GetByID(context.Background(), m.userID); err == nil {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+func sortCompanies(companies []Company) {
+	slices.SortFunc(companies, func(a, b Company) int {
+		if c := strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)); c != 0 {
+			return c
+		}
+		return int(a.ID - b.ID)
+	})
+}
+
+func (r *MemoryCompanyRepository) List(ctx context.Context, filter CompanyFilter) ([]Company, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	companies := []Company{}
+	for _, c := range r.companies {
+		if filter.Search != "" && !containsFold(c.Name, filter.Search) {
+			continue
+		}
+		if filter.Verified != nil && c.Verified != *filter.Verified {
+			continue
+		}
+		companies = append(companies, c)
+	}
+	sortCompanies(companies)
+	return companies, nil
+}
+
+func (r *MemoryCompanyRepository) Get(ctx context.Context, id int64) (Company, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.companies[id]
+	if !ok {
+		return Company{}, ErrNotFound
+	}
+	return c, nil
+}
+
+func (r *MemoryCompanyRepository) ListForUser(ctx context.Context, userID int64) ([]Company, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	companies := []Company{}
+	for id, members := range r.members {
+		for _, m := range members {
+			if m.userID == userID {
+				companies = append(companies, r.companies[id])
+				break
+			}
+		}
+	}
+	sortCompanies(companies)
+	return companies, nil
+}
+
+// innTaken проверяет уникальность ИНН; пустой ИНН не уникален, как NULL в MySQL
+func (r *MemoryCompanyRepository) innTaken(inn string, exceptID int64) bool {
+	if inn == "" {
+		return false
+	}
+	for _, c := range r.companies {
+		if c.INN == inn && c.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryCompanyRepository) Create(ctx context.Context, in CompanyInput, ownerID int64) (Company, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.innTaken(in.INN, 0) {
+		return Company{}, ErrCompanyExists
+	}
+	c := Company{
+		ID:          r.nextID,
+		Name:        in.Name,
+		INN:         in.INN,
+		Description: in.Description,
+		Website:     in.Website,
+		Phone:       in.Phone,
+		Email:       in.Email,
+		CreatedAt:   time.Now(),
+	}
+	r.companies[c.ID] = c
+	r.members[c.ID] = []memoryCompanyMember{{userID: ownerID, role: CompanyRoleOwner, createdAt: c.CreatedAt}}
+	r.nextID++
+	return c, nil
+}
+
+func (r *MemoryCompanyRepository) Update(ctx context.Context, id int64, in CompanyInput) (Company, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.companies[id]
+	if !ok {
+		return Company{}, ErrNotFound
+	}
+	if r.innTaken(in.INN, id) {
+		return Company{}, ErrCompanyExists
+	}
+	if c.Name != in.Name || c.INN != in.INN {
+		c.Verified = false
+		c.VerifiedAt = nil
+	}
+	c.Name = in.Name
+	c.INN = in.INN
+	c.Description = in.Description
+	c.Website = in.Website
+	c.Phone = in.Phone
+	c.Email = in.Email
+	r.companies[id] = c
+	return c, nil
+}
+
+func (r *MemoryCompanyRepository) SetLogo(ctx context.Context, id int64, key, url string) (Company, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.companies[id]
+	if !ok {
+		return Company{}, ErrNotFound
+	}
+	c.LogoKey, c.Logo = key, url
+	r.companies[id] = c
+	return c, nil
+}
+
+func (r *MemoryCompanyRepository) SetVerified(ctx context.Context, id int64, verified bool) (Company, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.companies[id]
+	if !ok {
+		return Company{}, ErrNotFound
+	}
+	c.Verified = verified
+	switch {
+	case !verified:
+		c.VerifiedAt = nil
+	case c.VerifiedAt == nil:
+		now := time.Now()
+		c.VerifiedAt = &now
+	}
+	r.companies[id] = c
+	return c, nil
+}
+
+func (r *MemoryCompanyRepository) IsMember(ctx context.Context, companyID, userID int64) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, m := range r.activeMembers(companyID) {
+		if m.userID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryCompanyRepository) Members(ctx context.Context, companyID int64) ([]CompanyMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	members := []CompanyMember{}
+	for _, m := range r.activeMembers(companyID) {
+		u, err := r.users.GetByID(ctx, m.userID)
+		if err != nil {
+			continue
+		}
+		members = append(members, CompanyMember{UserID: m.userID, Username: u.Username, Role: m.role, CreatedAt: m.createdAt})
+	}
+	return members, nil
+}
+
+func (r *MemoryCompanyRepository) invitation(companyID, userID int64) int {
+	return slices.IndexFunc(r.invitations, func(inv memoryCompanyInvitation) bool {
+		return inv.companyID == companyID && inv.userID == userID
+	})
+}
+
+func (r *MemoryCompanyRepository) Invite(ctx context.Context, companyID, userID, invitedBy int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.companies[companyID]; !ok {
+		return ErrNotFound
+	}
+	if slices.ContainsFunc(r.activeMembers(companyID), func(m memoryCompanyMember) bool { return m.userID == userID }) {
+		return ErrAlreadyMember
+	}
+	inv := memoryCompanyInvitation{companyID: companyID, userID: userID, invitedBy: invitedBy, createdAt: time.Now()}
+	if i := r.invitation(companyID, userID); i >= 0 {
+		r.invitations[i] = inv
+	} else {
+		r.invitations = append(r.invitations, inv)
+	}
+	return nil
+}
+
+func (r *MemoryCompanyRepository) Invitations(ctx context.Context, userID int64) ([]CompanyInvitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	invitations := []CompanyInvitation{}
+	for _, inv := range r.invitations {
+		if inv.userID != userID {
+			continue
+		}
+		out := CompanyInvitation{CompanyID: inv.companyID, Company: r.companies[inv.companyID].Name, CreatedAt: inv.createdAt}
+		// пригласивший удалил аккаунт — как ON DELETE SET NULL
+		if u, err := r.users.GetByID(ctx, inv.invitedBy); err == nil {
+			out.InvitedBy = u.Username
+		}
+		invitations = append(invitations, out)
+	}
+	slices.SortStableFunc(invitations, func(a, b CompanyInvitation) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return invitations, nil
+}
+
+func (r *MemoryCompanyRepository) AcceptInvitation(ctx context.Context, companyID, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.invitation(companyID, userID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	r.invitations = slices.Delete(r.invitations, i, i+1)
+	for _, m := range r.members[companyID] {
+		if m.userID == userID {
+			return nil
+		}
+	}
+	r.members[companyID] = append(r.members[companyID], memoryCompanyMember{userID: userID, role: CompanyRoleMember, createdAt: time.Now()})
+	return nil
+}
+
+func (r *MemoryCompanyRepository) DeclineInvitation(ctx context.Context, companyID, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.invitation(companyID, userID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	r.invitations = slices.Delete(r.invitations, i, i+1)
+	return nil
+}
+
+func (r *MemoryCompanyRepository) MemberRole(ctx context.Context, companyID, userID int64) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, m := range r.activeMembers(companyID) {
+		if m.userID == userID {
+			return m.role, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+// countOwners — сколько владельцев среди участников
+func countOwners(members []memoryCompanyMember) int {
+	n := 0
+	for _, m := range members {
+		if m.role == CompanyRoleOwner {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *MemoryCompanyRepository) SetMemberRole(ctx context.Context, companyID, userID int64, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	members := r.activeMembers(companyID)
+	i := slices.IndexFunc(members, func(m memoryCompanyMember) bool { return m.userID == userID })
+	if i < 0 {
+		return ErrNotFound
+	}
+	if members[i].role == CompanyRoleOwner && role != CompanyRoleOwner && countOwners(members) == 1 {
+		return ErrLastOwner
+	}
+	members[i].role = role
+	r.members[companyID] = members
+	return nil
+}
+
+func (r *MemoryCompanyRepository) RemoveMember(ctx context.Context, companyID, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.companies[companyID]; !ok {
+		return ErrNotFound
+	}
+	members := r.activeMembers(companyID)
+	i := slices.IndexFunc(members, func(m memoryCompanyMember) bool { return m.userID == userID })
+	if i < 0 {
+		return ErrNotFound
+	}
+	if len(members) == 1 {
+		return ErrLastMember
+	}
+	if members[i].role == CompanyRoleOwner && countOwners(members) == 1 {
+		return ErrLastOwner
+	}
+	r.members[companyID] = slices.Delete(members, i, i+1)
+	return nil
+}
+
+// ---------- Resumes ----------
+
+type MemoryResumeRepository struct {
+	mu           sync.RWMutex
+	nextID       int64
+	resumes      map[int64]Resume
+	log          []ResumeModerationEntry
+	invitations  []ResumeInvitation
+	users        *MemoryUserRepository
+	jobs         *MemoryJobRepository
+	applications *MemoryApplicationRepository
+}
+
+// NewMemoryResumeRepository берёт логины из users, вакансии из jobs и отклики из applications.
+// Резюме удалённых пользователей и приглашения на удалённые вакансии пропадают,
+// как при ON DELETE CASCADE.
+func NewMemoryResumeRepository(users *MemoryUserRepository, jobs *MemoryJobRepository, applications *MemoryApplicationRepository) *MemoryResumeRepository {
+	return &MemoryResumeRepository{
+		nextID:       1,
+		resumes:      map[int64]Resume{},
+		users:        users,
+		jobs:         jobs,
+		applications: applications,
+	}
+}
+
+// fill подставляет логин соискателя и строку зарплаты; false — соискателя уже нет
+func (r *MemoryResumeRepository) fill(resume Resume) (Resume, bool) {
+	u, err := r.users.GetByID(context.Background(), resume.UserID)
+	if err != nil {
+		return resume, false
+	}
+	resume.Username = u.Username
+	resume.Skills = slices.Clone(resume.Skills)
+	resume.Salary = resume.SalaryRange.String()
+	return resume, true
+}
+
+// get вызывается под r.mu
+func (r *MemoryResumeRepository) get(id int64) (Resume, bool) {
+	resume, ok := r.resumes[id]
+	if !ok {
+		return resume, false
+	}
+	return r.fill(resume)
+}
+
+func (r *MemoryResumeRepository) List(ctx context.Context, filter ResumeFilter, page PageRequest) (Page[Resume], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var resumes []Resume
+	for id := range r.resumes {
+		resume, ok := r.get(id)
+		if ok && resumeMatches(resume, filter) {
+			resumes = append(resumes, resume)
+		}
+	}
+
+	return paginateSlice(resumes, page, resumeSorts, "newest", resumeSortKey)
+}
+
+// resumeMatches — in-memory аналог resumeWhere
+func resumeMatches(resume Resume, filter ResumeFilter) bool {
+	if filter.Status != "" && resume.Status != filter.Status {
+		return false
+	}
+	if filter.AuthorID != nil && resume.UserID != *filter.AuthorID {
+		return false
+	}
+	if filter.Search != "" && !containsFold(resume.Title, filter.Search) &&
+		!slices.ContainsFunc(resume.Skills, func(s string) bool { return containsFold(s, filter.Search) }) {
+		return false
+	}
+	if filter.Skill != "" && !slices.ContainsFunc(resume.Skills, func(s string) bool { return strings.EqualFold(s, filter.Skill) }) {
+		return false
+	}
+	if filter.Region != "" && !containsFold(resume.Region, filter.Region) {
+		return false
+	}
+	if filter.Availability != "" && resume.Availability != filter.Availability {
+		return false
+	}
+	if filter.ExperienceFrom != nil && resume.ExperienceYears < *filter.ExperienceFrom {
+		return false
+	}
+	return salaryMatches(resume.SalaryRange, JobFilter{
+		SalaryTo:       filter.SalaryTo,
+		SalaryCurrency: filter.SalaryCurrency,
+		SalaryPeriod:   filter.SalaryPeriod,
+	})
+}
+
+func (r *MemoryResumeRepository) Get(ctx context.Context, id int64) (Resume, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	resume, ok := r.get(id)
+	if !ok {
+		return Resume{}, ErrNotFound
+	}
+	return resume, nil
+}
+
+func (r *MemoryResumeRepository) Create(ctx context.Context, in ResumeInput) (Resume, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	resume := Resume{
+		ID:              r.nextID,
+		UserID:          in.UserID,
+		Title:           in.Title,
+		Skills:          slices.Clone(in.Skills),
+		ExperienceYears: in.ExperienceYears,
+		Experience:      in.Experience,
+		Region:          in.Region,
+		Availability:    in.Availability,
+		ContactName:     in.ContactName,
+		ContactPhone:    in.ContactPhone,
+		ContactEmail:    in.ContactEmail,
+		Status:          JobStatusPending,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		SalaryRange:     in.SalaryRange,
+	}
+	if _, ok := r.fill(resume); !ok {
+		return Resume{}, ErrNotFound
+	}
+	r.resumes[resume.ID] = resume
+	r.nextID++
+
+	resume, _ = r.get(resume.ID)
+	return resume, nil
+}
+
+func (r *MemoryResumeRepository) ListForUser(ctx context.Context, userID int64) ([]Resume, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	resumes := []Resume{}
+	for id, resume := range r.resumes {
+		if resume.UserID != userID {
+			continue
+		}
+		if resume, ok := r.get(id); ok {
+			resumes = append(resumes, resume)
+		}
+	}
+	slices.SortFunc(resumes, func(a, b Resume) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return int(b.ID - a.ID)
+	})
+	return resumes, nil
+}
+
+func (r *MemoryResumeRepository) Update(ctx context.Context, id int64, in ResumeInput) (Resume, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	resume, ok := r.resumes[id]
+	if !ok {
+		return Resume{}, ErrNotFound
+	}
+	if resume.Status != JobStatusPending {
+		r.addLog(id, in.UserID, resume.Status, JobStatusPending, "")
+	}
+	resume.Title = in.Title
+	resume.Skills = slices.Clone(in.Skills)
+	resume.ExperienceYears = in.ExperienceYears
+	resume.Experience = in.Experience
+	resume.Region = in.Region
+	resume.Availability = in.Availability
+	resume.ContactName = in.ContactName
+	resume.ContactPhone = in.ContactPhone
+	resume.ContactEmail = in.ContactEmail
+	resume.SalaryRange = in.SalaryRange
+	resume.Status = JobStatusPending
+	resume.RejectionReason = ""
+	resume.UpdatedAt = time.Now()
+	r.resumes[id] = resume
+
+	if resume, ok = r.get(id); !ok {
+		return Resume{}, ErrNotFound
+	}
+	return resume, nil
+}
+
+func (r *MemoryResumeRepository) SetStatus(ctx context.Context, id int64, from, to, reason string, actorID int64) (Resume, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	resume, ok := r.resumes[id]
+	if !ok {
+		return Resume{}, ErrNotFound
+	}
+	if resume.Status != from {
+		return Resume{}, ErrStatusChanged
+	}
+
+	if to != JobStatusRejected {
+		reason = ""
+	}
+	now := time.Now()
+	resume.Status = to
+	resume.RejectionReason = reason
+	resume.ModeratedAt = &now
+	resume.UpdatedAt = now
+	r.resumes[id] = resume
+	r.addLog(id, actorID, from, to, reason)
+
+	if resume, ok = r.get(id); !ok {
+		return Resume{}, ErrNotFound
+	}
+	return resume, nil
+}
+
+// addLog вызывается под r.mu
+func (r *MemoryResumeRepository) addLog(resumeID, actorID int64, from, to, reason string) {
+	r.log = append(r.log, ResumeModerationEntry{
+		ID:         int64(len(r.log) + 1),
+		ResumeID:   resumeID,
+		ActorID:    &actorID,
+		FromStatus: from,
+		ToStatus:   to,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	})
+}
+
+func (r *MemoryResumeRepository) History(ctx context.Context, resumeID int64) ([]ResumeModerationEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := []ResumeModerationEntry{}
+	resume, ok := r.resumes[resumeID]
+	if !ok {
+		return entries, nil
+	}
+	for i := len(r.log) - 1; i >= 0; i-- {
+		e := r.log[i]
+		if e.ResumeID != resumeID {
+			continue
+		}
+		e.ResumeTitle = resume.Title
+		if u, err := r.users.GetByID(ctx, *e.ActorID); err == nil {
+			e.ActorName = u.Username
+		} else {
+			e.ActorID = nil
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func (r *MemoryResumeRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.resumes[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.resumes, id)
+	r.log = slices.DeleteFunc(r.log, func(e ResumeModerationEntry) bool { return e.ResumeID == id })
+	r.invitations = slices.DeleteFunc(r.invitations, func(i ResumeInvitation) bool { return i.ResumeID == id })
+	return nil
+}
+
+// invitationView подставляет названия резюме и вакансии и логин работодателя;
+// false — одной из записей уже нет. Вызывается под r.mu.
+func (r *MemoryResumeRepository) invitationView(i ResumeInvitation) (ResumeInvitation, bool) {
+	resume, ok := r.get(i.ResumeID)
+	if !ok {
+		return i, false
+	}
+	job, err := r.jobs.Get(context.Background(), i.JobID)
+	if err != nil {
+		return i, false
+	}
+	employer, err := r.users.GetByID(context.Background(), i.EmployerID)
+	if err != nil {
+		return i, false
+	}
+	i.ResumeTitle, i.JobTitle, i.Company, i.EmployerName = resume.Title, job.Title, job.Company, employer.Username
+	return i, true
+}
+
+func (r *MemoryResumeRepository) Invite(ctx context.Context, in InvitationInput) (ResumeInvitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, i := range r.invitations {
+		if _, ok := r.invitationView(i); ok && i.ResumeID == in.ResumeID && i.JobID == in.JobID {
+			return ResumeInvitation{}, ErrAlreadyInvited
+		}
+	}
+
+	i, ok := r.invitationView(ResumeInvitation{
+		ID:         int64(len(r.invitations) + 1),
+		ResumeID:   in.ResumeID,
+		JobID:      in.JobID,
+		EmployerID: in.EmployerID,
+		Message:    in.Message,
+		CreatedAt:  time.Now(),
+	})
+	if !ok {
+		return ResumeInvitation{}, ErrNotFound
+	}
+	r.invitations = append(r.invitations, i)
+	return i, nil
+}
+
+func (r *MemoryResumeRepository) Invitations(ctx context.Context, userID int64) ([]ResumeInvitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	invitations := []ResumeInvitation{}
+	for k := len(r.invitations) - 1; k >= 0; k-- {
+		i, ok := r.invitationView(r.invitations[k])
+		if ok && r.resumes[i.ResumeID].UserID == userID {
+			invitations = append(invitations, i)
+		}
+	}
+	return invitations, nil
+}
+
+func (r *MemoryResumeRepository) ContactsVisible(ctx context.Context, resumeID, employerID int64) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	resume, ok := r.get(resumeID)
+	if !ok {
+		return false, nil
+	}
+	for _, i := range r.invitations {
+		if _, ok := r.invitationView(i); ok && i.ResumeID == resumeID && i.EmployerID == employerID {
+			return true, nil
+		}
+	}
+
+	applications, err := r.applications.ListForUser(ctx, resume.UserID)
+	if err != nil {
+		return false, err
+	}
+	for _, a := range applications {
+		job, err := r.jobs.Get(ctx, a.JobID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		} else if err != nil {
+			return false, err
+		}
+		if ownsJob(job, employerID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ---------- Categories ----------
+
+type MemoryCategoryRepository struct {
+	mu         sync.RWMutex
+	nextID     int64
+	categories map[int64]Category
+
+	// товары и вакансии ссылаются на категории; нужны для Merge и Delete
+	products *MemoryProductRepository
+	jobs     *MemoryJobRepository
+}
+
+func NewMemoryCategoryRepository() *MemoryCategoryRepository {
+	return &MemoryCategoryRepository{nextID: 1, categories: map[int64]Category{}}
+}
+
+// attach связывает категории с репозиториями, которые на них ссылаются.
+// Вызывается после их создания, так как зависимость взаимная.
+func (r *MemoryCategoryRepository) attach(products *MemoryProductRepository, jobs *MemoryJobRepository) {
+	r.products = products
+	r.jobs = jobs
+}
+
+func (r *MemoryCategoryRepository) name(id *int64) string {
+	if id == nil {
+		return ""
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.categories[*id].Name
+}
+
+func (r *MemoryCategoryRepository) List(ctx context.Context) ([]Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	categories := make([]Category, 0, len(r.categories))
+	for _, c := range r.categories {
+		categories = append(categories, c)
+	}
+	slices.SortFunc(categories, func(a, b Category) int {
+		if a.SortOrder != b.SortOrder {
+			return a.SortOrder - b.SortOrder
+		}
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return int(a.ID - b.ID)
+	})
+	return categories, nil
+}
+
+func (r *MemoryCategoryRepository) Get(ctx context.Context, id int64) (Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.categories[id]
+	if !ok {
+		return Category{}, ErrNotFound
+	}
+	return c, nil
+}
+
+// slugTaken имитирует UNIQUE(slug) с регистронезависимой collation
+func (r *MemoryCategoryRepository) slugTaken(slug string, except int64) bool {
+	for _, c := range r.categories {
+		if c.ID != except && strings.EqualFold(c.Slug, slug) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryCategoryRepository) Create(ctx context.Context, in CategoryInput) (Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.slugTaken(in.Slug, 0) {
+		return Category{}, ErrDuplicateSlug
+	}
+	c := Category{
+		ID:        r.nextID,
+		ParentID:  in.ParentID,
+		Name:      in.Name,
+		Slug:      in.Slug,
+		SortOrder: in.SortOrder,
+	}
+	r.categories[c.ID] = c
+	r.nextID++
+	return c, nil
+}
+
+func (r *MemoryCategoryRepository) Update(ctx context.Context, id int64, in CategoryInput) (Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.categories[id]
+	if !ok {
+		return Category{}, ErrNotFound
+	}
+	if r.slugTaken(in.Slug, id) {
+		return Category{}, ErrDuplicateSlug
+	}
+	c.ParentID = in.ParentID
+	c.Name = in.Name
+	c.Slug = in.Slug
+	c.SortOrder = in.SortOrder
+	r.categories[id] = c
+	return c, nil
+}
+
+func (r *MemoryCategoryRepository) Merge(ctx context.Context, from, to int64) error {
+	r.mu.RLock()
+	_, fromOK := r.categories[from]
+	_, toOK := r.categories[to]
+	r.mu.RUnlock()
+	if !fromOK || !toOK || from == to {
+		return ErrNotFound
+	}
+
+	// товары и вакансии берут блокировку категорий внутри своей (view),
+	// поэтому переносим их, не держа r.mu
+	r.products.moveCategory(from, to)
+	r.jobs.moveCategory(from, to)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, c := range r.categories {
+		if c.ParentID != nil && *c.ParentID == from {
+			c.ParentID = &to
+			r.categories[id] = c
+		}
+	}
+	delete(r.categories, from)
+	return nil
+}
+
+func (r *MemoryCategoryRepository) Delete(ctx context.Context, id int64) error {
+	if _, err := r.Get(ctx, id); err != nil {
+		return err
+	}
+	if r.products.usesCategory(id) || r.jobs.usesCategory(id) {
+		return ErrCategoryInUse
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.categories {
+		if c.ParentID != nil && *c.ParentID == id {
+			return ErrCategoryInUse
+		}
+	}
+	delete(r.categories, id)
+	return nil
+}
+
+// ---------- Users ----------
+
+type memoryUser struct {
+	User
+	roleAssigned bool
+	passwordHash string
+	fullName     string
+	phone        string
+	addresses    []Address
+}
+
+type memoryUserToken struct {
+	userID    int64
+	purpose   string
+	expiresAt time.Time
+	used      bool
+}
+
+type MemoryUserRepository struct {
+	mu            sync.RWMutex
+	nextID        int64
+	nextAddressID int64
+	users         map[int64]*memoryUser
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{nextID: 1, nextAddressID: 1, users: map[int64]*memoryUser{}}
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, username, email, passwordHash, role string) (User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u := &memoryUser{
+		User: User{
+			ID:        r.nextID,
+			Username:  username,
+			Email:     email,
+			Role:      role,
+			CreatedAt: time.Now(),
+		},
+		passwordHash: passwordHash,
+	}
+	r.users[u.ID] = u
+	r.nextID++
+	return u.User, nil
+}
+
+func (r *MemoryUserRepository) GetByID(ctx context.Context, id int64) (User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	return u.User, nil
+}
+
+func (r *MemoryUserRepository) GetByUsername(ctx context.Context, username string) (User, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if u.Username == username {
+			return u.User, u.passwordHash, nil
+		}
+	}
+	return User{}, "", ErrNotFound
+}
+
+func (r *MemoryUserRepository) ExistsByUsernameOrEmail(ctx context.Context, username, email string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if u.Username == username || u.Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryUserRepository) SetRole(ctx context.Context, id int64, role string) (User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	u.Role = role
+	u.roleAssigned = true
+	return u.User, nil
+}
+
+func (r *MemoryUserRepository) SwitchRole(ctx context.Context, id int64, from, to string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok || u.Role != from || u.roleAssigned {
+		return false, nil
+	}
+	u.Role = to
+	return true, nil
+}
+
+func (r *MemoryUserRepository) GetByEmail(ctx context.Context, email string) (User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if strings.EqualFold(u.Email, email) {
+			return u.User, nil
+		}
+	}
+	return User{}, ErrNotFound
+}
+
+func (r *MemoryUserRepository) SetPassword(ctx context.Context, id int64, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	u.passwordHash = passwordHash
+	return nil
+}
+
+func (r *MemoryUserRepository) MarkEmailVerified(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if u, ok := r.users[id]; ok {
+		u.EmailVerified = true
+	}
+	return nil
+}
+
+func (r *MemoryUserRepository) PasswordHash(ctx context.Context, id int64) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return u.passwordHash, nil
+}
+
+func (u *memoryUser) profile() Profile {
+	return Profile{
+		User:      u.User,
+		FullName:  u.fullName,
+		Phone:     u.phone,
+		Addresses: append([]Address{}, u.addresses...),
+	}
+}
+
+func (r *MemoryUserRepository) GetProfile(ctx context.Context, id int64) (Profile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return Profile{}, ErrNotFound
+	}
+	return u.profile(), nil
+}
+
+func (r *MemoryUserRepository) UpdateProfile(ctx context.Context, id int64, in ProfileInput) (Profile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return Profile{}, ErrNotFound
+	}
+	for _, other := range r.users {
+		if other.ID != id && (strings.EqualFold(other.Username, in.Username) || strings.EqualFold(other.Email, in.Email)) {
+			return Profile{}, ErrUserExists
+		}
+	}
+
+	if !strings.EqualFold(u.Email, in.Email) {
+		u.EmailVerified = false
+	}
+	u.Username = in.Username
+	u.Email = in.Email
+	u.fullName = in.FullName
+	u.phone = in.Phone
+	u.addresses = make([]Address, 0, len(in.Addresses))
+	for _, a := range in.Addresses {
+		u.addresses = append(u.addresses, Address{
+			ID:        r.nextAddressID,
+			Label:     a.Label,
+			Address:   a.Address,
+			IsDefault: a.IsDefault,
+		})
+		r.nextAddressID++
+	}
+	return u.profile(), nil
+}
+
+func (r *MemoryUserRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
+
+// ---------- User tokens ----------
+
+type MemoryUserTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]*memoryUserToken // по хешу
+}
+
+func NewMemoryUserTokenRepository() *MemoryUserTokenRepository {
+	return &MemoryUserTokenRepository{tokens: map[string]*memoryUserToken{}}
+}
+
+func (r *MemoryUserTokenRepository) Create(ctx context.Context, userID int64, purpose, tokenHash string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range r.tokens {
+		if t.userID == userID && t.purpose == purpose {
+			t.used = true
+		}
+	}
+	r.tokens[tokenHash] = &memoryUserToken{userID: userID, purpose: purpose, expiresAt: expiresAt}
+	return nil
+}
+
+func (r *MemoryUserTokenRepository) Lookup(ctx context.Context, purpose, tokenHash string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tokens[tokenHash]
+	if !ok || t.used || t.purpose != purpose || !t.expiresAt.After(time.Now()) {
+		return 0, ErrInvalidToken
+	}
+	return t.userID, nil
+}
+
+func (r *MemoryUserTokenRepository) Consume(ctx context.Context, purpose, tokenHash string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tokens[tokenHash]
+	if !ok || t.used || t.purpose != purpose || !t.expiresAt.After(time.Now()) {
+		return 0, ErrInvalidToken
+	}
+	t.used = true
+	return t.userID, nil
+}
+
+func (r *MemoryUserTokenRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for hash, t := range r.tokens {
+		if t.expiresAt.Before(now) {
+			delete(r.tokens, hash)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// ---------- Login throttle ----------
+
+type throttleKey struct {
+	scope   string
+	subject string
+}
+
+type MemoryLoginThrottleRepository struct {
+	mu       sync.Mutex
+	nextID   int64
+	throttle map[throttleKey]LoginThrottle
+	lockouts []LoginLockout
+}
+
+func NewMemoryLoginThrottleRepository() *MemoryLoginThrottleRepository {
+	return &MemoryLoginThrottleRepository{nextID: 1, throttle: map[throttleKey]LoginThrottle{}}
+}
+
+func (r *MemoryLoginThrottleRepository) Get(ctx context.Context, scope, subject string) (LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.throttle[throttleKey{scope, subject}], nil
+}
+
+func (r *MemoryLoginThrottleRepository) RecordFailure(ctx context.Context, scope, subject string, policy LoginPolicy, now time.Time) (LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := throttleKey{scope, subject}
+	t := nextLoginThrottle(r.throttle[key], policy, now)
+	r.throttle[key] = t
+	return t, nil
+}
+
+func (r *MemoryLoginThrottleRepository) Reset(ctx context.Context, scope, subject string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.throttle, throttleKey{scope, subject})
+	return nil
+}
+
+func (r *MemoryLoginThrottleRepository) AddLockout(ctx context.Context, l LoginLockout) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l.ID = r.nextID
+	l.CreatedAt = time.Now()
+	r.nextID++
+	r.lockouts = append(r.lockouts, l)
+	return nil
+}
+
+func (r *MemoryLoginThrottleRepository) ListLockouts(ctx context.Context, limit int) ([]LoginLockout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lockouts := []LoginLockout{}
+	for i := len(r.lockouts) - 1; i >= 0 && len(lockouts) < limit; i-- {
+		lockouts = append(lockouts, r.lockouts[i])
+	}
+	return lockouts, nil
+}
+
+func (r *MemoryLoginThrottleRepository) DeleteStale(ctx context.Context, before, now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for key, t := range r.throttle {
+		if t.LastFailureAt.Before(before) && t.lockedFor(now) == 0 {
+			delete(r.throttle, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// ---------- Two-factor ----------
+
+type memoryTOTP struct {
+	TOTPState
+	recoveryCodes map[string]bool // хеш → использован
+}
+
+type MemoryTwoFactorRepository struct {
+	mu    sync.Mutex
+	users map[int64]*memoryTOTP
+}
+
+func NewMemoryTwoFactorRepository() *MemoryTwoFactorRepository {
+	return &MemoryTwoFactorRepository{users: map[int64]*memoryTOTP{}}
+}
+
+func (r *MemoryTwoFactorRepository) Get(ctx context.Context, userID int64) (TOTPState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.users[userID]
+	if !ok {
+		return TOTPState{}, ErrNotFound
+	}
+	return t.TOTPState, nil
+}
+
+func (r *MemoryTwoFactorRepository) SetPending(ctx context.Context, userID int64, secret string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.users[userID]
+	if !ok {
+		t = &memoryTOTP{recoveryCodes: map[string]bool{}}
+		r.users[userID] = t
+	}
+	t.TOTPState = TOTPState{Secret: secret}
+	return nil
+}
+
+func (r *MemoryTwoFactorRepository) Enable(ctx context.Context, userID int64, step int64, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.users[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	t.Enabled = true
+	t.LastStep = step
+	t.recoveryCodes = newMemoryRecoveryCodes(codeHashes)
+	return nil
+}
+
+func newMemoryRecoveryCodes(codeHashes []string) map[string]bool {
+	codes := make(map[string]bool, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes[hash] = false
+	}
+	return codes
+}
+
+func (r *MemoryTwoFactorRepository) UseStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.users[userID]
+	if !ok || t.LastStep >= step {
+		return false, nil
+	}
+	t.LastStep = step
+	return true, nil
+}
+
+func (r *MemoryTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.users[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	t.recoveryCodes = newMemoryRecoveryCodes(codeHashes)
+	return nil
+}
+
+func (r *MemoryTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.users[userID]
+	if !ok {
+		return false, nil
+	}
+	used, exists := t.recoveryCodes[codeHash]
+	if !exists || used {
+		return false, nil
+	}
+	t.recoveryCodes[codeHash] = true
+	return true, nil
+}
+
+func (r *MemoryTwoFactorRepository) RecoveryCodesLeft(ctx context.Context, userID int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	if t, ok := r.users[userID]; ok {
+		for _, used := range t.recoveryCodes {
+			if !used {
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+func (r *MemoryTwoFactorRepository) Disable(ctx context.Context, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.users, userID)
+	return nil
+}
+
+// ---------- Identities ----------
+
+type memoryIdentity struct {
+	Identity
+	userID int64
+}
+
+type MemoryIdentityRepository struct {
+	mu         sync.Mutex
+	identities []memoryIdentity
+	states     map[string]OAuthState
+}
+
+func NewMemoryIdentityRepository() *MemoryIdentityRepository {
+	return &MemoryIdentityRepository{states: map[string]OAuthState{}}
+}
+
+func (r *MemoryIdentityRepository) FindUser(ctx context.Context, provider, subject string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, i := range r.identities {
+		if i.Provider == provider && i.Subject == subject {
+			return i.userID, nil
+		}
+	}
+	return 0, ErrNotFound
+}
+
+func (r *MemoryIdentityRepository) Link(ctx context.Context, userID int64, in Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, i := range r.identities {
+		if i.Provider == in.Provider && (i.Subject == in.Subject || i.userID == userID) {
+			return ErrIdentityLinked
+		}
+	}
+	in.CreatedAt = time.Now()
+	r.identities = append(r.identities, memoryIdentity{Identity: in, userID: userID})
+	return nil
+}
+
+func (r *MemoryIdentityRepository) ListForUser(ctx context.Context, userID int64) ([]Identity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	identities := []Identity{}
+	for _, i := range r.identities {
+		if i.userID == userID {
+			identities = append(identities, i.Identity)
+		}
+	}
+	return identities, nil
+}
+
+func (r *MemoryIdentityRepository) Unlink(ctx context.Context, userID int64, provider string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for n, i := range r.identities {
+		if i.userID == userID && i.Provider == provider {
+			r.identities = slices.Delete(r.identities, n, n+1)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *MemoryIdentityRepository) SaveState(ctx context.Context, stateHash string, st OAuthState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.states[stateHash] = st
+	return nil
+}
+
+func (r *MemoryIdentityRepository) TakeState(ctx context.Context, stateHash string) (OAuthState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st, ok := r.states[stateHash]
+	delete(r.states, stateHash)
+	if !ok || !st.ExpiresAt.After(time.Now()) {
+		return OAuthState{}, ErrInvalidToken
+	}
+	return st, nil
+}
+
+func (r *MemoryIdentityRepository) DeleteExpiredStates(ctx context.Context, now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for hash, st := range r.states {
+		if !st.ExpiresAt.After(now) {
+			delete(r.states, hash)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// ---------- Roles ----------
+
+// MemoryRoleRepository повторяет роли и права из миграций 0005_rbac, 0015_companies и 0016_resumes
+type MemoryRoleRepository struct {
+	users *MemoryUserRepository
+	roles []Role
+}
+
+func NewMemoryRoleRepository(users *MemoryUserRepository) *MemoryRoleRepository {
+	return &MemoryRoleRepository{
+		users: users,
+		roles: []Role{
+			{Name: "admin", Title: "Администратор", Permissions: []string{
+				PermCategoriesWrite, PermCompaniesVerify, PermJobsModerate, PermOrdersManage, PermProductsWrite,
+				PermResumesModerate, PermResumesSearch, PermUsersManage,
+			}},
+			{Name: "catalog_manager", Title: "Менеджер каталога", Permissions: []string{PermCategoriesWrite, PermProductsWrite}},
+			{Name: RoleEmployer, Title: "Работодатель", Permissions: []string{PermResumesSearch}},
+			{Name: "moderator", Title: "Модератор вакансий и резюме", Permissions: []string{PermJobsModerate, PermResumesModerate}},
+			{Name: "order_operator", Title: "Оператор заказов", Permissions: []string{PermOrdersManage}},
+			{Name: RoleUser, Title: "Пользователь", Permissions: []string{}},
+		},
+	}
+}
+
+func (r *MemoryRoleRepository) List(ctx context.Context) ([]Role, error) {
+	roles := make([]Role, len(r.roles))
+	for i, role := range r.roles {
+		roles[i] = role
+		roles[i].Permissions = slices.Clone(role.Permissions)
+	}
+	return roles, nil
+}
+
+func (r *MemoryRoleRepository) Get(ctx context.Context, name string) (Role, error) {
+	for _, role := range r.roles {
+		if role.Name == name {
+			role.Permissions = slices.Clone(role.Permissions)
+			return role, nil
+		}
+	}
+	return Role{}, ErrNotFound
+}
+
+func (r *MemoryRoleRepository) UserPermissions(ctx context.Context, userID int64) ([]string, error) {
+	u, err := r.users.GetByID(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	role, err := r.Get(ctx, u.Role)
+	if errors.Is(err, ErrNotFound) {
+		return []string{}, nil
+	}
+	return role.Permissions, err
+}
+
+func (r *MemoryRoleRepository) CountUsersWithPermission(ctx context.Context, perm string) (int, error) {
+	r.users.mu.RLock()
+	defer r.users.mu.RUnlock()
+
+	count := 0
+	for _, u := range r.users.users {
+		for _, role := range r.roles {
+			if role.Name == u.Role && slices.Contains(role.Permissions, perm) {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+// ---------- Sessions ----------
+
+type memoryRefreshToken struct {
+	sessionID string
+	expiresAt time.Time
+	used      bool
+}
+
+type MemorySessionRepository struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+	tokens   map[string]*memoryRefreshToken // по хешу
+}
+
+func NewMemorySessionRepository() *MemorySessionRepository {
+	return &MemorySessionRepository{sessions: map[string]*Session{}, tokens: map[string]*memoryRefreshToken{}}
+}
+
+func (r *MemorySessionRepository) Create(ctx context.Context, in SessionInput) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.sessions[in.ID] = &Session{
+		ID:         in.ID,
+		UserID:     in.UserID,
+		UserAgent:  in.UserAgent,
+		IP:         in.IP,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  in.ExpiresAt,
+	}
+	r.tokens[in.TokenHash] = &memoryRefreshToken{sessionID: in.ID, expiresAt: in.ExpiresAt}
+	return nil
+}
+
+func (r *MemorySessionRepository) Rotate(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tokens[oldHash]
+	if !ok {
+		return Session{}, ErrInvalidRefreshToken
+	}
+	session, ok := r.sessions[t.sessionID]
+	if !ok {
+		return Session{}, ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	if session.RevokedAt != nil || !session.ExpiresAt.After(now) {
+		return *session, ErrInvalidRefreshToken
+	}
+	if t.used {
+		session.RevokedAt = &now
+		return *session, ErrRefreshTokenReused
+	}
+	if !t.expiresAt.After(now) {
+		return *session, ErrInvalidRefreshToken
+	}
+
+	t.used = true
+	r.tokens[newHash] = &memoryRefreshToken{sessionID: session.ID, expiresAt: expiresAt}
+	session.LastUsedAt = now
+	session.ExpiresAt = expiresAt
+	return *session, nil
+}
+
+func (r *MemorySessionRepository) IsActive(ctx context.Context, sessionID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[sessionID]
+	return ok && session.RevokedAt == nil && session.ExpiresAt.After(time.Now()), nil
+}
+
+func (r *MemorySessionRepository) Revoke(ctx context.Context, sessionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if session, ok := r.sessions[sessionID]; ok && session.RevokedAt == nil {
+		now := time.Now()
+		session.RevokedAt = &now
+	}
+	return nil
+}
+
+func (r *MemorySessionRepository) RevokeAllForUser(ctx context.Context, userID int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	revoked := 0
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func (r *MemorySessionRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, t := range r.tokens {
+		if t.expiresAt.Before(now) {
+			delete(r.tokens, hash)
+		}
+	}
+
+	deleted := 0
+	for id, session := range r.sessions {
+		if session.ExpiresAt.Before(now) || (session.RevokedAt != nil && session.RevokedAt.Before(now.Add(-revokedSessionRetention))) {
+			delete(r.sessions, id)
+			deleted++
+		}
+	}
+	// токены удалённых сессий уходят вместе с ними, как при ON DELETE CASCADE
+	for hash, t := range r.tokens {
+		if _, ok := r.sessions[t.sessionID]; !ok {
+			delete(r.tokens, hash)
+		}
+	}
+	return deleted, nil
+}
+
+// ---------- helpers ----------
+
+// containsFold имитирует LIKE '%x%' с регистронезависимой collation utf8mb4_unicode_ci
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,3387 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// limitSQL добавляет LIMIT с запасом в одну строку, чтобы понять, есть ли следующая страница.
+// OFFSET используется только без курсора.
+func limitSQL(query string, args []interface{}, page PageRequest, keyset bool) (string, []interface{}) {
+	if page.Limit <= 0 {
+		return query, args
+	}
+	query += " LIMIT ?"
+	args = append(args, page.Limit+1)
+	if !keyset && page.Offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, page.Offset)
+	}
+	return query, args
+}
+
+// trimPage отрезает лишнюю строку и сообщает, была ли она
+func trimPage[T any](result *Page[T], page PageRequest) bool {
+	if page.Limit <= 0 {
+		result.Total = len(result.Items)
+		return false
+	}
+	if len(result.Items) > page.Limit {
+		result.Items = result.Items[:page.Limit]
+		return true
+	}
+	return false
+}
+
+// idsSQL строит условие по списку id из поискового индекса
+func idsSQL(column string, ids []int64) (string, []interface{}) {
+	if ids == nil {
+		return "", nil
+	}
+	if len(ids) == 0 {
+		return " AND 1=0", nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return " AND " + column + " IN (?" + strings.Repeat(", ?", len(ids)-1) + ")", args
+}
+
+func nullInt64Ptr(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int64
+}
+
+// ---------- Products ----------
+
+const productSelect = `
+	SELECT p.id, p.name, p.description, p.price, p.category_id, COALESCE(c.name, ''),
+	       COALESCE(pi.url, p.image), COALESCE(s.quantity, 0), COALESCE(s.reserved, 0), p.created_at
+	FROM products p
+	LEFT JOIN product_stock s ON s.product_id = p.id
+	LEFT JOIN categories c ON c.id = p.category_id
+	LEFT JOIN product_images pi ON pi.product_id = p.id AND pi.is_primary = true
+`
+
+func scanProduct(row rowScanner) (Product, error) {
+	var p Product
+	var reserved int
+	var categoryID sql.NullInt64
+	err := row.Scan(
+		&p.ID, &p.Name, &p.Description, &p.Price, &categoryID,
+		&p.Category, &p.Image, &p.Stock, &reserved, &p.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return p, ErrNotFound
+	}
+	p.CategoryID = nullInt64Ptr(categoryID)
+	p.Available = max(p.Stock-reserved, 0)
+	p.InStock = p.Available > 0
+	return p, err
+}
+
+type MySQLProductRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLProductRepository(db *sql.DB) *MySQLProductRepository {
+	return &MySQLProductRepository{db: db}
+}
+
+func productWhere(filter ProductFilter) (string, []interface{}) {
+	where := " WHERE 1=1"
+	var args []interface{}
+
+	if filter.Search != "" {
+		where += " AND p.name LIKE ?"
+		args = append(args, "%"+filter.Search+"%")
+	}
+
+	clause, idArgs := idsSQL("p.category_id", filter.CategoryIDs)
+	where += clause
+	args = append(args, idArgs...)
+
+	if filter.MinPrice != nil {
+		where += " AND p.price >= ?"
+		args = append(args, *filter.MinPrice)
+	}
+
+	if filter.MaxPrice != nil {
+		where += " AND p.price <= ?"
+		args = append(args, *filter.MaxPrice)
+	}
+
+	if filter.InStock {
+		where += " AND COALESCE(s.quantity, 0) - COALESCE(s.reserved, 0) > 0"
+	}
+
+	clause, idArgs = idsSQL("p.id", filter.IDs)
+	where += clause
+	args = append(args, idArgs...)
+
+	return where, args
+}
+
+func (r *MySQLProductRepository) List(ctx context.Context, filter ProductFilter, page PageRequest) (Page[Product], error) {
+	sortName, spec, after, err := resolvePage(page, productSorts, "newest")
+	if err != nil {
+		return Page[Product]{}, err
+	}
+
+	where, args := productWhere(filter)
+	result := Page[Product]{Items: []Product{}}
+
+	if page.Limit > 0 {
+		countQuery := "SELECT COUNT(*) FROM products p LEFT JOIN product_stock s ON s.product_id = p.id" + where
+		if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&result.Total); err != nil {
+			return result, err
+		}
+	}
+
+	query := productSelect + where
+	if after != nil {
+		clause, keyArgs := keysetSQL(spec, "p.id", *after)
+		query += clause
+		args = append(args, keyArgs...)
+	}
+	query += orderBySQL(spec, "p.id")
+	query, args = limitSQL(query, args, page, after != nil)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return result, err
+		}
+		result.Items = append(result.Items, p)
+	}
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	hasMore := trimPage(&result, page)
+	result.NextCursor, err = nextCursorFor(result.Items, hasMore, sortName, func(p Product) sortKey {
+		return productSortKey(p, sortName)
+	})
+	return result, err
+}
+
+func (r *MySQLProductRepository) Get(ctx context.Context, id int64) (Product, error) {
+	return scanProduct(r.db.QueryRowContext(ctx, productSelect+" WHERE p.id = ?", id))
+}
+
+func (r *MySQLProductRepository) Exists(ctx context.Context, id int64) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM products WHERE id = ?)", id).Scan(&exists)
+	return exists, err
+}
+
+func (r *MySQLProductRepository) Create(ctx context.Context, in ProductInput) (Product, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Product{}, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO products (name, description, price, category_id, image) VALUES (?, ?, ?, ?, ?)",
+		in.Name, in.Description, in.Price, in.CategoryID, in.Image,
+	)
+	if err != nil {
+		return Product{}, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Product{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO product_stock (product_id, quantity) VALUES (?, ?)",
+		id, in.Stock,
+	); err != nil {
+		return Product{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Product{}, err
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *MySQLProductRepository) Update(ctx context.Context, id int64, in ProductInput) (Product, error) {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE products SET name = ?, description = ?, price = ?, category_id = ?, image = ? WHERE id = ?",
+		in.Name, in.Description, in.Price, in.CategoryID, in.Image, id,
+	)
+	if err != nil {
+		return Product{}, err
+	}
+
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return Product{}, err
+	}
+	if aff == 0 {
+		// MySQL не считает строку затронутой, если значения не изменились
+		if exists, err := r.Exists(ctx, id); err != nil {
+			return Product{}, err
+		} else if !exists {
+			return Product{}, ErrNotFound
+		}
+	}
+
+	return r.Get(ctx, id)
+}
+
+func (r *MySQLProductRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM products WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MySQLProductRepository) SetStock(ctx context.Context, id int64, stock int) (Product, error) {
+	exists, err := r.Exists(ctx, id)
+	if err != nil {
+		return Product{}, err
+	}
+	if !exists {
+		return Product{}, ErrNotFound
+	}
+
+	if _, err := r.db.ExecContext(ctx,
+		"INSERT INTO product_stock (product_id, quantity) VALUES (?, ?) ON DUPLICATE KEY UPDATE quantity = VALUES(quantity)",
+		id, stock,
+	); err != nil {
+		return Product{}, err
+	}
+
+	return r.Get(ctx, id)
+}
+
+func (r *MySQLProductRepository) Facets(ctx context.Context, filter ProductFilter, buckets int) (ProductFacets, error) {
+	const from = " FROM products p LEFT JOIN product_stock s ON s.product_id = p.id"
+	facets := ProductFacets{Categories: []CategoryCount{}, Price: []PriceBucket{}}
+
+	byCategory := filter
+	byCategory.CategoryIDs = nil
+	where, args := productWhere(byCategory)
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT c.id, c.name, c.slug, COUNT(*)"+from+" JOIN categories c ON c.id = p.category_id"+where+
+			" GROUP BY c.id ORDER BY c.sort_order, c.name", args...)
+	if err != nil {
+		return facets, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cc CategoryCount
+		if err := rows.Scan(&cc.CategoryID, &cc.Category, &cc.Slug, &cc.Count); err != nil {
+			return facets, err
+		}
+		facets.Categories = append(facets.Categories, cc)
+	}
+	if err := rows.Err(); err != nil {
+		return facets, err
+	}
+
+	byPrice := filter
+	byPrice.MinPrice, byPrice.MaxPrice = nil, nil
+	where, args = productWhere(byPrice)
+
+	var lo, hi sql.NullFloat64
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT MIN(p.price), MAX(p.price), COUNT(*)"+from+where, args...).Scan(&lo, &hi, &total); err != nil {
+		return facets, err
+	}
+	if total == 0 {
+		return facets, nil
+	}
+	facets.MinPrice, facets.MaxPrice = lo.Float64, hi.Float64
+	facets.Price = priceHistogram(lo.Float64, hi.Float64, buckets)
+
+	width := facets.Price[0].To - facets.Price[0].From
+	if width <= 0 {
+		facets.Price[0].Count = total
+		return facets, nil
+	}
+
+	// номер интервала считаем в БД, а крайние значения прижимаем к гистограмме в bucketIndex
+	priceRows, err := r.db.QueryContext(ctx,
+		"SELECT MIN(p.price), COUNT(*)"+from+where+" GROUP BY FLOOR((p.price - ?) / ?)",
+		append(args, facets.Price[0].From, width)...)
+	if err != nil {
+		return facets, err
+	}
+	defer priceRows.Close()
+
+	for priceRows.Next() {
+		var price float64
+		var count int
+		if err := priceRows.Scan(&price, &count); err != nil {
+			return facets, err
+		}
+		facets.Price[bucketIndex(facets.Price, price)].Count += count
+	}
+	return facets, priceRows.Err()
+}
+
+const productImageSelect = `
+	SELECT id, product_id, url, medium_url, thumb_url, content_type, width, height, is_primary, created_at,
+	       original_key, medium_key, thumb_key
+	FROM product_images
+`
+
+func scanProductImage(row rowScanner) (ProductImage, error) {
+	var img ProductImage
+	err := row.Scan(
+		&img.ID, &img.ProductID, &img.URL, &img.MediumURL, &img.ThumbURL,
+		&img.ContentType, &img.Width, &img.Height, &img.IsPrimary, &img.CreatedAt,
+		&img.OriginalKey, &img.MediumKey, &img.ThumbKey,
+	)
+	if err == sql.ErrNoRows {
+		return img, ErrNotFound
+	}
+	return img, err
+}
+
+// lockProduct блокирует строку товара, чтобы изменения флага is_primary не пересекались
+func lockProduct(ctx context.Context, tx *sql.Tx, id int64) error {
+	var locked int64
+	err := tx.QueryRowContext(ctx, "SELECT id FROM products WHERE id = ? FOR UPDATE", id).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *MySQLProductRepository) ListImages(ctx context.Context, productID int64) ([]ProductImage, error) {
+	rows, err := r.db.QueryContext(ctx,
+		productImageSelect+" WHERE product_id = ? ORDER BY is_primary DESC, created_at, id", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	images := []ProductImage{}
+	for rows.Next() {
+		img, err := scanProductImage(rows)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+	return images, rows.Err()
+}
+
+func (r *MySQLProductRepository) AddImage(ctx context.Context, productID int64, in ProductImageInput) (ProductImage, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ProductImage{}, err
+	}
+	defer tx.Rollback()
+
+	if err := lockProduct(ctx, tx, productID); err != nil {
+		return ProductImage{}, err
+	}
+
+	primary := in.Primary
+	if !primary {
+		var hasPrimary bool
+		if err := tx.QueryRowContext(ctx,
+			"SELECT EXISTS(SELECT 1 FROM product_images WHERE product_id = ? AND is_primary = true)", productID,
+		).Scan(&hasPrimary); err != nil {
+			return ProductImage{}, err
+		}
+		primary = !hasPrimary
+	}
+	if primary {
+		if _, err := tx.ExecContext(ctx, "UPDATE product_images SET is_primary = false WHERE product_id = ?", productID); err != nil {
+			return ProductImage{}, err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO product_images
+		    (product_id, original_key, medium_key, thumb_key, url, medium_url, thumb_url, content_type, width, height, is_primary)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		productID, in.OriginalKey, in.MediumKey, in.ThumbKey, in.URL, in.MediumURL, in.ThumbURL,
+		in.ContentType, in.Width, in.Height, primary,
+	)
+	if err != nil {
+		return ProductImage{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return ProductImage{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ProductImage{}, err
+	}
+	return scanProductImage(r.db.QueryRowContext(ctx, productImageSelect+" WHERE id = ?", id))
+}
+
+func (r *MySQLProductRepository) SetPrimaryImage(ctx context.Context, productID, imageID int64) (ProductImage, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ProductImage{}, err
+	}
+	defer tx.Rollback()
+
+	if err := lockProduct(ctx, tx, productID); err != nil {
+		return ProductImage{}, err
+	}
+	img, err := scanProductImage(tx.QueryRowContext(ctx,
+		productImageSelect+" WHERE id = ? AND product_id = ?", imageID, productID))
+	if err != nil {
+		return ProductImage{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE product_images SET is_primary = (id = ?) WHERE product_id = ?", imageID, productID,
+	); err != nil {
+		return ProductImage{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ProductImage{}, err
+	}
+	img.IsPrimary = true
+	return img, nil
+}
+
+func (r *MySQLProductRepository) DeleteImage(ctx context.Context, productID, imageID int64) (ProductImage, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ProductImage{}, err
+	}
+	defer tx.Rollback()
+
+	if err := lockProduct(ctx, tx, productID); err != nil {
+		return ProductImage{}, err
+	}
+	img, err := scanProductImage(tx.QueryRowContext(ctx,
+		productImageSelect+" WHERE id = ? AND product_id = ?", imageID, productID))
+	if err != nil {
+		return ProductImage{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM product_images WHERE id = ?", imageID); err != nil {
+		return ProductImage{}, err
+	}
+	if img.IsPrimary {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE product_images SET is_primary = true WHERE product_id = ? ORDER BY created_at, id LIMIT 1", productID,
+		); err != nil {
+			return ProductImage{}, err
+		}
+	}
+
+	return img, tx.Commit()
+}
+
+// ---------- Baskets ----------
+
+type MySQLBasketRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLBasketRepository(db *sql.DB) *MySQLBasketRepository {
+	return &MySQLBasketRepository{db: db}
+}
+
+func (r *MySQLBasketRepository) Get(ctx context.Context, userID int64) (Basket, error) {
+	basket := Basket{Items: []BasketItem{}}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT p.id, p.name, p.price, COALESCE(pi.url, p.image), bi.quantity
+		FROM baskets b
+		JOIN basket_items bi ON bi.basket_id = b.id
+		JOIN products p ON p.id = bi.product_id
+		LEFT JOIN product_images pi ON pi.product_id = p.id AND pi.is_primary = true
+		WHERE b.user_id = ?
+		ORDER BY bi.created_at, bi.id
+	`, userID)
+	if err != nil {
+		return basket, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item BasketItem
+		var image sql.NullString
+		if err := rows.Scan(&item.ProductID, &item.Name, &item.Price, &image, &item.Quantity); err != nil {
+			return basket, err
+		}
+		item.Image = image.String
+		basket.add(item)
+	}
+
+	return basket, rows.Err()
+}
+
+func (r *MySQLBasketRepository) AddItem(ctx context.Context, userID, productID int64, quantity int) error {
+	// корзина создаётся при первом добавлении товара
+	res, err := r.db.ExecContext(ctx,
+		"INSERT INTO baskets (user_id) VALUES (?) ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)",
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	basketID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO basket_items (basket_id, product_id, quantity) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE quantity = LEAST(quantity + VALUES(quantity), ?)
+	`, basketID, productID, quantity, maxBasketItemQuantity)
+	return err
+}
+
+func (r *MySQLBasketRepository) SetQuantity(ctx context.Context, userID, productID int64, quantity int) error {
+	if quantity == 0 {
+		return r.RemoveItem(ctx, userID, productID)
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE basket_items bi
+		JOIN baskets b ON b.id = bi.basket_id
+		SET bi.quantity = ?
+		WHERE b.user_id = ? AND bi.product_id = ?
+	`, quantity, userID, productID)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil || aff > 0 {
+		return err
+	}
+
+	// при неизменённом количестве MySQL возвращает 0 затронутых строк
+	var inBasket bool
+	if err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM basket_items bi
+			JOIN baskets b ON b.id = bi.basket_id
+			WHERE b.user_id = ? AND bi.product_id = ?
+		)
+	`, userID, productID).Scan(&inBasket); err != nil {
+		return err
+	}
+	if !inBasket {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MySQLBasketRepository) RemoveItem(ctx context.Context, userID, productID int64) error {
+	res, err := r.db.ExecContext(ctx, `
+		DELETE bi FROM basket_items bi
+		JOIN baskets b ON b.id = bi.basket_id
+		WHERE b.user_id = ? AND bi.product_id = ?
+	`, userID, productID)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MySQLBasketRepository) Clear(ctx context.Context, userID int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE bi FROM basket_items bi
+		JOIN baskets b ON b.id = bi.basket_id
+		WHERE b.user_id = ?
+	`, userID)
+	return err
+}
+
+// ---------- Orders ----------
+
+type MySQLOrderRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLOrderRepository(db *sql.DB) *MySQLOrderRepository {
+	return &MySQLOrderRepository{db: db}
+}
+
+const orderSelect = "SELECT id, user_id, status, total, comment, created_at, updated_at FROM orders"
+
+func (r *MySQLOrderRepository) Place(ctx context.Context, userID int64, comment string) (Order, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Order{}, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT p.id, p.name, p.price, bi.quantity
+		FROM baskets b
+		JOIN basket_items bi ON bi.basket_id = b.id
+		JOIN products p ON p.id = bi.product_id
+		WHERE b.user_id = ?
+		ORDER BY bi.id
+		FOR UPDATE
+	`, userID)
+	if err != nil {
+		return Order{}, err
+	}
+
+	var items []OrderItem
+	var total float64
+	for rows.Next() {
+		var item OrderItem
+		var productID int64
+		if err := rows.Scan(&productID, &item.ProductName, &item.Price, &item.Quantity); err != nil {
+			rows.Close()
+			return Order{}, err
+		}
+		item.ProductID = &productID
+		total += item.Price * float64(item.Quantity)
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return Order{}, err
+	}
+	rows.Close()
+
+	if len(items) == 0 {
+		return Order{}, errBasketEmpty
+	}
+
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO orders (user_id, status, total, comment) VALUES (?, ?, ?, ?)",
+		userID, OrderStatusNew, total, comment,
+	)
+	if err != nil {
+		return Order{}, err
+	}
+	orderID, err := res.LastInsertId()
+	if err != nil {
+		return Order{}, err
+	}
+
+	for _, item := range items {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO order_items (order_id, product_id, product_name, price, quantity) VALUES (?, ?, ?, ?, ?)",
+			orderID, *item.ProductID, item.ProductName, item.Price, item.Quantity,
+		); err != nil {
+			return Order{}, err
+		}
+	}
+
+	if err := reserveStock(ctx, tx, orderID, items); err != nil {
+		return Order{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE bi FROM basket_items bi
+		JOIN baskets b ON b.id = bi.basket_id
+		WHERE b.user_id = ?
+	`, userID); err != nil {
+		return Order{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Order{}, err
+	}
+	return r.Get(ctx, orderID)
+}
+
+// reserveStock резервирует товары заказа. Строки product_stock блокируются
+// в порядке возрастания id, чтобы параллельные оформления не взаимоблокировались.
+func reserveStock(ctx context.Context, tx *sql.Tx, orderID int64, items []OrderItem) error {
+	sorted := make([]OrderItem, len(items))
+	copy(sorted, items)
+	sort.Slice(sorted, func(i, j int) bool { return *sorted[i].ProductID < *sorted[j].ProductID })
+
+	expiresAt := time.Now().Add(reservationTTL)
+
+	for _, item := range sorted {
+		var quantity, reserved int
+		err := tx.QueryRowContext(ctx,
+			"SELECT quantity, reserved FROM product_stock WHERE product_id = ? FOR UPDATE",
+			*item.ProductID,
+		).Scan(&quantity, &reserved)
+		if err == sql.ErrNoRows {
+			quantity, reserved = 0, 0
+		} else if err != nil {
+			return err
+		}
+
+		if available := quantity - reserved; available < item.Quantity {
+			return &OutOfStockError{
+				ProductID: *item.ProductID,
+				Name:      item.ProductName,
+				Requested: item.Quantity,
+				Available: max(available, 0),
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE product_stock SET reserved = reserved + ? WHERE product_id = ?",
+			item.Quantity, *item.ProductID,
+		); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO stock_reservations (order_id, product_id, quantity, status, expires_at) VALUES (?, ?, ?, ?, ?)",
+			orderID, *item.ProductID, item.Quantity, ReservationActive, expiresAt,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *MySQLOrderRepository) Get(ctx context.Context, id int64) (Order, error) {
+	orders, err := r.query(ctx, orderSelect+" WHERE id = ?", id)
+	if err != nil {
+		return Order{}, err
+	}
+	if len(orders) == 0 {
+		return Order{}, ErrNotFound
+	}
+	return orders[0], nil
+}
+
+func (r *MySQLOrderRepository) List(ctx context.Context, filter OrderFilter) ([]Order, error) {
+	query := orderSelect + " WHERE 1=1"
+	var args []interface{}
+	if filter.UserID != nil {
+		query += " AND user_id = ?"
+		args = append(args, *filter.UserID)
+	}
+	if filter.Status != "" {
+		query += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+	return r.query(ctx, query+" ORDER BY created_at DESC, id DESC", args...)
+}
+
+func (r *MySQLOrderRepository) query(ctx context.Context, query string, args ...interface{}) ([]Order, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []Order{}
+	for rows.Next() {
+		var o Order
+		if err := rows.Scan(&o.ID, &o.UserID, &o.Status, &o.Total, &o.Comment, &o.CreatedAt, &o.UpdatedAt); err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadItems(ctx, orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r *MySQLOrderRepository) loadItems(ctx context.Context, orders []Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	index := make(map[int64]int, len(orders))
+	ids := make([]int64, len(orders))
+	for i := range orders {
+		orders[i].Items = []OrderItem{}
+		index[orders[i].ID] = i
+		ids[i] = orders[i].ID
+	}
+	in, args := idsSQL("order_id", ids)
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, order_id, product_id, product_name, price, quantity FROM order_items WHERE 1=1"+in+" ORDER BY id",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item OrderItem
+		var orderID int64
+		var productID sql.NullInt64
+		if err := rows.Scan(&item.ID, &orderID, &productID, &item.ProductName, &item.Price, &item.Quantity); err != nil {
+			return err
+		}
+		item.ProductID = nullInt64Ptr(productID)
+		item.Subtotal = item.Price * float64(item.Quantity)
+		i := index[orderID]
+		orders[i].Items = append(orders[i].Items, item)
+	}
+
+	return rows.Err()
+}
+
+// Transition блокирует строку заказа на время проверки перехода
+func (r *MySQLOrderRepository) Transition(ctx context.Context, id, userID int64, to string) (Order, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Order{}, err
+	}
+	defer tx.Rollback()
+
+	var from string
+	var ownerID sql.NullInt64
+	err = tx.QueryRowContext(ctx, "SELECT status, user_id FROM orders WHERE id = ? FOR UPDATE", id).Scan(&from, &ownerID)
+	if err == sql.ErrNoRows || (err == nil && userID > 0 && ownerID.Int64 != userID) {
+		return Order{}, ErrNotFound
+	} else if err != nil {
+		return Order{}, err
+	}
+
+	if !canTransitionOrder(from, to) || (userID > 0 && from != OrderStatusNew) {
+		return Order{}, errInvalidTransition
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE orders SET status = ? WHERE id = ?", to, id); err != nil {
+		return Order{}, err
+	}
+
+	if err := applyOrderStockTransition(ctx, tx, id, to); err != nil {
+		return Order{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Order{}, err
+	}
+	return r.Get(ctx, id)
+}
+
+// settleReservations снимает активные резервы заказа: при release товар
+// возвращается в свободный остаток, при consume — списывается со склада.
+func settleReservations(ctx context.Context, tx *sql.Tx, orderID int64, status string) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, product_id, quantity
+		FROM stock_reservations
+		WHERE order_id = ? AND status = ?
+		ORDER BY product_id
+		FOR UPDATE
+	`, orderID, ReservationActive)
+	if err != nil {
+		return err
+	}
+
+	type reservation struct {
+		id, productID int64
+		quantity      int
+	}
+	var reservations []reservation
+	for rows.Next() {
+		var r reservation
+		if err := rows.Scan(&r.id, &r.productID, &r.quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		reservations = append(reservations, r)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	rows.Close()
+
+	stockUpdate := "UPDATE product_stock SET reserved = GREATEST(reserved - ?, 0) WHERE product_id = ?"
+	if status == ReservationConsumed {
+		stockUpdate = "UPDATE product_stock SET reserved = GREATEST(reserved - ?, 0), quantity = GREATEST(quantity - ?, 0) WHERE product_id = ?"
+	}
+
+	for _, r := range reservations {
+		args := []interface{}{r.quantity, r.productID}
+		if status == ReservationConsumed {
+			args = []interface{}{r.quantity, r.quantity, r.productID}
+		}
+		if _, err := tx.ExecContext(ctx, stockUpdate, args...); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE stock_reservations SET status = ? WHERE id = ?", status, r.id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyOrderStockTransition синхронизирует резервы со сменой статуса заказа
+func applyOrderStockTransition(ctx context.Context, tx *sql.Tx, orderID int64, to string) error {
+	switch to {
+	case OrderStatusConfirmed:
+		// подтверждённый заказ больше не истекает
+		_, err := tx.ExecContext(ctx,
+			"UPDATE stock_reservations SET expires_at = NULL WHERE order_id = ? AND status = ?",
+			orderID, ReservationActive,
+		)
+		return err
+	case OrderStatusShipped:
+		return settleReservations(ctx, tx, orderID, ReservationConsumed)
+	case OrderStatusCancelled:
+		return settleReservations(ctx, tx, orderID, ReservationReleased)
+	}
+	return nil
+}
+
+func (r *MySQLOrderRepository) ExpiredReservations(ctx context.Context, now time.Time) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DISTINCT o.id
+		FROM orders o
+		JOIN stock_reservations r ON r.order_id = o.id
+		WHERE o.status = ? AND r.status = ? AND r.expires_at IS NOT NULL AND r.expires_at < ?
+	`, OrderStatusNew, ReservationActive, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ---------- Jobs ----------
+
+const jobSelect = `
+	SELECT j.id, j.title, j.description, j.salary, j.salary_min, j.salary_max, j.salary_currency,
+	       j.salary_period, j.salary_gross, j.category_id, COALESCE(c.name, ''), j.company,
+	       j.company_id, COALESCE(co.verified, false), j.user_id, j.status,
+	       COALESCE(j.rejection_reason, ''), j.moderated_at, j.expires_at, j.created_at,
+	       COALESCE(u.username, '')
+	FROM jobs j
+	LEFT JOIN users u ON j.user_id = u.id
+	LEFT JOIN categories c ON c.id = j.category_id
+	LEFT JOIN companies co ON co.id = j.company_id
+`
+
+func scanJob(row rowScanner) (Job, error) {
+	var j Job
+	var categoryID, companyID, salaryMin, salaryMax sql.NullInt64
+	var moderatedAt, expiresAt sql.NullTime
+	err := row.Scan(
+		&j.ID, &j.Title, &j.Description, &j.Salary, &salaryMin, &salaryMax, &j.Currency,
+		&j.Period, &j.Gross, &categoryID, &j.Category, &j.Company, &companyID, &j.CompanyVerified,
+		&j.UserID, &j.Status, &j.RejectionReason, &moderatedAt, &expiresAt, &j.CreatedAt, &j.Username,
+	)
+	if err == sql.ErrNoRows {
+		return j, ErrNotFound
+	}
+	j.CategoryID = nullInt64Ptr(categoryID)
+	j.CompanyID = nullInt64Ptr(companyID)
+	j.Min, j.Max = nullInt64Ptr(salaryMin), nullInt64Ptr(salaryMax)
+	if moderatedAt.Valid {
+		j.ModeratedAt = &moderatedAt.Time
+	}
+	if expiresAt.Valid {
+		j.ExpiresAt = &expiresAt.Time
+	}
+	return j, err
+}
+
+type MySQLJobRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLJobRepository(db *sql.DB) *MySQLJobRepository {
+	return &MySQLJobRepository{db: db}
+}
+
+func jobWhere(filter JobFilter) (string, []interface{}) {
+	where := " WHERE 1 = 1"
+	args := []interface{}{}
+
+	if filter.Status != "" {
+		where += " AND j.status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.AuthorID != nil {
+		where += " AND j.user_id = ?"
+		args = append(args, *filter.AuthorID)
+	}
+	if filter.CompanyID != nil {
+		where += " AND j.company_id = ?"
+		args = append(args, *filter.CompanyID)
+	}
+	if filter.ActiveAt != nil {
+		where += " AND (j.expires_at IS NULL OR j.expires_at > ?)"
+		args = append(args, *filter.ActiveAt)
+	}
+	if filter.Search != "" {
+		where += " AND j.title LIKE ?"
+		args = append(args, "%"+filter.Search+"%")
+	}
+	if filter.SalaryFrom != nil {
+		where += " AND COALESCE(j.salary_max, j.salary_min) >= ?"
+		args = append(args, *filter.SalaryFrom)
+	}
+	if filter.SalaryTo != nil {
+		where += " AND COALESCE(j.salary_min, j.salary_max) <= ?"
+		args = append(args, *filter.SalaryTo)
+	}
+	if filter.SalaryCurrency != "" {
+		where += " AND j.salary_currency = ?"
+		args = append(args, filter.SalaryCurrency)
+	}
+	if filter.SalaryPeriod != "" {
+		where += " AND j.salary_period = ?"
+		args = append(args, filter.SalaryPeriod)
+	}
+
+	clause, idArgs := idsSQL("j.category_id", filter.CategoryIDs)
+	where += clause
+	args = append(args, idArgs...)
+
+	clause, idArgs = idsSQL("j.id", filter.IDs)
+	where += clause
+	args = append(args, idArgs...)
+
+	return where, args
+}
+
+func (r *MySQLJobRepository) List(ctx context.Context, filter JobFilter, page PageRequest) (Page[Job], error) {
+	sortName, spec, after, err := resolvePage(page, jobSorts, "newest")
+	if err != nil {
+		return Page[Job]{}, err
+	}
+
+	where, args := jobWhere(filter)
+	result := Page[Job]{Items: []Job{}}
+
+	if page.Limit > 0 {
+		countQuery := "SELECT COUNT(*) FROM jobs j" + where
+		if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&result.Total); err != nil {
+			return result, err
+		}
+	}
+
+	query := jobSelect + where
+	if after != nil {
+		clause, keyArgs := keysetSQL(spec, "j.id", *after)
+		query += clause
+		args = append(args, keyArgs...)
+	}
+	query += orderBySQL(spec, "j.id")
+	query, args = limitSQL(query, args, page, after != nil)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return result, err
+		}
+		result.Items = append(result.Items, j)
+	}
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	hasMore := trimPage(&result, page)
+	result.NextCursor, err = nextCursorFor(result.Items, hasMore, sortName, func(j Job) sortKey {
+		return jobSortKey(j, sortName)
+	})
+	return result, err
+}
+
+func (r *MySQLJobRepository) Get(ctx context.Context, id int64) (Job, error) {
+	return scanJob(r.db.QueryRowContext(ctx, jobSelect+" WHERE j.id = ?", id))
+}
+
+func (r *MySQLJobRepository) Create(ctx context.Context, in JobInput) (Job, error) {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO jobs (title, description, salary, salary_min, salary_max, salary_currency, salary_period,
+		                   salary_gross, category_id, company, company_id, user_id)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		in.Title, in.Description, in.Salary, in.Min, in.Max, in.Currency, in.Period,
+		in.Gross, in.CategoryID, in.Company, in.CompanyID, in.UserID,
+	)
+	if err != nil {
+		return Job{}, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Job{}, err
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *MySQLJobRepository) ListForUser(ctx context.Context, userID int64) ([]Job, error) {
+	rows, err := r.db.QueryContext(ctx, jobSelect+" WHERE j.user_id = ? ORDER BY j.created_at DESC, j.id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []Job{}
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+func (r *MySQLJobRepository) Update(ctx context.Context, id int64, in JobInput) (Job, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Job{}, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRowContext(ctx, "SELECT status FROM jobs WHERE id = ? FOR UPDATE", id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return Job{}, ErrNotFound
+	} else if err != nil {
+		return Job{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE jobs SET title = ?, description = ?, salary = ?, salary_min = ?, salary_max = ?,
+		       salary_currency = ?, salary_period = ?, salary_gross = ?, category_id = ?, company = ?,
+		       company_id = ?, status = ?, rejection_reason = NULL, expires_at = NULL, expiry_warned_at = NULL
+		WHERE id = ?`,
+		in.Title, in.Description, in.Salary, in.Min, in.Max, in.Currency, in.Period, in.Gross,
+		in.CategoryID, in.Company, in.CompanyID, JobStatusPending, id,
+	); err != nil {
+		return Job{}, err
+	}
+	if status != JobStatusPending {
+		if err := logModeration(ctx, tx, id, &in.UserID, status, JobStatusPending, ""); err != nil {
+			return Job{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Job{}, err
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *MySQLJobRepository) SetStatus(ctx context.Context, id int64, from, to, reason string, actorID int64) (Job, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Job{}, err
+	}
+	defer tx.Rollback()
+
+	if to != JobStatusRejected {
+		reason = ""
+	}
+	// срок публикации отсчитывается заново с каждого одобрения
+	var expiresAt *time.Time
+	if to == JobStatusApproved {
+		t := time.Now().Add(jobLifetime)
+		expiresAt = &t
+	}
+	res, err := tx.ExecContext(ctx, `
+		UPDATE jobs SET status = ?, rejection_reason = NULLIF(?, ''), moderated_at = NOW(),
+		       expires_at = ?, expiry_warned_at = NULL
+		WHERE id = ? AND status = ?`,
+		to, reason, expiresAt, id, from,
+	)
+	if err != nil {
+		return Job{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := r.Get(ctx, id); err != nil {
+			return Job{}, err
+		}
+		return Job{}, ErrStatusChanged
+	}
+	if err := logModeration(ctx, tx, id, &actorID, from, to, reason); err != nil {
+		return Job{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Job{}, err
+	}
+	return r.Get(ctx, id)
+}
+
+// logModeration пишет смену статуса в журнал; actorID пуст у решений самого сервера
+func logModeration(ctx context.Context, tx *sql.Tx, jobID int64, actorID *int64, from, to, reason string) error {
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO job_moderation_log (job_id, actor_id, from_status, to_status, reason) VALUES (?, ?, ?, ?, NULLIF(?, ''))",
+		jobID, actorID, from, to, reason,
+	)
+	return err
+}
+
+func (r *MySQLJobRepository) Renew(ctx context.Context, id int64, from string, expiresAt time.Time, actorID int64) (Job, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Job{}, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE jobs SET status = ?, expires_at = ?, expiry_warned_at = NULL
+		WHERE id = ? AND status = ? AND expires_at IS NOT NULL`,
+		JobStatusApproved, expiresAt, id, from,
+	)
+	if err != nil {
+		return Job{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := r.Get(ctx, id); err != nil {
+			return Job{}, err
+		}
+		return Job{}, ErrStatusChanged
+	}
+	if from != JobStatusApproved {
+		if err := logModeration(ctx, tx, id, &actorID, from, JobStatusApproved, ""); err != nil {
+			return Job{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Job{}, err
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *MySQLJobRepository) ExpiringBefore(ctx context.Context, t time.Time) ([]Job, error) {
+	rows, err := r.db.QueryContext(ctx,
+		jobSelect+" WHERE j.status = ? AND j.expires_at <= ? AND j.expiry_warned_at IS NULL ORDER BY j.expires_at",
+		JobStatusApproved, t,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []Job{}
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+func (r *MySQLJobRepository) MarkExpiryWarned(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE jobs SET expiry_warned_at = NOW() WHERE id = ?", id)
+	return err
+}
+
+func (r *MySQLJobRepository) ArchiveExpired(ctx context.Context, now time.Time) ([]Job, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		"SELECT id FROM jobs WHERE status = ? AND expires_at <= ? FOR UPDATE", JobStatusApproved, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []Job{}, nil
+	}
+
+	clause, args := idsSQL("id", ids)
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE jobs SET status = ? WHERE 1 = 1"+clause, append([]interface{}{JobStatusArchived}, args...)...,
+	); err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if err := logModeration(ctx, tx, id, nil, JobStatusApproved, JobStatusArchived, jobExpiredReason); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	jobs := make([]Job, 0, len(ids))
+	for _, id := range ids {
+		j, err := r.Get(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue // автор успел удалить вакансию
+		} else if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+func (r *MySQLJobRepository) History(ctx context.Context, filter ModerationFilter) ([]ModerationEntry, error) {
+	query := `
+		SELECT l.id, l.job_id, j.title, l.actor_id, COALESCE(u.username, ''),
+		       l.from_status, l.to_status, COALESCE(l.reason, ''), l.created_at
+		FROM job_moderation_log l
+		JOIN jobs j ON j.id = l.job_id
+		LEFT JOIN users u ON u.id = l.actor_id
+		WHERE 1 = 1`
+	args := []interface{}{}
+	if filter.JobID != nil {
+		query += " AND l.job_id = ?"
+		args = append(args, *filter.JobID)
+	}
+	if filter.ActorID != nil {
+		query += " AND l.actor_id = ?"
+		args = append(args, *filter.ActorID)
+	}
+	query += " ORDER BY l.created_at DESC, l.id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []ModerationEntry{}
+	for rows.Next() {
+		var e ModerationEntry
+		var actorID sql.NullInt64
+		if err := rows.Scan(
+			&e.ID, &e.JobID, &e.JobTitle, &actorID, &e.ActorName,
+			&e.FromStatus, &e.ToStatus, &e.Reason, &e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		e.ActorID = nullInt64Ptr(actorID)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (r *MySQLJobRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM jobs WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ---------- Job applications ----------
+
+const applicationSelect = `
+	SELECT a.id, a.job_id, j.title, j.company, a.user_id, u.username, a.cover_letter,
+	       a.contact_name, a.contact_phone, a.contact_email, a.status, a.created_at, a.updated_at
+	FROM job_applications a
+	JOIN jobs j ON j.id = a.job_id
+	JOIN users u ON u.id = a.user_id
+`
+
+func scanApplication(row rowScanner) (JobApplication, error) {
+	var a JobApplication
+	err := row.Scan(
+		&a.ID, &a.JobID, &a.JobTitle, &a.Company, &a.UserID, &a.Username, &a.CoverLetter,
+		&a.ContactName, &a.ContactPhone, &a.ContactEmail, &a.Status, &a.CreatedAt, &a.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return a, ErrNotFound
+	}
+	return a, err
+}
+
+type MySQLApplicationRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLApplicationRepository(db *sql.DB) *MySQLApplicationRepository {
+	return &MySQLApplicationRepository{db: db}
+}
+
+func (r *MySQLApplicationRepository) Create(ctx context.Context, in ApplicationInput) (JobApplication, error) {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO job_applications (job_id, user_id, cover_letter, contact_name, contact_phone, contact_email)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		in.JobID, in.UserID, in.CoverLetter, in.ContactName, in.ContactPhone, in.ContactEmail,
+	)
+	if isDuplicateKey(err) {
+		return JobApplication{}, ErrAlreadyApplied
+	} else if err != nil {
+		return JobApplication{}, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return JobApplication{}, err
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *MySQLApplicationRepository) Get(ctx context.Context, id int64) (JobApplication, error) {
+	return scanApplication(r.db.QueryRowContext(ctx, applicationSelect+" WHERE a.id = ?", id))
+}
+
+func (r *MySQLApplicationRepository) list(ctx context.Context, where string, arg interface{}) ([]JobApplication, error) {
+	rows, err := r.db.QueryContext(ctx, applicationSelect+where+" ORDER BY a.created_at DESC, a.id DESC", arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applications := []JobApplication{}
+	for rows.Next() {
+		a, err := scanApplication(rows)
+		if err != nil {
+			return nil, err
+		}
+		applications = append(applications, a)
+	}
+	return applications, rows.Err()
+}
+
+func (r *MySQLApplicationRepository) ListForJob(ctx context.Context, jobID int64) ([]JobApplication, error) {
+	return r.list(ctx, " WHERE a.job_id = ?", jobID)
+}
+
+func (r *MySQLApplicationRepository) ListForUser(ctx context.Context, userID int64) ([]JobApplication, error) {
+	return r.list(ctx, " WHERE a.user_id = ?", userID)
+}
+
+func (r *MySQLApplicationRepository) SetStatus(ctx context.Context, id int64, from, to string) (JobApplication, error) {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE job_applications SET status = ? WHERE id = ? AND status = ?", to, id, from,
+	)
+	if err != nil {
+		return JobApplication{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := r.Get(ctx, id); err != nil {
+			return JobApplication{}, err
+		}
+		return JobApplication{}, ErrStatusChanged
+	}
+	return r.Get(ctx, id)
+}
+
+// ---------- Companies ----------
+
+const companySelect = `
+	SELECT id, name, COALESCE(inn, ''), description, COALESCE(logo_key, ''), COALESCE(logo_url, ''),
+	       website, phone, email, verified, verified_at, created_at
+	FROM companies
+`
+
+func scanCompany(row rowScanner) (Company, error) {
+	var c Company
+	var verifiedAt sql.NullTime
+	err := row.Scan(
+		&c.ID, &c.Name, &c.INN, &c.Description, &c.LogoKey, &c.Logo,
+		&c.Website, &c.Phone, &c.Email, &c.Verified, &verifiedAt, &c.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return c, ErrNotFound
+	}
+	if verifiedAt.Valid {
+		c.VerifiedAt = &verifiedAt.Time
+	}
+	return c, err
+}
+
+// nullIfEmpty пишет пустую строку как NULL — для UNIQUE-колонок, где пустых значений может быть много
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+type MySQLCompanyRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLCompanyRepository(db *sql.DB) *MySQLCompanyRepository {
+	return &MySQLCompanyRepository{db: db}
+}
+
+func (r *MySQLCompanyRepository) list(ctx context.Context, query string, args ...interface{}) ([]Company, error) {
+	rows, err := r.db.QueryContext(ctx, query+" ORDER BY name, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	companies := []Company{}
+	for rows.Next() {
+		c, err := scanCompany(rows)
+		if err != nil {
+			return nil, err
+		}
+		companies = append(companies, c)
+	}
+	return companies, rows.Err()
+}
+
+func (r *MySQLCompanyRepository) List(ctx context.Context, filter CompanyFilter) ([]Company, error) {
+	where := " WHERE 1 = 1"
+	args := []interface{}{}
+	if filter.Search != "" {
+		where += " AND name LIKE ?"
+		args = append(args, "%"+filter.Search+"%")
+	}
+	if filter.Verified != nil {
+		where += " AND verified = ?"
+		args = append(args, *filter.Verified)
+	}
+	return r.list(ctx, companySelect+where, args...)
+}
+
+func (r *MySQLCompanyRepository) Get(ctx context.Context, id int64) (Company, error) {
+	return scanCompany(r.db.QueryRowContext(ctx, companySelect+" WHERE id = ?", id))
+}
+
+func (r *MySQLCompanyRepository) ListForUser(ctx context.Context, userID int64) ([]Company, error) {
+	return r.list(ctx, companySelect+" WHERE id IN (SELECT company_id FROM company_members WHERE user_id = ?)", userID)
+}
+
+func (r *MySQLCompanyRepository) Create(ctx context.Context, in CompanyInput, ownerID int64) (Company, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Company{}, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO companies (name, inn, description, website, phone, email)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		in.Name, nullIfEmpty(in.INN), in.Description, in.Website, in.Phone, in.Email,
+	)
+	if isDuplicateKey(err) {
+		return Company{}, ErrCompanyExists
+	} else if err != nil {
+		return Company{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Company{}, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO company_members (company_id, user_id, role) VALUES (?, ?, ?)", id, ownerID, CompanyRoleOwner,
+	); err != nil {
+		return Company{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Company{}, err
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *MySQLCompanyRepository) Update(ctx context.Context, id int64, in CompanyInput) (Company, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Company{}, err
+	}
+	defer tx.Rollback()
+
+	var name, inn string
+	err = tx.QueryRowContext(ctx, "SELECT name, COALESCE(inn, '') FROM companies WHERE id = ? FOR UPDATE", id).Scan(&name, &inn)
+	if err == sql.ErrNoRows {
+		return Company{}, ErrNotFound
+	} else if err != nil {
+		return Company{}, err
+	}
+
+	query := "UPDATE companies SET name = ?, inn = ?, description = ?, website = ?, phone = ?, email = ?"
+	if name != in.Name || inn != in.INN {
+		query += ", verified = false, verified_at = NULL"
+	}
+	_, err = tx.ExecContext(ctx, query+" WHERE id = ?",
+		in.Name, nullIfEmpty(in.INN), in.Description, in.Website, in.Phone, in.Email, id,
+	)
+	if isDuplicateKey(err) {
+		return Company{}, ErrCompanyExists
+	} else if err != nil {
+		return Company{}, err
+	}
+	if name != in.Name {
+		if _, err := tx.ExecContext(ctx, "UPDATE jobs SET company = ? WHERE company_id = ?", in.Name, id); err != nil {
+			return Company{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Company{}, err
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *MySQLCompanyRepository) SetLogo(ctx context.Context, id int64, key, url string) (Company, error) {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE companies SET logo_key = ?, logo_url = ? WHERE id = ?", nullIfEmpty(key), nullIfEmpty(url), id,
+	)
+	if err != nil {
+		return Company{}, err
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *MySQLCompanyRepository) SetVerified(ctx context.Context, id int64, verified bool) (Company, error) {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE companies SET verified = ?,
+		        verified_at = CASE WHEN ? THEN COALESCE(verified_at, NOW()) ELSE NULL END
+		 WHERE id = ?`,
+		verified, verified, id,
+	)
+	if err != nil {
+		return Company{}, err
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *MySQLCompanyRepository) IsMember(ctx context.Context, companyID, userID int64) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM company_members WHERE company_id = ? AND user_id = ?)", companyID, userID,
+	).Scan(&exists)
+	return exists, err
+}
+
+func (r *MySQLCompanyRepository) Members(ctx context.Context, companyID int64) ([]CompanyMember, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT m.user_id, u.username, m.role, m.created_at
+		 FROM company_members m
+		 JOIN users u ON u.id = m.user_id
+		 WHERE m.company_id = ?
+		 ORDER BY m.created_at, m.user_id`,
+		companyID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []CompanyMember{}
+	for rows.Next() {
+		var m CompanyMember
+		if err := rows.Scan(&m.UserID, &m.Username, &m.Role, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+func (r *MySQLCompanyRepository) Invite(ctx context.Context, companyID, userID, invitedBy int64) error {
+	member, err := r.IsMember(ctx, companyID, userID)
+	if err != nil {
+		return err
+	}
+	if member {
+		return ErrAlreadyMember
+	}
+	_, err = r.db.ExecContext(ctx,
+		`INSERT INTO company_invitations (company_id, user_id, invited_by) VALUES (?, ?, ?)
+		 ON DUPLICATE KEY UPDATE invited_by = VALUES(invited_by), created_at = CURRENT_TIMESTAMP`,
+		companyID, userID, invitedBy,
+	)
+	return err
+}
+
+func (r *MySQLCompanyRepository) Invitations(ctx context.Context, userID int64) ([]CompanyInvitation, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT i.company_id, c.name, COALESCE(u.username, ''), i.created_at
+		 FROM company_invitations i
+		 JOIN companies c ON c.id = i.company_id
+		 LEFT JOIN users u ON u.id = i.invited_by
+		 WHERE i.user_id = ?
+		 ORDER BY i.created_at DESC, i.company_id`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []CompanyInvitation{}
+	for rows.Next() {
+		var inv CompanyInvitation
+		if err := rows.Scan(&inv.CompanyID, &inv.Company, &inv.InvitedBy, &inv.CreatedAt); err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, inv)
+	}
+	return invitations, rows.Err()
+}
+
+func (r *MySQLCompanyRepository) AcceptInvitation(ctx context.Context, companyID, userID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"DELETE FROM company_invitations WHERE company_id = ? AND user_id = ?", companyID, userID,
+	)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT IGNORE INTO company_members (company_id, user_id) VALUES (?, ?)", companyID, userID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *MySQLCompanyRepository) DeclineInvitation(ctx context.Context, companyID, userID int64) error {
+	res, err := r.db.ExecContext(ctx,
+		"DELETE FROM company_invitations WHERE company_id = ? AND user_id = ?", companyID, userID,
+	)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MySQLCompanyRepository) MemberRole(ctx context.Context, companyID, userID int64) (string, error) {
+	var role string
+	err := r.db.QueryRowContext(ctx,
+		"SELECT role FROM company_members WHERE company_id = ? AND user_id = ?", companyID, userID,
+	).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return role, err
+}
+
+// lockMembers блокирует строку компании, чтобы двое владельцев не сняли друг друга одновременно,
+// и считает участников и владельцев. role — роль userID; ErrNotFound, если он не участник.
+func (r *MySQLCompanyRepository) lockMembers(ctx context.Context, tx *sql.Tx, companyID, userID int64) (count, owners int, role string, err error) {
+	var id int64
+	err = tx.QueryRowContext(ctx, "SELECT id FROM companies WHERE id = ? FOR UPDATE", companyID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, 0, "", ErrNotFound
+	} else if err != nil {
+		return 0, 0, "", err
+	}
+	var member sql.NullString
+	err = tx.QueryRowContext(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(role = 'owner'), 0), MAX(CASE WHEN user_id = ? THEN role END)
+		 FROM company_members WHERE company_id = ?`,
+		userID, companyID,
+	).Scan(&count, &owners, &member)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	if !member.Valid {
+		return 0, 0, "", ErrNotFound
+	}
+	return count, owners, member.String, nil
+}
+
+func (r *MySQLCompanyRepository) SetMemberRole(ctx context.Context, companyID, userID int64, role string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, owners, current, err := r.lockMembers(ctx, tx, companyID, userID)
+	if err != nil {
+		return err
+	}
+	if current == CompanyRoleOwner && role != CompanyRoleOwner && owners == 1 {
+		return ErrLastOwner
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE company_members SET role = ? WHERE company_id = ? AND user_id = ?", role, companyID, userID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *MySQLCompanyRepository) RemoveMember(ctx context.Context, companyID, userID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	count, owners, role, err := r.lockMembers(ctx, tx, companyID, userID)
+	if err != nil {
+		return err
+	}
+	if count == 1 {
+		return ErrLastMember
+	}
+	if role == CompanyRoleOwner && owners == 1 {
+		return ErrLastOwner
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"DELETE FROM company_members WHERE company_id = ? AND user_id = ?", companyID, userID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ---------- Resumes ----------
+
+const resumeSelect = `
+	SELECT r.id, r.user_id, u.username, r.title,
+	       COALESCE((SELECT GROUP_CONCAT(s.skill ORDER BY s.position SEPARATOR '\n')
+	                 FROM resume_skills s WHERE s.resume_id = r.id), ''),
+	       r.experience_years, r.experience, r.region, r.availability,
+	       r.salary_min, r.salary_max, r.salary_currency, r.salary_period, r.salary_gross,
+	       r.contact_name, r.contact_phone, r.contact_email, r.status,
+	       COALESCE(r.rejection_reason, ''), r.moderated_at, r.created_at, r.updated_at
+	FROM resumes r
+	JOIN users u ON u.id = r.user_id
+`
+
+func scanResume(row rowScanner) (Resume, error) {
+	var r Resume
+	var skills string
+	var salaryMin, salaryMax sql.NullInt64
+	var moderatedAt sql.NullTime
+	err := row.Scan(
+		&r.ID, &r.UserID, &r.Username, &r.Title, &skills,
+		&r.ExperienceYears, &r.Experience, &r.Region, &r.Availability,
+		&salaryMin, &salaryMax, &r.Currency, &r.Period, &r.Gross,
+		&r.ContactName, &r.ContactPhone, &r.ContactEmail, &r.Status,
+		&r.RejectionReason, &moderatedAt, &r.CreatedAt, &r.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return r, ErrNotFound
+	}
+	r.Skills = []string{}
+	if skills != "" {
+		r.Skills = strings.Split(skills, "\n")
+	}
+	r.Min, r.Max = nullInt64Ptr(salaryMin), nullInt64Ptr(salaryMax)
+	r.Salary = r.SalaryRange.String()
+	if moderatedAt.Valid {
+		r.ModeratedAt = &moderatedAt.Time
+	}
+	return r, err
+}
+
+type MySQLResumeRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLResumeRepository(db *sql.DB) *MySQLResumeRepository {
+	return &MySQLResumeRepository{db: db}
+}
+
+func resumeWhere(filter ResumeFilter) (string, []interface{}) {
+	where := " WHERE 1 = 1"
+	args := []interface{}{}
+
+	if filter.Status != "" {
+		where += " AND r.status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.AuthorID != nil {
+		where += " AND r.user_id = ?"
+		args = append(args, *filter.AuthorID)
+	}
+	if filter.Search != "" {
+		where += ` AND (r.title LIKE ?
+		           OR EXISTS (SELECT 1 FROM resume_skills s WHERE s.resume_id = r.id AND s.skill LIKE ?))`
+		args = append(args, "%"+filter.Search+"%", "%"+filter.Search+"%")
+	}
+	if filter.Skill != "" {
+		where += " AND EXISTS (SELECT 1 FROM resume_skills s WHERE s.resume_id = r.id AND s.skill = ?)"
+		args = append(args, filter.Skill)
+	}
+	if filter.Region != "" {
+		where += " AND r.region LIKE ?"
+		args = append(args, "%"+filter.Region+"%")
+	}
+	if filter.Availability != "" {
+		where += " AND r.availability = ?"
+		args = append(args, filter.Availability)
+	}
+	if filter.ExperienceFrom != nil {
+		where += " AND r.experience_years >= ?"
+		args = append(args, *filter.ExperienceFrom)
+	}
+	if filter.SalaryTo != nil {
+		where += " AND COALESCE(r.salary_min, r.salary_max) <= ?"
+		args = append(args, *filter.SalaryTo)
+	}
+	if filter.SalaryCurrency != "" {
+		where += " AND r.salary_currency = ?"
+		args = append(args, filter.SalaryCurrency)
+	}
+	if filter.SalaryPeriod != "" {
+		where += " AND r.salary_period = ?"
+		args = append(args, filter.SalaryPeriod)
+	}
+
+	return where, args
+}
+
+func (r *MySQLResumeRepository) List(ctx context.Context, filter ResumeFilter, page PageRequest) (Page[Resume], error) {
+	sortName, spec, after, err := resolvePage(page, resumeSorts, "newest")
+	if err != nil {
+		return Page[Resume]{}, err
+	}
+
+	where, args := resumeWhere(filter)
+	result := Page[Resume]{Items: []Resume{}}
+
+	if page.Limit > 0 {
+		countQuery := "SELECT COUNT(*) FROM resumes r" + where
+		if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&result.Total); err != nil {
+			return result, err
+		}
+	}
+
+	query := resumeSelect + where
+	if after != nil {
+		clause, keyArgs := keysetSQL(spec, "r.id", *after)
+		query += clause
+		args = append(args, keyArgs...)
+	}
+	query += orderBySQL(spec, "r.id")
+	query, args = limitSQL(query, args, page, after != nil)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		resume, err := scanResume(rows)
+		if err != nil {
+			return result, err
+		}
+		result.Items = append(result.Items, resume)
+	}
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	hasMore := trimPage(&result, page)
+	result.NextCursor, err = nextCursorFor(result.Items, hasMore, sortName, func(resume Resume) sortKey {
+		return resumeSortKey(resume, sortName)
+	})
+	return result, err
+}
+
+func (r *MySQLResumeRepository) Get(ctx context.Context, id int64) (Resume, error) {
+	return scanResume(r.db.QueryRowContext(ctx, resumeSelect+" WHERE r.id = ?", id))
+}
+
+// replaceSkills заменяет навыки резюме целиком, сохраняя их порядок
+func replaceSkills(ctx context.Context, tx *sql.Tx, resumeID int64, skills []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM resume_skills WHERE resume_id = ?", resumeID); err != nil {
+		return err
+	}
+	for i, skill := range skills {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO resume_skills (resume_id, position, skill) VALUES (?, ?, ?)", resumeID, i, skill,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MySQLResumeRepository) Create(ctx context.Context, in ResumeInput) (Resume, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Resume{}, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO resumes (user_id, title, experience_years, experience, region, availability,
+		                      salary_min, salary_max, salary_currency, salary_period, salary_gross,
+		                      contact_name, contact_phone, contact_email)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		in.UserID, in.Title, in.ExperienceYears, in.Experience, in.Region, in.Availability,
+		in.Min, in.Max, in.Currency, in.Period, in.Gross,
+		in.ContactName, in.ContactPhone, in.ContactEmail,
+	)
+	if err != nil {
+		return Resume{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Resume{}, err
+	}
+	if err := replaceSkills(ctx, tx, id, in.Skills); err != nil {
+		return Resume{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Resume{}, err
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *MySQLResumeRepository) ListForUser(ctx context.Context, userID int64) ([]Resume, error) {
+	rows, err := r.db.QueryContext(ctx, resumeSelect+" WHERE r.user_id = ? ORDER BY r.created_at DESC, r.id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resumes := []Resume{}
+	for rows.Next() {
+		resume, err := scanResume(rows)
+		if err != nil {
+			return nil, err
+		}
+		resumes = append(resumes, resume)
+	}
+	return resumes, rows.Err()
+}
+
+func (r *MySQLResumeRepository) Update(ctx context.Context, id int64, in ResumeInput) (Resume, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Resume{}, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRowContext(ctx, "SELECT status FROM resumes WHERE id = ? FOR UPDATE", id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return Resume{}, ErrNotFound
+	} else if err != nil {
+		return Resume{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE resumes SET title = ?, experience_years = ?, experience = ?, region = ?, availability = ?,
+		       salary_min = ?, salary_max = ?, salary_currency = ?, salary_period = ?, salary_gross = ?,
+		       contact_name = ?, contact_phone = ?, contact_email = ?, status = ?, rejection_reason = NULL
+		WHERE id = ?`,
+		in.Title, in.ExperienceYears, in.Experience, in.Region, in.Availability,
+		in.Min, in.Max, in.Currency, in.Period, in.Gross,
+		in.ContactName, in.ContactPhone, in.ContactEmail, JobStatusPending, id,
+	); err != nil {
+		return Resume{}, err
+	}
+	if err := replaceSkills(ctx, tx, id, in.Skills); err != nil {
+		return Resume{}, err
+	}
+	if status != JobStatusPending {
+		if err := logResumeModeration(ctx, tx, id, in.UserID, status, JobStatusPending, ""); err != nil {
+			return Resume{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Resume{}, err
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *MySQLResumeRepository) SetStatus(ctx context.Context, id int64, from, to, reason string, actorID int64) (Resume, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Resume{}, err
+	}
+	defer tx.Rollback()
+
+	if to != JobStatusRejected {
+		reason = ""
+	}
+	res, err := tx.ExecContext(ctx, `
+		UPDATE resumes SET status = ?, rejection_reason = NULLIF(?, ''), moderated_at = NOW()
+		WHERE id = ? AND status = ?`,
+		to, reason, id, from,
+	)
+	if err != nil {
+		return Resume{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := r.Get(ctx, id); err != nil {
+			return Resume{}, err
+		}
+		return Resume{}, ErrStatusChanged
+	}
+	if err := logResumeModeration(ctx, tx, id, actorID, from, to, reason); err != nil {
+		return Resume{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Resume{}, err
+	}
+	return r.Get(ctx, id)
+}
+
+func logResumeModeration(ctx context.Context, tx *sql.Tx, resumeID, actorID int64, from, to, reason string) error {
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO resume_moderation_log (resume_id, actor_id, from_status, to_status, reason) VALUES (?, ?, ?, ?, NULLIF(?, ''))",
+		resumeID, actorID, from, to, reason,
+	)
+	return err
+}
+
+func (r *MySQLResumeRepository) History(ctx context.Context, resumeID int64) ([]ResumeModerationEntry, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT l.id, l.resume_id, r.title, l.actor_id, COALESCE(u.username, ''),
+		       l.from_status, l.to_status, COALESCE(l.reason, ''), l.created_at
+		FROM resume_moderation_log l
+		JOIN resumes r ON r.id = l.resume_id
+		LEFT JOIN users u ON u.id = l.actor_id
+		WHERE l.resume_id = ?
+		ORDER BY l.created_at DESC, l.id DESC`, resumeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []ResumeModerationEntry{}
+	for rows.Next() {
+		var e ResumeModerationEntry
+		var actorID sql.NullInt64
+		if err := rows.Scan(
+			&e.ID, &e.ResumeID, &e.ResumeTitle, &actorID, &e.ActorName,
+			&e.FromStatus, &e.ToStatus, &e.Reason, &e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		e.ActorID = nullInt64Ptr(actorID)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (r *MySQLResumeRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM resumes WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+const invitationSelect = `
+	SELECT i.id, i.resume_id, r.title, i.job_id, j.title, j.company, i.employer_id, u.username,
+	       i.message, i.created_at
+	FROM resume_invitations i
+	JOIN resumes r ON r.id = i.resume_id
+	JOIN jobs j ON j.id = i.job_id
+	JOIN users u ON u.id = i.employer_id
+`
+
+func scanInvitation(row rowScanner) (ResumeInvitation, error) {
+	var i ResumeInvitation
+	err := row.Scan(
+		&i.ID, &i.ResumeID, &i.ResumeTitle, &i.JobID, &i.JobTitle, &i.Company, &i.EmployerID, &i.EmployerName,
+		&i.Message, &i.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return i, ErrNotFound
+	}
+	return i, err
+}
+
+func (r *MySQLResumeRepository) Invite(ctx context.Context, in InvitationInput) (ResumeInvitation, error) {
+	res, err := r.db.ExecContext(ctx,
+		"INSERT INTO resume_invitations (resume_id, job_id, employer_id, message) VALUES (?, ?, ?, ?)",
+		in.ResumeID, in.JobID, in.EmployerID, in.Message,
+	)
+	if isDuplicateKey(err) {
+		return ResumeInvitation{}, ErrAlreadyInvited
+	} else if err != nil {
+		return ResumeInvitation{}, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return ResumeInvitation{}, err
+	}
+	return scanInvitation(r.db.QueryRowContext(ctx, invitationSelect+" WHERE i.id = ?", id))
+}
+
+func (r *MySQLResumeRepository) Invitations(ctx context.Context, userID int64) ([]ResumeInvitation, error) {
+	rows, err := r.db.QueryContext(ctx,
+		invitationSelect+" WHERE r.user_id = ? ORDER BY i.created_at DESC, i.id DESC", userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []ResumeInvitation{}
+	for rows.Next() {
+		i, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, i)
+	}
+	return invitations, rows.Err()
+}
+
+func (r *MySQLResumeRepository) ContactsVisible(ctx context.Context, resumeID, employerID int64) (bool, error) {
+	var visible bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM resume_invitations WHERE resume_id = ? AND employer_id = ?)
+		    OR EXISTS (SELECT 1 FROM job_applications a
+		               JOIN jobs j ON j.id = a.job_id
+		               JOIN resumes r ON r.user_id = a.user_id
+		               WHERE r.id = ? AND j.user_id = ?)`,
+		resumeID, employerID, resumeID, employerID,
+	).Scan(&visible)
+	return visible, err
+}
+
+// ---------- Categories ----------
+
+const categorySelect = "SELECT id, parent_id, name, slug, sort_order FROM categories"
+
+func scanCategory(row rowScanner) (Category, error) {
+	var c Category
+	var parentID sql.NullInt64
+	err := row.Scan(&c.ID, &parentID, &c.Name, &c.Slug, &c.SortOrder)
+	if err == sql.ErrNoRows {
+		return c, ErrNotFound
+	}
+	c.ParentID = nullInt64Ptr(parentID)
+	return c, err
+}
+
+// isDuplicateKey распознаёт нарушение UNIQUE (ошибка MySQL 1062)
+func isDuplicateKey(err error) bool {
+	var myErr *mysql.MySQLError
+	return errors.As(err, &myErr) && myErr.Number == 1062
+}
+
+type MySQLCategoryRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLCategoryRepository(db *sql.DB) *MySQLCategoryRepository {
+	return &MySQLCategoryRepository{db: db}
+}
+
+func (r *MySQLCategoryRepository) List(ctx context.Context) ([]Category, error) {
+	rows, err := r.db.QueryContext(ctx, categorySelect+" ORDER BY sort_order, name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []Category{}
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+func (r *MySQLCategoryRepository) Get(ctx context.Context, id int64) (Category, error) {
+	return scanCategory(r.db.QueryRowContext(ctx, categorySelect+" WHERE id = ?", id))
+}
+
+func (r *MySQLCategoryRepository) Create(ctx context.Context, in CategoryInput) (Category, error) {
+	res, err := r.db.ExecContext(ctx,
+		"INSERT INTO categories (parent_id, name, slug, sort_order) VALUES (?, ?, ?, ?)",
+		in.ParentID, in.Name, in.Slug, in.SortOrder,
+	)
+	if isDuplicateKey(err) {
+		return Category{}, ErrDuplicateSlug
+	} else if err != nil {
+		return Category{}, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Category{}, err
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *MySQLCategoryRepository) Update(ctx context.Context, id int64, in CategoryInput) (Category, error) {
+	if _, err := r.Get(ctx, id); err != nil {
+		return Category{}, err
+	}
+
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE categories SET parent_id = ?, name = ?, slug = ?, sort_order = ? WHERE id = ?",
+		in.ParentID, in.Name, in.Slug, in.SortOrder, id,
+	)
+	if isDuplicateKey(err) {
+		return Category{}, ErrDuplicateSlug
+	} else if err != nil {
+		return Category{}, err
+	}
+
+	return r.Get(ctx, id)
+}
+
+func (r *MySQLCategoryRepository) Merge(ctx context.Context, from, to int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// блокируем обе категории, чтобы их не удалили параллельно
+	var found int
+	if err := tx.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM categories WHERE id IN (?, ?) FOR UPDATE", from, to,
+	).Scan(&found); err != nil {
+		return err
+	}
+	if found != 2 {
+		return ErrNotFound
+	}
+
+	for _, query := range []string{
+		"UPDATE products SET category_id = ? WHERE category_id = ?",
+		"UPDATE jobs SET category_id = ? WHERE category_id = ?",
+		"UPDATE categories SET parent_id = ? WHERE parent_id = ?",
+	} {
+		if _, err := tx.ExecContext(ctx, query, to, from); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM categories WHERE id = ?", from); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *MySQLCategoryRepository) Delete(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := scanCategory(tx.QueryRowContext(ctx, categorySelect+" WHERE id = ? FOR UPDATE", id)); err != nil {
+		return err
+	}
+
+	var inUse bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM products WHERE category_id = ?)
+		    OR EXISTS(SELECT 1 FROM jobs WHERE category_id = ?)
+		    OR EXISTS(SELECT 1 FROM categories WHERE parent_id = ?)`,
+		id, id, id,
+	).Scan(&inUse); err != nil {
+		return err
+	}
+	if inUse {
+		return ErrCategoryInUse
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM categories WHERE id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ---------- Users ----------
+
+const userSelect = "SELECT id, username, email, role, email_verified_at IS NOT NULL, created_at FROM users"
+
+func scanUser(row rowScanner) (User, error) {
+	var u User
+	err := row.Scan(&u.ID, &u.Username, &u.Email, &u.Role, &u.EmailVerified, &u.CreatedAt)
+	if err == sql.ErrNoRows {
+		return u, ErrNotFound
+	}
+	return u, err
+}
+
+type MySQLUserRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLUserRepository(db *sql.DB) *MySQLUserRepository {
+	return &MySQLUserRepository{db: db}
+}
+
+func (r *MySQLUserRepository) Create(ctx context.Context, username, email, passwordHash, role string) (User, error) {
+	res, err := r.db.ExecContext(ctx,
+		"INSERT INTO users (username, password, email, role) VALUES (?, ?, ?, ?)",
+		username, passwordHash, email, role,
+	)
+	if err != nil {
+		return User{}, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return User{}, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *MySQLUserRepository) GetByID(ctx context.Context, id int64) (User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, userSelect+" WHERE id = ?", id))
+}
+
+func (r *MySQLUserRepository) GetByEmail(ctx context.Context, email string) (User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, userSelect+" WHERE email = ?", email))
+}
+
+func (r *MySQLUserRepository) GetByUsername(ctx context.Context, username string) (User, string, error) {
+	var u User
+	var hashed string
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, username, email, role, email_verified_at IS NOT NULL, password, created_at FROM users WHERE username = ?",
+		username,
+	).Scan(&u.ID, &u.Username, &u.Email, &u.Role, &u.EmailVerified, &hashed, &u.CreatedAt)
+	if err == sql.ErrNoRows {
+		return u, "", ErrNotFound
+	}
+	return u, hashed, err
+}
+
+func (r *MySQLUserRepository) ExistsByUsernameOrEmail(ctx context.Context, username, email string) (bool, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM users WHERE username = ? OR email = ?",
+		username, email,
+	).Scan(&count)
+	return count > 0, err
+}
+
+func (r *MySQLUserRepository) SetRole(ctx context.Context, id int64, role string) (User, error) {
+	if _, err := r.db.ExecContext(ctx, "UPDATE users SET role = ?, role_assigned = true WHERE id = ?", role, id); err != nil {
+		return User{}, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *MySQLUserRepository) SwitchRole(ctx context.Context, id int64, from, to string) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE users SET role = ? WHERE id = ? AND role = ? AND NOT role_assigned",
+		to, id, from,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *MySQLUserRepository) SetPassword(ctx context.Context, id int64, passwordHash string) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE users SET password = ? WHERE id = ?", passwordHash, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MySQLUserRepository) MarkEmailVerified(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE users SET email_verified_at = COALESCE(email_verified_at, ?) WHERE id = ?",
+		time.Now(), id,
+	)
+	return err
+}
+
+func (r *MySQLUserRepository) PasswordHash(ctx context.Context, id int64) (string, error) {
+	var hashed string
+	err := r.db.QueryRowContext(ctx, "SELECT password FROM users WHERE id = ?", id).Scan(&hashed)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return hashed, err
+}
+
+func (r *MySQLUserRepository) GetProfile(ctx context.Context, id int64) (Profile, error) {
+	var p Profile
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, username, email, role, email_verified_at IS NOT NULL, created_at, COALESCE(full_name, ''), COALESCE(phone, '') FROM users WHERE id = ?",
+		id,
+	).Scan(&p.ID, &p.Username, &p.Email, &p.Role, &p.EmailVerified, &p.CreatedAt, &p.FullName, &p.Phone)
+	if err == sql.ErrNoRows {
+		return p, ErrNotFound
+	} else if err != nil {
+		return p, err
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, label, address, is_default FROM user_addresses WHERE user_id = ? ORDER BY sort_order, id",
+		id,
+	)
+	if err != nil {
+		return p, err
+	}
+	defer rows.Close()
+
+	p.Addresses = []Address{}
+	for rows.Next() {
+		var a Address
+		if err := rows.Scan(&a.ID, &a.Label, &a.Address, &a.IsDefault); err != nil {
+			return p, err
+		}
+		p.Addresses = append(p.Addresses, a)
+	}
+	return p, rows.Err()
+}
+
+func (r *MySQLUserRepository) UpdateProfile(ctx context.Context, id int64, in ProfileInput) (Profile, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Profile{}, err
+	}
+	defer tx.Rollback()
+
+	// MySQL выполняет присваивания слева направо, поэтому email_verified_at сравнивается со старым email
+	res, err := tx.ExecContext(ctx, `
+		UPDATE users SET
+			email_verified_at = IF(email = ?, email_verified_at, NULL),
+			username = ?, email = ?, full_name = NULLIF(?, ''), phone = NULLIF(?, '')
+		WHERE id = ?`,
+		in.Email, in.Username, in.Email, in.FullName, in.Phone, id,
+	)
+	if isDuplicateKey(err) {
+		return Profile{}, ErrUserExists
+	} else if err != nil {
+		return Profile{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		// RowsAffected равен 0 и тогда, когда ничего не изменилось
+		var exists bool
+		if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)", id).Scan(&exists); err != nil {
+			return Profile{}, err
+		}
+		if !exists {
+			return Profile{}, ErrNotFound
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_addresses WHERE user_id = ?", id); err != nil {
+		return Profile{}, err
+	}
+	for i, a := range in.Addresses {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO user_addresses (user_id, label, address, is_default, sort_order) VALUES (?, ?, ?, ?, ?)",
+			id, a.Label, a.Address, a.IsDefault, i,
+		); err != nil {
+			return Profile{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Profile{}, err
+	}
+	return r.GetProfile(ctx, id)
+}
+
+// Delete полагается на внешние ключи: сессии, токены, корзина и адреса удаляются каскадно,
+// у вакансий и заказов user_id становится NULL
+func (r *MySQLUserRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ---------- User tokens ----------
+
+type MySQLUserTokenRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLUserTokenRepository(db *sql.DB) *MySQLUserTokenRepository {
+	return &MySQLUserTokenRepository{db: db}
+}
+
+func (r *MySQLUserTokenRepository) Create(ctx context.Context, userID int64, purpose, tokenHash string, expiresAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE user_tokens SET used_at = ? WHERE user_id = ? AND purpose = ? AND used_at IS NULL",
+		now, userID, purpose,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at) VALUES (?, ?, ?, ?)",
+		userID, purpose, tokenHash, expiresAt,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *MySQLUserTokenRepository) Lookup(ctx context.Context, purpose, tokenHash string) (int64, error) {
+	var userID int64
+	err := r.db.QueryRowContext(ctx,
+		"SELECT user_id FROM user_tokens WHERE token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?",
+		tokenHash, purpose, time.Now(),
+	).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, ErrInvalidToken
+	}
+	return userID, err
+}
+
+func (r *MySQLUserTokenRepository) Consume(ctx context.Context, purpose, tokenHash string) (int64, error) {
+	// условие в UPDATE делает погашение атомарным: из двух одновременных запросов пройдёт один
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE user_tokens SET used_at = ?
+		WHERE token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?`,
+		time.Now(), tokenHash, purpose, time.Now(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, ErrInvalidToken
+	}
+
+	var userID int64
+	err = r.db.QueryRowContext(ctx, "SELECT user_id FROM user_tokens WHERE token_hash = ?", tokenHash).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, ErrInvalidToken
+	}
+	return userID, err
+}
+
+func (r *MySQLUserTokenRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM user_tokens WHERE expires_at < ?", now)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// ---------- Login throttle ----------
+
+type MySQLLoginThrottleRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLLoginThrottleRepository(db *sql.DB) *MySQLLoginThrottleRepository {
+	return &MySQLLoginThrottleRepository{db: db}
+}
+
+func scanLoginThrottle(row rowScanner) (LoginThrottle, error) {
+	var t LoginThrottle
+	var lockedUntil sql.NullTime
+	err := row.Scan(&t.Failures, &t.LastFailureAt, &lockedUntil)
+	if err == sql.ErrNoRows {
+		return LoginThrottle{}, nil
+	}
+	if lockedUntil.Valid {
+		t.LockedUntil = &lockedUntil.Time
+	}
+	return t, err
+}
+
+func (r *MySQLLoginThrottleRepository) Get(ctx context.Context, scope, subject string) (LoginThrottle, error) {
+	return scanLoginThrottle(r.db.QueryRowContext(ctx,
+		"SELECT failures, last_failure_at, locked_until FROM login_throttle WHERE scope = ? AND subject = ?",
+		scope, subject,
+	))
+}
+
+func (r *MySQLLoginThrottleRepository) RecordFailure(ctx context.Context, scope, subject string, policy LoginPolicy, now time.Time) (LoginThrottle, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return LoginThrottle{}, err
+	}
+	defer tx.Rollback()
+
+	// строка создаётся заранее, чтобы FOR UPDATE было что блокировать
+	if _, err := tx.ExecContext(ctx,
+		"INSERT IGNORE INTO login_throttle (scope, subject, failures, last_failure_at) VALUES (?, ?, 0, ?)",
+		scope, subject, now,
+	); err != nil {
+		return LoginThrottle{}, err
+	}
+	t, err := scanLoginThrottle(tx.QueryRowContext(ctx,
+		"SELECT failures, last_failure_at, locked_until FROM login_throttle WHERE scope = ? AND subject = ? FOR UPDATE",
+		scope, subject,
+	))
+	if err != nil {
+		return LoginThrottle{}, err
+	}
+
+	t = nextLoginThrottle(t, policy, now)
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE login_throttle SET failures = ?, last_failure_at = ?, locked_until = ? WHERE scope = ? AND subject = ?",
+		t.Failures, t.LastFailureAt, t.LockedUntil, scope, subject,
+	); err != nil {
+		return LoginThrottle{}, err
+	}
+	return t, tx.Commit()
+}
+
+func (r *MySQLLoginThrottleRepository) Reset(ctx context.Context, scope, subject string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM login_throttle WHERE scope = ? AND subject = ?", scope, subject)
+	return err
+}
+
+func (r *MySQLLoginThrottleRepository) AddLockout(ctx context.Context, l LoginLockout) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO login_lockouts (scope, subject, user_id, ip, failures, locked_until) VALUES (?, ?, ?, ?, ?, ?)",
+		l.Scope, l.Subject, l.UserID, l.IP, l.Failures, l.LockedUntil,
+	)
+	return err
+}
+
+func (r *MySQLLoginThrottleRepository) ListLockouts(ctx context.Context, limit int) ([]LoginLockout, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, scope, subject, user_id, ip, failures, locked_until, created_at
+		FROM login_lockouts
+		ORDER BY id DESC
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lockouts := []LoginLockout{}
+	for rows.Next() {
+		var l LoginLockout
+		var userID sql.NullInt64
+		if err := rows.Scan(&l.ID, &l.Scope, &l.Subject, &userID, &l.IP, &l.Failures, &l.LockedUntil, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		l.UserID = nullInt64Ptr(userID)
+		lockouts = append(lockouts, l)
+	}
+	return lockouts, rows.Err()
+}
+
+func (r *MySQLLoginThrottleRepository) DeleteStale(ctx context.Context, before, now time.Time) (int, error) {
+	res, err := r.db.ExecContext(ctx,
+		"DELETE FROM login_throttle WHERE last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)",
+		before, now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// ---------- Two-factor ----------
+
+type MySQLTwoFactorRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLTwoFactorRepository(db *sql.DB) *MySQLTwoFactorRepository {
+	return &MySQLTwoFactorRepository{db: db}
+}
+
+func (r *MySQLTwoFactorRepository) Get(ctx context.Context, userID int64) (TOTPState, error) {
+	var t TOTPState
+	err := r.db.QueryRowContext(ctx,
+		"SELECT secret, enabled_at IS NOT NULL, last_step FROM user_totp WHERE user_id = ?", userID,
+	).Scan(&t.Secret, &t.Enabled, &t.LastStep)
+	if err == sql.ErrNoRows {
+		return t, ErrNotFound
+	}
+	return t, err
+}
+
+func (r *MySQLTwoFactorRepository) SetPending(ctx context.Context, userID int64, secret string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_totp (user_id, secret) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE secret = VALUES(secret), enabled_at = NULL, last_step = 0`,
+		userID, secret,
+	)
+	return err
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int64, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO user_recovery_codes (user_id, code_hash) VALUES (?, ?)", userID, hash,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MySQLTwoFactorRepository) Enable(ctx context.Context, userID int64, step int64, codeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE user_totp SET enabled_at = ?, last_step = ? WHERE user_id = ?",
+		time.Now(), step, userID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *MySQLTwoFactorRepository) UseStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE user_totp SET last_step = ? WHERE user_id = ? AND last_step < ?",
+		step, userID, step,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *MySQLTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *MySQLTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE user_recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL",
+		time.Now(), userID, codeHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *MySQLTwoFactorRepository) RecoveryCodesLeft(ctx context.Context, userID int64) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = ? AND used_at IS NULL", userID,
+	).Scan(&n)
+	return n, err
+}
+
+func (r *MySQLTwoFactorRepository) Disable(ctx context.Context, userID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_totp WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ---------- Identities ----------
+
+type MySQLIdentityRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLIdentityRepository(db *sql.DB) *MySQLIdentityRepository {
+	return &MySQLIdentityRepository{db: db}
+}
+
+func (r *MySQLIdentityRepository) FindUser(ctx context.Context, provider, subject string) (int64, error) {
+	var userID int64
+	err := r.db.QueryRowContext(ctx,
+		"SELECT user_id FROM user_identities WHERE provider = ? AND subject = ?", provider, subject,
+	).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	return userID, err
+}
+
+func (r *MySQLIdentityRepository) Link(ctx context.Context, userID int64, in Identity) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO user_identities (user_id, provider, subject, email) VALUES (?, ?, ?, NULLIF(?, ''))",
+		userID, in.Provider, in.Subject, in.Email,
+	)
+	if isDuplicateKey(err) {
+		return ErrIdentityLinked
+	}
+	return err
+}
+
+func (r *MySQLIdentityRepository) ListForUser(ctx context.Context, userID int64) ([]Identity, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT provider, subject, COALESCE(email, ''), created_at FROM user_identities WHERE user_id = ? ORDER BY created_at, id",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []Identity{}
+	for rows.Next() {
+		var i Identity
+		if err := rows.Scan(&i.Provider, &i.Subject, &i.Email, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, i)
+	}
+	return identities, rows.Err()
+}
+
+func (r *MySQLIdentityRepository) Unlink(ctx context.Context, userID int64, provider string) error {
+	res, err := r.db.ExecContext(ctx,
+		"DELETE FROM user_identities WHERE user_id = ? AND provider = ?", userID, provider,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MySQLIdentityRepository) SaveState(ctx context.Context, stateHash string, st OAuthState) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO oauth_states (state_hash, provider, code_verifier, nonce, user_id, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+		stateHash, st.Provider, st.CodeVerifier, st.Nonce, st.UserID, st.ExpiresAt,
+	)
+	return err
+}
+
+func (r *MySQLIdentityRepository) TakeState(ctx context.Context, stateHash string) (OAuthState, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return OAuthState{}, err
+	}
+	defer tx.Rollback()
+
+	var st OAuthState
+	var userID sql.NullInt64
+	err = tx.QueryRowContext(ctx,
+		"SELECT provider, code_verifier, nonce, user_id, expires_at FROM oauth_states WHERE state_hash = ? FOR UPDATE",
+		stateHash,
+	).Scan(&st.Provider, &st.CodeVerifier, &st.Nonce, &userID, &st.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return st, ErrInvalidToken
+	} else if err != nil {
+		return st, err
+	}
+	st.UserID = nullInt64Ptr(userID)
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM oauth_states WHERE state_hash = ?", stateHash); err != nil {
+		return st, err
+	}
+	if err := tx.Commit(); err != nil {
+		return st, err
+	}
+	if !st.ExpiresAt.After(time.Now()) {
+		return st, ErrInvalidToken
+	}
+	return st, nil
+}
+
+func (r *MySQLIdentityRepository) DeleteExpiredStates(ctx context.Context, now time.Time) (int, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM oauth_states WHERE expires_at <= ?", now)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// ---------- Roles ----------
+
+type MySQLRoleRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLRoleRepository(db *sql.DB) *MySQLRoleRepository {
+	return &MySQLRoleRepository{db: db}
+}
+
+func (r *MySQLRoleRepository) list(ctx context.Context, where string, args ...interface{}) ([]Role, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT r.name, r.title, rp.permission
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role = r.name`+where+`
+		ORDER BY r.name, rp.permission`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []Role{}
+	for rows.Next() {
+		var name, title string
+		var perm sql.NullString
+		if err := rows.Scan(&name, &title, &perm); err != nil {
+			return nil, err
+		}
+		if len(roles) == 0 || roles[len(roles)-1].Name != name {
+			roles = append(roles, Role{Name: name, Title: title, Permissions: []string{}})
+		}
+		if perm.Valid {
+			last := &roles[len(roles)-1]
+			last.Permissions = append(last.Permissions, perm.String)
+		}
+	}
+	return roles, rows.Err()
+}
+
+func (r *MySQLRoleRepository) List(ctx context.Context) ([]Role, error) {
+	return r.list(ctx, "")
+}
+
+func (r *MySQLRoleRepository) Get(ctx context.Context, name string) (Role, error) {
+	roles, err := r.list(ctx, " WHERE r.name = ?", name)
+	if err != nil {
+		return Role{}, err
+	}
+	if len(roles) == 0 {
+		return Role{}, ErrNotFound
+	}
+	return roles[0], nil
+}
+
+func (r *MySQLRoleRepository) UserPermissions(ctx context.Context, userID int64) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT rp.permission
+		FROM users u
+		JOIN role_permissions rp ON rp.role = u.role
+		WHERE u.id = ?
+		ORDER BY rp.permission`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	perms := []string{}
+	for rows.Next() {
+		var perm string
+		if err := rows.Scan(&perm); err != nil {
+			return nil, err
+		}
+		perms = append(perms, perm)
+	}
+	return perms, rows.Err()
+}
+
+func (r *MySQLRoleRepository) CountUsersWithPermission(ctx context.Context, perm string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM users u
+		JOIN role_permissions rp ON rp.role = u.role
+		WHERE rp.permission = ?`, perm).Scan(&count)
+	return count, err
+}
+
+// ---------- Sessions ----------
+
+const sessionSelect = `
+	SELECT id, user_id, user_agent, ip, created_at, last_used_at, expires_at, revoked_at
+	FROM auth_sessions`
+
+func scanSession(row rowScanner) (Session, error) {
+	var s Session
+	var revokedAt sql.NullTime
+	err := row.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		return s, ErrNotFound
+	}
+	if revokedAt.Valid {
+		s.RevokedAt = &revokedAt.Time
+	}
+	return s, err
+}
+
+type MySQLSessionRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLSessionRepository(db *sql.DB) *MySQLSessionRepository {
+	return &MySQLSessionRepository{db: db}
+}
+
+func (r *MySQLSessionRepository) Create(ctx context.Context, in SessionInput) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO auth_sessions (id, user_id, user_agent, ip, expires_at) VALUES (?, ?, ?, ?, ?)",
+		in.ID, in.UserID, in.UserAgent, in.IP, in.ExpiresAt,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO refresh_tokens (session_id, token_hash, expires_at) VALUES (?, ?, ?)",
+		in.ID, in.TokenHash, in.ExpiresAt,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *MySQLSessionRepository) Rotate(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (Session, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Session{}, err
+	}
+	defer tx.Rollback()
+
+	var tokenID int64
+	var sessionID string
+	var tokenExpires time.Time
+	var usedAt sql.NullTime
+	err = tx.QueryRowContext(ctx,
+		"SELECT id, session_id, expires_at, used_at FROM refresh_tokens WHERE token_hash = ? FOR UPDATE",
+		oldHash,
+	).Scan(&tokenID, &sessionID, &tokenExpires, &usedAt)
+	if err == sql.ErrNoRows {
+		return Session{}, ErrInvalidRefreshToken
+	} else if err != nil {
+		return Session{}, err
+	}
+
+	session, err := scanSession(tx.QueryRowContext(ctx, sessionSelect+" WHERE id = ? FOR UPDATE", sessionID))
+	if errors.Is(err, ErrNotFound) {
+		return Session{}, ErrInvalidRefreshToken
+	} else if err != nil {
+		return Session{}, err
+	}
+
+	now := time.Now()
+	if session.RevokedAt != nil || !session.ExpiresAt.After(now) {
+		return session, ErrInvalidRefreshToken
+	}
+
+	// токен уже обменивали — им воспользовался кто-то ещё, закрываем сессию
+	if usedAt.Valid {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE auth_sessions SET revoked_at = ? WHERE id = ?", now, session.ID,
+		); err != nil {
+			return Session{}, err
+		}
+		if err := tx.Commit(); err != nil {
+			return Session{}, err
+		}
+		session.RevokedAt = &now
+		return session, ErrRefreshTokenReused
+	}
+
+	if !tokenExpires.After(now) {
+		return session, ErrInvalidRefreshToken
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE refresh_tokens SET used_at = ? WHERE id = ?", now, tokenID,
+	); err != nil {
+		return Session{}, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO refresh_tokens (session_id, token_hash, expires_at) VALUES (?, ?, ?)",
+		session.ID, newHash, expiresAt,
+	); err != nil {
+		return Session{}, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE auth_sessions SET last_used_at = ?, expires_at = ? WHERE id = ?", now, expiresAt, session.ID,
+	); err != nil {
+		return Session{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Session{}, err
+	}
+
+	session.LastUsedAt = now
+	session.ExpiresAt = expiresAt
+	return session, nil
+}
+
+func (r *MySQLSessionRepository) IsActive(ctx context.Context, sessionID string) (bool, error) {
+	var active bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM auth_sessions WHERE id = ? AND revoked_at IS NULL AND expires_at > ?)",
+		sessionID, time.Now(),
+	).Scan(&active)
+	return active, err
+}
+
+func (r *MySQLSessionRepository) Revoke(ctx context.Context, sessionID string) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE auth_sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL",
+		time.Now(), sessionID,
+	)
+	return err
+}
+
+func (r *MySQLSessionRepository) RevokeAllForUser(ctx context.Context, userID int64) (int, error) {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE auth_sessions SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL",
+		time.Now(), userID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (r *MySQLSessionRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	if _, err := r.db.ExecContext(ctx,
+		"DELETE FROM refresh_tokens WHERE expires_at < ?", now,
+	); err != nil {
+		return 0, err
+	}
+	res, err := r.db.ExecContext(ctx,
+		"DELETE FROM auth_sessions WHERE expires_at < ? OR revoked_at < ?",
+		now, now.Add(-revokedSessionRetention),
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,719 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Когда соискатель готов выйти на объект
+const (
+	ResumeAvailableImmediately = "immediately"
+	ResumeAvailableTwoWeeks    = "two_weeks"
+	ResumeAvailableMonth       = "month"
+)
+
+var resumeAvailability = []string{ResumeAvailableImmediately, ResumeAvailableTwoWeeks, ResumeAvailableMonth}
+
+const (
+	maxResumeTitleLength  = 100
+	maxResumeSkills       = 20
+	maxResumeSkillLength  = 50
+	maxResumeExperience   = 5000
+	maxExperienceYears    = 60
+	maxRegionLength       = 100
+	maxInvitationMessage  = 2000
+	maxContactNameLength  = 150
+	maxContactEmailLength = 100
+)
+
+// Resume — резюме соискателя. Резюме проходят ту же модерацию, что и вакансии:
+// статусы и допустимые переходы общие (см. jobModerationTransitions).
+// Контакты работодатель видит, только если соискатель откликался на его вакансию
+// или работодатель пригласил его по этому резюме (см. ResumeRepository.ContactsVisible).
+type Resume struct {
+	ID              int64    `json:"id"`
+	UserID          int64    `json:"user_id"`
+	Username        string   `json:"username,omitempty"`
+	Title           string   `json:"title"` // желаемая должность: «Электрик», «Штукатур-маляр»
+	Skills          []string `json:"skills"`
+	ExperienceYears int      `json:"experience_years"`
+	Experience      string   `json:"experience"`
+	Region          string   `json:"region"`
+	Availability    string   `json:"availability"`
+	Salary          string   `json:"salary"` // строка для показа, собирается из SalaryRange
+	ContactName     string   `json:"contact_name,omitempty"`
+	ContactPhone    string   `json:"contact_phone,omitempty"`
+	ContactEmail    string   `json:"contact_email,omitempty"`
+	ContactsHidden  bool     `json:"contacts_hidden"`
+	Status          string   `json:"status"`
+	// RejectionReason заполнен только у отклонённых резюме
+	RejectionReason string     `json:"rejection_reason,omitempty"`
+	ModeratedAt     *time.Time `json:"moderated_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	SalaryRange
+}
+
+// withoutContacts скрывает контакты и логин соискателя от работодателя, с которым он ещё не на связи
+func (r Resume) withoutContacts() Resume {
+	r.Username, r.ContactName, r.ContactPhone, r.ContactEmail = "", "", "", ""
+	r.ContactsHidden = true
+	return r
+}
+
+// ResumeInvitation — приглашение работодателя на вакансию по резюме
+type ResumeInvitation struct {
+	ID           int64     `json:"id"`
+	ResumeID     int64     `json:"resume_id"`
+	ResumeTitle  string    `json:"resume_title"`
+	JobID        int64     `json:"job_id"`
+	JobTitle     string    `json:"job_title"`
+	Company      string    `json:"company"`
+	EmployerID   int64     `json:"employer_id"`
+	EmployerName string    `json:"employer_name"`
+	Message      string    `json:"message"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ResumeModerationEntry — запись журнала модерации резюме, как ModerationEntry у вакансий
+type ResumeModerationEntry struct {
+	ID          int64     `json:"id"`
+	ResumeID    int64     `json:"resume_id"`
+	ResumeTitle string    `json:"resume_title"`
+	ActorID     *int64    `json:"actor_id"`
+	ActorName   string    `json:"actor_name"`
+	FromStatus  string    `json:"from_status"`
+	ToStatus    string    `json:"to_status"`
+	Reason      string    `json:"reason,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// normalizeSkills убирает лишние пробелы, пустые строки и повторы без учёта регистра
+func normalizeSkills(skills []string) []string {
+	result := []string{}
+	for _, skill := range skills {
+		skill = strings.Join(strings.Fields(skill), " ")
+		if skill == "" || slices.ContainsFunc(result, func(s string) bool { return strings.EqualFold(s, skill) }) {
+			continue
+		}
+		result = append(result, skill)
+	}
+	return result
+}
+
+// bindResumeInput читает и проверяет резюме соискателя userID из запроса на создание или изменение.
+// false — клиенту уже ответили ошибкой.
+func bindResumeInput(c *gin.Context, userID int64) (ResumeInput, bool) {
+	var req struct {
+		Title           string   `json:"title"`
+		Skills          []string `json:"skills"`
+		ExperienceYears int      `json:"experience_years"`
+		Experience      string   `json:"experience"`
+		Region          string   `json:"region"`
+		Availability    string   `json:"availability"`
+		ContactName     string   `json:"contact_name"`
+		ContactPhone    string   `json:"contact_phone"`
+		ContactEmail    string   `json:"contact_email"`
+		SalaryRange
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return ResumeInput{}, false
+	}
+
+	in := ResumeInput{
+		UserID:          userID,
+		Title:           strings.Join(strings.Fields(req.Title), " "),
+		Skills:          normalizeSkills(req.Skills),
+		ExperienceYears: req.ExperienceYears,
+		Experience:      strings.TrimSpace(req.Experience),
+		Region:          strings.Join(strings.Fields(req.Region), " "),
+		Availability:    req.Availability,
+		ContactName:     strings.TrimSpace(req.ContactName),
+		ContactPhone:    strings.TrimSpace(req.ContactPhone),
+		ContactEmail:    strings.TrimSpace(req.ContactEmail),
+		SalaryRange:     req.SalaryRange,
+	}
+	if in.Availability == "" {
+		in.Availability = ResumeAvailableImmediately
+	}
+
+	var msg string
+	switch {
+	case in.Title == "" || in.Region == "" || len(in.Skills) == 0:
+		msg = "Укажите должность, регион и хотя бы один навык"
+	case utf8.RuneCountInString(in.Title) > maxResumeTitleLength:
+		msg = "Должность не должна быть длиннее 100 символов"
+	case utf8.RuneCountInString(in.Region) > maxRegionLength:
+		msg = "Регион не должен быть длиннее 100 символов"
+	case len(in.Skills) > maxResumeSkills:
+		msg = "Укажите не больше 20 навыков"
+	case slices.ContainsFunc(in.Skills, func(s string) bool { return utf8.RuneCountInString(s) > maxResumeSkillLength }):
+		msg = "Навык не должен быть длиннее 50 символов"
+	case in.ExperienceYears < 0 || in.ExperienceYears > maxExperienceYears:
+		msg = "Неверно указан стаж"
+	case utf8.RuneCountInString(in.Experience) > maxResumeExperience:
+		msg = "Описание опыта не должно быть длиннее 5000 символов"
+	case !slices.Contains(resumeAvailability, in.Availability):
+		msg = "Неизвестный срок выхода на работу"
+	case in.SalaryRange.normalize() != nil:
+		msg = "Неверно указана зарплата"
+	case in.ContactName == "" || utf8.RuneCountInString(in.ContactName) > maxContactNameLength:
+		msg = "Укажите имя"
+	case in.ContactPhone == "" && in.ContactEmail == "":
+		msg = "Укажите телефон или email для связи"
+	case in.ContactPhone != "" && !phonePattern.MatchString(in.ContactPhone):
+		msg = "Неверный формат телефона"
+	case in.ContactEmail != "" && (!validEmail(in.ContactEmail) || len(in.ContactEmail) > maxContactEmailLength):
+		msg = "Неверный формат email"
+	}
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": msg})
+		return ResumeInput{}, false
+	}
+	return in, true
+}
+
+// ownResume находит резюме из :id и проверяет, что оно принадлежит пользователю.
+// false — клиенту уже ответили ошибкой.
+func (s *Server) ownResume(c *gin.Context, userID int64) (Resume, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return Resume{}, false
+	}
+
+	resume, err := s.resumes.Get(c.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Резюме не найдено"})
+		return resume, false
+	} else if err != nil {
+		log.Println("Get resume error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return resume, false
+	}
+	if resume.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Недостаточно прав"})
+		return resume, false
+	}
+	return resume, true
+}
+
+func (s *Server) createResumeHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	// как и вакансии, резюме публикуют только владельцы подтверждённых адресов
+	ctx := c.Request.Context()
+	user, err := s.users.GetByID(ctx, claims.ID)
+	if err != nil {
+		log.Println("Create resume error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if !user.EmailVerified {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Подтвердите email, чтобы размещать резюме"})
+		return
+	}
+
+	in, ok := bindResumeInput(c, claims.ID)
+	if !ok {
+		return
+	}
+
+	resume, err := s.resumes.Create(ctx, in)
+	if err != nil {
+		log.Println("Create resume error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resume)
+}
+
+// getMyResumesHandler — резюме соискателя, в том числе ожидающие модерации
+func (s *Server) getMyResumesHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	resumes, err := s.resumes.ListForUser(c.Request.Context(), claims.ID)
+	if err != nil {
+		log.Println("Get my resumes error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resumes)
+}
+
+// updateResumeHandler — соискатель правит резюме; изменённое резюме заново проходит
+// модерацию и до одобрения пропадает из поиска
+func (s *Server) updateResumeHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	resume, ok := s.ownResume(c, claims.ID)
+	if !ok {
+		return
+	}
+	if resume.Status == JobStatusArchived {
+		c.JSON(http.StatusConflict, gin.H{"message": "Резюме в архиве, его нельзя изменить"})
+		return
+	}
+	in, ok := bindResumeInput(c, claims.ID)
+	if !ok {
+		return
+	}
+
+	resume, err := s.resumes.Update(c.Request.Context(), resume.ID, in)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Резюме не найдено"})
+		return
+	} else if err != nil {
+		log.Println("Update resume error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resume)
+}
+
+// deleteResumeHandler — соискатель удаляет резюме вместе с приглашениями по нему
+func (s *Server) deleteResumeHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	resume, ok := s.ownResume(c, claims.ID)
+	if !ok {
+		return
+	}
+
+	err := s.resumes.Delete(c.Request.Context(), resume.ID)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Резюме не найдено"})
+		return
+	} else if err != nil {
+		log.Println("Delete resume error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Резюме удалено"})
+}
+
+// getResumesHandler — поиск мастеров для работодателей: только одобренные резюме.
+// Контакты в выдаче скрыты у всех, открываются на странице резюме (см. getResumeHandler).
+func (s *Server) getResumesHandler(c *gin.Context) {
+	filter := ResumeFilter{
+		Search:         strings.TrimSpace(c.Query("search")),
+		Skill:          strings.Join(strings.Fields(c.Query("skill")), " "),
+		Region:         strings.TrimSpace(c.Query("region")),
+		Availability:   c.Query("availability"),
+		Status:         JobStatusApproved,
+		SalaryCurrency: strings.ToUpper(c.Query("salary_currency")),
+		SalaryPeriod:   c.Query("salary_period"),
+	}
+	if filter.Availability != "" && !slices.Contains(resumeAvailability, filter.Availability) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неизвестный срок выхода на работу"})
+		return
+	}
+	if v := c.Query("experience_from"); v != "" {
+		years, err := strconv.Atoi(v)
+		if err != nil || years < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Неверно указан стаж"})
+			return
+		}
+		filter.ExperienceFrom = &years
+	}
+	var err error
+	if filter.SalaryTo, err = parseSalaryParam(c.Query("salary_to")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный диапазон зарплаты"})
+		return
+	}
+
+	page, paginated, err := parsePageRequest(c, resumeSorts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": pageErrorMessage(err)})
+		return
+	}
+	if salaryUnitsMissing(filter.SalaryCurrency, filter.SalaryPeriod, nil, filter.SalaryTo, page.Sort) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": salaryUnitsMessage})
+		return
+	}
+
+	result, err := s.resumes.List(c.Request.Context(), filter, page)
+	if errors.Is(err, errInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": pageErrorMessage(err)})
+		return
+	} else if err != nil {
+		log.Println("Get resumes error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	for i := range result.Items {
+		result.Items[i] = result.Items[i].withoutContacts()
+	}
+
+	if !paginated {
+		c.JSON(http.StatusOK, result.Items)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// getResumeHandler — резюме целиком. Соискатель видит своё резюме в любом статусе,
+// модератор — любое резюме, работодатель — одобренное. Контакты открываются работодателю,
+// только если соискатель откликался на его вакансию или уже получил от него приглашение.
+func (s *Server) getResumeHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	resume, err := s.resumes.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Резюме не найдено"})
+		return
+	} else if err != nil {
+		log.Println("Get resume error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if resume.UserID == claims.ID {
+		c.JSON(http.StatusOK, resume)
+		return
+	}
+
+	moderator, err := s.hasPermission(ctx, claims, PermResumesModerate)
+	var employer bool
+	if err == nil {
+		employer, err = s.hasPermission(ctx, claims, PermResumesSearch)
+	}
+	if err != nil {
+		log.Println("Get resume error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if !moderator && !employer {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Недостаточно прав"})
+		return
+	}
+	// неодобренные резюме для работодателя «не существуют», как чужие отклики
+	if !moderator && resume.Status != JobStatusApproved {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Резюме не найдено"})
+		return
+	}
+
+	visible, err := s.resumes.ContactsVisible(ctx, resume.ID, claims.ID)
+	if err != nil {
+		log.Println("Get resume error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if !visible {
+		resume = resume.withoutContacts()
+	}
+	c.JSON(http.StatusOK, resume)
+}
+
+// inviteResumeHandler — работодатель приглашает соискателя на свою опубликованную вакансию.
+// Соискатель получает письмо, работодателю открываются контакты из резюме.
+func (s *Server) inviteResumeHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	var req struct {
+		JobID   int64  `json:"job_id"`
+		Message string `json:"message"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.JobID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+	message := strings.TrimSpace(req.Message)
+	if utf8.RuneCountInString(message) > maxInvitationMessage {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Сообщение не должно быть длиннее 2000 символов"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	resume, err := s.resumes.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) || (err == nil && resume.Status != JobStatusApproved) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Резюме не найдено"})
+		return
+	} else if err != nil {
+		log.Println("Invite resume error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if resume.UserID == claims.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Нельзя пригласить самого себя"})
+		return
+	}
+
+	job, err := s.jobs.Get(ctx, req.JobID)
+	if errors.Is(err, ErrNotFound) || (err == nil && !ownsJob(job, claims.ID)) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Вакансия не найдена"})
+		return
+	} else if err != nil {
+		log.Println("Invite resume error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if job.Status != JobStatusApproved {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Пригласить можно только на опубликованную вакансию"})
+		return
+	}
+
+	invitation, err := s.resumes.Invite(ctx, InvitationInput{
+		ResumeID:   resume.ID,
+		JobID:      job.ID,
+		EmployerID: claims.ID,
+		Message:    message,
+	})
+	if errors.Is(err, ErrAlreadyInvited) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Вы уже приглашали этого соискателя на эту вакансию"})
+		return
+	} else if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Резюме не найдено"})
+		return
+	} else if err != nil {
+		log.Println("Invite resume error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	log.Printf("Приглашение %d: %s по резюме %d на вакансию %d", invitation.ID, claims.Username, resume.ID, job.ID)
+	if err := s.sendResumeInvitationEmail(ctx, resume, invitation); err != nil {
+		log.Println("Send resume invitation email error:", err)
+	}
+	c.JSON(http.StatusCreated, resume)
+}
+
+func (s *Server) sendResumeInvitationEmail(ctx context.Context, resume Resume, invitation ResumeInvitation) error {
+	body := "Работодатель %s приглашает вас по резюме «%s» на вакансию «%s» (%s):\n%s/jobs/%d\n"
+	args := []interface{}{invitation.EmployerName, resume.Title, invitation.JobTitle, invitation.Company, s.appURL, invitation.JobID}
+	if invitation.Message != "" {
+		body += "\nСообщение работодателя:\n%s\n"
+		args = append(args, invitation.Message)
+	}
+	body += "\nРаботодателю открыты контакты из вашего резюме.\n"
+	return s.mailUser(ctx, resume.UserID, "Приглашение на вакансию", body, args...)
+}
+
+// getMyInvitationsHandler — приглашения работодателей по резюме соискателя
+func (s *Server) getMyInvitationsHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	invitations, err := s.resumes.Invitations(c.Request.Context(), claims.ID)
+	if err != nil {
+		log.Println("Get my invitations error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// getResumeModerationQueueHandler — очередь модерации резюме, как getModerationQueueHandler
+func (s *Server) getResumeModerationQueueHandler(c *gin.Context) {
+	filter := ResumeFilter{
+		Search:         strings.TrimSpace(c.Query("search")),
+		Status:         c.DefaultQuery("status", JobStatusPending),
+		SalaryCurrency: strings.ToUpper(c.Query("salary_currency")),
+		SalaryPeriod:   c.Query("salary_period"),
+	}
+	if filter.Status == "all" {
+		filter.Status = ""
+	} else if _, ok := jobModerationTransitions[filter.Status]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неизвестный статус"})
+		return
+	}
+
+	page, paginated, err := parsePageRequest(c, resumeSorts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": pageErrorMessage(err)})
+		return
+	}
+	if page.Sort == "" {
+		page.Sort = "oldest"
+	}
+	if salaryUnitsMissing(filter.SalaryCurrency, filter.SalaryPeriod, nil, nil, page.Sort) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": salaryUnitsMessage})
+		return
+	}
+
+	result, err := s.resumes.List(c.Request.Context(), filter, page)
+	if errors.Is(err, errInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": pageErrorMessage(err)})
+		return
+	} else if err != nil {
+		log.Println("Get resume moderation queue error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	// модератору контакты для проверки не нужны
+	for i := range result.Items {
+		result.Items[i] = result.Items[i].withoutContacts()
+	}
+
+	if !paginated {
+		c.JSON(http.StatusOK, result.Items)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// setResumeStatusHandler — решение модератора по резюме; правила те же, что у setJobStatusHandler
+func (s *Server) setResumeStatusHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	var req struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+	if _, ok := jobModerationTransitions[req.Status]; !ok || req.Status == JobStatusPending {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неизвестный статус"})
+		return
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if req.Status == JobStatusRejected {
+		if n := utf8.RuneCountInString(reason); n < minRejectionReason || n > maxRejectionReason {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Укажите причину отказа (от 3 до 500 символов)"})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	resume, err := s.resumes.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Резюме не найдено"})
+		return
+	} else if err != nil {
+		log.Println("Moderate resume error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	if resume.Status == req.Status {
+		c.JSON(http.StatusOK, resume.withoutContacts())
+		return
+	}
+	if !canTransitionJob(resume.Status, req.Status) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Недопустимая смена статуса резюме"})
+		return
+	}
+
+	from := resume.Status
+	resume, err = s.resumes.SetStatus(ctx, id, from, req.Status, reason, claims.ID)
+	if errors.Is(err, ErrStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Статус резюме уже изменился, обновите страницу"})
+		return
+	} else if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Резюме не найдено"})
+		return
+	} else if err != nil {
+		log.Println("Moderate resume error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	log.Printf("Модерация резюме %d: %s → %s, модератор %s", id, from, req.Status, claims.Username)
+	if req.Status == JobStatusRejected {
+		if err := s.sendResumeRejectedEmail(ctx, resume); err != nil {
+			log.Println("Send resume rejected email error:", err)
+		}
+	}
+	c.JSON(http.StatusOK, resume.withoutContacts())
+}
+
+func (s *Server) sendResumeRejectedEmail(ctx context.Context, resume Resume) error {
+	return s.mailUser(ctx, resume.UserID, "Резюме не прошло модерацию",
+		"Резюме «%s» отклонено модератором.\nПричина: %s\n\n"+
+			"Исправьте резюме в профиле, и оно снова уйдёт на проверку:\n%s/profile\n",
+		resume.Title, resume.RejectionReason, s.appURL)
+}
+
+func (s *Server) getResumeModerationHistoryHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := s.resumes.Get(ctx, id); errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Резюме не найдено"})
+		return
+	} else if err != nil {
+		log.Println("Get resume moderation history error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	entries, err := s.resumes.History(ctx, id)
+	if err != nil {
+		log.Println("Get resume moderation history error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	SalaryPeriodMonth = "month"
+	SalaryPeriodShift = "shift"
+	SalaryPeriodHour  = "hour"
+)
+
+// maxSalary — верхняя граница суммы, чтобы опечатка не попала в сортировку первой
+const maxSalary = 100_000_000
+
+var salaryCurrencySigns = map[string]string{
+	"RUB": "₽",
+	"USD": "$",
+	"EUR": "€",
+}
+
+var salaryPeriodSuffixes = map[string]string{
+	SalaryPeriodMonth: "",
+	SalaryPeriodShift: " за смену",
+	SalaryPeriodHour:  " в час",
+}
+
+var errInvalidSalary = errors.New("invalid salary")
+
+// SalaryRange — зарплата вакансии в числах. Пустые Min и Max — «по договорённости»,
+// одна из границ — «от» или «до». Gross — сумма до вычета налогов.
+type SalaryRange struct {
+	Min      *int64 `json:"salary_min"`
+	Max      *int64 `json:"salary_max"`
+	Currency string `json:"salary_currency"`
+	Period   string `json:"salary_period"`
+	Gross    bool   `json:"salary_gross"`
+}
+
+// String собирает строку для показа: «50 000 – 70 000 ₽», «от 1 500 ₽ за смену»
+func (r SalaryRange) String() string {
+	var amount string
+	switch {
+	case r.Min == nil && r.Max == nil:
+		return "По договорённости"
+	case r.Max == nil:
+		amount = "от " + formatSalaryAmount(*r.Min)
+	case r.Min == nil:
+		amount = "до " + formatSalaryAmount(*r.Max)
+	case *r.Min == *r.Max:
+		amount = formatSalaryAmount(*r.Min)
+	default:
+		amount = formatSalaryAmount(*r.Min) + " – " + formatSalaryAmount(*r.Max)
+	}
+
+	sign, ok := salaryCurrencySigns[r.Currency]
+	if !ok {
+		sign = r.Currency
+	}
+	s := amount + " " + sign + salaryPeriodSuffixes[r.Period]
+	if r.Gross {
+		s += " до вычета налогов"
+	}
+	return s
+}
+
+// formatSalaryAmount делит число на разряды: 80000 → «80 000»
+func formatSalaryAmount(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(d)
+	}
+	return b.String()
+}
+
+var (
+	salaryDigitSpaceRe = regexp.MustCompile(`(\d)[\s\x{00a0}\x{202f}]+(\d)`)
+	salaryNumberRe     = regexp.MustCompile(`\d+`)
+	salaryFromRe       = regexp.MustCompile(`от\s*\d`)
+	salaryToRe         = regexp.MustCompile(`до\s*\d`)
+	salaryHourRe       = regexp.MustCompile(`час|/\s*ч`)
+)
+
+// parseSalary разбирает строку вроде «от 50 000 до 70 000 руб.» или «1500 ₽/смена».
+// Правила те же, что в миграции 0013_job_salary, — чтобы старые и новые вакансии
+// разбирались одинаково. Непонятая строка даёт «по договорённости».
+func parseSalary(s string) SalaryRange {
+	p := strings.ToLower(s)
+	for {
+		next := salaryDigitSpaceRe.ReplaceAllString(p, "$1$2")
+		if next == p {
+			break
+		}
+		p = next
+	}
+
+	r := SalaryRange{Currency: "RUB", Period: SalaryPeriodMonth}
+	switch {
+	case strings.Contains(p, "$") || strings.Contains(p, "usd") || strings.Contains(p, "долл"):
+		r.Currency = "USD"
+	case strings.Contains(p, "€") || strings.Contains(p, "eur") || strings.Contains(p, "евро"):
+		r.Currency = "EUR"
+	}
+	switch {
+	case strings.Contains(p, "смен"):
+		r.Period = SalaryPeriodShift
+	case salaryHourRe.MatchString(p):
+		r.Period = SalaryPeriodHour
+	}
+	r.Gross = strings.Contains(p, "до вычета") || strings.Contains(p, "gross") || strings.Contains(p, "брутто")
+
+	var numbers []int64
+	for _, m := range salaryNumberRe.FindAllString(p, 2) {
+		n, err := strconv.ParseInt(m, 10, 64)
+		if err != nil || n > maxSalary {
+			return r
+		}
+		if strings.Contains(p, "тыс") {
+			n *= 1000
+		}
+		if n > maxSalary {
+			return r
+		}
+		numbers = append(numbers, n)
+	}
+
+	switch {
+	case len(numbers) == 0:
+	case len(numbers) == 2:
+		lo, hi := min(numbers[0], numbers[1]), max(numbers[0], numbers[1])
+		r.Min, r.Max = &lo, &hi
+	case salaryToRe.MatchString(p) && !salaryFromRe.MatchString(p):
+		r.Max = &numbers[0]
+	case salaryFromRe.MatchString(p):
+		r.Min = &numbers[0]
+	default:
+		r.Min, r.Max = &numbers[0], &numbers[0]
+	}
+	return r
+}
+
+// normalize подставляет валюту и период по умолчанию и проверяет границы
+func (r *SalaryRange) normalize() error {
+	if r.Currency == "" {
+		r.Currency = "RUB"
+	}
+	r.Currency = strings.ToUpper(r.Currency)
+	if r.Period == "" {
+		r.Period = SalaryPeriodMonth
+	}
+	if _, ok := salaryCurrencySigns[r.Currency]; !ok {
+		return errInvalidSalary
+	}
+	if _, ok := salaryPeriodSuffixes[r.Period]; !ok {
+		return errInvalidSalary
+	}
+	for _, v := range []*int64{r.Min, r.Max} {
+		if v != nil && (*v < 0 || *v > maxSalary) {
+			return errInvalidSalary
+		}
+	}
+	if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+		return errInvalidSalary
+	}
+	return nil
+}
+
+// salaryValue — сумма для сортировки: верхняя граница при сортировке по убыванию,
+// нижняя — по возрастанию; вакансии без суммы идут как 0. Повторяет выражения из jobSorts.
+// Сравнивать значения имеет смысл только в одной валюте и периоде (см. salaryUnitsMissing).
+func salaryValue(r SalaryRange, desc bool) float64 {
+	first, second := r.Min, r.Max
+	if desc {
+		first, second = r.Max, r.Min
+	}
+	switch {
+	case first != nil:
+		return float64(*first)
+	case second != nil:
+		return float64(*second)
+	}
+	return 0
+}
+
+// salaryUnitsMissing сообщает, что запрос сравнивает суммы (фильтром по сумме или сортировкой
+// по зарплате), не указав валюту и период: рубли с долларами и оплату за смену с месячной
+// не сравниваем, поэтому такие запросы отклоняются.
+func salaryUnitsMissing(currency, period string, from, to *int64, sort string) bool {
+	compares := from != nil || to != nil || strings.HasPrefix(sort, "salary_")
+	return compares && (currency == "" || period == "")
+}
+
+// salaryUnitsMessage — ответ на запрос, для которого salaryUnitsMissing вернула true
+const salaryUnitsMessage = "Для поиска и сортировки по зарплате укажите валюту и период"
+
+func parseSalaryParam(v string) (*int64, error) {
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return nil, errInvalidSalary
+	}
+	return &n, nil
+}
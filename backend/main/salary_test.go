@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func salaryAmount(n int64) *int64 {
+	return &n
+}
+
+func TestJobSalaryUnits(t *testing.T) {
+	ts := newTestServer(t)
+	ts.job(t, "Прораб", SalaryRange{Min: salaryAmount(120000), Max: salaryAmount(150000), Currency: "RUB", Period: SalaryPeriodMonth})
+	ts.job(t, "Каменщик", SalaryRange{Min: salaryAmount(80000), Currency: "RUB", Period: SalaryPeriodMonth})
+	ts.job(t, "Разнорабочий", SalaryRange{Min: salaryAmount(3500), Currency: "RUB", Period: SalaryPeriodShift})
+	ts.job(t, "Инженер", SalaryRange{Min: salaryAmount(3000), Currency: "USD", Period: SalaryPeriodMonth})
+
+	// без валюты и периода суммы несравнимы
+	for _, query := range []string{"salary_from=1000", "sort=salary_desc", "salary_to=5000&salary_currency=RUB", "sort=salary_asc&salary_period=month"} {
+		expect(t, ts.do(t, http.MethodGet, "/api/jobs?"+query, "", nil), http.StatusBadRequest, nil)
+	}
+
+	titles := func(query string) []string {
+		t.Helper()
+		var jobs []Job
+		expect(t, ts.do(t, http.MethodGet, "/api/jobs?"+query, "", nil), http.StatusOK, &jobs)
+		var out []string
+		for _, j := range jobs {
+			out = append(out, j.Title)
+		}
+		return out
+	}
+	check := func(query string, want ...string) {
+		t.Helper()
+		got := titles(query)
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %v, want %v", query, got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s: got %v, want %v", query, got, want)
+			}
+		}
+	}
+
+	check("salary_from=2000&salary_currency=RUB&salary_period=month&sort=salary_desc", "Прораб", "Каменщик")
+	check("salary_to=5000&salary_currency=RUB&salary_period=shift", "Разнорабочий")
+	check("salary_from=2000&salary_currency=usd&salary_period=month", "Инженер")
+	check("sort=salary_asc&salary_currency=RUB&salary_period=month", "Каменщик", "Прораб")
+	if got := titles(""); len(got) != 4 {
+		t.Fatalf("unfiltered list: %v", got)
+	}
+}
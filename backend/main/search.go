@@ -0,0 +1,440 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"html"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	SearchKindProduct = "product"
+	SearchKindJob     = "job"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+	snippetRadius      = 8 // слов по обе стороны от первого совпадения
+)
+
+// SearchField — поле документа с весом для ранжирования
+type SearchField struct {
+	Name   string
+	Text   string
+	Weight float64
+}
+
+type SearchDoc struct {
+	Kind   string
+	ID     int64
+	Fields []SearchField
+}
+
+type SearchHit struct {
+	Kind    string  `json:"type"`
+	ID      int64   `json:"id"`
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet"`
+}
+
+// SearchIndex — подключаемый полнотекстовый индекс. Сейчас используется
+// in-process реализация; её можно заменить внешним движком без изменения обработчиков.
+type SearchIndex interface {
+	Index(doc SearchDoc)
+	Remove(kind string, id int64)
+	Search(query string, kinds []string, limit int) []SearchHit
+}
+
+type searchToken struct {
+	Term  string
+	Start int // позиция в байтах исходного текста
+	End   int
+}
+
+// tokenize разбивает текст на слова и приводит их к основе: русские слова
+// проходят через стеммер, остальные только переводятся в нижний регистр
+func tokenize(text string) []searchToken {
+	var tokens []searchToken
+	start := -1
+
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		word := strings.ToLower(text[start:end])
+		if term := stemWord(word); term != "" {
+			tokens = append(tokens, searchToken{Term: term, Start: start, End: end})
+		}
+		start = -1
+	}
+
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(text))
+
+	return tokens
+}
+
+func stemWord(word string) string {
+	for _, r := range word {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return stemRussian(word)
+		}
+	}
+	return word
+}
+
+type docKey struct {
+	Kind string
+	ID   int64
+}
+
+type indexedDoc struct {
+	doc SearchDoc
+	// weighted — сумма весов полей по каждому термину
+	weighted map[string]float64
+	length   float64
+}
+
+// MemorySearchIndex — инвертированный индекс в памяти с ранжированием BM25
+type MemorySearchIndex struct {
+	mu       sync.RWMutex
+	docs     map[docKey]*indexedDoc
+	postings map[string]map[docKey]struct{}
+	totalLen map[string]float64 // по видам документов, для средней длины
+	counts   map[string]int
+}
+
+func NewMemorySearchIndex() *MemorySearchIndex {
+	return &MemorySearchIndex{
+		docs:     map[docKey]*indexedDoc{},
+		postings: map[string]map[docKey]struct{}{},
+		totalLen: map[string]float64{},
+		counts:   map[string]int{},
+	}
+}
+
+func (ix *MemorySearchIndex) Index(doc SearchDoc) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	key := docKey{doc.Kind, doc.ID}
+	ix.removeLocked(key)
+
+	d := &indexedDoc{doc: doc, weighted: map[string]float64{}}
+	for _, f := range doc.Fields {
+		for _, t := range tokenize(f.Text) {
+			d.weighted[t.Term] += f.Weight
+			d.length += f.Weight
+		}
+	}
+
+	ix.docs[key] = d
+	ix.totalLen[doc.Kind] += d.length
+	ix.counts[doc.Kind]++
+	for term := range d.weighted {
+		if ix.postings[term] == nil {
+			ix.postings[term] = map[docKey]struct{}{}
+		}
+		ix.postings[term][key] = struct{}{}
+	}
+}
+
+func (ix *MemorySearchIndex) Remove(kind string, id int64) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.removeLocked(docKey{kind, id})
+}
+
+func (ix *MemorySearchIndex) removeLocked(key docKey) {
+	d, ok := ix.docs[key]
+	if !ok {
+		return
+	}
+	for term := range d.weighted {
+		delete(ix.postings[term], key)
+		if len(ix.postings[term]) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	ix.totalLen[key.Kind] -= d.length
+	ix.counts[key.Kind]--
+	delete(ix.docs, key)
+}
+
+func (ix *MemorySearchIndex) Search(query string, kinds []string, limit int) []SearchHit {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	allowed := map[string]bool{}
+	for _, k := range kinds {
+		allowed[k] = true
+	}
+
+	terms := map[string]bool{}
+	for _, t := range tokenize(query) {
+		terms[t.Term] = true
+	}
+
+	const k1, b = 1.2, 0.75
+	scores := map[docKey]float64{}
+
+	for term := range terms {
+		posting := ix.postings[term]
+		for key := range posting {
+			if len(allowed) > 0 && !allowed[key.Kind] {
+				continue
+			}
+			n := float64(ix.counts[key.Kind])
+			df := 0.0
+			for other := range posting {
+				if other.Kind == key.Kind {
+					df++
+				}
+			}
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			avgLen := ix.totalLen[key.Kind] / math.Max(n, 1)
+
+			d := ix.docs[key]
+			tf := d.weighted[term]
+			scores[key] += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*d.length/math.Max(avgLen, 1)))
+		}
+	}
+
+	hits := make([]SearchHit, 0, len(scores))
+	for key, score := range scores {
+		hits = append(hits, SearchHit{
+			Kind:    key.Kind,
+			ID:      key.ID,
+			Score:   math.Round(score*1000) / 1000,
+			Snippet: buildSnippet(ix.docs[key].doc, terms),
+		})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Kind != hits[j].Kind {
+			return hits[i].Kind < hits[j].Kind
+		}
+		return hits[i].ID > hits[j].ID
+	})
+
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// buildSnippet берёт фрагмент первого поля с совпадением и выделяет найденные
+// слова тегом <mark>. Остальной текст экранируется, чтобы фрагмент можно было вставить как HTML.
+func buildSnippet(doc SearchDoc, terms map[string]bool) string {
+	// в первую очередь показываем описание — в нём больше контекста
+	fields := make([]SearchField, len(doc.Fields))
+	copy(fields, doc.Fields)
+	sort.SliceStable(fields, func(i, j int) bool { return len(fields[i].Text) > len(fields[j].Text) })
+
+	for _, f := range fields {
+		tokens := tokenize(f.Text)
+		first := -1
+		for i, t := range tokens {
+			if terms[t.Term] {
+				first = i
+				break
+			}
+		}
+		if first < 0 {
+			continue
+		}
+
+		from := max(first-snippetRadius, 0)
+		to := min(first+snippetRadius+1, len(tokens))
+
+		var b strings.Builder
+		if from > 0 {
+			b.WriteString("…")
+		}
+		pos := tokens[from].Start
+		for _, t := range tokens[from:to] {
+			b.WriteString(html.EscapeString(f.Text[pos:t.Start]))
+			word := html.EscapeString(f.Text[t.Start:t.End])
+			if terms[t.Term] {
+				b.WriteString("<mark>" + word + "</mark>")
+			} else {
+				b.WriteString(word)
+			}
+			pos = t.End
+		}
+		if to < len(tokens) {
+			b.WriteString("…")
+		} else {
+			b.WriteString(html.EscapeString(f.Text[pos:]))
+		}
+		return b.String()
+	}
+	return ""
+}
+
+func productSearchDoc(p Product) SearchDoc {
+	return SearchDoc{
+		Kind: SearchKindProduct,
+		ID:   p.ID,
+		Fields: []SearchField{
+			{Name: "name", Text: p.Name, Weight: 3},
+			{Name: "category", Text: p.Category, Weight: 2},
+			{Name: "description", Text: p.Description, Weight: 1},
+		},
+	}
+}
+
+func jobSearchDoc(j Job) SearchDoc {
+	return SearchDoc{
+		Kind: SearchKindJob,
+		ID:   j.ID,
+		Fields: []SearchField{
+			{Name: "title", Text: j.Title, Weight: 3},
+			{Name: "category", Text: j.Category, Weight: 2},
+			{Name: "company", Text: j.Company, Weight: 1},
+			{Name: "description", Text: j.Description, Weight: 1},
+		},
+	}
+}
+
+// rebuildSearchIndex заполняет индекс при старте сервера
+func (s *Server) rebuildSearchIndex(ctx context.Context) error {
+	products, err := s.products.List(ctx, ProductFilter{}, PageRequest{})
+	if err != nil {
+		return err
+	}
+	for _, p := range products.Items {
+		s.search.Index(productSearchDoc(p))
+	}
+
+	jobs, err := s.jobs.List(ctx, JobFilter{Status: JobStatusApproved}, PageRequest{})
+	if err != nil {
+		return err
+	}
+	for _, j := range jobs.Items {
+		s.search.Index(jobSearchDoc(j))
+	}
+
+	log.Printf("Поисковый индекс построен: товаров %d, вакансий %d", len(products.Items), len(jobs.Items))
+	return nil
+}
+
+// reindexJob держит в индексе только вакансии, видимые на публичной доске
+func (s *Server) reindexJob(ctx context.Context, id int64) {
+	j, err := s.jobs.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) || (err == nil && j.Status != JobStatusApproved) {
+		s.search.Remove(SearchKindJob, id)
+		return
+	} else if err != nil {
+		log.Println("Reindex job error:", err)
+		return
+	}
+	s.search.Index(jobSearchDoc(j))
+}
+
+// searchIDs возвращает id найденных документов одного вида в порядке релевантности
+func (s *Server) searchIDs(query, kind string) []int64 {
+	hits := s.search.Search(query, []string{kind}, 0)
+	ids := make([]int64, len(hits))
+	for i, h := range hits {
+		ids[i] = h.ID
+	}
+	return ids
+}
+
+func (s *Server) searchHandler(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Пустой поисковый запрос"})
+		return
+	}
+
+	var kinds []string
+	switch c.DefaultQuery("type", "all") {
+	case "all":
+	case "products":
+		kinds = []string{SearchKindProduct}
+	case "jobs":
+		kinds = []string{SearchKindJob}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неизвестный тип поиска"})
+		return
+	}
+
+	limit := defaultSearchLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Неверные параметры страницы"})
+			return
+		}
+		limit = min(n, maxSearchLimit)
+	}
+
+	ctx := c.Request.Context()
+	hits := s.search.Search(query, kinds, limit)
+
+	type result struct {
+		SearchHit
+		Product *Product `json:"product,omitempty"`
+		Job     *Job     `json:"job,omitempty"`
+	}
+	results := make([]result, 0, len(hits))
+
+	for _, h := range hits {
+		r := result{SearchHit: h}
+		switch h.Kind {
+		case SearchKindProduct:
+			p, err := s.products.Get(ctx, h.ID)
+			if errors.Is(err, ErrNotFound) {
+				s.search.Remove(h.Kind, h.ID)
+				continue
+			} else if err != nil {
+				log.Println("Search product error:", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+				return
+			}
+			r.Product = &p
+		case SearchKindJob:
+			j, err := s.jobs.Get(ctx, h.ID)
+			if errors.Is(err, ErrNotFound) {
+				s.search.Remove(h.Kind, h.ID)
+				continue
+			} else if err != nil {
+				log.Println("Search job error:", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+				return
+			}
+			r.Job = &j
+		}
+		results = append(results, r)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query": query,
+		"items": results,
+		"total": len(results),
+	})
+}
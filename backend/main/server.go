@@ -0,0 +1,102 @@
+package main
+
+import (
+	"database/sql"
+)
+
+// Server хранит зависимости обработчиков. db нужен только проверке здоровья
+// и равен nil при запуске на in-memory репозиториях.
+type Server struct {
+	db           *sql.DB
+	products     ProductRepository
+	baskets      BasketRepository
+	orders       OrderRepository
+	jobs         JobRepository
+	applications ApplicationRepository
+	companies    CompanyRepository
+	resumes      ResumeRepository
+	categories   CategoryRepository
+	users        UserRepository
+	roles        RoleRepository
+	sessions     SessionRepository
+	userTokens   UserTokenRepository
+	logins       LoginThrottleRepository
+	twoFactor    TwoFactorRepository
+	identities   IdentityRepository
+	search       SearchIndex
+	storage      FileStorage
+	mailer       Mailer
+	passwords    *PasswordHasher
+	policy       PasswordPolicy
+	appURL       string // адрес фронтенда для ссылок в письмах
+	jwtSecret    []byte
+
+	oauthProviders []*OAuthProvider
+}
+
+// NewServer собирает сервер на MySQL-репозиториях
+func NewServer(db *sql.DB, storage FileStorage, mailer Mailer, jwtSecret []byte) *Server {
+	return &Server{
+		db:           db,
+		products:     NewMySQLProductRepository(db),
+		baskets:      NewMySQLBasketRepository(db),
+		orders:       NewMySQLOrderRepository(db),
+		jobs:         NewMySQLJobRepository(db),
+		applications: NewMySQLApplicationRepository(db),
+		companies:    NewMySQLCompanyRepository(db),
+		resumes:      NewMySQLResumeRepository(db),
+		categories:   NewMySQLCategoryRepository(db),
+		users:        NewMySQLUserRepository(db),
+		roles:        NewMySQLRoleRepository(db),
+		sessions:     NewMySQLSessionRepository(db),
+		userTokens:   NewMySQLUserTokenRepository(db),
+		logins:       NewMySQLLoginThrottleRepository(db),
+		twoFactor:    NewMySQLTwoFactorRepository(db),
+		identities:   NewMySQLIdentityRepository(db),
+		search:       NewMemorySearchIndex(),
+		storage:      storage,
+		mailer:       mailer,
+		passwords:    defaultPasswordHasher(),
+		policy:       defaultPasswordPolicy(),
+		appURL:       defaultAppURL,
+		jwtSecret:    jwtSecret,
+	}
+}
+
+// NewMemoryServer собирает сервер на in-memory репозиториях — для httptest без MySQL.
+// Файлы сохраняются в storage, письма уходят в mailer, как и в основном сервере.
+func NewMemoryServer(storage FileStorage, mailer Mailer, jwtSecret []byte) *Server {
+	users := NewMemoryUserRepository()
+	categories := NewMemoryCategoryRepository()
+	products := NewMemoryProductRepository(categories)
+	baskets := NewMemoryBasketRepository(products)
+	companies := NewMemoryCompanyRepository(users)
+	jobs := NewMemoryJobRepository(users, categories, companies)
+	applications := NewMemoryApplicationRepository(jobs, users)
+	categories.attach(products, jobs)
+
+	return &Server{
+		products:     products,
+		baskets:      baskets,
+		orders:       NewMemoryOrderRepository(baskets, products),
+		jobs:         jobs,
+		applications: applications,
+		companies:    companies,
+		resumes:      NewMemoryResumeRepository(users, jobs, applications),
+		categories:   categories,
+		users:        users,
+		roles:        NewMemoryRoleRepository(users),
+		sessions:     NewMemorySessionRepository(),
+		userTokens:   NewMemoryUserTokenRepository(),
+		logins:       NewMemoryLoginThrottleRepository(),
+		twoFactor:    NewMemoryTwoFactorRepository(),
+		identities:   NewMemoryIdentityRepository(),
+		search:       NewMemorySearchIndex(),
+		storage:      storage,
+		mailer:       mailer,
+		passwords:    defaultPasswordHasher(),
+		policy:       defaultPasswordPolicy(),
+		appURL:       defaultAppURL,
+		jwtSecret:    jwtSecret,
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// recordingMailer запоминает письма вместо отправки
+type recordingMailer struct {
+	mu   sync.Mutex
+	sent []Mail
+}
+
+func (m *recordingMailer) Send(ctx context.Context, mail Mail) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, mail)
+	return nil
+}
+
+func (m *recordingMailer) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.sent)
+}
+
+type testServer struct {
+	*Server
+	router http.Handler
+	mail   *recordingMailer
+}
+
+// newTestServer собирает сервер на in-memory репозиториях с быстрым bcrypt вместо argon2id
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	mail := &recordingMailer{}
+	s := NewMemoryServer(NewLocalStorage(t.TempDir(), "/uploads"), mail, []byte("test-secret"))
+	hasher, err := NewPasswordHasher(HashBcrypt, bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.passwords = hasher
+	return &testServer{Server: s, router: s.setupRouter(), mail: mail}
+}
+
+// do выполняет запрос с JSON-телом body (если не nil) и токеном token (если не пустой)
+func (ts *testServer) do(t *testing.T, method, path, token string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, r)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, req)
+	return w
+}
+
+// expect проверяет код ответа и разбирает тело в out (если не nil)
+func expect(t *testing.T, w *httptest.ResponseRecorder, status int, out any) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, status, w.Body.String())
+	}
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatalf("decode %s: %v", w.Body.String(), err)
+		}
+	}
+}
+
+// register заводит пользователя через API и возвращает его id и access-токен
+func (ts *testServer) register(t *testing.T, username string) (int64, string) {
+	t.Helper()
+	var resp struct {
+		Token string `json:"token"`
+		User  struct {
+			ID int64 `json:"id"`
+		} `json:"user"`
+	}
+	w := ts.do(t, http.MethodPost, "/api/register", "", gin.H{
+		"username": username,
+		"password": "Kirpich-2024-stroy",
+		"email":    username + "@example.com",
+	})
+	expect(t, w, http.StatusCreated, &resp)
+	return resp.User.ID, resp.Token
+}
+
+// grantRole назначает роль и включает пользователю 2FA, как если бы он вошёл со вторым фактором:
+// без неё административные права не действуют
+func (ts *testServer) grantRole(t *testing.T, userID int64, role string) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := ts.users.SetRole(ctx, userID, role); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.twoFactor.SetPending(ctx, userID, "JBSWY3DPEHPK3PXP"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.twoFactor.Enable(ctx, userID, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func itoa(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+func (ts *testServer) product(t *testing.T, name string, price float64, stock int) Product {
+	t.Helper()
+	p, err := ts.products.Create(context.Background(), ProductInput{Name: name, Price: price, Stock: stock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func (ts *testServer) available(t *testing.T, productID int64) int {
+	t.Helper()
+	p, err := ts.products.Get(context.Background(), productID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.Available
+}
+
+// job публикует вакансию с зарплатой в обход модерации
+func (ts *testServer) job(t *testing.T, title string, salary SalaryRange) Job {
+	t.Helper()
+	ctx := context.Background()
+	job, err := ts.jobs.Create(ctx, JobInput{Title: title, Description: title, Salary: salary.String(), SalaryRange: salary})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job, err = ts.jobs.SetStatus(ctx, job.ID, job.Status, JobStatusApproved, "", 0); err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func TestHealthInMemory(t *testing.T) {
+	ts := newTestServer(t)
+
+	var resp struct {
+		Database string `json:"database"`
+	}
+	expect(t, ts.do(t, http.MethodGet, "/api/health", "", nil), http.StatusOK, &resp)
+	if resp.Database != "In-memory" {
+		t.Errorf("database = %q, want In-memory", resp.Database)
+	}
+}
+
+func TestBasket(t *testing.T) {
+	ts := newTestServer(t)
+	_, token := ts.register(t, "buyer")
+	cement := ts.product(t, "Цемент М500", 450, 10)
+	sand := ts.product(t, "Песок", 120, 10)
+
+	var basket Basket
+	expect(t, ts.do(t, http.MethodGet, "/api/basket", token, nil), http.StatusOK, &basket)
+	if len(basket.Items) != 0 {
+		t.Fatalf("new basket has %d items", len(basket.Items))
+	}
+
+	expect(t, ts.do(t, http.MethodPost, "/api/basket/items", token, gin.H{"product_id": cement.ID, "quantity": 2}), http.StatusOK, nil)
+	expect(t, ts.do(t, http.MethodPost, "/api/basket/items", token, gin.H{"product_id": cement.ID, "quantity": 998}), http.StatusOK, nil)
+	expect(t, ts.do(t, http.MethodPost, "/api/basket/items", token, gin.H{"product_id": sand.ID}), http.StatusOK, &basket)
+	if len(basket.Items) != 2 || basket.Items[0].Quantity != maxBasketItemQuantity || basket.Items[1].Quantity != 1 {
+		t.Fatalf("basket items = %+v", basket.Items)
+	}
+
+	expect(t, ts.do(t, http.MethodPut, "/api/basket/items/"+itoa(cement.ID), token, gin.H{"quantity": 3}), http.StatusOK, &basket)
+	if basket.Count != 4 || basket.Total != 3*450+120 {
+		t.Errorf("count = %d, total = %v", basket.Count, basket.Total)
+	}
+
+	expect(t, ts.do(t, http.MethodPost, "/api/basket/items", token, gin.H{"product_id": 999}), http.StatusNotFound, nil)
+	expect(t, ts.do(t, http.MethodDelete, "/api/basket/items/"+itoa(sand.ID), token, nil), http.StatusOK, nil)
+	expect(t, ts.do(t, http.MethodDelete, "/api/basket/items/"+itoa(sand.ID), token, nil), http.StatusNotFound, nil)
+	expect(t, ts.do(t, http.MethodPut, "/api/basket/items/"+itoa(sand.ID), token, gin.H{"quantity": 1}), http.StatusNotFound, nil)
+
+	// корзины разных пользователей не пересекаются
+	_, other := ts.register(t, "other")
+	expect(t, ts.do(t, http.MethodGet, "/api/basket", other, nil), http.StatusOK, &basket)
+	if len(basket.Items) != 0 {
+		t.Errorf("other user sees %d items", len(basket.Items))
+	}
+
+	expect(t, ts.do(t, http.MethodDelete, "/api/basket", token, nil), http.StatusOK, &basket)
+	if len(basket.Items) != 0 {
+		t.Errorf("cleared basket has %d items", len(basket.Items))
+	}
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	ts := newTestServer(t)
+	buyerID, buyer := ts.register(t, "buyer")
+	_, stranger := ts.register(t, "stranger")
+	operatorID, operator := ts.register(t, "operator")
+	ts.grantRole(t, operatorID, "order_operator")
+	brick := ts.product(t, "Кирпич", 30, 5)
+
+	expect(t, ts.do(t, http.MethodPost, "/api/orders", buyer, nil), http.StatusBadRequest, nil)
+
+	expect(t, ts.do(t, http.MethodPost, "/api/basket/items", buyer, gin.H{"product_id": brick.ID, "quantity": 3}), http.StatusOK, nil)
+	var order Order
+	expect(t, ts.do(t, http.MethodPost, "/api/orders", buyer, gin.H{"comment": "к подъезду"}), http.StatusCreated, &order)
+	if order.Status != OrderStatusNew || order.Total != 90 || len(order.Items) != 1 || *order.UserID != buyerID {
+		t.Fatalf("order = %+v", order)
+	}
+	if got := ts.available(t, brick.ID); got != 2 {
+		t.Errorf("available after order = %d, want 2", got)
+	}
+	var basket Basket
+	expect(t, ts.do(t, http.MethodGet, "/api/basket", buyer, nil), http.StatusOK, &basket)
+	if len(basket.Items) != 0 {
+		t.Errorf("basket not emptied after order")
+	}
+
+	// зарезервированный товар другому покупателю не достаётся
+	expect(t, ts.do(t, http.MethodPost, "/api/basket/items", stranger, gin.H{"product_id": brick.ID, "quantity": 3}), http.StatusOK, nil)
+	var outOfStock struct {
+		Available int `json:"available"`
+	}
+	expect(t, ts.do(t, http.MethodPost, "/api/orders", stranger, nil), http.StatusConflict, &outOfStock)
+	if outOfStock.Available != 2 {
+		t.Errorf("available in conflict = %d, want 2", outOfStock.Available)
+	}
+
+	path := "/api/orders/" + itoa(order.ID)
+	expect(t, ts.do(t, http.MethodGet, path, stranger, nil), http.StatusNotFound, nil)
+	expect(t, ts.do(t, http.MethodPost, path+"/cancel", stranger, nil), http.StatusNotFound, nil)
+	expect(t, ts.do(t, http.MethodGet, path, operator, nil), http.StatusOK, nil)
+
+	var orders []Order
+	expect(t, ts.do(t, http.MethodGet, "/api/orders", buyer, nil), http.StatusOK, &orders)
+	if len(orders) != 1 {
+		t.Errorf("buyer has %d orders", len(orders))
+	}
+	expect(t, ts.do(t, http.MethodGet, "/api/admin/orders?status=new", buyer, nil), http.StatusForbidden, nil)
+	expect(t, ts.do(t, http.MethodGet, "/api/admin/orders?status=new", operator, nil), http.StatusOK, &orders)
+	if len(orders) != 1 {
+		t.Errorf("operator sees %d new orders", len(orders))
+	}
+
+	status := "/api/admin/orders/" + itoa(order.ID) + "/status"
+	expect(t, ts.do(t, http.MethodPut, status, operator, gin.H{"status": OrderStatusShipped}), http.StatusConflict, nil)
+	expect(t, ts.do(t, http.MethodPut, status, operator, gin.H{"status": OrderStatusConfirmed}), http.StatusOK, nil)
+	// подтверждённый заказ покупатель уже не отменяет
+	expect(t, ts.do(t, http.MethodPost, path+"/cancel", buyer, nil), http.StatusConflict, nil)
+	expect(t, ts.do(t, http.MethodPut, status, operator, gin.H{"status": OrderStatusAssembled}), http.StatusOK, nil)
+	expect(t, ts.do(t, http.MethodPut, status, operator, gin.H{"status": OrderStatusShipped}), http.StatusOK, &order)
+	if order.Status != OrderStatusShipped {
+		t.Errorf("status = %s", order.Status)
+	}
+
+	// отгрузка списывает товар со склада и снимает резерв
+	p, err := ts.products.Get(context.Background(), brick.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Stock != 2 || p.Available != 2 {
+		t.Errorf("stock = %d, available = %d, want 2 and 2", p.Stock, p.Available)
+	}
+}
+
+func TestCancelReleasesReservation(t *testing.T) {
+	ts := newTestServer(t)
+	_, buyer := ts.register(t, "buyer")
+	brick := ts.product(t, "Кирпич", 30, 5)
+
+	expect(t, ts.do(t, http.MethodPost, "/api/basket/items", buyer, gin.H{"product_id": brick.ID, "quantity": 5}), http.StatusOK, nil)
+	var order Order
+	expect(t, ts.do(t, http.MethodPost, "/api/orders", buyer, nil), http.StatusCreated, &order)
+	if got := ts.available(t, brick.ID); got != 0 {
+		t.Fatalf("available after order = %d, want 0", got)
+	}
+
+	expect(t, ts.do(t, http.MethodPost, "/api/orders/"+itoa(order.ID)+"/cancel", buyer, nil), http.StatusOK, &order)
+	if order.Status != OrderStatusCancelled {
+		t.Errorf("status = %s", order.Status)
+	}
+	if got := ts.available(t, brick.ID); got != 5 {
+		t.Errorf("available after cancel = %d, want 5", got)
+	}
+}
+
+func TestExpiredReservationsAreReleased(t *testing.T) {
+	ts := newTestServer(t)
+	_, buyer := ts.register(t, "buyer")
+	brick := ts.product(t, "Кирпич", 30, 5)
+
+	ttl := reservationTTL
+	reservationTTL = -1
+	t.Cleanup(func() { reservationTTL = ttl })
+
+	expect(t, ts.do(t, http.MethodPost, "/api/basket/items", buyer, gin.H{"product_id": brick.ID, "quantity": 4}), http.StatusOK, nil)
+	var order Order
+	expect(t, ts.do(t, http.MethodPost, "/api/orders", buyer, nil), http.StatusCreated, &order)
+
+	n, err := ts.releaseExpiredReservations(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("released = %d, %v; want 1", n, err)
+	}
+	if got := ts.available(t, brick.ID); got != 5 {
+		t.Errorf("available = %d, want 5", got)
+	}
+	expect(t, ts.do(t, http.MethodGet, "/api/orders/"+itoa(order.ID), buyer, nil), http.StatusOK, &order)
+	if order.Status != OrderStatusCancelled {
+		t.Errorf("status = %s", order.Status)
+	}
+
+	// повторный проход ничего не находит
+	if n, err := ts.releaseExpiredReservations(context.Background()); err != nil || n != 0 {
+		t.Errorf("second pass released = %d, %v", n, err)
+	}
+}
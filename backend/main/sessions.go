@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+	// отозванные сессии хранятся ещё неделю — видно, когда и какие сессии завершили
+	revokedSessionRetention = 7 * 24 * time.Hour
+)
+
+// Session — вход пользователя с конкретного устройства. Access-токены несут её id в claim sid,
+// refresh-токены ротируются внутри сессии.
+type Session struct {
+	ID         string     `json:"id"`
+	UserID     int64      `json:"user_id"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type sessionTokens struct {
+	Token        string
+	RefreshToken string
+}
+
+// hashToken — в БД хранится только хеш refresh-токена. Токен случайный и длинный,
+// поэтому соль и медленный хеш не нужны.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Server) createToken(id int64, username, role, sessionID string) (string, error) {
+	jti, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := &Claims{
+		ID:        id,
+		Username:  username,
+		Role:      role,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenTTL)),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(s.jwtSecret)
+}
+
+// startSession открывает новую сессию после входа и выдаёт пару токенов
+func (s *Server) startSession(c *gin.Context, user User) (sessionTokens, error) {
+	sessionID, err := randomHex(16)
+	if err != nil {
+		return sessionTokens{}, err
+	}
+	refresh, err := randomHex(32)
+	if err != nil {
+		return sessionTokens{}, err
+	}
+
+	userAgent := c.Request.UserAgent()
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	if err := s.sessions.Create(c.Request.Context(), SessionInput{
+		ID:        sessionID,
+		UserID:    user.ID,
+		TokenHash: hashToken(refresh),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+		UserAgent: userAgent,
+		IP:        c.ClientIP(),
+	}); err != nil {
+		return sessionTokens{}, err
+	}
+
+	token, err := s.createToken(user.ID, user.Username, user.Role, sessionID)
+	if err != nil {
+		return sessionTokens{}, err
+	}
+	return sessionTokens{Token: token, RefreshToken: refresh}, nil
+}
+
+// authResponse — ответ на вход, регистрацию и обновление токена.
+// Права нужны фронтенду, чтобы показывать только доступные разделы.
+func (s *Server) authResponse(ctx context.Context, tokens sessionTokens, user User) (gin.H, error) {
+	perms, err := s.roles.UserPermissions(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{
+		"token":         tokens.Token,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    int(accessTokenTTL.Seconds()),
+		"user": gin.H{
+			"id":             user.ID,
+			"username":       user.Username,
+			"email":          user.Email,
+			"role":           user.Role,
+			"email_verified": user.EmailVerified,
+			"permissions":    perms,
+		},
+	}, nil
+}
+
+// refreshTokenHandler меняет refresh-токен на новую пару. Старый токен становится недействительным;
+// повторное его предъявление означает утечку, и сессия отзывается целиком.
+func (s *Server) refreshTokenHandler(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+
+	refresh, err := randomHex(32)
+	if err != nil {
+		log.Println("Refresh token error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	session, err := s.sessions.Rotate(ctx, hashToken(req.RefreshToken), hashToken(refresh), time.Now().Add(refreshTokenTTL))
+	if errors.Is(err, ErrRefreshTokenReused) {
+		log.Printf("Повторное использование refresh-токена, сессия %s отозвана", session.ID)
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Сессия завершена, войдите заново"})
+		return
+	} else if errors.Is(err, ErrInvalidRefreshToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Сессия завершена, войдите заново"})
+		return
+	} else if err != nil {
+		log.Println("Refresh token error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	// роль берём из БД: она могла измениться с момента входа
+	user, err := s.users.GetByID(ctx, session.UserID)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Сессия завершена, войдите заново"})
+		return
+	} else if err != nil {
+		log.Println("Refresh token error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	// пользователь без 2FA, получивший административную роль, должен войти заново и настроить её
+	required, err := s.twoFactorRequired(ctx, user.ID)
+	enabled := false
+	if err == nil && required {
+		enabled, err = s.twoFactorEnabled(ctx, user.ID)
+	}
+	if err != nil {
+		log.Println("Refresh token error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if required && !enabled {
+		if err := s.sessions.Revoke(ctx, session.ID); err != nil {
+			log.Println("Refresh token error:", err)
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Нужна двухфакторная аутентификация, войдите заново"})
+		return
+	}
+
+	token, err := s.createToken(user.ID, user.Username, user.Role, session.ID)
+	if err != nil {
+		log.Println("Refresh token error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	resp, err := s.authResponse(ctx, sessionTokens{Token: token, RefreshToken: refresh}, user)
+	if err != nil {
+		log.Println("Refresh token error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// logoutHandler завершает текущую сессию: её refresh-токен и выданные access-токены перестают действовать
+func (s *Server) logoutHandler(c *gin.Context) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return
+	}
+
+	if err := s.sessions.Revoke(c.Request.Context(), claims.SessionID); err != nil {
+		log.Println("Logout error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Вы вышли из системы"})
+}
+
+// revokeUserSessionsHandler завершает все сессии пользователя, например после увольнения сотрудника
+func (s *Server) revokeUserSessionsHandler(c *gin.Context) {
+	user := getUserClaims(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := s.users.GetByID(ctx, id); errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Пользователь не найден"})
+		return
+	} else if err != nil {
+		log.Println("Revoke sessions error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	revoked, err := s.sessions.RevokeAllForUser(ctx, id)
+	if err != nil {
+		log.Println("Revoke sessions error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	log.Printf("Администратор %s завершил %d сессий пользователя %d", user.Username, revoked, id)
+	c.JSON(http.StatusOK, gin.H{"message": "Сессии пользователя завершены", "revoked": revoked})
+}
+
+// startSessionJanitor периодически удаляет истёкшие сессии, refresh-токены, токены из писем
+// старые счётчики неудачных входов и незавершённые входы через внешних провайдеров
+func (s *Server) startSessionJanitor(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			n, err := s.sessions.DeleteExpired(context.Background(), time.Now())
+			if err != nil {
+				log.Println("Session cleanup error:", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("Удалено устаревших сессий: %d", n)
+			}
+
+			if _, err := s.userTokens.DeleteExpired(context.Background(), time.Now()); err != nil {
+				log.Println("User token cleanup error:", err)
+			}
+			if _, err := s.logins.DeleteStale(context.Background(), time.Now().Add(-loginThrottleRetention), time.Now()); err != nil {
+				log.Println("Login throttle cleanup error:", err)
+			}
+			if _, err := s.identities.DeleteExpiredStates(context.Background(), time.Now()); err != nil {
+				log.Println("OAuth state cleanup error:", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type sessionResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (ts *testServer) login(t *testing.T, username string) sessionResponse {
+	t.Helper()
+	var resp sessionResponse
+	w := ts.do(t, http.MethodPost, "/api/login", "", gin.H{"username": username, "password": "Kirpich-2024-stroy"})
+	expect(t, w, http.StatusOK, &resp)
+	return resp
+}
+
+func (ts *testServer) refresh(t *testing.T, token string, status int) sessionResponse {
+	t.Helper()
+	var resp sessionResponse
+	expect(t, ts.do(t, http.MethodPost, "/api/token/refresh", "", gin.H{"refresh_token": token}), status, &resp)
+	return resp
+}
+
+// повторно предъявленный refresh-токен отзывает всю его сессию, но не другие сессии пользователя
+func TestRefreshTokenReuse(t *testing.T) {
+	ts := newTestServer(t)
+	ts.register(t, "prorab")
+	laptop := ts.login(t, "prorab")
+	phone := ts.login(t, "prorab")
+
+	rotated := ts.refresh(t, laptop.RefreshToken, http.StatusOK)
+	if rotated.RefreshToken == laptop.RefreshToken || rotated.Token == "" {
+		t.Fatalf("refresh did not rotate the token: %+v", rotated)
+	}
+	expect(t, ts.do(t, http.MethodGet, "/api/me", rotated.Token, nil), http.StatusOK, nil)
+
+	// старый токен у злоумышленника: сессия отзывается вместе с новым токеном и access-токенами
+	ts.refresh(t, laptop.RefreshToken, http.StatusUnauthorized)
+	ts.refresh(t, rotated.RefreshToken, http.StatusUnauthorized)
+	expect(t, ts.do(t, http.MethodGet, "/api/me", rotated.Token, nil), http.StatusUnauthorized, nil)
+	expect(t, ts.do(t, http.MethodGet, "/api/me", laptop.Token, nil), http.StatusUnauthorized, nil)
+
+	expect(t, ts.do(t, http.MethodGet, "/api/me", phone.Token, nil), http.StatusOK, nil)
+	ts.refresh(t, phone.RefreshToken, http.StatusOK)
+
+	ts.refresh(t, "neizvestny-token", http.StatusUnauthorized)
+}
+
+// после выхода refresh-токен сессии не действует
+func TestLogoutRevokesRefreshToken(t *testing.T) {
+	ts := newTestServer(t)
+	ts.register(t, "prorab")
+	session := ts.login(t, "prorab")
+
+	expect(t, ts.do(t, http.MethodPost, "/api/logout", session.Token, nil), http.StatusOK, nil)
+	ts.refresh(t, session.RefreshToken, http.StatusUnauthorized)
+	expect(t, ts.do(t, http.MethodGet, "/api/me", session.Token, nil), http.StatusUnauthorized, nil)
+}
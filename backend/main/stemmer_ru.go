@@ -0,0 +1,168 @@
+package main
+
+import (
+	"sort"
+)
+
+// Русский стеммер по алгоритму Snowball:
+// https://snowballstem.org/algorithms/russian/stemmer.html
+
+func suffixes(list ...string) [][]rune {
+	out := make([][]rune, len(list))
+	for i, s := range list {
+		out[i] = []rune(s)
+	}
+	// сначала ищем самое длинное окончание
+	sort.Slice(out, func(i, j int) bool { return len(out[i]) > len(out[j]) })
+	return out
+}
+
+var (
+	ruPerfectiveGerund1 = suffixes("в", "вши", "вшись")
+	ruPerfectiveGerund2 = suffixes("ив", "ивши", "ившись", "ыв", "ывши", "ывшись")
+	ruAdjective         = suffixes("ее", "ие", "ые", "ое", "ими", "ыми", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом",
+		"его", "ого", "ему", "ому", "их", "ых", "ую", "юю", "ая", "яя", "ою", "ею")
+	ruParticiple1 = suffixes("ем", "нн", "вш", "ющ", "щ")
+	ruParticiple2 = suffixes("ивш", "ывш", "ующ")
+	ruReflexive   = suffixes("ся", "сь")
+	ruVerb1       = suffixes("ла", "на", "ете", "йте", "ли", "й", "л", "ем", "н", "ло", "но", "ет", "ют", "ны", "ть", "ешь", "нно")
+	ruVerb2       = suffixes("ила", "ыла", "ена", "ейте", "уйте", "ите", "или", "ыли", "ей", "уй", "ил", "ыл", "им", "ым", "ен",
+		"ило", "ыло", "ено", "ят", "ует", "уют", "ит", "ыт", "ены", "ить", "ыть", "ишь", "ую", "ю")
+	ruNoun = suffixes("а", "ев", "ов", "ие", "ье", "е", "иями", "ями", "ами", "еи", "ии", "и", "ией", "ей", "ой", "ий", "й",
+		"иям", "ям", "ием", "ем", "ам", "ом", "о", "у", "ах", "иях", "ях", "ы", "ь", "ию", "ью", "ю", "ия", "ья", "я")
+	ruSuperlative  = suffixes("ейш", "ейше")
+	ruDerivational = suffixes("ост", "ость")
+)
+
+func isRuVowel(r rune) bool {
+	switch r {
+	case 'а', 'е', 'и', 'о', 'у', 'ы', 'э', 'ю', 'я':
+		return true
+	}
+	return false
+}
+
+func hasSuffix(word, suffix []rune) bool {
+	if len(suffix) > len(word) {
+		return false
+	}
+	for i := range suffix {
+		if word[len(word)-len(suffix)+i] != suffix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// cutSuffix удаляет самое длинное окончание из list, целиком лежащее в регионе [region:].
+// Для групп, где окончанию должна предшествовать «а» или «я», afterAYa == true.
+func cutSuffix(word []rune, region int, list [][]rune, afterAYa bool) ([]rune, bool) {
+	for _, suf := range list {
+		start := len(word) - len(suf)
+		if start < region || !hasSuffix(word, suf) {
+			continue
+		}
+		if afterAYa {
+			if start-1 < region || (word[start-1] != 'а' && word[start-1] != 'я') {
+				continue
+			}
+		}
+		return word[:start], true
+	}
+	return word, false
+}
+
+func cutEither(word []rune, region int, group1, group2 [][]rune) ([]rune, bool) {
+	// ищем самое длинное совпадение среди обеих групп
+	w1, ok1 := cutSuffix(word, region, group1, true)
+	w2, ok2 := cutSuffix(word, region, group2, false)
+	switch {
+	case ok1 && ok2:
+		if len(w1) < len(w2) {
+			return w1, true
+		}
+		return w2, true
+	case ok1:
+		return w1, true
+	case ok2:
+		return w2, true
+	}
+	return word, false
+}
+
+// ruRegions возвращает начало RV и R2
+func ruRegions(word []rune) (rv, r2 int) {
+	rv, r1 := len(word), len(word)
+	for i, r := range word {
+		if isRuVowel(r) {
+			rv = i + 1
+			break
+		}
+	}
+	for i := 1; i < len(word); i++ {
+		if !isRuVowel(word[i]) && isRuVowel(word[i-1]) {
+			r1 = i + 1
+			break
+		}
+	}
+	r2 = len(word)
+	for i := r1 + 1; i < len(word); i++ {
+		if !isRuVowel(word[i]) && isRuVowel(word[i-1]) {
+			r2 = i + 1
+			break
+		}
+	}
+	return rv, r2
+}
+
+// stemRussian возвращает основу слова в нижнем регистре («ё» заменяется на «е»)
+func stemRussian(s string) string {
+	word := []rune(s)
+	for i, r := range word {
+		if r == 'ё' {
+			word[i] = 'е'
+		}
+	}
+
+	rv, r2 := ruRegions(word)
+
+	// Шаг 1
+	if w, ok := cutEither(word, rv, ruPerfectiveGerund1, ruPerfectiveGerund2); ok {
+		word = w
+	} else {
+		word, _ = cutSuffix(word, rv, ruReflexive, false)
+
+		if w, ok := cutSuffix(word, rv, ruAdjective, false); ok {
+			word = w
+			if w, ok := cutEither(word, rv, ruParticiple1, ruParticiple2); ok {
+				word = w
+			}
+		} else if w, ok := cutEither(word, rv, ruVerb1, ruVerb2); ok {
+			word = w
+		} else {
+			word, _ = cutSuffix(word, rv, ruNoun, false)
+		}
+	}
+
+	// Шаг 2
+	if len(word) > rv && word[len(word)-1] == 'и' {
+		word = word[:len(word)-1]
+	}
+
+	// Шаг 3
+	word, _ = cutSuffix(word, r2, ruDerivational, false)
+
+	// Шаг 4
+	undoubleN := len(word)-2 >= rv && hasSuffix(word, []rune("нн"))
+	if w, ok := cutSuffix(word, rv, ruSuperlative, false); ok {
+		word = w
+		undoubleN = len(word)-2 >= rv && hasSuffix(word, []rune("нн"))
+	} else if !undoubleN && len(word) > rv && word[len(word)-1] == 'ь' {
+		word = word[:len(word)-1]
+	}
+	if undoubleN {
+		word = word[:len(word)-1]
+	}
+
+	return string(word)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var errInvalidStorageKey = errors.New("invalid storage key")
+
+// FileStorage хранит загруженные файлы. Ключ — относительный путь вида
+// "products/12/ab34.jpg"; URL возвращает адрес, по которому файл отдаётся клиентам.
+// Сейчас файлы лежат на диске (LocalStorage), позже можно подключить S3-совместимое хранилище.
+type FileStorage interface {
+	Save(ctx context.Context, key string, r io.Reader, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// LocalStorage хранит файлы в каталоге dir и отдаёт их через роутер по префиксу urlPrefix
+type LocalStorage struct {
+	dir       string
+	urlPrefix string
+}
+
+func NewLocalStorage(dir, urlPrefix string) *LocalStorage {
+	return &LocalStorage{dir: dir, urlPrefix: strings.TrimSuffix(urlPrefix, "/")}
+}
+
+func (st *LocalStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean != "/"+key {
+		return "", errInvalidStorageKey
+	}
+	return filepath.Join(st.dir, filepath.FromSlash(clean)), nil
+}
+
+// Save пишет во временный файл и переименовывает его, чтобы клиенты не увидели недописанный файл
+func (st *LocalStorage) Save(ctx context.Context, key string, r io.Reader, contentType string) error {
+	dst, err := st.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+func (st *LocalStorage) Delete(ctx context.Context, key string) error {
+	dst, err := st.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(dst); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (st *LocalStorage) URL(key string) string {
+	return st.urlPrefix + "/" + key
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ThrottleScopeAccount = "account"
+	ThrottleScopeIP      = "ip"
+)
+
+// LoginPolicy — после Threshold неудач подряд вход блокируется на BaseLock,
+// и каждая следующая неудача удваивает блокировку вплоть до MaxLock.
+// Счётчик сбрасывается, если неудач не было дольше Window.
+type LoginPolicy struct {
+	Threshold int
+	BaseLock  time.Duration
+	MaxLock   time.Duration
+	Window    time.Duration
+}
+
+// lockFor — длительность блокировки после failures неудач подряд (0 — без блокировки)
+func (p LoginPolicy) lockFor(failures int) time.Duration {
+	if failures < p.Threshold {
+		return 0
+	}
+	lock := float64(p.BaseLock) * math.Pow(2, float64(failures-p.Threshold))
+	return time.Duration(math.Min(lock, float64(p.MaxLock)))
+}
+
+// С одного IP пробуют много разных логинов, поэтому порог для IP выше
+var loginPolicies = map[string]LoginPolicy{
+	ThrottleScopeAccount: {Threshold: 5, BaseLock: time.Minute, MaxLock: time.Hour, Window: time.Hour},
+	ThrottleScopeIP:      {Threshold: 20, BaseLock: time.Minute, MaxLock: time.Hour, Window: time.Hour},
+}
+
+// login_throttle хранит счётчики сутки после последней неудачи
+const loginThrottleRetention = 24 * time.Hour
+
+type LoginThrottle struct {
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+// lockedFor — сколько ещё действует блокировка
+func (t LoginThrottle) lockedFor(now time.Time) time.Duration {
+	if t.LockedUntil == nil || !t.LockedUntil.After(now) {
+		return 0
+	}
+	return t.LockedUntil.Sub(now)
+}
+
+// nextLoginThrottle — состояние счётчика после ещё одной неудачи. Общая логика
+// для MySQL- и in-memory репозиториев.
+func nextLoginThrottle(t LoginThrottle, policy LoginPolicy, now time.Time) LoginThrottle {
+	if t.lockedFor(now) == 0 && now.Sub(t.LastFailureAt) > policy.Window {
+		t.Failures = 0
+	}
+	t.Failures++
+	t.LastFailureAt = now
+	t.LockedUntil = nil
+	if lock := policy.lockFor(t.Failures); lock > 0 {
+		until := now.Add(lock)
+		t.LockedUntil = &until
+	}
+	return t
+}
+
+type LoginLockout struct {
+	ID          int64     `json:"id"`
+	Scope       string    `json:"scope"`
+	Subject     string    `json:"subject"`
+	UserID      *int64    `json:"user_id"`
+	IP          string    `json:"ip"`
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"locked_until"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// loginSubjects — ключи счётчиков для попытки входа. Логин приводится к нижнему регистру,
+// как и при сравнении в MySQL, и обрезается по размеру колонки subject.
+func loginSubjects(username, ip string) map[string]string {
+	account := []rune(strings.ToLower(username))
+	if len(account) > 100 {
+		account = account[:100]
+	}
+	return map[string]string{
+		ThrottleScopeAccount: string(account),
+		ThrottleScopeIP:      ip,
+	}
+}
+
+// loginRetryAfter возвращает, сколько ждать до следующей попытки, если логин или IP заблокированы
+func (s *Server) loginRetryAfter(ctx context.Context, username, ip string) (time.Duration, error) {
+	now := time.Now()
+	var wait time.Duration
+	for scope, subject := range loginSubjects(username, ip) {
+		t, err := s.logins.Get(ctx, scope, subject)
+		if err != nil {
+			return 0, err
+		}
+		wait = max(wait, t.lockedFor(now))
+	}
+	return wait, nil
+}
+
+// recordLoginFailure учитывает неудачную попытку и возвращает блокировку, если она началась сейчас
+func (s *Server) recordLoginFailure(ctx context.Context, username, ip string, userID *int64) (time.Duration, error) {
+	now := time.Now()
+	var wait time.Duration
+	for scope, subject := range loginSubjects(username, ip) {
+		t, err := s.logins.RecordFailure(ctx, scope, subject, loginPolicies[scope], now)
+		if err != nil {
+			return 0, err
+		}
+		lock := t.lockedFor(now)
+		if lock == 0 {
+			continue
+		}
+		wait = max(wait, lock)
+
+		lockout := LoginLockout{Scope: scope, Subject: subject, IP: ip, Failures: t.Failures, LockedUntil: *t.LockedUntil}
+		if scope == ThrottleScopeAccount {
+			lockout.UserID = userID
+		}
+		if err := s.logins.AddLockout(ctx, lockout); err != nil {
+			return 0, err
+		}
+		log.Printf("Вход заблокирован (%s %s) на %s после %d неудачных попыток", scope, subject, lock.Round(time.Second), t.Failures)
+	}
+	return wait, nil
+}
+
+// tooManyLoginAttempts отвечает 429 с Retry-After в секундах
+func tooManyLoginAttempts(c *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"message":     fmt.Sprintf("Слишком много неудачных попыток входа. Повторите через %d мин.", int(math.Ceil(wait.Minutes()))),
+		"retry_after": seconds,
+	})
+}
+
+func (s *Server) getLoginLockoutsHandler(c *gin.Context) {
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Неверные параметры страницы"})
+			return
+		}
+		limit = min(n, 200)
+	}
+
+	lockouts, err := s.logins.ListLockouts(c.Request.Context(), limit)
+	if err != nil {
+		log.Println("Get lockouts error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	c.JSON(http.StatusOK, lockouts)
+}
+
+// unlockLoginHandler снимает блокировку раньше срока, например по звонку пользователя
+func (s *Server) unlockLoginHandler(c *gin.Context) {
+	var req struct {
+		Scope   string `json:"scope"`
+		Subject string `json:"subject"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Subject == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+	if _, ok := loginPolicies[req.Scope]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неизвестный тип блокировки"})
+		return
+	}
+
+	subject := req.Subject
+	if req.Scope == ThrottleScopeAccount {
+		subject = strings.ToLower(subject)
+	}
+	if err := s.logins.Reset(c.Request.Context(), req.Scope, subject); err != nil {
+		log.Println("Unlock login error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	if claims := getUserClaims(c); claims != nil {
+		log.Printf("%s снял блокировку входа (%s %s)", claims.Username, req.Scope, subject)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Блокировка снята"})
+}
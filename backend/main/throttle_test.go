@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoginPolicyLockFor(t *testing.T) {
+	p := LoginPolicy{Threshold: 5, BaseLock: time.Minute, MaxLock: time.Hour, Window: time.Hour}
+	for failures, want := range map[int]time.Duration{
+		4:  0,
+		5:  time.Minute,
+		6:  2 * time.Minute,
+		8:  8 * time.Minute,
+		20: time.Hour,
+	} {
+		if got := p.lockFor(failures); got != want {
+			t.Errorf("lockFor(%d) = %s, want %s", failures, got, want)
+		}
+	}
+
+	// неудачи старше окна забываются
+	now := time.Now()
+	state := LoginThrottle{Failures: 4, LastFailureAt: now.Add(-2 * time.Hour)}
+	if next := nextLoginThrottle(state, p, now); next.Failures != 1 || next.LockedUntil != nil {
+		t.Fatalf("after window: %+v", next)
+	}
+}
+
+// после пяти неверных паролей вход блокируется с Retry-After, даже для верного пароля
+func TestLoginLockout(t *testing.T) {
+	ts := newTestServer(t)
+	ts.register(t, "prorab")
+	adminID, admin := ts.register(t, "admin")
+	ts.grantRole(t, adminID, "admin")
+
+	wrong := gin.H{"username": "prorab", "password": "ne-tot-parol"}
+	for i := 0; i < 4; i++ {
+		expect(t, ts.do(t, http.MethodPost, "/api/login", "", wrong), http.StatusBadRequest, nil)
+	}
+	w := ts.do(t, http.MethodPost, "/api/login", "", wrong)
+	expect(t, w, http.StatusTooManyRequests, nil)
+	if s, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || s <= 0 || s > 60 {
+		t.Fatalf("Retry-After = %q", w.Header().Get("Retry-After"))
+	}
+
+	// регистр логина не обходит блокировку
+	right := gin.H{"username": "Prorab", "password": "Kirpich-2024-stroy"}
+	w = ts.do(t, http.MethodPost, "/api/login", "", right)
+	expect(t, w, http.StatusTooManyRequests, nil)
+	if w.Header().Get("Retry-After") == "" {
+		t.Fatal("no Retry-After on a locked account")
+	}
+
+	var lockouts []LoginLockout
+	expect(t, ts.do(t, http.MethodGet, "/api/admin/security/lockouts", admin, nil), http.StatusOK, &lockouts)
+	if len(lockouts) != 1 || lockouts[0].Scope != ThrottleScopeAccount || lockouts[0].Subject != "prorab" || lockouts[0].Failures != 5 {
+		t.Fatalf("lockouts = %+v", lockouts)
+	}
+
+	w = ts.do(t, http.MethodPost, "/api/admin/security/unlock", admin, gin.H{"scope": ThrottleScopeAccount, "subject": "PRORAB"})
+	expect(t, w, http.StatusOK, nil)
+	right["username"] = "prorab"
+	expect(t, ts.do(t, http.MethodPost, "/api/login", "", right), http.StatusOK, nil)
+}
@@ -0,0 +1,514 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	TokenPurposeLogin2FA = "login_2fa"
+
+	// за это время нужно ввести код после пароля
+	loginChallengeTTL = 5 * time.Minute
+)
+
+// nonAdminPermissions не делают роль административной, и 2FA для них не обязательна.
+// resumes.search — право работодателя: его дают только проверенная администратором компания
+// или назначение администратором (см. syncEmployerRole), а открывает оно лишь контакты
+// соискателей, которых работодатель пригласил или которые откликнулись сами. Требовать 2FA
+// от каждого работодателя ради этого права несоразмерно.
+var nonAdminPermissions = []string{PermResumesSearch}
+
+// twoFactorRequired — 2FA обязательна для всех ролей с административными правами
+func (s *Server) twoFactorRequired(ctx context.Context, userID int64) (bool, error) {
+	perms, err := s.roles.UserPermissions(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, perm := range perms {
+		if !slices.Contains(nonAdminPermissions, perm) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Server) twoFactorEnabled(ctx context.Context, userID int64) (bool, error) {
+	state, err := s.twoFactor.Get(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return state.Enabled, err
+}
+
+// loginChallenge возвращает ответ на первый шаг входа, если после пароля нужен код.
+// nil — второй фактор не нужен, можно сразу выдавать токены.
+func (s *Server) loginChallenge(ctx context.Context, user User) (gin.H, error) {
+	required, err := s.twoFactorRequired(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := s.twoFactorEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !required && !enabled {
+		return nil, nil
+	}
+
+	challenge, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userTokens.Create(ctx, user.ID, TokenPurposeLogin2FA, hashToken(challenge), time.Now().Add(loginChallengeTTL)); err != nil {
+		return nil, err
+	}
+
+	// setup — администратор ещё не подключил приложение и должен сделать это до входа
+	step := "verify"
+	if !enabled {
+		step = "setup"
+	}
+	return gin.H{
+		"two_factor": step,
+		"challenge":  challenge,
+		"expires_in": int(loginChallengeTTL.Seconds()),
+	}, nil
+}
+
+// loginLocked отвечает 429, если вход для пользователя или IP заблокирован
+func (s *Server) loginLocked(c *gin.Context, username string) bool {
+	wait, err := s.loginRetryAfter(c.Request.Context(), username, c.ClientIP())
+	if err != nil {
+		log.Println("Login lock check error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return true
+	}
+	if wait > 0 {
+		tooManyLoginAttempts(c, wait)
+		return true
+	}
+	return false
+}
+
+// secondFactorFailed считает неверный код неудачной попыткой входа, иначе шесть цифр
+// можно было бы перебрать
+func (s *Server) secondFactorFailed(c *gin.Context, user User) {
+	wait, err := s.recordLoginFailure(c.Request.Context(), user.Username, c.ClientIP(), &user.ID)
+	if err != nil {
+		log.Println("Record 2FA failure error:", err)
+	}
+	if wait > 0 {
+		tooManyLoginAttempts(c, wait)
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный код"})
+}
+
+// verifySecondFactor принимает код из приложения или код восстановления у включённой 2FA
+func (s *Server) verifySecondFactor(ctx context.Context, userID int64, state TOTPState, code string) (ok, recovery bool, err error) {
+	if step, match := matchTOTP(state.Secret, code, time.Now()); match {
+		ok, err = s.twoFactor.UseStep(ctx, userID, step)
+		return ok, false, err
+	}
+	ok, err = s.twoFactor.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code)))
+	return ok, ok, err
+}
+
+func hashRecoveryCodes(codes []string) []string {
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = hashToken(code)
+	}
+	return hashes
+}
+
+// startTOTPSetup выдаёт новый секрет, который вступит в силу после первого верного кода
+func (s *Server) startTOTPSetup(c *gin.Context, user User) {
+	ctx := c.Request.Context()
+	if enabled, err := s.twoFactorEnabled(ctx, user.ID); err != nil {
+		log.Println("2FA setup error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	} else if enabled {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Двухфакторная аутентификация уже включена"})
+		return
+	}
+
+	secret, err := newTOTPSecret()
+	if err == nil {
+		err = s.twoFactor.SetPending(ctx, user.ID, secret)
+	}
+	if err != nil {
+		log.Println("2FA setup error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      secret,
+		"otpauth_uri": totpURI(user.Username, secret),
+	})
+}
+
+// enableTOTP проверяет первый код из приложения и включает 2FA.
+// Возвращает коды восстановления; nil — ответ клиенту уже отправлен.
+func (s *Server) enableTOTP(c *gin.Context, user User, code string) []string {
+	ctx := c.Request.Context()
+	state, err := s.twoFactor.Get(ctx, user.ID)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Сначала добавьте аккаунт в приложение-аутентификатор"})
+		return nil
+	} else if err != nil {
+		log.Println("2FA enable error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return nil
+	}
+	if state.Enabled {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Двухфакторная аутентификация уже включена"})
+		return nil
+	}
+
+	step, ok := matchTOTP(state.Secret, code, time.Now())
+	if !ok {
+		s.secondFactorFailed(c, user)
+		return nil
+	}
+
+	codes, err := newRecoveryCodes()
+	if err == nil {
+		err = s.twoFactor.Enable(ctx, user.ID, step, hashRecoveryCodes(codes))
+	}
+	if err != nil {
+		log.Println("2FA enable error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return nil
+	}
+
+	log.Printf("Пользователь %s включил двухфакторную аутентификацию", user.Username)
+	return codes
+}
+
+// challengeUser находит пользователя по токену первого шага входа
+func (s *Server) challengeUser(c *gin.Context, challenge string) (User, bool) {
+	ctx := c.Request.Context()
+	userID, err := s.userTokens.Lookup(ctx, TokenPurposeLogin2FA, hashToken(challenge))
+	var user User
+	if err == nil {
+		user, err = s.users.GetByID(ctx, userID)
+	}
+	if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Время на вход истекло, войдите заново"})
+		return user, false
+	} else if err != nil {
+		log.Println("Login challenge error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return user, false
+	}
+	return user, true
+}
+
+// loginSetupTwoFactorHandler — настройка приложения прямо во время входа, когда 2FA обязательна
+func (s *Server) loginSetupTwoFactorHandler(c *gin.Context) {
+	var req struct {
+		Challenge string `json:"challenge"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Challenge == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+
+	user, ok := s.challengeUser(c, req.Challenge)
+	if !ok {
+		return
+	}
+	s.startTOTPSetup(c, user)
+}
+
+// loginTwoFactorHandler — второй шаг входа: код из приложения или код восстановления.
+// Если 2FA настраивается во время входа, первый верный код её включает.
+func (s *Server) loginTwoFactorHandler(c *gin.Context) {
+	var req struct {
+		Challenge string `json:"challenge"`
+		Code      string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Challenge == "" || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат запроса"})
+		return
+	}
+
+	user, ok := s.challengeUser(c, req.Challenge)
+	if !ok || s.loginLocked(c, user.Username) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	state, err := s.twoFactor.Get(ctx, user.ID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		log.Println("Login 2FA error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	var recoveryCodes []string
+	usedRecovery := false
+	if state.Enabled {
+		ok, usedRecovery, err = s.verifySecondFactor(ctx, user.ID, state, req.Code)
+		if err != nil {
+			log.Println("Login 2FA error:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+			return
+		}
+		if !ok {
+			s.secondFactorFailed(c, user)
+			return
+		}
+	} else if recoveryCodes = s.enableTOTP(c, user, req.Code); recoveryCodes == nil {
+		return
+	}
+
+	if _, err := s.userTokens.Consume(ctx, TokenPurposeLogin2FA, hashToken(req.Challenge)); errors.Is(err, ErrInvalidToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Время на вход истекло, войдите заново"})
+		return
+	} else if err != nil {
+		log.Println("Login 2FA error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	// счётчик неудач сбрасываем только после второго фактора: иначе верный пароль
+	// позволял бы перебирать коды без блокировки
+	if err := s.logins.Reset(ctx, ThrottleScopeAccount, loginSubjects(user.Username, c.ClientIP())[ThrottleScopeAccount]); err != nil {
+		log.Println("Login 2FA error:", err)
+	}
+
+	tokens, err := s.startSession(c, user)
+	if err != nil {
+		log.Println("Login 2FA error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	resp, err := s.authResponse(ctx, tokens, user)
+	if err != nil {
+		log.Println("Login 2FA error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	// коды показываются один раз, в БД хранятся только их хеши
+	if recoveryCodes != nil {
+		resp["recovery_codes"] = recoveryCodes
+	}
+	if usedRecovery {
+		left, err := s.twoFactor.RecoveryCodesLeft(ctx, user.ID)
+		if err != nil {
+			log.Println("Login 2FA error:", err)
+		}
+		resp["recovery_codes_left"] = left
+		log.Printf("%s вошёл по коду восстановления, осталось %d", user.Username, left)
+	}
+
+	log.Printf("Успешный логин: %s (2FA)", user.Username)
+	c.JSON(http.StatusOK, resp)
+}
+
+// currentUser читает пользователя из БД по токену: логин в токене может быть устаревшим
+func (s *Server) currentUser(c *gin.Context) (User, bool) {
+	claims := getUserClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return User{}, false
+	}
+	user, err := s.users.GetByID(c.Request.Context(), claims.ID)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неавторизован"})
+		return user, false
+	} else if err != nil {
+		log.Println("Get user error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return user, false
+	}
+	return user, true
+}
+
+func (s *Server) getTwoFactorHandler(c *gin.Context) {
+	user, ok := s.currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	required, err := s.twoFactorRequired(ctx, user.ID)
+	var enabled bool
+	if err == nil {
+		enabled, err = s.twoFactorEnabled(ctx, user.ID)
+	}
+	var left int
+	if err == nil && enabled {
+		left, err = s.twoFactor.RecoveryCodesLeft(ctx, user.ID)
+	}
+	if err != nil {
+		log.Println("Get 2FA error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":             enabled,
+		"required":            required,
+		"recovery_codes_left": left,
+	})
+}
+
+func (s *Server) setupTwoFactorHandler(c *gin.Context) {
+	if user, ok := s.currentUser(c); ok {
+		s.startTOTPSetup(c, user)
+	}
+}
+
+func (s *Server) enableTwoFactorHandler(c *gin.Context) {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Введите код из приложения"})
+		return
+	}
+
+	user, ok := s.currentUser(c)
+	if !ok || s.loginLocked(c, user.Username) {
+		return
+	}
+	if codes := s.enableTOTP(c, user, req.Code); codes != nil {
+		c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+	}
+}
+
+// regenerateRecoveryCodesHandler заменяет все коды восстановления новыми
+func (s *Server) regenerateRecoveryCodesHandler(c *gin.Context) {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Введите код из приложения"})
+		return
+	}
+
+	user, ok := s.currentUser(c)
+	if !ok || s.loginLocked(c, user.Username) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	state, err := s.twoFactor.Get(ctx, user.ID)
+	if errors.Is(err, ErrNotFound) || (err == nil && !state.Enabled) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Двухфакторная аутентификация не включена"})
+		return
+	} else if err != nil {
+		log.Println("Regenerate recovery codes error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	ok, _, err = s.verifySecondFactor(ctx, user.ID, state, req.Code)
+	if err != nil {
+		log.Println("Regenerate recovery codes error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if !ok {
+		s.secondFactorFailed(c, user)
+		return
+	}
+
+	codes, err := newRecoveryCodes()
+	if err == nil {
+		err = s.twoFactor.ReplaceRecoveryCodes(ctx, user.ID, hashRecoveryCodes(codes))
+	}
+	if err != nil {
+		log.Println("Regenerate recovery codes error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func (s *Server) disableTwoFactorHandler(c *gin.Context) {
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Введите пароль для подтверждения"})
+		return
+	}
+
+	user, ok := s.currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if required, err := s.twoFactorRequired(ctx, user.ID); err != nil {
+		log.Println("Disable 2FA error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	} else if required {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Для вашей роли двухфакторная аутентификация обязательна"})
+		return
+	}
+
+	if !s.checkCurrentPassword(c, user, req.Password) {
+		return
+	}
+	if err := s.twoFactor.Disable(ctx, user.ID); err != nil {
+		log.Println("Disable 2FA error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	log.Printf("Пользователь %s отключил двухфакторную аутентификацию", user.Username)
+	c.JSON(http.StatusOK, gin.H{"message": "Двухфакторная аутентификация отключена"})
+}
+
+// resetUserTwoFactorHandler сбрасывает 2FA пользователю, потерявшему и телефон, и коды восстановления.
+// Его сессии завершаются; при следующем входе приложение придётся подключить заново.
+func (s *Server) resetUserTwoFactorHandler(c *gin.Context) {
+	admin := getUserClaims(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := s.users.GetByID(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Пользователь не найден"})
+		return
+	} else if err != nil {
+		log.Println("Reset 2FA error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+
+	if err := s.twoFactor.Disable(ctx, id); err != nil {
+		log.Println("Reset 2FA error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сервера"})
+		return
+	}
+	if _, err := s.sessions.RevokeAllForUser(ctx, id); err != nil {
+		log.Println("Reset 2FA error:", err)
+	}
+
+	log.Printf("Администратор %s сбросил 2FA пользователя %s", admin.Username, user.Username)
+	c.JSON(http.StatusOK, gin.H{"message": "Двухфакторная аутентификация сброшена"})
+}
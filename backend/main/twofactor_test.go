@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// код для шага step, как его покажет приложение-аутентификатор
+func totpAt(t *testing.T, secret string, step int64) string {
+	t.Helper()
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return totpCode(key, step)
+}
+
+func TestMatchTOTP(t *testing.T) {
+	// тестовый вектор RFC 6238 для SHA1: T = 59 с, шестизначный хвост 94287082
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	if code := totpAt(t, secret, 1); code != "287082" {
+		t.Fatalf("code = %s, want 287082", code)
+	}
+
+	now := time.Unix(59, 0)
+	if step, ok := matchTOTP(secret, "287 082", now); !ok || step != 1 {
+		t.Fatalf("matchTOTP = %d, %v", step, ok)
+	}
+	// соседние шаги принимаются, дальние — нет
+	if _, ok := matchTOTP(secret, totpAt(t, secret, 2), now); !ok {
+		t.Fatal("next step rejected")
+	}
+	if _, ok := matchTOTP(secret, totpAt(t, secret, 3), now); ok {
+		t.Fatal("step outside the skew accepted")
+	}
+}
+
+type loginChallengeResponse struct {
+	TwoFactor string `json:"two_factor"`
+	Challenge string `json:"challenge"`
+}
+
+type loginResponse struct {
+	Token             string   `json:"token"`
+	RecoveryCodes     []string `json:"recovery_codes"`
+	RecoveryCodesLeft int      `json:"recovery_codes_left"`
+}
+
+// администратор подключает приложение при первом входе, затем входит по коду
+// или по коду восстановления; использованные коды второй раз не принимаются
+func TestTwoFactorLogin(t *testing.T) {
+	ts := newTestServer(t)
+	adminID, _ := ts.register(t, "admin")
+	if _, err := ts.users.SetRole(context.Background(), adminID, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	password := gin.H{"username": "admin", "password": "Kirpich-2024-stroy"}
+	login := func(want string) string {
+		t.Helper()
+		var ch loginChallengeResponse
+		expect(t, ts.do(t, http.MethodPost, "/api/login", "", password), http.StatusOK, &ch)
+		if ch.TwoFactor != want || ch.Challenge == "" {
+			t.Fatalf("login = %+v, want two_factor %s", ch, want)
+		}
+		return ch.Challenge
+	}
+	secondFactor := func(challenge, code string, status int) loginResponse {
+		t.Helper()
+		var resp loginResponse
+		w := ts.do(t, http.MethodPost, "/api/login/2fa", "", gin.H{"challenge": challenge, "code": code})
+		expect(t, w, status, &resp)
+		return resp
+	}
+
+	// первый вход: без подключённого приложения токенов нет
+	challenge := login("setup")
+	var setup struct {
+		Secret string `json:"secret"`
+	}
+	expect(t, ts.do(t, http.MethodPost, "/api/login/2fa/setup", "", gin.H{"challenge": challenge}), http.StatusOK, &setup)
+	secondFactor(challenge, "abcdef", http.StatusBadRequest)
+
+	step := time.Now().Unix() / totpPeriod
+	code := totpAt(t, setup.Secret, step)
+	resp := secondFactor(challenge, code, http.StatusOK)
+	if resp.Token == "" || len(resp.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("enable response: %+v", resp)
+	}
+	expect(t, ts.do(t, http.MethodGet, "/api/admin/jobs", resp.Token, nil), http.StatusOK, nil)
+	// запрос второго шага одноразовый
+	secondFactor(challenge, totpAt(t, setup.Secret, step+1), http.StatusBadRequest)
+
+	// код, которым включили 2FA, повторно не подходит, следующий — подходит,
+	// а после него не подходит и предыдущий
+	challenge = login("verify")
+	secondFactor(challenge, code, http.StatusBadRequest)
+	secondFactor(challenge, totpAt(t, setup.Secret, step+1), http.StatusOK)
+	challenge = login("verify")
+	secondFactor(challenge, code, http.StatusBadRequest)
+
+	// код восстановления — без учёта регистра и дефиса, и только один раз
+	recovery := resp.RecoveryCodes[0]
+	resp = secondFactor(challenge, strings.ToUpper(strings.ReplaceAll(recovery, "-", "")), http.StatusOK)
+	if resp.Token == "" || resp.RecoveryCodesLeft != recoveryCodeCount-1 {
+		t.Fatalf("recovery login: %+v", resp)
+	}
+	secondFactor(login("verify"), recovery, http.StatusBadRequest)
+}
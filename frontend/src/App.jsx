@@ -6,12 +6,20 @@ import Footer from './components/Footer/Footer';
 import Main from './pages/Main/Main';
 import Products from './pages/Products/Products';
 import Job from './pages/Job/Job';
+import Company from './pages/Company/Company';
+import Resumes from './pages/Resumes/Resumes';
 import Basket from './pages/Basket/Basket';
 import Login from './pages/Login/Login';
 import Registration from './pages/Registration/Registration';
 import Profile from './pages/Profile/Profile';
+import VerifyEmail from './pages/VerifyEmail/VerifyEmail';
+import OAuthCallback from './pages/OAuthCallback/OAuthCallback';
+import ForgotPassword from './pages/Password/ForgotPassword';
+import ResetPassword from './pages/Password/ResetPassword';
 import AdminProducts from './pages/Admin/AdminProducts';
 import AdminJobs from './pages/Admin/AdminJobs';
+import AdminCompanies from './pages/Admin/AdminCompanies';
+import AdminResumes from './pages/Admin/AdminResumes';
 import './styles/global.css';
 
 function App() {
@@ -25,12 +33,20 @@ function App() {
               <Route path="/" element={<Main />} />
               <Route path="/products" element={<Products />} />
               <Route path="/jobs" element={<Job />} />
+              <Route path="/companies/:id" element={<Company />} />
+              <Route path="/resumes" element={<Resumes />} />
               <Route path="/basket" element={<Basket />} />
               <Route path="/login" element={<Login />} />
               <Route path="/registration" element={<Registration />} />
               <Route path="/profile" element={<Profile />} />
+              <Route path="/verify-email" element={<VerifyEmail />} />
+              <Route path="/oauth/callback" element={<OAuthCallback />} />
+              <Route path="/forgot-password" element={<ForgotPassword />} />
+              <Route path="/reset-password" element={<ResetPassword />} />
               <Route path="/admin/products" element={<AdminProducts />} />
               <Route path="/admin/jobs" element={<AdminJobs />} />
+              <Route path="/admin/companies" element={<AdminCompanies />} />
+              <Route path="/admin/resumes" element={<AdminResumes />} />
             </Routes>
           </main>
           <Footer />
@@ -5,7 +5,7 @@ import BasketPopup from '../Basket/BasketPopup';
 import './Header.css';
 
 const Header = () => {
-  const { user, logout } = useContext(AuthContext);
+  const { user, logout, can } = useContext(AuthContext);
   const [isBasketOpen, setIsBasketOpen] = useState(false);
 
   const handleLogout = () => {
@@ -22,6 +22,9 @@ const Header = () => {
         <nav className="nav">
           <Link to="/products" className="nav-link">Продукты</Link>
           <Link to="/jobs" className="nav-link">Вакансии</Link>
+          {can('resumes.search') && (
+            <Link to="/resumes" className="nav-link">Резюме</Link>
+          )}
         </nav>
 
         <div className="header-actions">
@@ -37,11 +40,17 @@ const Header = () => {
               <Link to="/profile" className="nav-link">
                 Профиль
               </Link>
-              {user.role === 'admin' && (
-                <>
-                  <Link to="/admin/products" className="nav-link">Админ-Продукты</Link>
-                  <Link to="/admin/jobs" className="nav-link">Админ-Вакансии</Link>
-                </>
+              {can('products.write') && (
+                <Link to="/admin/products" className="nav-link">Админ-Продукты</Link>
+              )}
+              {can('jobs.moderate') && (
+                <Link to="/admin/jobs" className="nav-link">Админ-Вакансии</Link>
+              )}
+              {can('resumes.moderate') && (
+                <Link to="/admin/resumes" className="nav-link">Админ-Резюме</Link>
+              )}
+              {can('companies.verify') && (
+                <Link to="/admin/companies" className="nav-link">Админ-Компании</Link>
               )}
               <button onClick={handleLogout} className="logout-btn">
                 Выйти
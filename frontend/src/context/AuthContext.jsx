@@ -46,6 +46,24 @@ export const AuthProvider = ({ children }) => {
     }
   };
 
+  // saveSession сохраняет токены и пользователя из ответа входа, регистрации или смены пароля
+  const saveSession = ({ token, refresh_token, user: userData }) => {
+    setUser(userData);
+    localStorage.setItem('token', token);
+    localStorage.setItem('refresh_token', refresh_token);
+    localStorage.setItem('user', JSON.stringify(userData));
+    return userData;
+  };
+
+  // updateUser подхватывает изменения профиля (логин, email) без повторного входа
+  const updateUser = (changes) => {
+    setUser((prev) => {
+      const next = { ...prev, ...changes };
+      localStorage.setItem('user', JSON.stringify(next));
+      return next;
+    });
+  };
+
   const login = async (username, password) => {
     try {
       const response = await axios.post(`${API_URL}/login`, {
@@ -53,12 +71,53 @@ export const AuthProvider = ({ children }) => {
         password
       });
 
-      const { token, user: userData } = response.data;
-      
-      setUser(userData);
-      localStorage.setItem('token', token);
-      localStorage.setItem('user', JSON.stringify(userData));
-      
+      // для администраторов сервер сначала запрашивает код из приложения
+      if (response.data.two_factor) {
+        return {
+          success: false,
+          twoFactor: response.data.two_factor,
+          challenge: response.data.challenge
+        };
+      }
+
+      const userData = saveSession(response.data);
+      return { success: true, user: userData };
+    } catch (error) {
+      const message = error.response?.data?.message || 'Ошибка при входе';
+      return { success: false, error: message };
+    }
+  };
+
+  const completeTwoFactor = async (challenge, code) => {
+    try {
+      const response = await axios.post(`${API_URL}/login/2fa`, { challenge, code });
+      const userData = saveSession(response.data);
+      return {
+        success: true,
+        user: userData,
+        recoveryCodes: response.data.recovery_codes,
+        recoveryCodesLeft: response.data.recovery_codes_left
+      };
+    } catch (error) {
+      const message = error.response?.data?.message || 'Ошибка при входе';
+      return { success: false, error: message };
+    }
+  };
+
+  // loginWithOAuth меняет одноразовый код из редиректа провайдера на токены
+  const loginWithOAuth = async (token) => {
+    try {
+      const response = await axios.post(`${API_URL}/oauth/exchange`, { token });
+
+      if (response.data.two_factor) {
+        return {
+          success: false,
+          twoFactor: response.data.two_factor,
+          challenge: response.data.challenge
+        };
+      }
+
+      const userData = saveSession(response.data);
       return { success: true, user: userData };
     } catch (error) {
       const message = error.response?.data?.message || 'Ошибка при входе';
@@ -74,12 +133,7 @@ export const AuthProvider = ({ children }) => {
         password
       });
 
-      const { token, user: userData } = response.data;
-      
-      setUser(userData);
-      localStorage.setItem('token', token);
-      localStorage.setItem('user', JSON.stringify(userData));
-      
+      const userData = saveSession(response.data);
       return { success: true, user: userData };
     } catch (error) {
       const message = error.response?.data?.message || 'Ошибка при регистрации';
@@ -88,9 +142,18 @@ export const AuthProvider = ({ children }) => {
   };
 
   const logout = () => {
+    const token = localStorage.getItem('token');
+    if (token) {
+      // завершаем сессию на сервере; локальные данные очищаем в любом случае
+      axios.post(`${API_URL}/logout`, null, {
+        headers: { Authorization: `Bearer ${token}` }
+      }).catch(() => {});
+    }
+
     setUser(null);
     setBasket([]);
     localStorage.removeItem('token');
+    localStorage.removeItem('refresh_token');
     localStorage.removeItem('user');
     localStorage.removeItem('basket');
   };
@@ -138,13 +201,21 @@ export const AuthProvider = ({ children }) => {
     return token ? { Authorization: `Bearer ${token}` } : {};
   };
 
+  // права приходят с сервера вместе с пользователем; проверка на сервере всё равно выполняется
+  const can = (permission) => Boolean(user?.permissions?.includes(permission));
+
   const value = {
     user,
+    can,
     basket,
     loading,
     login,
+    completeTwoFactor,
+    loginWithOAuth,
     register,
     logout,
+    saveSession,
+    updateUser,
     addToBasket,
     removeFromBasket,
     updateQuantity,
@@ -0,0 +1,139 @@
+import React, { useState, useEffect, useContext } from 'react';
+import { Link, useNavigate } from 'react-router-dom';
+import { AuthContext } from '../../context/AuthContext';
+import { companiesAPI } from '../../utils/api';
+import './Admin.css';
+
+const tabs = [
+  { verified: false, label: 'Ожидают проверки' },
+  { verified: true, label: 'Проверенные' },
+];
+
+const AdminCompanies = () => {
+  const { user, can } = useContext(AuthContext);
+  const navigate = useNavigate();
+  const [companies, setCompanies] = useState([]);
+  const [loading, setLoading] = useState(true);
+  const [verified, setVerified] = useState(false);
+  const [search, setSearch] = useState('');
+
+  useEffect(() => {
+    if (user && !can('companies.verify')) {
+      navigate('/');
+      return;
+    }
+    fetchCompanies();
+  }, [user, navigate, verified]);
+
+  const fetchCompanies = async () => {
+    try {
+      setLoading(true);
+      const params = { verified };
+      if (search) params.search = search;
+
+      const response = await companiesAPI.getAll(params);
+      setCompanies(response.data);
+    } catch (error) {
+      console.error('Error fetching companies:', error);
+      alert('Ошибка при загрузке компаний');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const handleSearchSubmit = (e) => {
+    e.preventDefault();
+    fetchCompanies();
+  };
+
+  const handleVerify = async (company, value) => {
+    try {
+      await companiesAPI.verify(company.id, value);
+      fetchCompanies();
+    } catch (error) {
+      alert('Ошибка: ' + (error.response?.data?.message || 'Неизвестная ошибка'));
+    }
+  };
+
+  if (!user || !can('companies.verify')) {
+    return (
+      <div className="admin-page">
+        <div className="container">
+          <h1>Доступ запрещен</h1>
+          <p>У вас нет прав для доступа к этой странице.</p>
+        </div>
+      </div>
+    );
+  }
+
+  return (
+    <div className="admin-page">
+      <div className="container">
+        <div className="admin-header">
+          <h1>Проверка компаний</h1>
+          <form className="admin-stats" onSubmit={handleSearchSubmit}>
+            <input
+              className="form-input"
+              placeholder="Название"
+              value={search}
+              onChange={e => setSearch(e.target.value)}
+            />
+            <button type="submit" className="btn btn-secondary">Найти</button>
+          </form>
+        </div>
+
+        <div className="admin-tabs">
+          {tabs.map(tab => (
+            <button
+              key={tab.label}
+              className={`tab-btn ${verified === tab.verified ? 'active' : ''}`}
+              onClick={() => setVerified(tab.verified)}
+            >
+              {tab.label}
+            </button>
+          ))}
+        </div>
+
+        <div className="admin-content">
+          {loading ? (
+            <div className="loading">Загрузка компаний...</div>
+          ) : companies.length === 0 ? (
+            <div className="no-items">
+              <p>Нет компаний</p>
+            </div>
+          ) : (
+            companies.map(company => (
+              <div key={company.id} className="admin-item">
+                <div className="item-details">
+                  <h3><Link to={`/companies/${company.id}`}>{company.name}</Link></h3>
+                  <p className="company">{company.inn ? `ИНН ${company.inn}` : 'ИНН не указан'}</p>
+                  <p className="author">
+                    {[company.website, company.phone, company.email].filter(Boolean).join(', ') || 'Контакты не указаны'}
+                  </p>
+                  {company.description && <p className="description">{company.description}</p>}
+                  {company.verified_at && (
+                    <p className="author">Проверена {new Date(company.verified_at).toLocaleDateString('ru-RU')}</p>
+                  )}
+                </div>
+
+                <div className="item-actions">
+                  {company.verified ? (
+                    <button className="btn btn-danger" onClick={() => handleVerify(company, false)}>
+                      Снять отметку
+                    </button>
+                  ) : (
+                    <button className="btn btn-primary" onClick={() => handleVerify(company, true)}>
+                      Подтвердить
+                    </button>
+                  )}
+                </div>
+              </div>
+            ))
+          )}
+        </div>
+      </div>
+    </div>
+  );
+};
+
+export default AdminCompanies;
@@ -1,37 +1,54 @@
 import React, { useState, useEffect, useContext } from 'react';
 import { AuthContext } from '../../context/AuthContext';
 import { useNavigate } from 'react-router-dom';
-import axios from 'axios';
+import { jobsAPI, jobStatusLabels } from '../../utils/api';
 import './Admin.css';
 
+const statusTabs = ['pending', 'approved', 'rejected', 'archived'];
+
+// какие решения доступны модератору в каждом статусе, как на сервере
+const statusActions = {
+  pending: ['approved', 'rejected', 'archived'],
+  approved: ['rejected', 'archived'],
+  rejected: ['approved', 'archived'],
+  archived: ['approved'],
+};
+
+const actionLabels = {
+  approved: 'Одобрить',
+  rejected: 'Отклонить',
+  archived: 'В архив',
+};
+
 const AdminJobs = () => {
-  const { user, getAuthHeader, API_URL } = useContext(AuthContext);
+  const { user, can } = useContext(AuthContext);
   const navigate = useNavigate();
-  const [pendingJobs, setPendingJobs] = useState([]);
-  const [approvedJobs, setApprovedJobs] = useState([]);
+  const [jobs, setJobs] = useState([]);
   const [loading, setLoading] = useState(true);
   const [activeTab, setActiveTab] = useState('pending');
+  const [filters, setFilters] = useState({ search: '', author: '' });
+  const [rejectJob, setRejectJob] = useState(null);
+  const [reason, setReason] = useState('');
+  const [error, setError] = useState('');
+  const [history, setHistory] = useState(null);
 
   useEffect(() => {
-    if (user && user.role !== 'admin') {
+    if (user && !can('jobs.moderate')) {
       navigate('/');
       return;
     }
     fetchJobs();
-  }, [user, navigate]);
+  }, [user, navigate, activeTab]);
 
   const fetchJobs = async () => {
     try {
       setLoading(true);
-      
-      const pendingResponse = await axios.get(`${API_URL}/admin/jobs`, {
-        headers: getAuthHeader()
-      });
-      setPendingJobs(pendingResponse.data);
-      
-      const approvedResponse = await axios.get(`${API_URL}/jobs`);
-      setApprovedJobs(approvedResponse.data);
-      
+      const params = { status: activeTab };
+      if (filters.search) params.search = filters.search;
+      if (filters.author) params.author = filters.author;
+
+      const response = await jobsAPI.getQueue(params);
+      setJobs(response.data);
     } catch (error) {
       console.error('Error fetching jobs:', error);
       alert('Ошибка при загрузке вакансий');
@@ -40,49 +57,47 @@ const AdminJobs = () => {
     }
   };
 
-  const handleApprove = async (jobId) => {
+  const handleFilterSubmit = (e) => {
+    e.preventDefault();
+    fetchJobs();
+  };
+
+  const handleStatus = async (job, status) => {
+    if (status === 'rejected') {
+      setError('');
+      setReason('');
+      setRejectJob(job);
+      return;
+    }
     try {
-      await axios.put(
-        `${API_URL}/admin/jobs/${jobId}/approve`,
-        {},
-        { headers: getAuthHeader() }
-      );
-      alert('Вакансия одобрена!');
+      await jobsAPI.setStatus(job.id, status);
       fetchJobs();
     } catch (error) {
       alert('Ошибка: ' + (error.response?.data?.message || 'Неизвестная ошибка'));
     }
   };
 
-  const handleReject = async (jobId) => {
-    if (window.confirm('Вы уверены, что хотите отклонить эту вакансию?')) {
-      try {
-        await axios.delete(`${API_URL}/admin/jobs/${jobId}`, {
-          headers: getAuthHeader()
-        });
-        alert('Вакансия отклонена!');
-        fetchJobs();
-      } catch (error) {
-        alert('Ошибка: ' + (error.response?.data?.message || 'Неизвестная ошибка'));
-      }
+  const handleRejectSubmit = async (e) => {
+    e.preventDefault();
+    try {
+      await jobsAPI.setStatus(rejectJob.id, 'rejected', reason);
+      setRejectJob(null);
+      fetchJobs();
+    } catch (error) {
+      setError(error.response?.data?.message || 'Неизвестная ошибка');
     }
   };
 
-  const handleDeleteApproved = async (jobId) => {
-    if (window.confirm('Вы уверены, что хотите удалить эту вакансию?')) {
-      try {
-        await axios.delete(`${API_URL}/admin/jobs/${jobId}`, {
-          headers: getAuthHeader()
-        });
-        alert('Вакансия удалена!');
-        fetchJobs();
-      } catch (error) {
-        alert('Ошибка: ' + (error.response?.data?.message || 'Неизвестная ошибка'));
-      }
+  const handleHistory = async (job) => {
+    try {
+      const response = await jobsAPI.getHistory(job.id);
+      setHistory({ job, entries: response.data });
+    } catch (error) {
+      alert('Ошибка: ' + (error.response?.data?.message || 'Неизвестная ошибка'));
     }
   };
 
-  if (!user || user.role !== 'admin') {
+  if (!user || !can('jobs.moderate')) {
     return (
       <div className="admin-page">
         <div className="container">
@@ -98,109 +113,128 @@ const AdminJobs = () => {
       <div className="container">
         <div className="admin-header">
           <h1>Управление вакансиями</h1>
-          <div className="admin-stats">
-            <span>Ожидают: {pendingJobs.length}</span>
-            <span>Одобрены: {approvedJobs.length}</span>
-          </div>
+          <form className="admin-stats" onSubmit={handleFilterSubmit}>
+            <input
+              className="form-input"
+              placeholder="Название"
+              value={filters.search}
+              onChange={e => setFilters({ ...filters, search: e.target.value })}
+            />
+            <input
+              className="form-input"
+              placeholder="Автор"
+              value={filters.author}
+              onChange={e => setFilters({ ...filters, author: e.target.value })}
+            />
+            <button type="submit" className="btn btn-secondary">Найти</button>
+          </form>
         </div>
 
         <div className="admin-tabs">
-          <button 
-            className={`tab-btn ${activeTab === 'pending' ? 'active' : ''}`}
-            onClick={() => setActiveTab('pending')}
-          >
-            На модерации ({pendingJobs.length})
-          </button>
-          <button 
-            className={`tab-btn ${activeTab === 'approved' ? 'active' : ''}`}
-            onClick={() => setActiveTab('approved')}
-          >
-            Одобренные ({approvedJobs.length})
-          </button>
+          {statusTabs.map(status => (
+            <button
+              key={status}
+              className={`tab-btn ${activeTab === status ? 'active' : ''}`}
+              onClick={() => setActiveTab(status)}
+            >
+              {jobStatusLabels[status]}
+            </button>
+          ))}
         </div>
 
         <div className="admin-content">
           {loading ? (
             <div className="loading">Загрузка вакансий...</div>
+          ) : jobs.length === 0 ? (
+            <div className="no-items">
+              <p>Нет вакансий</p>
+            </div>
           ) : (
-            <>
-              {activeTab === 'pending' && (
-                <div className="pending-jobs">
-                  <h2>Вакансии на модерации</h2>
-                  
-                  {pendingJobs.length === 0 ? (
-                    <div className="no-items">
-                      <p>Нет вакансий для модерации</p>
-                    </div>
-                  ) : (
-                    pendingJobs.map(job => (
-                      <div key={job.id} className="admin-item">
-                        <div className="item-details">
-                          <h3>{job.title}</h3>
-                          <p className="company">{job.company}</p>
-                          <p className="salary">{job.salary}</p>
-                          <p className="category">{job.category}</p>
-                          <p className="description">{job.description}</p>
-                          <p className="author">Автор: {job.username}</p>
-                        </div>
-                        
-                        <div className="item-actions">
-                          <button 
-                            className="btn btn-primary"
-                            onClick={() => handleApprove(job.id)}
-                          >
-                            Одобрить
-                          </button>
-                          <button 
-                            className="btn btn-danger"
-                            onClick={() => handleReject(job.id)}
-                          >
-                            Отклонить
-                          </button>
-                        </div>
-                      </div>
-                    ))
+            jobs.map(job => (
+              <div key={job.id} className="admin-item">
+                <div className="item-details">
+                  <h3>{job.title}</h3>
+                  <p className="company">{job.company}</p>
+                  <p className="salary">{job.salary}</p>
+                  <p className="category">{job.category}</p>
+                  <p className="description">{job.description}</p>
+                  <p className="author">Автор: {job.username}</p>
+                  {job.rejection_reason && (
+                    <p className="author">Причина отказа: {job.rejection_reason}</p>
                   )}
                 </div>
-              )}
-
-              {activeTab === 'approved' && (
-                <div className="approved-jobs">
-                  <h2>Одобренные вакансии</h2>
-                  
-                  {approvedJobs.length === 0 ? (
-                    <div className="no-items">
-                      <p>Нет одобренных вакансий</p>
-                    </div>
-                  ) : (
-                    approvedJobs.map(job => (
-                      <div key={job.id} className="admin-item">
-                        <div className="item-details">
-                          <h3>{job.title}</h3>
-                          <p className="company">{job.company}</p>
-                          <p className="salary">{job.salary}</p>
-                          <p className="category">{job.category}</p>
-                          <p className="description">{job.description}</p>
-                          <p className="author">Автор: {job.username}</p>
-                        </div>
-                        
-                        <div className="item-actions">
-                          <button 
-                            className="btn btn-danger"
-                            onClick={() => handleDeleteApproved(job.id)}
-                          >
-                            Удалить
-                          </button>
-                        </div>
-                      </div>
-                    ))
-                  )}
+
+                <div className="item-actions">
+                  {statusActions[job.status].map(status => (
+                    <button
+                      key={status}
+                      className={`btn ${status === 'approved' ? 'btn-primary' : status === 'rejected' ? 'btn-danger' : 'btn-secondary'}`}
+                      onClick={() => handleStatus(job, status)}
+                    >
+                      {actionLabels[status]}
+                    </button>
+                  ))}
+                  <button className="btn btn-secondary" onClick={() => handleHistory(job)}>
+                    История
+                  </button>
                 </div>
-              )}
-            </>
+              </div>
+            ))
           )}
         </div>
       </div>
+
+      {rejectJob && (
+        <div className="modal-overlay" onClick={() => setRejectJob(null)}>
+          <div className="modal" onClick={e => e.stopPropagation()}>
+            <h2>Отклонить «{rejectJob.title}»</h2>
+            <form onSubmit={handleRejectSubmit}>
+              <div className="form-group">
+                <label className="form-label">Причина отказа (увидит автор)</label>
+                <textarea
+                  value={reason}
+                  onChange={e => setReason(e.target.value)}
+                  className="form-input"
+                  rows="4"
+                  maxLength={500}
+                  required
+                />
+              </div>
+
+              {error && <div className="error-message">{error}</div>}
+
+              <div className="form-actions">
+                <button type="button" className="btn btn-secondary" onClick={() => setRejectJob(null)}>Отмена</button>
+                <button type="submit" className="btn btn-danger">Отклонить</button>
+              </div>
+            </form>
+          </div>
+        </div>
+      )}
+
+      {history && (
+        <div className="modal-overlay" onClick={() => setHistory(null)}>
+          <div className="modal" onClick={e => e.stopPropagation()}>
+            <h2>История «{history.job.title}»</h2>
+            {history.entries.length === 0 ? (
+              <p>Решений по вакансии ещё не было</p>
+            ) : (
+              history.entries.map(entry => (
+                <div key={entry.id} className="moderation-entry">
+                  <p>
+                    {new Date(entry.created_at).toLocaleString('ru-RU')}, {entry.actor_name || (entry.actor_id ? 'удалённый пользователь' : 'система')}:{' '}
+                    {jobStatusLabels[entry.from_status]} → {jobStatusLabels[entry.to_status]}
+                  </p>
+                  {entry.reason && <p className="author">Причина: {entry.reason}</p>}
+                </div>
+              ))
+            )}
+            <div className="form-actions">
+              <button type="button" className="btn btn-secondary" onClick={() => setHistory(null)}>Закрыть</button>
+            </div>
+          </div>
+        </div>
+      )}
     </div>
   );
 };
@@ -5,7 +5,7 @@ import axios from 'axios';
 import './Admin.css';
 
 const AdminProducts = () => {
-  const { user, getAuthHeader, API_URL } = useContext(AuthContext);
+  const { user, can, getAuthHeader, API_URL } = useContext(AuthContext);
   const navigate = useNavigate();
   const [products, setProducts] = useState([]);
   const [loading, setLoading] = useState(true);
@@ -21,7 +21,7 @@ const AdminProducts = () => {
   });
 
   useEffect(() => {
-    if (user && user.role !== 'admin') {
+    if (user && !can('products.write')) {
       navigate('/');
       return;
     }
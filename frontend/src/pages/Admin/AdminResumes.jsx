@@ -0,0 +1,236 @@
+import React, { useState, useEffect, useContext } from 'react';
+import { AuthContext } from '../../context/AuthContext';
+import { useNavigate } from 'react-router-dom';
+import { resumeAvailabilityLabels, resumesAPI, resumeStatusLabels } from '../../utils/api';
+import './Admin.css';
+
+const statusTabs = ['pending', 'approved', 'rejected', 'archived'];
+
+// переходы те же, что у вакансий (см. AdminJobs)
+const statusActions = {
+  pending: ['approved', 'rejected', 'archived'],
+  approved: ['rejected', 'archived'],
+  rejected: ['approved', 'archived'],
+  archived: ['approved'],
+};
+
+const actionLabels = {
+  approved: 'Одобрить',
+  rejected: 'Отклонить',
+  archived: 'В архив',
+};
+
+const AdminResumes = () => {
+  const { user, can } = useContext(AuthContext);
+  const navigate = useNavigate();
+  const [resumes, setResumes] = useState([]);
+  const [loading, setLoading] = useState(true);
+  const [activeTab, setActiveTab] = useState('pending');
+  const [filters, setFilters] = useState({ search: '' });
+  const [rejectResume, setRejectResume] = useState(null);
+  const [reason, setReason] = useState('');
+  const [error, setError] = useState('');
+  const [history, setHistory] = useState(null);
+
+  useEffect(() => {
+    if (user && !can('resumes.moderate')) {
+      navigate('/');
+      return;
+    }
+    fetchResumes();
+  }, [user, navigate, activeTab]);
+
+  const fetchResumes = async () => {
+    try {
+      setLoading(true);
+      const params = { status: activeTab };
+      if (filters.search) params.search = filters.search;
+
+      const response = await resumesAPI.getQueue(params);
+      setResumes(response.data);
+    } catch (error) {
+      console.error('Error fetching resumes:', error);
+      alert('Ошибка при загрузке резюме');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const handleFilterSubmit = (e) => {
+    e.preventDefault();
+    fetchResumes();
+  };
+
+  const handleStatus = async (resume, status) => {
+    if (status === 'rejected') {
+      setError('');
+      setReason('');
+      setRejectResume(resume);
+      return;
+    }
+    try {
+      await resumesAPI.setStatus(resume.id, status);
+      fetchResumes();
+    } catch (error) {
+      alert('Ошибка: ' + (error.response?.data?.message || 'Неизвестная ошибка'));
+    }
+  };
+
+  const handleRejectSubmit = async (e) => {
+    e.preventDefault();
+    try {
+      await resumesAPI.setStatus(rejectResume.id, 'rejected', reason);
+      setRejectResume(null);
+      fetchResumes();
+    } catch (error) {
+      setError(error.response?.data?.message || 'Неизвестная ошибка');
+    }
+  };
+
+  const handleHistory = async (resume) => {
+    try {
+      const response = await resumesAPI.getHistory(resume.id);
+      setHistory({ resume, entries: response.data });
+    } catch (error) {
+      alert('Ошибка: ' + (error.response?.data?.message || 'Неизвестная ошибка'));
+    }
+  };
+
+  if (!user || !can('resumes.moderate')) {
+    return (
+      <div className="admin-page">
+        <div className="container">
+          <h1>Доступ запрещен</h1>
+          <p>У вас нет прав для доступа к этой странице.</p>
+        </div>
+      </div>
+    );
+  }
+
+  return (
+    <div className="admin-page">
+      <div className="container">
+        <div className="admin-header">
+          <h1>Модерация резюме</h1>
+          <form className="admin-stats" onSubmit={handleFilterSubmit}>
+            <input
+              className="form-input"
+              placeholder="Должность или навык"
+              value={filters.search}
+              onChange={e => setFilters({ ...filters, search: e.target.value })}
+            />
+            <button type="submit" className="btn btn-secondary">Найти</button>
+          </form>
+        </div>
+
+        <div className="admin-tabs">
+          {statusTabs.map(status => (
+            <button
+              key={status}
+              className={`tab-btn ${activeTab === status ? 'active' : ''}`}
+              onClick={() => setActiveTab(status)}
+            >
+              {resumeStatusLabels[status]}
+            </button>
+          ))}
+        </div>
+
+        <div className="admin-content">
+          {loading ? (
+            <div className="loading">Загрузка резюме...</div>
+          ) : resumes.length === 0 ? (
+            <div className="no-items">
+              <p>Нет резюме</p>
+            </div>
+          ) : (
+            resumes.map(resume => (
+              <div key={resume.id} className="admin-item">
+                <div className="item-details">
+                  <h3>{resume.title}</h3>
+                  <p className="salary">{resume.salary}</p>
+                  <p className="category">
+                    {resume.region}, стаж {resume.experience_years} г. · {resumeAvailabilityLabels[resume.availability]}
+                  </p>
+                  <p className="category">Навыки: {resume.skills.join(', ')}</p>
+                  {resume.experience && <p className="description">{resume.experience}</p>}
+                  {resume.rejection_reason && (
+                    <p className="author">Причина отказа: {resume.rejection_reason}</p>
+                  )}
+                </div>
+
+                <div className="item-actions">
+                  {statusActions[resume.status].map(status => (
+                    <button
+                      key={status}
+                      className={`btn ${status === 'approved' ? 'btn-primary' : status === 'rejected' ? 'btn-danger' : 'btn-secondary'}`}
+                      onClick={() => handleStatus(resume, status)}
+                    >
+                      {actionLabels[status]}
+                    </button>
+                  ))}
+                  <button className="btn btn-secondary" onClick={() => handleHistory(resume)}>
+                    История
+                  </button>
+                </div>
+              </div>
+            ))
+          )}
+        </div>
+      </div>
+
+      {rejectResume && (
+        <div className="modal-overlay" onClick={() => setRejectResume(null)}>
+          <div className="modal" onClick={e => e.stopPropagation()}>
+            <h2>Отклонить «{rejectResume.title}»</h2>
+            <form onSubmit={handleRejectSubmit}>
+              <div className="form-group">
+                <label className="form-label">Причина отказа (увидит автор)</label>
+                <textarea
+                  value={reason}
+                  onChange={e => setReason(e.target.value)}
+                  className="form-input"
+                  rows="4"
+                  maxLength={500}
+                  required
+                />
+              </div>
+
+              {error && <div className="error-message">{error}</div>}
+
+              <div className="form-actions">
+                <button type="button" className="btn btn-secondary" onClick={() => setRejectResume(null)}>Отмена</button>
+                <button type="submit" className="btn btn-danger">Отклонить</button>
+              </div>
+            </form>
+          </div>
+        </div>
+      )}
+
+      {history && (
+        <div className="modal-overlay" onClick={() => setHistory(null)}>
+          <div className="modal" onClick={e => e.stopPropagation()}>
+            <h2>История «{history.resume.title}»</h2>
+            {history.entries.length === 0 ? (
+              <p>Решений по резюме ещё не было</p>
+            ) : (
+              history.entries.map(entry => (
+                <div key={entry.id} className="moderation-entry">
+                  <p>
+                    {new Date(entry.created_at).toLocaleString('ru-RU')}, {entry.actor_name || (entry.actor_id ? 'удалённый пользователь' : 'система')}:{' '}
+                    {resumeStatusLabels[entry.from_status]} → {resumeStatusLabels[entry.to_status]}
+                  </p>
+                  {entry.reason && <p className="author">Причина: {entry.reason}</p>}
+                </div>
+              ))
+            )}
+            <div className="form-actions">
+              <button type="button" className="btn btn-secondary" onClick={() => setHistory(null)}>Закрыть</button>
+            </div>
+          </div>
+        </div>
+      )}
+    </div>
+  );
+};
+
+export default AdminResumes;
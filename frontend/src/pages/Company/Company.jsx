@@ -0,0 +1,85 @@
+import React, { useEffect, useState } from 'react';
+import { useParams } from 'react-router-dom';
+import { companiesAPI } from '../../utils/api';
+import '../Job/Job.css';
+import './Company.css';
+
+const Company = () => {
+  const { id } = useParams();
+  const [company, setCompany] = useState(null);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState('');
+
+  useEffect(() => {
+    setLoading(true);
+    companiesAPI.get(id)
+      .then((response) => {
+        setCompany(response.data);
+        setError('');
+      })
+      .catch((err) => setError(err.response?.data?.message || 'Ошибка при загрузке компании'))
+      .finally(() => setLoading(false));
+  }, [id]);
+
+  if (loading) {
+    return <div className="loading">Загрузка...</div>;
+  }
+
+  if (error) {
+    return (
+      <div className="job-page">
+        <div className="container">
+          <div className="error-message">{error}</div>
+        </div>
+      </div>
+    );
+  }
+
+  return (
+    <div className="job-page">
+      <div className="container">
+        <div className="company-header">
+          {company.logo && <img src={company.logo} alt={company.name} className="company-logo" />}
+          <div>
+            <h1>{company.name}</h1>
+            {company.verified ? (
+              <p className="company-verified">✓ Компания проверена</p>
+            ) : (
+              <p className="company-unverified">Компания ещё не проверена</p>
+            )}
+            {company.inn && <p>ИНН {company.inn}</p>}
+            <p className="company-contacts">
+              {company.website && (
+                <a href={company.website} target="_blank" rel="noopener noreferrer nofollow">{company.website}</a>
+              )}
+              {company.phone && <span>{company.phone}</span>}
+              {company.email && <a href={`mailto:${company.email}`}>{company.email}</a>}
+            </p>
+          </div>
+        </div>
+
+        {company.description && <p className="company-description">{company.description}</p>}
+
+        <h2 className="company-jobs-title">Открытые вакансии</h2>
+        {company.jobs.length === 0 ? (
+          <div className="no-jobs">
+            <p>Сейчас открытых вакансий нет</p>
+          </div>
+        ) : (
+          <div className="jobs-grid">
+            {company.jobs.map(job => (
+              <div key={job.id} className="job-card card">
+                <h3>{job.title}</h3>
+                <p className="salary">{job.salary}</p>
+                <p className="category">{job.category}</p>
+                <p className="description">{job.description}</p>
+              </div>
+            ))}
+          </div>
+        )}
+      </div>
+    </div>
+  );
+};
+
+export default Company;
@@ -1,6 +1,15 @@
 import React, { useState, useEffect, useContext } from 'react';
+import { Link } from 'react-router-dom';
 import { AuthContext } from '../../context/AuthContext';
 import axios from 'axios';
+import {
+  applicationsAPI,
+  applicationStatusLabels,
+  companiesAPI,
+  companyPayload,
+  salaryPayload,
+  salaryPeriodLabels
+} from '../../utils/api';
 import './Job.css';
 
 const Job = () => {
@@ -10,20 +19,42 @@ const Job = () => {
   const [error, setError] = useState('');
   const [searchTerm, setSearchTerm] = useState('');
   const [selectedCategory, setSelectedCategory] = useState('');
+  const [salaryFrom, setSalaryFrom] = useState('');
+  const [salaryPeriod, setSalaryPeriod] = useState('month');
+  const [sort, setSort] = useState('');
   const [showJobForm, setShowJobForm] = useState(false);
-  const [newJob, setNewJob] = useState({
+  const emptyJob = {
     title: '',
     description: '',
-    salary: '',
+    salary_min: '',
+    salary_max: '',
+    salary_period: 'month',
+    salary_gross: false,
     category: '',
+    company_id: '',
     company: ''
+  };
+  const [newJob, setNewJob] = useState(emptyJob);
+  // компании пользователя, от которых можно разместить вакансию
+  const [myCompanies, setMyCompanies] = useState([]);
+
+  // отклик соискателя на выбранную вакансию
+  const [applyJob, setApplyJob] = useState(null);
+  const [application, setApplication] = useState({
+    contact_name: '',
+    contact_phone: '',
+    contact_email: '',
+    cover_letter: ''
   });
+  // отклики на свою вакансию для работодателя
+  const [applicationsJob, setApplicationsJob] = useState(null);
+  const [applications, setApplications] = useState([]);
 
   const categories = ['Все', 'Строительство', 'Отделка', 'Электрика', 'Сантехника', 'Проектирование'];
 
   useEffect(() => {
     fetchJobs();
-  }, [searchTerm, selectedCategory]);
+  }, [searchTerm, selectedCategory, salaryFrom, salaryPeriod, sort]);
 
   const fetchJobs = async () => {
     try {
@@ -31,6 +62,13 @@ const Job = () => {
       const params = {};
       if (searchTerm) params.search = searchTerm;
       if (selectedCategory && selectedCategory !== 'Все') params.category = selectedCategory;
+      if (salaryFrom) params.salary_from = salaryFrom;
+      if (sort) params.sort = sort;
+      // суммы сравниваются только в одной валюте и за один период
+      if (salaryFrom || sort.startsWith('salary_')) {
+        params.salary_currency = 'RUB';
+        params.salary_period = salaryPeriod;
+      }
 
       const response = await axios.get(`${API_URL}/jobs`, { params });
       setJobs(response.data);
@@ -44,23 +82,30 @@ const Job = () => {
     }
   };
 
+  const openJobForm = async () => {
+    try {
+      const response = await companiesAPI.getMine();
+      setMyCompanies(response.data);
+      setNewJob({ ...emptyJob, company_id: response.data[0]?.id ?? '' });
+    } catch (error) {
+      setMyCompanies([]);
+      setNewJob(emptyJob);
+    }
+    setShowJobForm(true);
+  };
+
   const handleSubmitJob = async (e) => {
     e.preventDefault();
     try {
+      const { salary_min, salary_max, salary_period, salary_gross, company_id, company, ...job } = newJob;
       const response = await axios.post(
         `${API_URL}/jobs`,
-        newJob,
+        { ...job, ...salaryPayload(newJob), ...companyPayload(newJob) },
         { headers: getAuthHeader() }
       );
       
       setJobs([...jobs, response.data]);
-      setNewJob({
-        title: '',
-        description: '',
-        salary: '',
-        category: '',
-        company: ''
-      });
+      setNewJob(emptyJob);
       setShowJobForm(false);
       alert('Вакансия отправлена на модерацию!');
     } catch (error) {
@@ -69,13 +114,58 @@ const Job = () => {
   };
 
   const handleInputChange = (e) => {
-    const { name, value } = e.target;
+    const { name, value, type, checked } = e.target;
     setNewJob(prev => ({
       ...prev,
-      [name]: value
+      [name]: type === 'checkbox' ? checked : value
     }));
   };
 
+  const openApply = (job) => {
+    setApplication({
+      contact_name: '',
+      contact_phone: '',
+      contact_email: user?.email || '',
+      cover_letter: ''
+    });
+    setApplyJob(job);
+  };
+
+  const handleApplicationChange = (e) => {
+    const { name, value } = e.target;
+    setApplication(prev => ({ ...prev, [name]: value }));
+  };
+
+  const handleSubmitApplication = async (e) => {
+    e.preventDefault();
+    try {
+      await applicationsAPI.apply(applyJob.id, application);
+      setApplyJob(null);
+      alert('Отклик отправлен! Статус можно отслеживать в профиле.');
+    } catch (error) {
+      alert('Ошибка при отправке отклика: ' + (error.response?.data?.message || 'Неизвестная ошибка'));
+    }
+  };
+
+  const openApplications = async (job) => {
+    try {
+      const response = await applicationsAPI.getForJob(job.id);
+      setApplications(response.data);
+      setApplicationsJob(job);
+    } catch (error) {
+      alert('Ошибка при загрузке откликов: ' + (error.response?.data?.message || 'Неизвестная ошибка'));
+    }
+  };
+
+  const handleApplicationStatus = async (id, status) => {
+    try {
+      const response = await applicationsAPI.setStatus(id, status);
+      setApplications(applications.map(a => (a.id === id ? response.data : a)));
+    } catch (error) {
+      alert(error.response?.data?.message || 'Ошибка сервера');
+    }
+  };
+
   const filteredJobs = jobs.filter(job => {
     const matchesSearch = job.title.toLowerCase().includes(searchTerm.toLowerCase());
     const matchesCategory = selectedCategory === '' || selectedCategory === 'Все' || job.category === selectedCategory;
@@ -112,12 +202,49 @@ const Job = () => {
                 ))}
               </select>
             </div>
+
+            <div className="category-filter">
+              <input
+                type="number"
+                min="0"
+                placeholder="Зарплата от, ₽"
+                value={salaryFrom}
+                onChange={(e) => setSalaryFrom(e.target.value)}
+                className="category-select"
+              />
+            </div>
+
+            {(salaryFrom || sort.startsWith('salary_')) && (
+              <div className="category-filter">
+                <select
+                  value={salaryPeriod}
+                  onChange={(e) => setSalaryPeriod(e.target.value)}
+                  className="category-select"
+                >
+                  {Object.entries(salaryPeriodLabels).map(([value, label]) => (
+                    <option key={value} value={value}>Зарплата {label}</option>
+                  ))}
+                </select>
+              </div>
+            )}
+
+            <div className="category-filter">
+              <select
+                value={sort}
+                onChange={(e) => setSort(e.target.value)}
+                className="category-select"
+              >
+                <option value="">Сначала новые</option>
+                <option value="salary_desc">Сначала с высокой зарплатой</option>
+                <option value="salary_asc">Сначала с низкой зарплатой</option>
+              </select>
+            </div>
           </div>
 
           {user && (
             <button 
               className="btn btn-primary"
-              onClick={() => setShowJobForm(true)}
+              onClick={openJobForm}
             >
               Создать вакансию
             </button>
@@ -134,12 +261,24 @@ const Job = () => {
               {filteredJobs.map(job => (
                 <div key={job.id} className="job-card card">
                   <h3>{job.title}</h3>
-                  <p className="company">{job.company}</p>
+                  <p className="company">
+                    {job.company_id ? (
+                      <Link to={`/companies/${job.company_id}`}>{job.company}</Link>
+                    ) : job.company}
+                    {job.company_verified && (
+                      <span className="company-verified" title="Компания проверена"> ✓</span>
+                    )}
+                  </p>
                   <p className="salary">{job.salary}</p>
                   <p className="category">{job.category}</p>
                   <p className="description">{job.description}</p>
-                  {user && (
-                    <button className="btn btn-secondary">
+                  {user && job.user_id === user.id && (
+                    <button className="btn btn-secondary" onClick={() => openApplications(job)}>
+                      Отклики
+                    </button>
+                  )}
+                  {user && job.user_id !== user.id && (
+                    <button className="btn btn-secondary" onClick={() => openApply(job)}>
                       Откликнуться
                     </button>
                   )}
@@ -155,6 +294,105 @@ const Job = () => {
           </>
         )}
 
+        {applyJob && (
+          <div className="modal-overlay" onClick={() => setApplyJob(null)}>
+            <div className="modal" onClick={e => e.stopPropagation()}>
+              <h2>Отклик: {applyJob.title}</h2>
+              <form onSubmit={handleSubmitApplication}>
+                <div className="form-group">
+                  <label className="form-label">Имя</label>
+                  <input
+                    type="text"
+                    name="contact_name"
+                    value={application.contact_name}
+                    onChange={handleApplicationChange}
+                    className="form-input"
+                    required
+                  />
+                </div>
+
+                <div className="form-group">
+                  <label className="form-label">Телефон</label>
+                  <input
+                    type="tel"
+                    name="contact_phone"
+                    value={application.contact_phone}
+                    onChange={handleApplicationChange}
+                    className="form-input"
+                    placeholder="+7 900 000-00-00"
+                  />
+                </div>
+
+                <div className="form-group">
+                  <label className="form-label">Email</label>
+                  <input
+                    type="email"
+                    name="contact_email"
+                    value={application.contact_email}
+                    onChange={handleApplicationChange}
+                    className="form-input"
+                  />
+                </div>
+
+                <div className="form-group">
+                  <label className="form-label">Сопроводительное письмо</label>
+                  <textarea
+                    name="cover_letter"
+                    value={application.cover_letter}
+                    onChange={handleApplicationChange}
+                    className="form-input"
+                    rows="5"
+                    maxLength={5000}
+                    placeholder="Расскажите об опыте и когда готовы приступить"
+                  />
+                </div>
+
+                <div className="form-actions">
+                  <button type="button" className="btn btn-secondary" onClick={() => setApplyJob(null)}>
+                    Отмена
+                  </button>
+                  <button type="submit" className="btn btn-primary">
+                    Отправить отклик
+                  </button>
+                </div>
+              </form>
+            </div>
+          </div>
+        )}
+
+        {applicationsJob && (
+          <div className="modal-overlay" onClick={() => setApplicationsJob(null)}>
+            <div className="modal" onClick={e => e.stopPropagation()}>
+              <h2>Отклики: {applicationsJob.title}</h2>
+              {applications.length === 0 ? (
+                <p>Откликов пока нет</p>
+              ) : (
+                applications.map(a => (
+                  <div key={a.id} className="application-card">
+                    <p><strong>{a.contact_name}</strong> ({a.username})</p>
+                    <p>{[a.contact_phone, a.contact_email].filter(Boolean).join(', ')}</p>
+                    {a.cover_letter && <p className="description">{a.cover_letter}</p>}
+                    <select
+                      value={a.status}
+                      onChange={e => handleApplicationStatus(a.id, e.target.value)}
+                      className="form-input"
+                    >
+                      {Object.entries(applicationStatusLabels).map(([status, label]) => (
+                        <option key={status} value={status}>{label}</option>
+                      ))}
+                    </select>
+                  </div>
+                ))
+              )}
+              <div className="form-actions">
+                <button type="button" className="btn btn-secondary" onClick={() => setApplicationsJob(null)}>
+                  Закрыть
+                </button>
+              </div>
+            </div>
+          </div>
+        )}
+
         {showJobForm && (
           <div className="modal-overlay" onClick={() => setShowJobForm(false)}>
             <div className="modal" onClick={e => e.stopPropagation()}>
@@ -174,27 +412,74 @@ const Job = () => {
                 
                 <div className="form-group">
                   <label className="form-label">Компания</label>
-                  <input
-                    type="text"
-                    name="company"
-                    value={newJob.company}
-                    onChange={handleInputChange}
-                    className="form-input"
-                    required
-                  />
+                  {myCompanies.length > 0 && (
+                    <select
+                      name="company_id"
+                      value={newJob.company_id}
+                      onChange={handleInputChange}
+                      className="form-input"
+                    >
+                      {myCompanies.map(company => (
+                        <option key={company.id} value={company.id}>{company.name}</option>
+                      ))}
+                      <option value="">Новая компания</option>
+                    </select>
+                  )}
+                  {newJob.company_id === '' && (
+                    <input
+                      type="text"
+                      name="company"
+                      value={newJob.company}
+                      onChange={handleInputChange}
+                      className="form-input"
+                      placeholder="Название компании"
+                      maxLength={100}
+                      required
+                    />
+                  )}
                 </div>
                 
                 <div className="form-group">
-                  <label className="form-label">Зарплата</label>
-                  <input
-                    type="text"
-                    name="salary"
-                    value={newJob.salary}
-                    onChange={handleInputChange}
-                    className="form-input"
-                    placeholder="80000 ₽"
-                    required
-                  />
+                  <label className="form-label">Зарплата, ₽ (пусто — по договорённости)</label>
+                  <div className="salary-inputs">
+                    <input
+                      type="number"
+                      min="0"
+                      name="salary_min"
+                      value={newJob.salary_min}
+                      onChange={handleInputChange}
+                      className="form-input"
+                      placeholder="от"
+                    />
+                    <input
+                      type="number"
+                      min="0"
+                      name="salary_max"
+                      value={newJob.salary_max}
+                      onChange={handleInputChange}
+                      className="form-input"
+                      placeholder="до"
+                    />
+                    <select
+                      name="salary_period"
+                      value={newJob.salary_period}
+                      onChange={handleInputChange}
+                      className="form-input"
+                    >
+                      {Object.entries(salaryPeriodLabels).map(([value, label]) => (
+                        <option key={value} value={value}>{label}</option>
+                      ))}
+                    </select>
+                  </div>
+                  <label className="checkbox-label">
+                    <input
+                      type="checkbox"
+                      name="salary_gross"
+                      checked={newJob.salary_gross}
+                      onChange={handleInputChange}
+                    />{' '}
+                    до вычета налогов
+                  </label>
                 </div>
                 
                 <div className="form-group">
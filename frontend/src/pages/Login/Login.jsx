@@ -1,6 +1,7 @@
-import React, { useState, useContext } from 'react';
+import React, { useState, useContext, useEffect } from 'react';
 import { AuthContext } from '../../context/AuthContext';
-import { useNavigate, Link } from 'react-router-dom';
+import { useNavigate, useLocation, useSearchParams, Link } from 'react-router-dom';
+import { authAPI, oauthAPI, oauthErrorMessages } from '../../utils/api';
 import './Login.css';
 
 const Login = () => {
@@ -8,11 +9,53 @@ const Login = () => {
     username: '',
     password: ''
   });
-  const [error, setError] = useState('');
+  const [searchParams] = useSearchParams();
+  const [error, setError] = useState(oauthErrorMessages[searchParams.get('oauth_error')] || '');
   const [loading, setLoading] = useState(false);
+  const [providers, setProviders] = useState([]);
+
+  // второй шаг входа: verify — ввод кода, setup — подключение приложения
+  const [twoFactor, setTwoFactor] = useState(null);
+  const [challenge, setChallenge] = useState('');
+  const [setupInfo, setSetupInfo] = useState(null);
+  const [code, setCode] = useState('');
+  const [recoveryCodes, setRecoveryCodes] = useState(null);
   
-  const { login } = useContext(AuthContext);
+  const { login, completeTwoFactor } = useContext(AuthContext);
   const navigate = useNavigate();
+  const location = useLocation();
+
+  const startTwoFactor = async (step, challengeToken) => {
+    setTwoFactor(step);
+    setChallenge(challengeToken);
+    if (step === 'setup') {
+      const response = await authAPI.loginTwoFactorSetup(challengeToken);
+      setSetupInfo(response.data);
+    }
+  };
+
+  useEffect(() => {
+    oauthAPI.providers()
+      .then((response) => setProviders(response.data))
+      .catch(() => setProviders([]));
+
+    // после входа через провайдера код из приложения запрашивается здесь же
+    const state = location.state;
+    if (state?.twoFactor) {
+      startTwoFactor(state.twoFactor, state.challenge)
+        .catch((err) => setError(err.response?.data?.message || 'Ошибка при входе в систему'));
+    }
+  }, [location.state]);
+
+  const handleOAuth = async (provider) => {
+    setError('');
+    try {
+      const response = await oauthAPI.start(provider);
+      window.location.assign(response.data.url);
+    } catch (err) {
+      setError(err.response?.data?.message || 'Ошибка при входе в систему');
+    }
+  };
 
   const handleChange = (e) => {
     setFormData({
@@ -32,6 +75,8 @@ const Login = () => {
       
       if (result.success) {
         navigate('/');
+      } else if (result.twoFactor) {
+        await startTwoFactor(result.twoFactor, result.challenge);
       } else {
         setError(result.error);
       }
@@ -42,6 +87,99 @@ const Login = () => {
     }
   };
 
+  const handleCodeSubmit = async (e) => {
+    e.preventDefault();
+    setLoading(true);
+    setError('');
+
+    const result = await completeTwoFactor(challenge, code);
+    setLoading(false);
+    if (!result.success) {
+      setError(result.error);
+      return;
+    }
+    if (result.recoveryCodes) {
+      // коды показываются один раз — уходим со страницы только после подтверждения
+      setRecoveryCodes(result.recoveryCodes);
+      return;
+    }
+    if (result.recoveryCodesLeft !== undefined) {
+      alert(`Вы вошли по коду восстановления. Осталось кодов: ${result.recoveryCodesLeft}`);
+    }
+    navigate('/');
+  };
+
+  if (recoveryCodes) {
+    return (
+      <div className="login-page">
+        <div className="login-container">
+          <div className="login-card">
+            <h1>Коды восстановления</h1>
+            <p>
+              Сохраните эти коды в надёжном месте. Каждый из них можно использовать один раз
+              вместо кода из приложения, если телефон будет недоступен.
+            </p>
+            <pre>{recoveryCodes.join('\n')}</pre>
+            <button className="btn btn-primary login-btn" onClick={() => navigate('/')}>
+              Я сохранил коды
+            </button>
+          </div>
+        </div>
+      </div>
+    );
+  }
+
+  if (twoFactor) {
+    return (
+      <div className="login-page">
+        <div className="login-container">
+          <div className="login-card">
+            <h1>Подтверждение входа</h1>
+
+            {twoFactor === 'setup' ? (
+              <div>
+                <p>
+                  Для вашей роли обязательна двухфакторная аутентификация. Добавьте аккаунт
+                  в приложение-аутентификатор (Google Authenticator, Яндекс Ключ и т.п.):
+                  откройте ссылку на телефоне или введите ключ вручную.
+                </p>
+                {setupInfo && (
+                  <>
+                    <p><a href={setupInfo.otpauth_uri} className="link">Добавить в приложение</a></p>
+                    <p>Ключ: <code>{setupInfo.secret}</code></p>
+                  </>
+                )}
+              </div>
+            ) : (
+              <p>Введите код из приложения-аутентификатора или один из кодов восстановления.</p>
+            )}
+
+            <form onSubmit={handleCodeSubmit} className="login-form">
+              <div className="form-group">
+                <label className="form-label">Код</label>
+                <input
+                  type="text"
+                  value={code}
+                  onChange={(e) => { setCode(e.target.value); setError(''); }}
+                  className="form-input"
+                  required
+                  autoComplete="one-time-code"
+                  placeholder="123456"
+                />
+              </div>
+
+              {error && <div className="error-message">{error}</div>}
+
+              <button type="submit" className="btn btn-primary login-btn" disabled={loading}>
+                {loading ? 'Проверка...' : 'Подтвердить'}
+              </button>
+            </form>
+          </div>
+        </div>
+      </div>
+    );
+  }
+
   return (
     <div className="login-page">
       <div className="login-container">
@@ -85,8 +223,25 @@ const Login = () => {
               {loading ? 'Вход...' : 'Войти'}
             </button>
           </form>
+
+          {providers.length > 0 && (
+            <div className="oauth-providers">
+              <p>или войдите через</p>
+              {providers.map((provider) => (
+                <button
+                  key={provider.name}
+                  type="button"
+                  className="btn btn-secondary oauth-btn"
+                  onClick={() => handleOAuth(provider.name)}
+                >
+                  {provider.title}
+                </button>
+              ))}
+            </div>
+          )}
           
           <div className="login-footer">
+            <p><Link to="/forgot-password" className="link">Забыли пароль?</Link></p>
             <p>Нет аккаунта? <Link to="/registration" className="link">Зарегистрироваться</Link></p>
           </div>
 
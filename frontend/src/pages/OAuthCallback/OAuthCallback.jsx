@@ -0,0 +1,57 @@
+import React, { useContext, useEffect, useRef, useState } from 'react';
+import { Link, useNavigate, useSearchParams } from 'react-router-dom';
+import { AuthContext } from '../../context/AuthContext';
+import '../Login/Login.css';
+
+// OAuthCallback — сюда сервер возвращает браузер после входа у провайдера
+const OAuthCallback = () => {
+  const [searchParams] = useSearchParams();
+  const [error, setError] = useState('');
+  const { loginWithOAuth } = useContext(AuthContext);
+  const navigate = useNavigate();
+  // код одноразовый: в StrictMode эффект выполняется дважды, второй запрос не нужен
+  const sent = useRef(false);
+
+  useEffect(() => {
+    if (sent.current) return;
+    sent.current = true;
+
+    const token = searchParams.get('token');
+    if (!token) {
+      setError('Ссылка недействительна или устарела');
+      return;
+    }
+
+    loginWithOAuth(token).then((result) => {
+      if (result.success) {
+        navigate('/', { replace: true });
+      } else if (result.twoFactor) {
+        // код из приложения вводится на странице входа, как после пароля
+        navigate('/login', {
+          replace: true,
+          state: { twoFactor: result.twoFactor, challenge: result.challenge }
+        });
+      } else {
+        setError(result.error);
+      }
+    });
+  }, [searchParams, loginWithOAuth, navigate]);
+
+  return (
+    <div className="login-page">
+      <div className="login-container">
+        <div className="login-card">
+          <h1>Вход</h1>
+
+          {error ? <div className="error-message">{error}</div> : <p>Выполняем вход...</p>}
+
+          <div className="login-footer">
+            <p><Link to="/login" className="link">Вернуться ко входу</Link></p>
+          </div>
+        </div>
+      </div>
+    </div>
+  );
+};
+
+export default OAuthCallback;
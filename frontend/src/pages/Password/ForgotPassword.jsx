@@ -0,0 +1,70 @@
+import React, { useState } from 'react';
+import { Link } from 'react-router-dom';
+import { authAPI } from '../../utils/api';
+import '../Login/Login.css';
+
+const ForgotPassword = () => {
+  const [email, setEmail] = useState('');
+  const [message, setMessage] = useState('');
+  const [error, setError] = useState('');
+  const [loading, setLoading] = useState(false);
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+    setLoading(true);
+    setError('');
+
+    try {
+      const response = await authAPI.forgotPassword(email);
+      setMessage(response.data.message);
+    } catch (err) {
+      setError(err.response?.data?.message || 'Не удалось отправить письмо');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <div className="login-page">
+      <div className="login-container">
+        <div className="login-card">
+          <h1>Восстановление пароля</h1>
+
+          {message ? (
+            <div className="success-message">{message}</div>
+          ) : (
+            <form onSubmit={handleSubmit} className="login-form">
+              <div className="form-group">
+                <label className="form-label">Email</label>
+                <input
+                  type="email"
+                  value={email}
+                  onChange={(e) => setEmail(e.target.value)}
+                  className="form-input"
+                  required
+                  placeholder="Email, указанный при регистрации"
+                />
+              </div>
+
+              {error && <div className="error-message">{error}</div>}
+
+              <button
+                type="submit"
+                className="btn btn-primary login-btn"
+                disabled={loading}
+              >
+                {loading ? 'Отправка...' : 'Отправить ссылку'}
+              </button>
+            </form>
+          )}
+
+          <div className="login-footer">
+            <p><Link to="/login" className="link">Вернуться ко входу</Link></p>
+          </div>
+        </div>
+      </div>
+    </div>
+  );
+};
+
+export default ForgotPassword;
@@ -0,0 +1,103 @@
+import React, { useState } from 'react';
+import { Link, useSearchParams } from 'react-router-dom';
+import { authAPI } from '../../utils/api';
+import '../Login/Login.css';
+
+const ResetPassword = () => {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get('token') || '';
+  const [formData, setFormData] = useState({
+    password: '',
+    confirmPassword: ''
+  });
+  const [message, setMessage] = useState('');
+  const [error, setError] = useState('');
+  const [loading, setLoading] = useState(false);
+
+  const handleChange = (e) => {
+    setFormData({
+      ...formData,
+      [e.target.name]: e.target.value
+    });
+    setError('');
+  };
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+
+    if (formData.password !== formData.confirmPassword) {
+      setError('Пароли не совпадают');
+      return;
+    }
+
+    setLoading(true);
+    try {
+      const response = await authAPI.resetPassword(token, formData.password);
+      setMessage(response.data.message);
+    } catch (err) {
+      setError(err.response?.data?.message || 'Не удалось изменить пароль');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <div className="login-page">
+      <div className="login-container">
+        <div className="login-card">
+          <h1>Новый пароль</h1>
+
+          {message ? (
+            <div className="success-message">{message}</div>
+          ) : !token ? (
+            <div className="error-message">Ссылка недействительна или устарела</div>
+          ) : (
+            <form onSubmit={handleSubmit} className="login-form">
+              <div className="form-group">
+                <label className="form-label">Новый пароль (минимум 8 символов)</label>
+                <input
+                  type="password"
+                  name="password"
+                  value={formData.password}
+                  onChange={handleChange}
+                  className="form-input"
+                  required
+                  minLength="8"
+                />
+              </div>
+
+              <div className="form-group">
+                <label className="form-label">Подтверждение пароля</label>
+                <input
+                  type="password"
+                  name="confirmPassword"
+                  value={formData.confirmPassword}
+                  onChange={handleChange}
+                  className="form-input"
+                  required
+                  minLength="8"
+                />
+              </div>
+
+              {error && <div className="error-message">{error}</div>}
+
+              <button
+                type="submit"
+                className="btn btn-primary login-btn"
+                disabled={loading}
+              >
+                {loading ? 'Сохранение...' : 'Сохранить пароль'}
+              </button>
+            </form>
+          )}
+
+          <div className="login-footer">
+            <p><Link to="/login" className="link">Войти</Link></p>
+          </div>
+        </div>
+      </div>
+    </div>
+  );
+};
+
+export default ResetPassword;
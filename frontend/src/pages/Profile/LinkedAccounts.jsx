@@ -0,0 +1,96 @@
+import React, { useEffect, useState } from 'react';
+import { useSearchParams } from 'react-router-dom';
+import { oauthAPI, oauthErrorMessages } from '../../utils/api';
+
+const LinkedAccounts = () => {
+  const [searchParams, setSearchParams] = useSearchParams();
+  const [providers, setProviders] = useState([]);
+  const [data, setData] = useState(null);
+  const [message, setMessage] = useState('');
+  const [error, setError] = useState('');
+
+  const load = () => {
+    oauthAPI.providers()
+      .then((response) => setProviders(response.data))
+      .catch(() => setProviders([]));
+    oauthAPI.identities()
+      .then((response) => setData(response.data))
+      .catch(() => setData(null));
+  };
+
+  useEffect(() => {
+    load();
+
+    // сюда сервер возвращает браузер после привязки у провайдера
+    const linked = searchParams.get('linked');
+    const oauthError = searchParams.get('oauth_error');
+    if (linked || oauthError) {
+      if (linked) setMessage('Аккаунт привязан');
+      if (oauthError) setError(oauthErrorMessages[oauthError] || 'Ошибка сервера');
+      setSearchParams({}, { replace: true });
+    }
+  }, []);
+
+  const handleLink = async (provider) => {
+    setError('');
+    try {
+      const response = await oauthAPI.link(provider);
+      window.location.assign(response.data.url);
+    } catch (err) {
+      setError(err.response?.data?.message || 'Ошибка сервера');
+    }
+  };
+
+  const handleUnlink = async (provider) => {
+    setError('');
+    setMessage('');
+    try {
+      await oauthAPI.unlink(provider);
+      load();
+    } catch (err) {
+      setError(err.response?.data?.message || 'Ошибка сервера');
+    }
+  };
+
+  if (!data || providers.length === 0) {
+    return null;
+  }
+
+  return (
+    <div className="user-stats">
+      <h3>Вход через внешние сервисы</h3>
+
+      {providers.map((provider) => {
+        const identity = data.identities.find((i) => i.provider === provider.name);
+        return (
+          <p key={provider.name}>
+            <strong>{provider.title}</strong>:{' '}
+            {identity ? (
+              <>
+                привязан{identity.email && ` (${identity.email})`}{' '}
+                <button type="button" className="link-btn" onClick={() => handleUnlink(provider.name)}>
+                  Отвязать
+                </button>
+              </>
+            ) : (
+              <button type="button" className="link-btn" onClick={() => handleLink(provider.name)}>
+                Привязать
+              </button>
+            )}
+          </p>
+        );
+      })}
+
+      {!data.has_password && (
+        <p className="user-email">
+          Пароль не задан. Его можно задать через «Забыли пароль?» на странице входа.
+        </p>
+      )}
+
+      {message && <div className="success-message">{message}</div>}
+      {error && <div className="error-message">{error}</div>}
+    </div>
+  );
+};
+
+export default LinkedAccounts;
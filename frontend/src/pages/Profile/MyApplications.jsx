@@ -0,0 +1,31 @@
+import React, { useEffect, useState } from 'react';
+import { applicationsAPI, applicationStatusLabels } from '../../utils/api';
+
+const MyApplications = () => {
+  const [applications, setApplications] = useState(null);
+
+  useEffect(() => {
+    applicationsAPI.getMine()
+      .then((response) => setApplications(response.data))
+      .catch(() => setApplications(null));
+  }, []);
+
+  if (!applications || applications.length === 0) {
+    return null;
+  }
+
+  return (
+    <div className="user-stats">
+      <h3>Мои отклики</h3>
+      {applications.map((a) => (
+        <p key={a.id}>
+          <strong>{a.job_title}</strong>, {a.company} —{' '}
+          {applicationStatusLabels[a.status] || a.status}
+          <span className="user-email"> ({new Date(a.created_at).toLocaleDateString('ru-RU')})</span>
+        </p>
+      ))}
+    </div>
+  );
+};
+
+export default MyApplications;
@@ -0,0 +1,305 @@
+import React, { useContext, useEffect, useState } from 'react';
+import { Link } from 'react-router-dom';
+import { AuthContext } from '../../context/AuthContext';
+import { authAPI, companiesAPI } from '../../utils/api';
+
+const emptyCompany = {
+  name: '',
+  inn: '',
+  description: '',
+  website: '',
+  phone: '',
+  email: ''
+};
+
+const MyCompanies = () => {
+  const { user, can, saveSession } = useContext(AuthContext);
+  const [companies, setCompanies] = useState([]);
+  const [invitations, setInvitations] = useState([]);
+  // editCompany без id — регистрация новой компании
+  const [editCompany, setEditCompany] = useState(null);
+  const [membersCompany, setMembersCompany] = useState(null);
+  const [members, setMembers] = useState([]);
+  const [newMember, setNewMember] = useState('');
+  const [message, setMessage] = useState('');
+  const [error, setError] = useState('');
+
+  // роль работодателя даёт участие в проверенной компании — после проверки или её снятия
+  // подтягиваем новые права
+  const refreshPermissions = () => {
+    authAPI.refresh(localStorage.getItem('refresh_token'))
+      .then((response) => saveSession(response.data))
+      .catch(() => {});
+  };
+
+  const load = () => {
+    companiesAPI.getMine()
+      .then((response) => {
+        setCompanies(response.data);
+        if (response.data.some(company => company.verified) && !can('resumes.search')) {
+          refreshPermissions();
+        }
+      })
+      .catch(() => setCompanies([]));
+    companiesAPI.getInvitations()
+      .then((response) => setInvitations(response.data))
+      .catch(() => setInvitations([]));
+  };
+
+  useEffect(load, []);
+
+  const openEdit = (company) => {
+    setError('');
+    setEditCompany(company ? {
+      id: company.id,
+      name: company.name,
+      inn: company.inn,
+      description: company.description,
+      website: company.website,
+      phone: company.phone,
+      email: company.email,
+      verified: company.verified
+    } : emptyCompany);
+  };
+
+  const handleEditChange = (e) => {
+    const { name, value } = e.target;
+    setEditCompany(prev => ({ ...prev, [name]: value }));
+  };
+
+  const handleEditSubmit = async (e) => {
+    e.preventDefault();
+    const { id, verified, ...data } = editCompany;
+    try {
+      if (id) {
+        await companiesAPI.update(id, data);
+        if (verified) {
+          refreshPermissions();
+        }
+      } else {
+        await companiesAPI.create(data);
+      }
+      setEditCompany(null);
+      load();
+    } catch (err) {
+      setError(err.response?.data?.message || 'Ошибка сервера');
+    }
+  };
+
+  const handleLogo = async (company, file) => {
+    if (!file) return;
+    try {
+      await companiesAPI.uploadLogo(company.id, file);
+      load();
+    } catch (err) {
+      alert(err.response?.data?.message || 'Ошибка сервера');
+    }
+  };
+
+  const openMembers = async (company) => {
+    try {
+      const response = await companiesAPI.getMembers(company.id);
+      setMembers(response.data);
+      setNewMember('');
+      setMessage('');
+      setError('');
+      setMembersCompany(company);
+    } catch (err) {
+      alert(err.response?.data?.message || 'Ошибка сервера');
+    }
+  };
+
+  const handleInvite = async (e) => {
+    e.preventDefault();
+    try {
+      const response = await companiesAPI.invite(membersCompany.id, newMember);
+      setMessage(response.data.message);
+      setNewMember('');
+      setError('');
+    } catch (err) {
+      setMessage('');
+      setError(err.response?.data?.message || 'Ошибка сервера');
+    }
+  };
+
+  const handleInvitation = async (invitation, accept) => {
+    try {
+      if (accept) {
+        await companiesAPI.acceptInvitation(invitation.company_id);
+      } else {
+        await companiesAPI.declineInvitation(invitation.company_id);
+      }
+      load();
+    } catch (err) {
+      alert(err.response?.data?.message || 'Ошибка сервера');
+    }
+  };
+
+  const handleMemberRole = async (member, role) => {
+    try {
+      const response = await companiesAPI.setMemberRole(membersCompany.id, member.user_id, role);
+      setMembers(response.data);
+      setError('');
+    } catch (err) {
+      setError(err.response?.data?.message || 'Ошибка сервера');
+    }
+  };
+
+  const handleRemoveMember = async (member) => {
+    const self = member.user_id === user?.id;
+    const question = self
+      ? `Выйти из компании «${membersCompany.name}»?`
+      : `Убрать ${member.username} из компании «${membersCompany.name}»?`;
+    if (!window.confirm(question)) {
+      return;
+    }
+    try {
+      await companiesAPI.removeMember(membersCompany.id, member.user_id);
+      if (self) {
+        setMembersCompany(null);
+      } else {
+        setMembers(members.filter(m => m.user_id !== member.user_id));
+      }
+      load();
+    } catch (err) {
+      setError(err.response?.data?.message || 'Ошибка сервера');
+    }
+  };
+
+  // приглашать, убирать коллег и назначать владельцев может только владелец
+  const isOwner = members.some(m => m.user_id === user?.id && m.role === 'owner');
+
+  return (
+    <div className="user-stats">
+      <h3>Мои компании</h3>
+      {invitations.map((invitation) => (
+        <p key={invitation.company_id}>
+          {invitation.invited_by || 'Участник'} приглашает вас в компанию{' '}
+          <Link to={`/companies/${invitation.company_id}`}><strong>{invitation.company}</strong></Link>{' '}
+          <button type="button" className="link-btn" onClick={() => handleInvitation(invitation, true)}>Принять</button>{' '}
+          <button type="button" className="link-btn" onClick={() => handleInvitation(invitation, false)}>Отклонить</button>
+        </p>
+      ))}
+      {companies.map((company) => (
+        <p key={company.id}>
+          <Link to={`/companies/${company.id}`}><strong>{company.name}</strong></Link>
+          {company.inn && <>, ИНН {company.inn}</>} —{' '}
+          {company.verified ? 'проверена' : 'не проверена'}{' '}
+          <button type="button" className="link-btn" onClick={() => openEdit(company)}>Изменить</button>{' '}
+          <button type="button" className="link-btn" onClick={() => openMembers(company)}>Участники</button>{' '}
+          <label className="link-btn">
+            Логотип
+            <input
+              type="file"
+              accept="image/jpeg,image/png,image/gif"
+              hidden
+              onChange={e => handleLogo(company, e.target.files[0])}
+            />
+          </label>
+        </p>
+      ))}
+      <button type="button" className="btn btn-secondary" onClick={() => openEdit(null)}>
+        Зарегистрировать компанию
+      </button>
+
+      {editCompany && (
+        <div className="modal-overlay" onClick={() => setEditCompany(null)}>
+          <div className="modal" onClick={e => e.stopPropagation()}>
+            <h2>{editCompany.id ? 'Профиль компании' : 'Новая компания'}</h2>
+            {editCompany.verified && (
+              <p className="user-email">После смены названия или ИНН компанию проверят заново.</p>
+            )}
+            <form onSubmit={handleEditSubmit}>
+              <div className="form-group">
+                <label className="form-label">Название</label>
+                <input name="name" value={editCompany.name} onChange={handleEditChange} className="form-input" maxLength={100} required />
+              </div>
+              <div className="form-group">
+                <label className="form-label">ИНН</label>
+                <input name="inn" value={editCompany.inn} onChange={handleEditChange} className="form-input" maxLength={12} placeholder="10 цифр для организации, 12 — для ИП" />
+              </div>
+              <div className="form-group">
+                <label className="form-label">О компании</label>
+                <textarea name="description" value={editCompany.description} onChange={handleEditChange} className="form-input" rows="4" maxLength={5000} />
+              </div>
+              <div className="form-group">
+                <label className="form-label">Сайт</label>
+                <input name="website" value={editCompany.website} onChange={handleEditChange} className="form-input" placeholder="example.ru" />
+              </div>
+              <div className="form-group">
+                <label className="form-label">Телефон</label>
+                <input type="tel" name="phone" value={editCompany.phone} onChange={handleEditChange} className="form-input" placeholder="+7 900 000-00-00" />
+              </div>
+              <div className="form-group">
+                <label className="form-label">Email</label>
+                <input type="email" name="email" value={editCompany.email} onChange={handleEditChange} className="form-input" />
+              </div>
+
+              {error && <div className="error-message">{error}</div>}
+
+              <div className="form-actions">
+                <button type="button" className="btn btn-secondary" onClick={() => setEditCompany(null)}>Отмена</button>
+                <button type="submit" className="btn btn-primary">Сохранить</button>
+              </div>
+            </form>
+          </div>
+        </div>
+      )}
+
+      {membersCompany && (
+        <div className="modal-overlay" onClick={() => setMembersCompany(null)}>
+          <div className="modal" onClick={e => e.stopPropagation()}>
+            <h2>Участники «{membersCompany.name}»</h2>
+            <p className="user-email">
+              Участники размещают вакансии от имени компании и правят её профиль, владельцы ещё и приглашают коллег.
+            </p>
+            {members.map(member => (
+              <p key={member.user_id}>
+                {member.username}{member.role === 'owner' && ' — владелец'}{' '}
+                {isOwner && (
+                  <button
+                    type="button"
+                    className="link-btn"
+                    onClick={() => handleMemberRole(member, member.role === 'owner' ? 'member' : 'owner')}
+                  >
+                    {member.role === 'owner' ? 'Снять владельца' : 'Сделать владельцем'}
+                  </button>
+                )}{' '}
+                {(isOwner || member.user_id === user?.id) && (
+                  <button type="button" className="link-btn" onClick={() => handleRemoveMember(member)}>
+                    {member.user_id === user?.id ? 'Выйти' : 'Убрать'}
+                  </button>
+                )}
+              </p>
+            ))}
+            {isOwner ? (
+              <form onSubmit={handleInvite}>
+                <div className="form-group">
+                  <label className="form-label">Пригласить по логину</label>
+                  <input value={newMember} onChange={e => setNewMember(e.target.value)} className="form-input" required />
+                </div>
+
+                {message && <div className="success-message">{message}</div>}
+                {error && <div className="error-message">{error}</div>}
+
+                <div className="form-actions">
+                  <button type="button" className="btn btn-secondary" onClick={() => setMembersCompany(null)}>Закрыть</button>
+                  <button type="submit" className="btn btn-primary">Пригласить</button>
+                </div>
+              </form>
+            ) : (
+              <>
+                {error && <div className="error-message">{error}</div>}
+                <div className="form-actions">
+                  <button type="button" className="btn btn-secondary" onClick={() => setMembersCompany(null)}>Закрыть</button>
+                </div>
+              </>
+            )}
+          </div>
+        </div>
+      )}
+    </div>
+  );
+};
+
+export default MyCompanies;
@@ -0,0 +1,185 @@
+import React, { useEffect, useState } from 'react';
+import {
+  companiesAPI,
+  companyPayload,
+  jobsAPI,
+  jobStatusLabels,
+  salaryPayload,
+  salaryPeriodLabels
+} from '../../utils/api';
+
+const jobCategories = ['Строительство', 'Отделка', 'Электрика', 'Сантехника', 'Проектирование'];
+
+const MyJobs = () => {
+  const [jobs, setJobs] = useState([]);
+  const [editJob, setEditJob] = useState(null);
+  const [myCompanies, setMyCompanies] = useState([]);
+  const [error, setError] = useState('');
+
+  const load = () => {
+    jobsAPI.getMine()
+      .then((response) => setJobs(response.data))
+      .catch(() => setJobs([]));
+  };
+
+  useEffect(load, []);
+
+  const openEdit = (job) => {
+    setError('');
+    companiesAPI.getMine()
+      .then((response) => setMyCompanies(response.data))
+      .catch(() => setMyCompanies([]));
+    setEditJob({
+      id: job.id,
+      title: job.title,
+      company_id: job.company_id ?? '',
+      company: job.company,
+      salary_min: job.salary_min ?? '',
+      salary_max: job.salary_max ?? '',
+      salary_period: job.salary_period || 'month',
+      salary_gross: Boolean(job.salary_gross),
+      category: job.category,
+      description: job.description
+    });
+  };
+
+  const handleEditChange = (e) => {
+    const { name, value, type, checked } = e.target;
+    setEditJob(prev => ({ ...prev, [name]: type === 'checkbox' ? checked : value }));
+  };
+
+  const handleEditSubmit = async (e) => {
+    e.preventDefault();
+    const { id, salary_min, salary_max, salary_period, salary_gross, company_id, company, ...data } = editJob;
+    try {
+      await jobsAPI.update(id, { ...data, ...salaryPayload(editJob), ...companyPayload(editJob) });
+      setEditJob(null);
+      load();
+      alert('Вакансия изменена и отправлена на модерацию');
+    } catch (err) {
+      setError(err.response?.data?.message || 'Ошибка сервера');
+    }
+  };
+
+  const handleRenew = async (job) => {
+    try {
+      await jobsAPI.renew(job.id);
+      load();
+      alert('Вакансия продлена');
+    } catch (err) {
+      alert(err.response?.data?.message || 'Ошибка сервера');
+    }
+  };
+
+  // снятые модератором вакансии срока не имеют, продлить можно только снятые по сроку
+  const canRenew = (job) => job.status === 'approved' || (job.status === 'archived' && job.expires_at);
+
+  const handleWithdraw = async (job) => {
+    if (!window.confirm(`Снять вакансию «${job.title}»? Отклики на неё будут удалены.`)) {
+      return;
+    }
+    try {
+      await jobsAPI.withdraw(job.id);
+      load();
+    } catch (err) {
+      alert(err.response?.data?.message || 'Ошибка сервера');
+    }
+  };
+
+  if (jobs.length === 0) {
+    return null;
+  }
+
+  return (
+    <div className="user-stats">
+      <h3>Мои вакансии</h3>
+      {jobs.map((job) => (
+        <p key={job.id}>
+          <strong>{job.title}</strong>, {job.salary} —{' '}
+          {(jobStatusLabels[job.status] || job.status).toLowerCase()}{' '}
+          {job.status === 'approved' && job.expires_at && (
+            <>до {new Date(job.expires_at).toLocaleDateString('ru-RU')}{' '}</>
+          )}
+          {job.status !== 'archived' && (
+            <button type="button" className="link-btn" onClick={() => openEdit(job)}>Изменить</button>
+          )}{' '}
+          {canRenew(job) && (
+            <>
+              <button type="button" className="link-btn" onClick={() => handleRenew(job)}>Продлить</button>{' '}
+            </>
+          )}
+          <button type="button" className="link-btn" onClick={() => handleWithdraw(job)}>Снять</button>
+          {job.status === 'rejected' && job.rejection_reason && (
+            <span className="user-email"><br />Причина отказа: {job.rejection_reason}</span>
+          )}
+        </p>
+      ))}
+
+      {editJob && (
+        <div className="modal-overlay" onClick={() => setEditJob(null)}>
+          <div className="modal" onClick={e => e.stopPropagation()}>
+            <h2>Редактирование вакансии</h2>
+            <p className="user-email">После изменения вакансия снова пройдёт модерацию.</p>
+            <form onSubmit={handleEditSubmit}>
+              <div className="form-group">
+                <label className="form-label">Название вакансии</label>
+                <input name="title" value={editJob.title} onChange={handleEditChange} className="form-input" required />
+              </div>
+              <div className="form-group">
+                <label className="form-label">Компания</label>
+                {myCompanies.length > 0 && (
+                  <select name="company_id" value={editJob.company_id} onChange={handleEditChange} className="form-input">
+                    {myCompanies.map(company => (
+                      <option key={company.id} value={company.id}>{company.name}</option>
+                    ))}
+                    <option value="">Новая компания</option>
+                  </select>
+                )}
+                {editJob.company_id === '' && (
+                  <input name="company" value={editJob.company} onChange={handleEditChange} className="form-input" placeholder="Название компании" maxLength={100} required />
+                )}
+              </div>
+              <div className="form-group">
+                <label className="form-label">Зарплата, ₽ (пусто — по договорённости)</label>
+                <div className="salary-inputs">
+                  <input type="number" min="0" name="salary_min" value={editJob.salary_min} onChange={handleEditChange} className="form-input" placeholder="от" />
+                  <input type="number" min="0" name="salary_max" value={editJob.salary_max} onChange={handleEditChange} className="form-input" placeholder="до" />
+                  <select name="salary_period" value={editJob.salary_period} onChange={handleEditChange} className="form-input">
+                    {Object.entries(salaryPeriodLabels).map(([value, label]) => (
+                      <option key={value} value={value}>{label}</option>
+                    ))}
+                  </select>
+                </div>
+                <label className="checkbox-label">
+                  <input type="checkbox" name="salary_gross" checked={editJob.salary_gross} onChange={handleEditChange} /> до вычета налогов
+                </label>
+              </div>
+              <div className="form-group">
+                <label className="form-label">Категория</label>
+                <select name="category" value={editJob.category} onChange={handleEditChange} className="form-input" required>
+                  <option value="">Выберите категорию</option>
+                  {jobCategories.map(category => (
+                    <option key={category} value={category}>{category}</option>
+                  ))}
+                </select>
+              </div>
+              <div className="form-group">
+                <label className="form-label">Описание</label>
+                <textarea name="description" value={editJob.description} onChange={handleEditChange} className="form-input" rows="4" required />
+              </div>
+
+              {error && <div className="error-message">{error}</div>}
+
+              <div className="form-actions">
+                <button type="button" className="btn btn-secondary" onClick={() => setEditJob(null)}>Отмена</button>
+                <button type="submit" className="btn btn-primary">Сохранить</button>
+              </div>
+            </form>
+          </div>
+        </div>
+      )}
+    </div>
+  );
+};
+
+export default MyJobs;
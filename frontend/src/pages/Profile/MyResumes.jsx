@@ -0,0 +1,207 @@
+import React, { useContext, useEffect, useState } from 'react';
+import { AuthContext } from '../../context/AuthContext';
+import {
+  resumeAvailabilityLabels,
+  resumesAPI,
+  resumeStatusLabels,
+  salaryPayload,
+  salaryPeriodLabels
+} from '../../utils/api';
+
+const MyResumes = () => {
+  const { user } = useContext(AuthContext);
+  const [resumes, setResumes] = useState([]);
+  const [invitations, setInvitations] = useState([]);
+  const [form, setForm] = useState(null);
+  const [error, setError] = useState('');
+
+  const load = () => {
+    resumesAPI.getMine()
+      .then((response) => setResumes(response.data))
+      .catch(() => setResumes([]));
+    resumesAPI.getInvitations()
+      .then((response) => setInvitations(response.data))
+      .catch(() => setInvitations([]));
+  };
+
+  useEffect(load, []);
+
+  const openForm = (resume = null) => {
+    setError('');
+    setForm({
+      id: resume?.id ?? null,
+      title: resume?.title ?? '',
+      skills: resume?.skills?.join(', ') ?? '',
+      experience_years: resume?.experience_years ?? 0,
+      experience: resume?.experience ?? '',
+      region: resume?.region ?? '',
+      availability: resume?.availability || 'immediately',
+      salary_min: resume?.salary_min ?? '',
+      salary_max: resume?.salary_max ?? '',
+      salary_period: resume?.salary_period || 'month',
+      salary_gross: Boolean(resume?.salary_gross),
+      contact_name: resume?.contact_name ?? '',
+      contact_phone: resume?.contact_phone ?? '',
+      contact_email: resume?.contact_email ?? user?.email ?? ''
+    });
+  };
+
+  const handleChange = (e) => {
+    const { name, value, type, checked } = e.target;
+    setForm(prev => ({ ...prev, [name]: type === 'checkbox' ? checked : value }));
+  };
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+    const { id, skills, experience_years, salary_min, salary_max, salary_period, salary_gross, ...data } = form;
+    // «по договорённости» у резюме — просто пустая зарплата
+    const { salary, ...salaryData } = salaryPayload(form);
+    const payload = {
+      ...data,
+      ...salaryData,
+      skills: skills.split(','),
+      experience_years: Number(experience_years) || 0
+    };
+    try {
+      if (id) {
+        await resumesAPI.update(id, payload);
+      } else {
+        await resumesAPI.create(payload);
+      }
+      setForm(null);
+      load();
+      alert('Резюме отправлено на модерацию');
+    } catch (err) {
+      setError(err.response?.data?.message || 'Ошибка сервера');
+    }
+  };
+
+  const handleDelete = async (resume) => {
+    if (!window.confirm(`Удалить резюме «${resume.title}»?`)) {
+      return;
+    }
+    try {
+      await resumesAPI.remove(resume.id);
+      load();
+    } catch (err) {
+      alert(err.response?.data?.message || 'Ошибка сервера');
+    }
+  };
+
+  return (
+    <div className="user-stats">
+      <h3>Мои резюме</h3>
+      {resumes.length === 0 && (
+        <p className="user-email">Разместите резюме, и работодатели смогут пригласить вас на вакансию.</p>
+      )}
+      {resumes.map((resume) => (
+        <p key={resume.id}>
+          <strong>{resume.title}</strong>, {resume.region}
+          {resume.salary && <>, {resume.salary}</>} —{' '}
+          {(resumeStatusLabels[resume.status] || resume.status).toLowerCase()}{' '}
+          {resume.status !== 'archived' && (
+            <button type="button" className="link-btn" onClick={() => openForm(resume)}>Изменить</button>
+          )}{' '}
+          <button type="button" className="link-btn" onClick={() => handleDelete(resume)}>Удалить</button>
+          {resume.status === 'rejected' && resume.rejection_reason && (
+            <span className="user-email"><br />Причина отказа: {resume.rejection_reason}</span>
+          )}
+        </p>
+      ))}
+      <button type="button" className="btn btn-secondary" onClick={() => openForm()}>Разместить резюме</button>
+
+      {invitations.length > 0 && (
+        <>
+          <h3>Приглашения от работодателей</h3>
+          {invitations.map((invitation) => (
+            <p key={invitation.id}>
+              <strong>{invitation.job_title}</strong>, {invitation.company} — по резюме «{invitation.resume_title}»,{' '}
+              {new Date(invitation.created_at).toLocaleDateString('ru-RU')}
+              {invitation.message && (
+                <span className="user-email"><br />{invitation.message}</span>
+              )}
+            </p>
+          ))}
+        </>
+      )}
+
+      {form && (
+        <div className="modal-overlay" onClick={() => setForm(null)}>
+          <div className="modal" onClick={e => e.stopPropagation()}>
+            <h2>{form.id ? 'Редактирование резюме' : 'Новое резюме'}</h2>
+            <p className="user-email">
+              Резюме появится в поиске после модерации. Контакты увидят только работодатели,
+              которые пригласят вас или на вакансии которых вы откликнулись.
+            </p>
+            <form onSubmit={handleSubmit}>
+              <div className="form-group">
+                <label className="form-label">Желаемая должность</label>
+                <input name="title" value={form.title} onChange={handleChange} className="form-input" maxLength={100} required />
+              </div>
+              <div className="form-group">
+                <label className="form-label">Навыки через запятую</label>
+                <input name="skills" value={form.skills} onChange={handleChange} className="form-input" placeholder="штукатурка, покраска, гипсокартон" required />
+              </div>
+              <div className="form-group">
+                <label className="form-label">Стаж, лет</label>
+                <input type="number" min="0" max="60" name="experience_years" value={form.experience_years} onChange={handleChange} className="form-input" />
+              </div>
+              <div className="form-group">
+                <label className="form-label">Опыт работы</label>
+                <textarea name="experience" value={form.experience} onChange={handleChange} className="form-input" rows="4" maxLength={5000} />
+              </div>
+              <div className="form-group">
+                <label className="form-label">Регион</label>
+                <input name="region" value={form.region} onChange={handleChange} className="form-input" maxLength={100} required />
+              </div>
+              <div className="form-group">
+                <label className="form-label">Готовность выйти на работу</label>
+                <select name="availability" value={form.availability} onChange={handleChange} className="form-input">
+                  {Object.entries(resumeAvailabilityLabels).map(([value, label]) => (
+                    <option key={value} value={value}>{label}</option>
+                  ))}
+                </select>
+              </div>
+              <div className="form-group">
+                <label className="form-label">Желаемая зарплата, ₽ (необязательно)</label>
+                <div className="salary-inputs">
+                  <input type="number" min="0" name="salary_min" value={form.salary_min} onChange={handleChange} className="form-input" placeholder="от" />
+                  <input type="number" min="0" name="salary_max" value={form.salary_max} onChange={handleChange} className="form-input" placeholder="до" />
+                  <select name="salary_period" value={form.salary_period} onChange={handleChange} className="form-input">
+                    {Object.entries(salaryPeriodLabels).map(([value, label]) => (
+                      <option key={value} value={value}>{label}</option>
+                    ))}
+                  </select>
+                </div>
+                <label className="checkbox-label">
+                  <input type="checkbox" name="salary_gross" checked={form.salary_gross} onChange={handleChange} /> до вычета налогов
+                </label>
+              </div>
+              <div className="form-group">
+                <label className="form-label">Имя</label>
+                <input name="contact_name" value={form.contact_name} onChange={handleChange} className="form-input" maxLength={150} required />
+              </div>
+              <div className="form-group">
+                <label className="form-label">Телефон</label>
+                <input type="tel" name="contact_phone" value={form.contact_phone} onChange={handleChange} className="form-input" placeholder="+7 999 123-45-67" />
+              </div>
+              <div className="form-group">
+                <label className="form-label">Email</label>
+                <input type="email" name="contact_email" value={form.contact_email} onChange={handleChange} className="form-input" maxLength={100} />
+              </div>
+
+              {error && <div className="error-message">{error}</div>}
+
+              <div className="form-actions">
+                <button type="button" className="btn btn-secondary" onClick={() => setForm(null)}>Отмена</button>
+                <button type="submit" className="btn btn-primary">Сохранить</button>
+              </div>
+            </form>
+          </div>
+        </div>
+      )}
+    </div>
+  );
+};
+
+export default MyResumes;
@@ -1,20 +1,66 @@
-import React, { useContext, useState } from 'react';
+import React, { useContext, useEffect, useState } from 'react';
 import { AuthContext } from '../../context/AuthContext';
+import { authAPI, profileAPI } from '../../utils/api';
+import TwoFactorSettings from './TwoFactorSettings';
+import LinkedAccounts from './LinkedAccounts';
+import MyApplications from './MyApplications';
+import MyResumes from './MyResumes';
+import MyCompanies from './MyCompanies';
+import MyJobs from './MyJobs';
 import './Profile.css';
 
+const roleTitles = {
+  admin: 'Администратор',
+  catalog_manager: 'Менеджер каталога',
+  moderator: 'Модератор вакансий',
+  order_operator: 'Оператор заказов',
+  user: 'Пользователь',
+};
+
+const emptyPasswordForm = {
+  currentPassword: '',
+  newPassword: '',
+  confirmPassword: ''
+};
+
 const Profile = () => {
-  const { user, logout } = useContext(AuthContext);
+  const { user, logout, saveSession, updateUser } = useContext(AuthContext);
+  const [profile, setProfile] = useState(null);
   const [showChangePassword, setShowChangePassword] = useState(false);
-  const [passwordForm, setPasswordForm] = useState({
-    currentPassword: '',
-    newPassword: '',
-    confirmPassword: ''
-  });
+  const [passwordForm, setPasswordForm] = useState(emptyPasswordForm);
+  const [passwordError, setPasswordError] = useState('');
+
+  const [showEdit, setShowEdit] = useState(false);
+  const [editForm, setEditForm] = useState(null);
+  const [editError, setEditError] = useState('');
+
+  const [showDelete, setShowDelete] = useState(false);
+  const [deletePassword, setDeletePassword] = useState('');
+  const [deleteError, setDeleteError] = useState('');
+
+  const [verificationMessage, setVerificationMessage] = useState('');
+
+  useEffect(() => {
+    if (user) {
+      profileAPI.get()
+        .then((response) => setProfile(response.data))
+        .catch(() => setProfile(null));
+    }
+  }, [user?.id]);
 
   const handleLogout = () => {
     logout();
   };
 
+  const handleResendVerification = async () => {
+    try {
+      const response = await authAPI.resendVerification();
+      setVerificationMessage(response.data.message);
+    } catch (error) {
+      setVerificationMessage(error.response?.data?.message || 'Не удалось отправить письмо');
+    }
+  };
+
   const handlePasswordChange = (e) => {
     setPasswordForm({
       ...passwordForm,
@@ -22,17 +68,91 @@ const Profile = () => {
     });
   };
 
-  const handlePasswordSubmit = (e) => {
+  const handlePasswordSubmit = async (e) => {
     e.preventDefault();
-    alert('Пароль успешно изменен!');
-    setShowChangePassword(false);
-    setPasswordForm({
-      currentPassword: '',
-      newPassword: '',
-      confirmPassword: ''
+    if (passwordForm.newPassword !== passwordForm.confirmPassword) {
+      setPasswordError('Пароли не совпадают');
+      return;
+    }
+
+    try {
+      // остальные сессии сервер завершает, а эта продолжается с новыми токенами
+      const response = await profileAPI.changePassword(passwordForm.currentPassword, passwordForm.newPassword);
+      saveSession(response.data);
+      alert('Пароль успешно изменен!');
+      setShowChangePassword(false);
+      setPasswordForm(emptyPasswordForm);
+      setPasswordError('');
+    } catch (error) {
+      setPasswordError(error.response?.data?.message || 'Не удалось сменить пароль');
+    }
+  };
+
+  const openEdit = () => {
+    setEditForm({
+      username: profile?.username ?? user.username,
+      email: profile?.email ?? user.email,
+      full_name: profile?.full_name ?? '',
+      phone: profile?.phone ?? '',
+      addresses: (profile?.addresses ?? []).map(({ label, address, is_default }) => ({ label, address, is_default })),
+    });
+    setEditError('');
+    setShowEdit(true);
+  };
+
+  const handleEditChange = (e) => {
+    setEditForm({ ...editForm, [e.target.name]: e.target.value });
+  };
+
+  const handleAddressChange = (index, field, value) => {
+    const addresses = editForm.addresses.map((a, i) => {
+      if (field === 'is_default') {
+        return { ...a, is_default: i === index };
+      }
+      return i === index ? { ...a, [field]: value } : a;
+    });
+    setEditForm({ ...editForm, addresses });
+  };
+
+  const addAddress = () => {
+    setEditForm({
+      ...editForm,
+      addresses: [...editForm.addresses, { label: '', address: '', is_default: editForm.addresses.length === 0 }],
     });
   };
 
+  const removeAddress = (index) => {
+    setEditForm({ ...editForm, addresses: editForm.addresses.filter((_, i) => i !== index) });
+  };
+
+  const handleEditSubmit = async (e) => {
+    e.preventDefault();
+    try {
+      const response = await profileAPI.update(editForm);
+      setProfile(response.data);
+      updateUser({
+        username: response.data.username,
+        email: response.data.email,
+        email_verified: response.data.email_verified,
+      });
+      setShowEdit(false);
+    } catch (error) {
+      setEditError(error.response?.data?.message || 'Не удалось сохранить профиль');
+    }
+  };
+
+  const handleDeleteSubmit = async (e) => {
+    e.preventDefault();
+    try {
+      await profileAPI.remove(deletePassword);
+      logout();
+    } catch (error) {
+      setDeleteError(error.response?.data?.message || 'Не удалось удалить аккаунт');
+    }
+  };
+
+  const defaultAddress = profile?.addresses?.find((a) => a.is_default);
+
   if (!user) {
     return (
       <div className="profile-page">
@@ -58,19 +178,45 @@ const Profile = () => {
               <div className="user-info">
                 <h2>{user.username}</h2>
                 <p className="user-email">{user.email}</p>
+                {user.email_verified === false && (
+                  <p className="user-email">
+                    Email не подтверждён.{' '}
+                    <button type="button" className="link-btn" onClick={handleResendVerification}>
+                      Отправить письмо ещё раз
+                    </button>
+                  </p>
+                )}
+                {verificationMessage && <p className="user-email">{verificationMessage}</p>}
+                {profile?.full_name && <p className="user-email">{profile.full_name}</p>}
+                {profile?.phone && <p className="user-email">{profile.phone}</p>}
+                {defaultAddress && <p className="user-email">Доставка: {defaultAddress.address}</p>}
                 <p className="user-role">
-                  {user.role === 'admin' ? 'Администратор' : 'Пользователь'}
+                  {roleTitles[user.role] || 'Пользователь'}
                 </p>
               </div>
             </div>
             
             <div className="profile-actions">
+              <button
+                className="btn btn-secondary"
+                onClick={openEdit}
+              >
+                Редактировать профиль
+              </button>
+
               <button 
                 className="btn btn-secondary"
                 onClick={() => setShowChangePassword(true)}
               >
                 Сменить пароль
               </button>
+
+              <button
+                className="btn btn-secondary"
+                onClick={() => setShowDelete(true)}
+              >
+                Удалить аккаунт
+              </button>
               
               <button 
                 className="btn btn-primary logout-btn"
@@ -81,6 +227,13 @@ const Profile = () => {
             </div>
           </div>
           
+          <TwoFactorSettings />
+          <LinkedAccounts />
+          <MyCompanies />
+          <MyJobs />
+          <MyApplications />
+          <MyResumes />
+
           {user.role === 'user' && (
             <div className="user-stats">
               <h3>Моя активность</h3>
@@ -128,7 +281,7 @@ const Profile = () => {
                     onChange={handlePasswordChange}
                     className="form-input"
                     required
-                    minLength="6"
+                    minLength="8"
                   />
                 </div>
                 
@@ -141,9 +294,11 @@ const Profile = () => {
                     onChange={handlePasswordChange}
                     className="form-input"
                     required
-                    minLength="6"
+                    minLength="8"
                   />
                 </div>
+
+                {passwordError && <div className="error-message">{passwordError}</div>}
                 
                 <div className="form-actions">
                   <button 
@@ -161,6 +316,121 @@ const Profile = () => {
             </div>
           </div>
         )}
+
+        {showEdit && editForm && (
+          <div className="modal-overlay" onClick={() => setShowEdit(false)}>
+            <div className="modal" onClick={e => e.stopPropagation()}>
+              <h2>Редактирование профиля</h2>
+              <form onSubmit={handleEditSubmit}>
+                <div className="form-group">
+                  <label className="form-label">Логин</label>
+                  <input name="username" value={editForm.username} onChange={handleEditChange} className="form-input" required minLength="3" maxLength="50" />
+                </div>
+                <div className="form-group">
+                  <label className="form-label">Email</label>
+                  <input type="email" name="email" value={editForm.email} onChange={handleEditChange} className="form-input" required />
+                </div>
+                <div className="form-group">
+                  <label className="form-label">ФИО</label>
+                  <input name="full_name" value={editForm.full_name} onChange={handleEditChange} className="form-input" maxLength="150" />
+                </div>
+                <div className="form-group">
+                  <label className="form-label">Телефон</label>
+                  <input type="tel" name="phone" value={editForm.phone} onChange={handleEditChange} className="form-input" placeholder="+7 (900) 000-00-00" />
+                </div>
+
+                <div className="form-group">
+                  <label className="form-label">Адреса доставки</label>
+                  {editForm.addresses.map((a, index) => (
+                    <div key={index} className="form-group">
+                      <input
+                        value={a.label}
+                        onChange={e => handleAddressChange(index, 'label', e.target.value)}
+                        className="form-input"
+                        placeholder="Название, например «Дом»"
+                        maxLength="50"
+                      />
+                      <input
+                        value={a.address}
+                        onChange={e => handleAddressChange(index, 'address', e.target.value)}
+                        className="form-input"
+                        placeholder="Адрес"
+                        required
+                        maxLength="255"
+                      />
+                      <label>
+                        <input
+                          type="radio"
+                          name="default_address"
+                          checked={a.is_default}
+                          onChange={() => handleAddressChange(index, 'is_default', true)}
+                        />{' '}
+                        Основной
+                      </label>{' '}
+                      <button type="button" className="link-btn" onClick={() => removeAddress(index)}>
+                        Удалить
+                      </button>
+                    </div>
+                  ))}
+                  {editForm.addresses.length < 10 && (
+                    <button type="button" className="link-btn" onClick={addAddress}>
+                      Добавить адрес
+                    </button>
+                  )}
+                </div>
+
+                {editForm.email !== user.email && (
+                  <p className="user-email">На новый адрес придёт письмо для подтверждения.</p>
+                )}
+                {editError && <div className="error-message">{editError}</div>}
+
+                <div className="form-actions">
+                  <button type="button" className="btn btn-secondary" onClick={() => setShowEdit(false)}>
+                    Отмена
+                  </button>
+                  <button type="submit" className="btn btn-primary">
+                    Сохранить
+                  </button>
+                </div>
+              </form>
+            </div>
+          </div>
+        )}
+
+        {showDelete && (
+          <div className="modal-overlay" onClick={() => setShowDelete(false)}>
+            <div className="modal" onClick={e => e.stopPropagation()}>
+              <h2>Удаление аккаунта</h2>
+              <p>
+                Профиль, адреса и корзина будут удалены без возможности восстановления.
+                Ваши заказы и вакансии останутся, но больше не будут связаны с вами.
+              </p>
+              <form onSubmit={handleDeleteSubmit}>
+                <div className="form-group">
+                  <label className="form-label">Пароль</label>
+                  <input
+                    type="password"
+                    value={deletePassword}
+                    onChange={e => setDeletePassword(e.target.value)}
+                    className="form-input"
+                    required
+                  />
+                </div>
+
+                {deleteError && <div className="error-message">{deleteError}</div>}
+
+                <div className="form-actions">
+                  <button type="button" className="btn btn-secondary" onClick={() => setShowDelete(false)}>
+                    Отмена
+                  </button>
+                  <button type="submit" className="btn btn-primary">
+                    Удалить аккаунт
+                  </button>
+                </div>
+              </form>
+            </div>
+          </div>
+        )}
       </div>
     </div>
   );
@@ -0,0 +1,133 @@
+import React, { useEffect, useState } from 'react';
+import { twoFactorAPI } from '../../utils/api';
+
+const TwoFactorSettings = () => {
+  const [status, setStatus] = useState(null);
+  const [setupInfo, setSetupInfo] = useState(null);
+  const [code, setCode] = useState('');
+  const [password, setPassword] = useState('');
+  const [recoveryCodes, setRecoveryCodes] = useState(null);
+  const [error, setError] = useState('');
+
+  const loadStatus = () => {
+    twoFactorAPI.status()
+      .then((response) => setStatus(response.data))
+      .catch(() => setStatus(null));
+  };
+
+  useEffect(loadStatus, []);
+
+  const run = async (action) => {
+    setError('');
+    try {
+      await action();
+    } catch (err) {
+      setError(err.response?.data?.message || 'Ошибка сервера');
+    }
+  };
+
+  const handleSetup = () => run(async () => {
+    const response = await twoFactorAPI.setup();
+    setSetupInfo(response.data);
+  });
+
+  const handleEnable = (e) => {
+    e.preventDefault();
+    run(async () => {
+      const response = await twoFactorAPI.enable(code);
+      setRecoveryCodes(response.data.recovery_codes);
+      setSetupInfo(null);
+      setCode('');
+      loadStatus();
+    });
+  };
+
+  const handleRegenerate = (e) => {
+    e.preventDefault();
+    run(async () => {
+      const response = await twoFactorAPI.regenerateCodes(code);
+      setRecoveryCodes(response.data.recovery_codes);
+      setCode('');
+      loadStatus();
+    });
+  };
+
+  const handleDisable = (e) => {
+    e.preventDefault();
+    run(async () => {
+      await twoFactorAPI.disable(password);
+      setPassword('');
+      loadStatus();
+    });
+  };
+
+  if (!status) {
+    return null;
+  }
+
+  return (
+    <div className="user-stats">
+      <h3>Двухфакторная аутентификация</h3>
+
+      {recoveryCodes && (
+        <div>
+          <p>Сохраните коды восстановления — они показываются один раз:</p>
+          <pre>{recoveryCodes.join('\n')}</pre>
+          <button type="button" className="link-btn" onClick={() => setRecoveryCodes(null)}>
+            Скрыть
+          </button>
+        </div>
+      )}
+
+      {!status.enabled && !setupInfo && (
+        <p>
+          Вход не защищён кодом из приложения.{' '}
+          <button type="button" className="link-btn" onClick={handleSetup}>Включить</button>
+        </p>
+      )}
+
+      {setupInfo && (
+        <form onSubmit={handleEnable}>
+          <p>
+            Добавьте аккаунт в приложение-аутентификатор:{' '}
+            <a href={setupInfo.otpauth_uri} className="link">открыть в приложении</a> или ввести ключ{' '}
+            <code>{setupInfo.secret}</code>, затем введите код из приложения.
+          </p>
+          <div className="form-group">
+            <input value={code} onChange={e => setCode(e.target.value)} className="form-input" placeholder="123456" required />
+          </div>
+          <button type="submit" className="btn btn-primary">Подтвердить</button>
+        </form>
+      )}
+
+      {status.enabled && (
+        <>
+          <p>Включена. Осталось кодов восстановления: {status.recovery_codes_left}</p>
+          <form onSubmit={handleRegenerate}>
+            <div className="form-group">
+              <label className="form-label">Код из приложения</label>
+              <input value={code} onChange={e => setCode(e.target.value)} className="form-input" required />
+            </div>
+            <button type="submit" className="btn btn-secondary">Получить новые коды восстановления</button>
+          </form>
+
+          {status.required ? (
+            <p className="user-email">Для вашей роли двухфакторная аутентификация обязательна.</p>
+          ) : (
+            <form onSubmit={handleDisable}>
+              <div className="form-group">
+                <label className="form-label">Пароль</label>
+                <input type="password" value={password} onChange={e => setPassword(e.target.value)} className="form-input" required />
+              </div>
+              <button type="submit" className="btn btn-secondary">Отключить</button>
+            </form>
+          )}
+        </>
+      )}
+
+      {error && <div className="error-message">{error}</div>}
+    </div>
+  );
+};
+
+export default TwoFactorSettings;
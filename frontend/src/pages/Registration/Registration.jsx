@@ -35,8 +35,8 @@ const Registration = () => {
       return;
     }
 
-    if (formData.password.length < 6) {
-      setError('Пароль должен содержать минимум 6 символов');
+    if (formData.password.length < 8) {
+      setError('Пароль должен содержать минимум 8 символов');
       setLoading(false);
       return;
     }
@@ -97,7 +97,7 @@ const Registration = () => {
             </div>
             
             <div className="form-group">
-              <label className="form-label">Пароль (минимум 6 символов)</label>
+              <label className="form-label">Пароль (минимум 8 символов)</label>
               <input
                 type="password"
                 name="password"
@@ -106,7 +106,7 @@ const Registration = () => {
                 className="form-input"
                 required
                 placeholder="Придумайте пароль"
-                minLength="6"
+                minLength="8"
               />
             </div>
             
@@ -120,7 +120,7 @@ const Registration = () => {
                 className="form-input"
                 required
                 placeholder="Повторите пароль"
-                minLength="6"
+                minLength="8"
               />
             </div>
             
@@ -0,0 +1,295 @@
+import React, { useContext, useEffect, useState } from 'react';
+import { Navigate } from 'react-router-dom';
+import { AuthContext } from '../../context/AuthContext';
+import { jobsAPI, resumeAvailabilityLabels, resumesAPI, salaryPeriodLabels } from '../../utils/api';
+import '../Job/Job.css';
+import './Resumes.css';
+
+const Resumes = () => {
+  const { user, can } = useContext(AuthContext);
+  const [resumes, setResumes] = useState([]);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState('');
+  const [filters, setFilters] = useState({
+    search: '',
+    skill: '',
+    region: '',
+    availability: '',
+    experience_from: '',
+    salary_to: '',
+    sort: ''
+  });
+  // период, в котором сравниваются зарплаты при фильтре и сортировке по ним
+  const [salaryPeriod, setSalaryPeriod] = useState('month');
+  const comparesSalary = filters.salary_to !== '' || filters.sort === 'salary_asc';
+
+  // открытое резюме и приглашение по нему
+  const [selected, setSelected] = useState(null);
+  const [myJobs, setMyJobs] = useState([]);
+  const [invitation, setInvitation] = useState({ job_id: '', message: '' });
+  const [inviteError, setInviteError] = useState('');
+
+  const allowed = can('resumes.search');
+
+  useEffect(() => {
+    if (allowed) {
+      fetchResumes();
+    }
+  }, [filters, salaryPeriod, allowed]);
+
+  const fetchResumes = async () => {
+    try {
+      setLoading(true);
+      const params = Object.fromEntries(Object.entries(filters).filter(([, value]) => value !== ''));
+      if (comparesSalary) {
+        params.salary_currency = 'RUB';
+        params.salary_period = salaryPeriod;
+      }
+      const response = await resumesAPI.search(params);
+      setResumes(response.data);
+      setError('');
+    } catch (err) {
+      setError(err.response?.data?.message || 'Ошибка при загрузке резюме');
+      setResumes([]);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const handleFilterChange = (e) => {
+    const { name, value } = e.target;
+    setFilters(prev => ({ ...prev, [name]: value }));
+  };
+
+  const openResume = async (resume) => {
+    setInviteError('');
+    try {
+      const [resumeResponse, jobsResponse] = await Promise.all([
+        resumesAPI.get(resume.id),
+        jobsAPI.getMine()
+      ]);
+      const approved = jobsResponse.data.filter(job => job.status === 'approved');
+      setMyJobs(approved);
+      setInvitation({ job_id: approved[0]?.id ?? '', message: '' });
+      setSelected(resumeResponse.data);
+    } catch (err) {
+      alert(err.response?.data?.message || 'Ошибка сервера');
+    }
+  };
+
+  const handleInvitationChange = (e) => {
+    const { name, value } = e.target;
+    setInvitation(prev => ({ ...prev, [name]: value }));
+  };
+
+  const handleInvite = async (e) => {
+    e.preventDefault();
+    try {
+      const response = await resumesAPI.invite(selected.id, Number(invitation.job_id), invitation.message);
+      setSelected(response.data);
+      alert('Приглашение отправлено соискателю');
+    } catch (err) {
+      setInviteError(err.response?.data?.message || 'Ошибка сервера');
+    }
+  };
+
+  if (!user) {
+    return <Navigate to="/login" replace />;
+  }
+
+  if (!allowed) {
+    return (
+      <div className="job-page">
+        <div className="container">
+          <div className="error-message">
+            Поиск резюме доступен работодателям из проверенных компаний — зарегистрируйте компанию
+            в профиле и дождитесь её проверки.
+          </div>
+        </div>
+      </div>
+    );
+  }
+
+  return (
+    <div className="job-page">
+      <div className="container">
+        <h1>Резюме</h1>
+
+        <div className="job-actions">
+          <div className="filters">
+            <div className="search-box">
+              <input
+                type="text"
+                name="search"
+                placeholder="Поиск по должности и навыкам..."
+                value={filters.search}
+                onChange={handleFilterChange}
+                className="search-input"
+              />
+            </div>
+
+            <div className="category-filter">
+              <input name="skill" placeholder="Навык" value={filters.skill} onChange={handleFilterChange} className="category-select" />
+            </div>
+
+            <div className="category-filter">
+              <input name="region" placeholder="Регион" value={filters.region} onChange={handleFilterChange} className="category-select" />
+            </div>
+
+            <div className="category-filter">
+              <select name="availability" value={filters.availability} onChange={handleFilterChange} className="category-select">
+                <option value="">Любой срок выхода</option>
+                {Object.entries(resumeAvailabilityLabels).map(([value, label]) => (
+                  <option key={value} value={value}>{label}</option>
+                ))}
+              </select>
+            </div>
+
+            <div className="category-filter">
+              <input
+                type="number"
+                min="0"
+                name="experience_from"
+                placeholder="Стаж от, лет"
+                value={filters.experience_from}
+                onChange={handleFilterChange}
+                className="category-select"
+              />
+            </div>
+
+            <div className="category-filter">
+              <input
+                type="number"
+                min="0"
+                name="salary_to"
+                placeholder="Зарплата до, ₽"
+                value={filters.salary_to}
+                onChange={handleFilterChange}
+                className="category-select"
+              />
+            </div>
+
+            {comparesSalary && (
+              <div className="category-filter">
+                <select value={salaryPeriod} onChange={(e) => setSalaryPeriod(e.target.value)} className="category-select">
+                  {Object.entries(salaryPeriodLabels).map(([value, label]) => (
+                    <option key={value} value={value}>Зарплата {label}</option>
+                  ))}
+                </select>
+              </div>
+            )}
+
+            <div className="category-filter">
+              <select name="sort" value={filters.sort} onChange={handleFilterChange} className="category-select">
+                <option value="">Сначала новые</option>
+                <option value="experience">Сначала опытные</option>
+                <option value="salary_asc">Сначала с низкой зарплатой</option>
+              </select>
+            </div>
+          </div>
+        </div>
+
+        {loading ? (
+          <div className="loading">Загрузка резюме...</div>
+        ) : error ? (
+          <div className="error-message">{error}</div>
+        ) : (
+          <>
+            <div className="jobs-grid">
+              {resumes.map(resume => (
+                <div key={resume.id} className="job-card card">
+                  <h3>{resume.title}</h3>
+                  <p className="salary">{resume.salary}</p>
+                  <p className="resume-meta">
+                    {resume.region}, стаж {resume.experience_years} г. · {resumeAvailabilityLabels[resume.availability]}
+                  </p>
+                  <div className="resume-skills">
+                    {resume.skills.map(skill => (
+                      <span key={skill} className="resume-skill">{skill}</span>
+                    ))}
+                  </div>
+                  <button className="btn btn-secondary" onClick={() => openResume(resume)}>
+                    Подробнее
+                  </button>
+                </div>
+              ))}
+            </div>
+
+            {resumes.length === 0 && (
+              <div className="no-jobs">
+                <p>Резюме не найдены</p>
+              </div>
+            )}
+          </>
+        )}
+
+        {selected && (
+          <div className="modal-overlay" onClick={() => setSelected(null)}>
+            <div className="modal" onClick={e => e.stopPropagation()}>
+              <h2>{selected.title}</h2>
+              <p className="salary">{selected.salary}</p>
+              <p className="resume-meta">
+                {selected.region}, стаж {selected.experience_years} г. · {resumeAvailabilityLabels[selected.availability]}
+              </p>
+              <div className="resume-skills">
+                {selected.skills.map(skill => (
+                  <span key={skill} className="resume-skill">{skill}</span>
+                ))}
+              </div>
+              {selected.experience && <p className="resume-experience">{selected.experience}</p>}
+
+              {!selected.contacts_hidden ? (
+                <div className="application-card">
+                  <p><strong>{selected.contact_name}</strong>{selected.username && <> ({selected.username})</>}</p>
+                  <p>{[selected.contact_phone, selected.contact_email].filter(Boolean).join(', ')}</p>
+                </div>
+              ) : myJobs.length === 0 ? (
+                <p className="resume-meta">
+                  Контакты откроются после приглашения. Чтобы пригласить соискателя, нужна опубликованная вакансия.
+                </p>
+              ) : (
+                <form onSubmit={handleInvite}>
+                  <p className="resume-meta">Контакты откроются после приглашения на вакансию.</p>
+                  <div className="form-group">
+                    <label className="form-label">Вакансия</label>
+                    <select name="job_id" value={invitation.job_id} onChange={handleInvitationChange} className="form-input" required>
+                      {myJobs.map(job => (
+                        <option key={job.id} value={job.id}>{job.title}</option>
+                      ))}
+                    </select>
+                  </div>
+                  <div className="form-group">
+                    <label className="form-label">Сообщение</label>
+                    <textarea
+                      name="message"
+                      value={invitation.message}
+                      onChange={handleInvitationChange}
+                      className="form-input"
+                      rows="4"
+                      maxLength={2000}
+                      placeholder="Когда и где ждёте соискателя"
+                    />
+                  </div>
+
+                  {inviteError && <div className="error-message">{inviteError}</div>}
+
+                  <div className="form-actions">
+                    <button type="submit" className="btn btn-primary">Пригласить</button>
+                  </div>
+                </form>
+              )}
+
+              <div className="form-actions">
+                <button type="button" className="btn btn-secondary" onClick={() => setSelected(null)}>
+                  Закрыть
+                </button>
+              </div>
+            </div>
+          </div>
+        )}
+      </div>
+    </div>
+  );
+};
+
+export default Resumes;
@@ -0,0 +1,59 @@
+import React, { useEffect, useRef, useState } from 'react';
+import { Link, useSearchParams } from 'react-router-dom';
+import { authAPI } from '../../utils/api';
+import '../Login/Login.css';
+
+const VerifyEmail = () => {
+  const [searchParams] = useSearchParams();
+  const [status, setStatus] = useState('loading');
+  const [message, setMessage] = useState('');
+  // токен одноразовый: в StrictMode эффект выполняется дважды, второй запрос не нужен
+  const sent = useRef(false);
+
+  useEffect(() => {
+    if (sent.current) return;
+    sent.current = true;
+
+    const token = searchParams.get('token');
+    if (!token) {
+      setStatus('error');
+      setMessage('Ссылка недействительна или устарела');
+      return;
+    }
+
+    authAPI.verifyEmail(token)
+      .then((response) => {
+        setStatus('success');
+        setMessage(response.data.message);
+
+        const savedUser = localStorage.getItem('user');
+        if (savedUser) {
+          localStorage.setItem('user', JSON.stringify({ ...JSON.parse(savedUser), email_verified: true }));
+        }
+      })
+      .catch((err) => {
+        setStatus('error');
+        setMessage(err.response?.data?.message || 'Не удалось подтвердить email');
+      });
+  }, [searchParams]);
+
+  return (
+    <div className="login-page">
+      <div className="login-container">
+        <div className="login-card">
+          <h1>Подтверждение email</h1>
+
+          {status === 'loading' && <p>Проверяем ссылку...</p>}
+          {status === 'success' && <div className="success-message">{message}</div>}
+          {status === 'error' && <div className="error-message">{message}</div>}
+
+          <div className="login-footer">
+            <p><Link to="/" className="link">На главную</Link></p>
+          </div>
+        </div>
+      </div>
+    </div>
+  );
+};
+
+export default VerifyEmail;
@@ -15,15 +15,47 @@ api.interceptors.request.use((config) => {
   return config;
 });
 
+// Один запрос обновления на все параллельные 401
+let refreshing = null;
+
+const refreshTokens = () => {
+  if (!refreshing) {
+    const refreshToken = localStorage.getItem('refresh_token');
+    refreshing = (refreshToken
+      ? axios.post(`${API_BASE_URL}/token/refresh`, { refresh_token: refreshToken })
+      : Promise.reject(new Error('no refresh token'))
+    )
+      .then((response) => {
+        localStorage.setItem('token', response.data.token);
+        localStorage.setItem('refresh_token', response.data.refresh_token);
+        localStorage.setItem('user', JSON.stringify(response.data.user));
+        return response.data.token;
+      })
+      .finally(() => {
+        refreshing = null;
+      });
+  }
+  return refreshing;
+};
+
 api.interceptors.response.use(
   (response) => response,
-  (error) => {
+  async (error) => {
     console.error('API Error:', error.response?.data || error.message);
     
-    if (error.response?.status === 401) {
-      localStorage.removeItem('token');
-      localStorage.removeItem('user');
-      window.location.href = '/login';
+    const original = error.config;
+    if (error.response?.status === 401 && original && !original._retried) {
+      original._retried = true;
+      try {
+        const token = await refreshTokens();
+        original.headers.Authorization = `Bearer ${token}`;
+        return api(original);
+      } catch {
+        localStorage.removeItem('token');
+        localStorage.removeItem('refresh_token');
+        localStorage.removeItem('user');
+        window.location.href = '/login';
+      }
     }
     
     return Promise.reject(error);
@@ -32,19 +64,206 @@ api.interceptors.response.use(
 
 export const authAPI = {
   login: (credentials) => api.post('/login', credentials),
+  loginTwoFactor: (challenge, code) => api.post('/login/2fa', { challenge, code }),
+  loginTwoFactorSetup: (challenge) => api.post('/login/2fa/setup', { challenge }),
   register: (userData) => api.post('/register', userData),
+  refresh: (refreshToken) => api.post('/token/refresh', { refresh_token: refreshToken }),
+  logout: () => api.post('/logout'),
+  verifyEmail: (token) => api.post('/email/verify', { token }),
+  resendVerification: () => api.post('/email/verify/resend'),
+  forgotPassword: (email) => api.post('/password/forgot', { email }),
+  resetPassword: (token, password) => api.post('/password/reset', { token, password }),
+};
+
+export const profileAPI = {
+  get: () => api.get('/me'),
+  update: (profile) => api.put('/me', profile),
+  changePassword: (currentPassword, newPassword) =>
+    api.post('/me/password', { current_password: currentPassword, new_password: newPassword }),
+  remove: (password) => api.delete('/me', { data: { password } }),
+};
+
+export const twoFactorAPI = {
+  status: () => api.get('/me/2fa'),
+  setup: () => api.post('/me/2fa/setup'),
+  enable: (code) => api.post('/me/2fa/enable', { code }),
+  regenerateCodes: (code) => api.post('/me/2fa/recovery-codes', { code }),
+  disable: (password) => api.delete('/me/2fa', { data: { password } }),
+};
+
+export const oauthAPI = {
+  providers: () => api.get('/oauth/providers'),
+  start: (provider) => api.post(`/oauth/${provider}/start`),
+  link: (provider) => api.post(`/oauth/${provider}/link`),
+  exchange: (token) => api.post('/oauth/exchange', { token }),
+  identities: () => api.get('/me/identities'),
+  unlink: (provider) => api.delete(`/me/identities/${provider}`),
+};
+
+// тексты для кодов ?oauth_error=..., с которыми сервер возвращает браузер от провайдера
+export const oauthErrorMessages = {
+  denied: 'Вход через провайдера отменён',
+  expired: 'Время на вход истекло, попробуйте ещё раз',
+  provider: 'Не удалось получить данные от провайдера',
+  no_email: 'Провайдер не передал email — разрешите доступ к почте или зарегистрируйтесь по паролю',
+  email_taken: 'Аккаунт с этим email уже есть: войдите по паролю и привяжите провайдера в профиле',
+  linked: 'Этот аккаунт провайдера уже привязан к другому пользователю',
+  server: 'Ошибка сервера',
 };
 
 export const productsAPI = {
   getAll: (params = {}) => api.get('/products', { params }),
+  getFacets: (params = {}) => api.get('/products/facets', { params }),
+  getImages: (productId) => api.get(`/products/${productId}/images`),
+  uploadImage: (productId, file, primary = false) => {
+    const form = new FormData();
+    form.append('image', file);
+    if (primary) form.append('primary', 'true');
+    return api.post(`/products/${productId}/images`, form);
+  },
+  setPrimaryImage: (productId, imageId) => api.put(`/products/${productId}/images/${imageId}/primary`),
+  deleteImage: (productId, imageId) => api.delete(`/products/${productId}/images/${imageId}`),
   create: (productData) => api.post('/products', productData),
 };
 
+export const categoriesAPI = {
+  getTree: () => api.get('/categories'),
+  create: (data) => api.post('/admin/categories', data),
+  update: (id, data) => api.put(`/admin/categories/${id}`, data),
+  merge: (id, targetId) => api.post(`/admin/categories/${id}/merge`, { target_id: targetId }),
+  remove: (id) => api.delete(`/admin/categories/${id}`),
+};
+
 export const jobsAPI = {
   getAll: (params = {}) => api.get('/jobs', { params }),
   create: (jobData) => api.post('/jobs', jobData),
-  getPending: () => api.get('/admin/jobs'),
+  getMine: () => api.get('/my/jobs'),
+  update: (jobId, jobData) => api.put(`/jobs/${jobId}`, jobData),
+  withdraw: (jobId) => api.delete(`/jobs/${jobId}`),
+  renew: (jobId) => api.put(`/jobs/${jobId}/renew`),
+  getQueue: (params = {}) => api.get('/admin/jobs', { params }),
   approve: (jobId) => api.put(`/admin/jobs/${jobId}/approve`),
+  setStatus: (jobId, status, reason = '') => api.put(`/admin/jobs/${jobId}/status`, { status, reason }),
+  getHistory: (jobId) => api.get(`/admin/jobs/${jobId}/history`),
+};
+
+export const companiesAPI = {
+  getAll: (params = {}) => api.get('/companies', { params }),
+  get: (id) => api.get(`/companies/${id}`),
+  getMine: () => api.get('/my/companies'),
+  create: (data) => api.post('/companies', data),
+  update: (id, data) => api.put(`/companies/${id}`, data),
+  uploadLogo: (id, file) => {
+    const form = new FormData();
+    form.append('logo', file);
+    return api.post(`/companies/${id}/logo`, form);
+  },
+  getMembers: (id) => api.get(`/companies/${id}/members`),
+  invite: (id, username) => api.post(`/companies/${id}/invitations`, { username }),
+  getInvitations: () => api.get('/my/company-invitations'),
+  acceptInvitation: (id) => api.post(`/my/company-invitations/${id}/accept`),
+  declineInvitation: (id) => api.delete(`/my/company-invitations/${id}`),
+  setMemberRole: (id, userId, role) => api.put(`/companies/${id}/members/${userId}`, { role }),
+  removeMember: (id, userId) => api.delete(`/companies/${id}/members/${userId}`),
+  verify: (id, verified) => api.put(`/admin/companies/${id}/verify`, { verified }),
+};
+
+// companyPayload переводит выбор компании в форме вакансии в company_id
+// или название новой компании, которую сервер зарегистрирует сам
+export const companyPayload = ({ company_id, company }) => (
+  company_id ? { company_id: Number(company_id) } : { company }
+);
+
+export const salaryPeriodLabels = {
+  month: 'в месяц',
+  shift: 'за смену',
+  hour: 'в час',
+};
+
+// salaryPayload переводит поля формы вакансии в salary_min/salary_max;
+// без обеих сумм вакансия публикуется «по договорённости»
+export const salaryPayload = ({ salary_min, salary_max, salary_period, salary_gross }) => {
+  if (salary_min === '' && salary_max === '') {
+    return { salary: 'По договорённости' };
+  }
+  return {
+    salary_min: salary_min === '' ? null : Number(salary_min),
+    salary_max: salary_max === '' ? null : Number(salary_max),
+    salary_period,
+    salary_gross,
+  };
+};
+
+export const jobStatusLabels = {
+  pending: 'На модерации',
+  approved: 'Опубликована',
+  rejected: 'Отклонена',
+  archived: 'В архиве',
+};
+
+export const applicationsAPI = {
+  apply: (jobId, data) => api.post(`/jobs/${jobId}/applications`, data),
+  getForJob: (jobId) => api.get(`/jobs/${jobId}/applications`),
+  getMine: () => api.get('/my/applications'),
+  setStatus: (id, status) => api.put(`/applications/${id}/status`, { status }),
+};
+
+export const applicationStatusLabels = {
+  new: 'Новый',
+  viewed: 'Просмотрен',
+  invited: 'Приглашение',
+  rejected: 'Отказ',
+};
+
+export const resumesAPI = {
+  create: (data) => api.post('/resumes', data),
+  getMine: () => api.get('/my/resumes'),
+  update: (id, data) => api.put(`/resumes/${id}`, data),
+  remove: (id) => api.delete(`/resumes/${id}`),
+  search: (params = {}) => api.get('/resumes', { params }),
+  get: (id) => api.get(`/resumes/${id}`),
+  invite: (id, jobId, message) => api.post(`/resumes/${id}/invitations`, { job_id: jobId, message }),
+  getInvitations: () => api.get('/my/invitations'),
+  getQueue: (params = {}) => api.get('/admin/resumes', { params }),
+  setStatus: (id, status, reason = '') => api.put(`/admin/resumes/${id}/status`, { status, reason }),
+  getHistory: (id) => api.get(`/admin/resumes/${id}/history`),
+};
+
+// статусы у резюме те же, что у вакансий, меняется только род
+export const resumeStatusLabels = {
+  ...jobStatusLabels,
+  approved: 'Опубликовано',
+  rejected: 'Отклонено',
+};
+
+export const resumeAvailabilityLabels = {
+  immediately: 'Готов выйти сразу',
+  two_weeks: 'Через две недели',
+  month: 'Через месяц',
+};
+
+export const basketAPI = {
+  get: () => api.get('/basket'),
+  addItem: (productId, quantity = 1) => api.post('/basket/items', { product_id: productId, quantity }),
+  updateItem: (productId, quantity) => api.put(`/basket/items/${productId}`, { quantity }),
+  removeItem: (productId) => api.delete(`/basket/items/${productId}`),
+  clear: () => api.delete('/basket'),
+};
+
+export const ordersAPI = {
+  create: (orderData = {}) => api.post('/orders', orderData),
+  getAll: () => api.get('/orders'),
+  get: (orderId) => api.get(`/orders/${orderId}`),
+  cancel: (orderId) => api.post(`/orders/${orderId}/cancel`),
+  getAllAdmin: (params = {}) => api.get('/admin/orders', { params }),
+  setStatus: (orderId, status) => api.put(`/admin/orders/${orderId}/status`, { status }),
+};
+
+export const usersAPI = {
+  getRoles: () => api.get('/admin/roles'),
+  setRole: (userId, role) => api.put(`/admin/users/${userId}/role`, { role }),
+  revokeSessions: (userId) => api.delete(`/admin/users/${userId}/sessions`),
+  resetTwoFactor: (userId) => api.delete(`/admin/users/${userId}/2fa`),
 };
 
 export const checkServerHealth = async () => {